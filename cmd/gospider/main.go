@@ -0,0 +1,673 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/benji-bou/gospider/core"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type options struct {
+	config          string
+	site            string
+	sites           string
+	proxy           string
+	proxyPool       []string
+	proxyStrategy   string
+	tlsVerify       bool
+	caCert          string
+	tlsFingerprint  string
+	resolver        string
+	doh             string
+	hostMap         map[string]string
+	dnsCacheTTL     time.Duration
+	auth            string
+	authHosts       []string
+	authInsecure    bool
+	ntlmAuth        string
+	bearer          string
+	awsSigV4        string
+	loginURL        string
+	loginFields     map[string]string
+	loginSuccess    string
+	cookieJar       string
+	sessionRefresh  bool
+	loggedOut       string
+	dnsCacheSize    int
+	output          string
+	format          string
+	sinks           []string
+	userAgent       string
+	cookie          string
+	headers         []string
+	burp            string
+	burpReplay      string
+	harImport       string
+	harExport       string
+	harBodies       bool
+	auditLog        string
+	record          string
+	replay          string
+	bodyArchive     string
+	connectionInfo  bool
+	title           bool
+	favicon         bool
+	tech            bool
+	securityHeaders bool
+	cspDomains      bool
+	cspCrawl        bool
+	corsProbe       bool
+	secrets         bool
+	secretRules     []string
+	backends        bool
+	comments        bool
+	cssLinks        bool
+	apiRefs         bool
+	sourceMaps      bool
+	jsLiterals      bool
+	openAPI         bool
+	openAPIVisit    bool
+	webSockets      bool
+	wsProbe         bool
+	submitForms     bool
+	submitPost      bool
+	params          bool
+	paramsWordlist  string
+	patternDedup    bool
+	stripTracking   bool
+	stripParams     []string
+	keepParams      []string
+	ignoreQuery     bool
+	canonicalize    bool
+	stripFragment   bool
+	sortQuery       bool
+	unicodeURLs     bool
+	feeds           bool
+	crawlSubs       bool
+	blacklist       string
+	whitelist       string
+	whitelistDomain string
+	scope           []string
+	includeHosts    []string
+	excludeHosts    []string
+	includePaths    []string
+	excludePaths    []string
+	dropOutOfScope  bool
+	depthPolicy     map[string]int
+	reportTypes     []string
+	subs            bool
+	concurrent      int
+	depth           int
+	delay           int
+	randomDelay     int
+	timeout         int
+	base            bool
+	js              bool
+	sitemap         bool
+	sitemapPaths    []string
+	robots          bool
+	respectRobots   bool
+	otherSource     bool
+	sourceCache     string
+	sourceCacheTTL  time.Duration
+	noRedirect      bool
+	filterLength    string
+	quiet           bool
+	debug           bool
+	verbose         bool
+}
+
+func main() {
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func newRootCommand() *cobra.Command {
+	opts := &options{}
+	cmd := &cobra.Command{
+		Use:           core.CLIName,
+		Short:         fmt.Sprintf("Fast web spider written in Go - %s by %s", core.VERSION, core.AUTHOR),
+		Version:       core.VERSION,
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := run(opts)
+			if err != nil {
+				core.Logger.Error(err)
+			}
+			return err
+		},
+	}
+	cmd.AddCommand(newServeCommand())
+	f := cmd.Flags()
+	f.StringVar(&opts.config, "config", "", "Load crawl settings from a YAML or TOML file, applied on top of the flags")
+	f.StringVarP(&opts.site, "site", "s", "", "Site to crawl")
+	f.StringVarP(&opts.sites, "sites", "S", "", "Site list to crawl")
+	f.StringVarP(&opts.proxy, "proxy", "p", "", "Proxy (Ex: http://127.0.0.1:8080, socks5://127.0.0.1:1080 or socks5h:// to resolve through the proxy)")
+	f.StringArrayVar(&opts.proxyPool, "proxy-pool", nil, "Rotate the requests over these proxies, unhealthy ones are quarantined (Use multiple flag to set multiple proxy)")
+	f.StringVar(&opts.proxyStrategy, "proxy-strategy", "round-robin", "Proxy pool rotation: round-robin, random or sticky (same proxy per host)")
+	f.BoolVar(&opts.tlsVerify, "tls-verify", false, "Verify the server certificates")
+	f.StringVar(&opts.caCert, "ca-cert", "", "Verify the server certificates against this PEM CA bundle besides the system roots")
+	f.StringVar(&opts.resolver, "resolver", "", "Resolve the host names with this DNS server (host or host:port)")
+	f.StringVar(&opts.doh, "doh", "", "Resolve the host names with this DNS-over-HTTPS endpoint")
+	f.DurationVar(&opts.dnsCacheTTL, "dns-cache-ttl", 0, "Cache the resolved host names for this duration (e.g 5m), disabled when 0")
+	f.IntVar(&opts.dnsCacheSize, "dns-cache-size", core.DefaultDNSCacheMaxEntries, "Maximum number of host names in the DNS cache")
+	f.StringToStringVar(&opts.hostMap, "host-map", nil, "Dial this address for a host, keeping its Host header and SNI (host=ip[:port], Use multiple flag to set multiple mapping)")
+	f.StringVar(&opts.auth, "auth", "", "Answer the Basic and Digest authentication challenges with these credentials (user:password)")
+	f.StringArrayVar(&opts.authHosts, "auth-host", nil, "Host the --auth credentials are sent to instead of the hosts of the sites (Use multiple flag to set multiple host)")
+	f.BoolVar(&opts.authInsecure, "auth-insecure", false, "Send the --auth Basic credentials over plain http")
+	f.StringVar(&opts.ntlmAuth, "ntlm-auth", "", "Answer the NTLM authentication challenges with these credentials (domain\\user:password)")
+	f.StringVar(&opts.bearer, "bearer", "", "Authenticate the requests with this bearer token")
+	f.StringVar(&opts.awsSigV4, "aws-sigv4", "", "Sign the requests with AWS SigV4 (region:service), with the credentials of the AWS_* environment variables")
+	f.StringVar(&opts.loginURL, "login-url", "", "Log in through the form of this page before crawling")
+	f.StringToStringVar(&opts.loginFields, "login-field", nil, "Value submitted in the login form (name=value, Use multiple flag to set multiple field)")
+	f.StringVar(&opts.loginSuccess, "login-success", "", "Regex the response to the login form must match")
+	f.BoolVar(&opts.sessionRefresh, "session-refresh", false, "Log in again when the session expires mid-crawl (401, redirect to the login page or --logged-out match)")
+	f.StringVar(&opts.loggedOut, "logged-out", "", "Regex matching the pages served to a logged out user")
+	f.StringVar(&opts.cookieJar, "cookie-jar", "", "Load the cookies of this file before crawling and save them afterwards (Netscape cookies.txt, or JSON with a .json extension)")
+	f.StringVar(&opts.tlsFingerprint, "tls-fingerprint", "", "Mimic the TLS ClientHello of a browser (chrome, firefox, safari, edge, ios, random)")
+	f.StringVarP(&opts.output, "output", "o", "", "Also write the output to this file")
+	f.StringVarP(&opts.format, "format", "f", "text", "Output format: text, jsonl or plain")
+	f.StringArrayVar(&opts.sinks, "sink", nil, "Send reports to a sink (Use multiple flag to set multiple sink)\n\tes://host:9200/index (es+https:// for TLS)\n\tkafka://broker1:9092,broker2:9092/topic?encoding=avro")
+	f.StringVarP(&opts.userAgent, "user-agent", "u", "web", "User Agent to use\n\tweb: random web user-agent\n\tmobi: random mobile user-agent\n\tor you can set your special user-agent")
+	f.StringVar(&opts.cookie, "cookie", "", "Cookie to use (testA=a; testB=b)")
+	f.StringArrayVarP(&opts.headers, "header", "H", nil, "Header to use (Use multiple flag to set multiple header)")
+	f.StringVar(&opts.burp, "burp", "", "Load headers and cookie from burp raw http request, or a directory of them")
+	f.StringVar(&opts.burpReplay, "burp-replay", "", "Replay the burp raw http requests of this file or directory as seeds")
+	f.StringVar(&opts.harImport, "har-import", "", "Seed the urls of this HAR file and reuse its cookies and session headers")
+	f.StringVar(&opts.harExport, "har-export", "", "Write every request and response of the crawl to this HAR file")
+	f.BoolVar(&opts.harBodies, "har-bodies", false, "Include the request and response bodies in the HAR file")
+	f.StringVar(&opts.auditLog, "audit-log", "", "Append a JSON line for every request sent to this file")
+	f.StringVar(&opts.record, "record", "", "Record every response to a cassette in this directory")
+	f.StringVar(&opts.replay, "replay", "", "Serve the responses recorded in this directory, without network")
+	f.StringVar(&opts.bodyArchive, "body-archive", "", "Archive every visited response body in this directory, indexed in index.jsonl")
+	f.BoolVar(&opts.connectionInfo, "connection-info", false, "Add the remote address, TLS version and certificate of the responses to the reports")
+	f.BoolVar(&opts.title, "title", false, "Add the title of the pages to the url reports")
+	f.BoolVar(&opts.favicon, "favicon", false, "Report the favicons of the hosts with their Shodan mmh3 hash")
+	f.BoolVar(&opts.tech, "tech", false, "Report the technologies of the hosts found in the headers, cookies, meta and script tags")
+	f.BoolVar(&opts.securityHeaders, "security-headers", false, "Report the missing or weak security headers (HSTS, CSP, X-Frame-Options...) of every origin")
+	f.BoolVar(&opts.cspDomains, "csp-domains", false, "Report the hosts referenced by the Content-Security-Policy of the pages as domains")
+	f.BoolVar(&opts.cspCrawl, "csp-crawl", false, "Crawl the hosts of the CSPs sharing the domain of their page (implies --csp-domains)")
+	f.BoolVar(&opts.corsProbe, "cors-probe", false, "Probe the CORS policy of the API endpoints with a foreign and a null Origin")
+	f.BoolVar(&opts.secrets, "secrets", false, "Scan the bodies for secrets (AWS and GCP keys, Slack tokens, private keys...)")
+	f.StringArrayVar(&opts.secretRules, "secret-rule", nil, "Additional secret rule name=regex, implies --secrets (Use multiple flag to set multiple rule)")
+	f.BoolVar(&opts.backends, "backends", false, "Report the Firebase and Supabase projects referenced by the bodies")
+	f.BoolVar(&opts.comments, "comments", false, "Report the html comments holding urls, credentials or TODO/FIXME markers, and crawl their urls")
+	f.BoolVar(&opts.cssLinks, "css", false, "Crawl the url(...) and @import references of the stylesheets and style blocks")
+	f.BoolVar(&opts.apiRefs, "api-refs", false, "Report and crawl the url-shaped values of the JSON and XML responses")
+	f.BoolVar(&opts.sourceMaps, "source-maps", false, "Report the exposed source maps of the scripts and their original sources, and crawl their endpoints")
+	f.BoolVar(&opts.jsLiterals, "js-literals", false, "Run linkfinder on the string literals of the scripts too, joining the concatenated ones and decoding their escapes")
+	f.BoolVar(&opts.openAPI, "openapi", false, "Probe the Swagger and OpenAPI spec paths of every host and report the operations of the specs found")
+	f.BoolVar(&opts.openAPIVisit, "openapi-visit", false, "Crawl the GET endpoints of the specs found with --openapi")
+	f.BoolVar(&opts.webSockets, "websockets", false, "Report the ws:// and wss:// urls found in the pages and scripts")
+	f.BoolVar(&opts.wsProbe, "ws-probe", false, "Send a handshake to the websocket urls to report whether they are live and their subprotocol (implies --websockets)")
+	f.BoolVar(&opts.submitForms, "submit-forms", false, "Submit the safe GET forms with canned values to reach the pages no link leads to")
+	f.BoolVar(&opts.submitPost, "submit-post-forms", false, "Submit the safe POST forms too (implies --submit-forms)")
+	f.BoolVar(&opts.params, "params", false, "Report the query parameter names seen on every host at the end of the crawl")
+	f.StringVar(&opts.paramsWordlist, "params-wordlist", "", "Write the query parameter names seen to this file, one per line (implies --params)")
+	f.BoolVar(&opts.patternDedup, "pattern-dedup", false, "Visit a single url per path pattern (/product/123 and /product/456 share /product/{id}) and report the pattern counts")
+	f.BoolVar(&opts.stripTracking, "strip-tracking", false, "Remove the tracking parameters (utm_*, gclid, fbclid...) from the visited urls")
+	f.StringArrayVar(&opts.stripParams, "strip-param", nil, "Query parameter to remove from the visited urls, prefix* to remove a family (Use multiple flag to set multiple param)")
+	f.StringArrayVar(&opts.keepParams, "keep-param", nil, "Query parameter to keep in the visited urls, removing the others (Use multiple flag to set multiple param)")
+	f.BoolVar(&opts.ignoreQuery, "ignore-query", false, "Do not visit an url already visited with another query")
+	f.BoolVar(&opts.canonicalize, "canonicalize", false, "Normalize the urls before dedup and scope checks: lowercase scheme and host, no default port, no dot segments, minimal percent-encoding")
+	f.BoolVar(&opts.stripFragment, "strip-fragment", false, "Remove the fragment of the urls (implies --canonicalize)")
+	f.BoolVar(&opts.sortQuery, "sort-query", false, "Sort the query parameters of the urls (implies --canonicalize)")
+	f.BoolVar(&opts.unicodeURLs, "unicode-urls", false, "Add the unicode form of the internationalized urls, reported in punycode, to their report")
+	f.BoolVar(&opts.feeds, "feeds", false, "Read the RSS and Atom feeds advertised by the pages or at the common feed paths, and crawl their items")
+	f.BoolVar(&opts.crawlSubs, "crawl-subdomains", false, "Crawl the subdomains reported as domains when they match the scope (see --subs)")
+	f.StringVar(&opts.blacklist, "blacklist", "", "Blacklist URL Regex")
+	f.StringVar(&opts.whitelist, "whitelist", "", "Whitelist URL Regex")
+	f.StringVar(&opts.whitelistDomain, "whitelist-domain", "", "Whitelist Domain")
+	f.StringArrayVar(&opts.scope, "scope", nil, "Scope URL Regex, defaults to the hostnames of the sites (Use multiple flag to set multiple scope)")
+	f.StringArrayVar(&opts.includeHosts, "include-host", nil, "Host in scope: hostname, *.domain for its subdomains or CIDR range (Use multiple flag to set multiple hosts)")
+	f.StringArrayVar(&opts.excludeHosts, "exclude-host", nil, "Host out of scope: hostname, *.domain for its subdomains or CIDR range")
+	f.StringArrayVar(&opts.includePaths, "include-path", nil, "Path prefix in scope, the urls with other paths being out of scope")
+	f.StringArrayVar(&opts.excludePaths, "exclude-path", nil, "Path prefix out of scope")
+	f.BoolVar(&opts.dropOutOfScope, "drop-out-of-scope", false, "Don't report the urls out of scope instead of reporting them flagged as out_of_scope")
+	f.StringToIntVar(&opts.depthPolicy, "depth-policy", nil, "Max depth of the followed urls per output type, overriding --depth (type=depth, e.g. ref=5,linkfinder=2)")
+	f.StringArrayVar(&opts.reportTypes, "report-type", nil, "Only output the reports of this type, e.g. url or form (Use multiple flag to set multiple type)")
+	f.BoolVar(&opts.subs, "subs", false, "Include subdomains in the default scope")
+	f.IntVarP(&opts.concurrent, "concurrent", "c", 5, "The number of the maximum allowed concurrent requests of the matching domains")
+	f.IntVarP(&opts.depth, "depth", "d", 1, "MaxDepth limits the recursion depth of visited URLs. (Set it to 0 for infinite recursion)")
+	f.IntVarP(&opts.delay, "delay", "k", 0, "Delay is the duration to wait before creating a new request to the matching domains (second)")
+	f.IntVarP(&opts.randomDelay, "random-delay", "K", 0, "RandomDelay is the extra randomized duration to wait added to Delay before creating a new request (second)")
+	f.IntVarP(&opts.timeout, "timeout", "m", 10, "Request timeout (second)")
+	f.BoolVarP(&opts.base, "base", "B", false, "Disable all and only use HTML content")
+	f.BoolVar(&opts.js, "js", true, "Enable linkfinder in javascript file")
+	f.BoolVar(&opts.sitemap, "sitemap", false, "Try to crawl sitemap.xml")
+	f.StringArrayVar(&opts.sitemapPaths, "sitemap-path", nil, "Sitemap path to probe instead of the default ones (Use multiple flag to set multiple path)")
+	f.BoolVar(&opts.robots, "robots", true, "Try to crawl robots.txt")
+	f.BoolVar(&opts.respectRobots, "respect-robots", false, "Do not visit urls disallowed by robots.txt and honor Crawl-delay")
+	f.BoolVarP(&opts.otherSource, "other-source", "a", false, "Find URLs from 3rd party (Archive.org, CommonCrawl.org, urlscan.io, AlienVault.com)")
+	f.StringVar(&opts.sourceCache, "source-cache", "", "Cache the 3rd party URLs in this directory, to re-use them across runs")
+	f.DurationVar(&opts.sourceCacheTTL, "source-cache-ttl", 24*time.Hour, "How long the cached 3rd party URLs are re-used")
+	f.BoolVar(&opts.noRedirect, "no-redirect", false, "Disable redirect")
+	f.StringVarP(&opts.filterLength, "filter-length", "L", "", "Turn on length filter")
+	f.BoolVarP(&opts.quiet, "quiet", "q", false, "Suppress all the output and only show URL")
+	f.BoolVar(&opts.debug, "debug", false, "Turn on debug mode")
+	f.BoolVarP(&opts.verbose, "verbose", "v", false, "Turn on verbose")
+	return cmd
+}
+
+func run(opts *options) error {
+	switch {
+	case opts.debug:
+		core.Logger.SetLevel(logrus.DebugLevel)
+	case opts.verbose:
+		core.Logger.SetLevel(logrus.InfoLevel)
+	default:
+		core.Logger.SetLevel(logrus.ErrorLevel)
+	}
+
+	siteList, err := readSites(opts.site, opts.sites)
+	if err != nil {
+		return err
+	}
+	if len(siteList) == 0 && opts.burpReplay == "" && opts.harImport == "" {
+		return fmt.Errorf("no site to crawl, use --site, --sites, --burp-replay or --har-import")
+	}
+
+	crawlerOpts, err := crawlerOptions(opts, siteList)
+	if err != nil {
+		return err
+	}
+	crawler := core.NewCrawler(crawlerOpts...)
+
+	signalC := make(chan os.Signal, 1)
+	signal.Notify(signalC, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalC)
+	go func() {
+		if _, ok := <-signalC; ok {
+			crawler.Stop()
+		}
+	}()
+
+	outputC, errC := crawler.Start(siteList...)
+	for outputC != nil || errC != nil {
+		select {
+		case _, ok := <-outputC:
+			if !ok {
+				outputC = nil
+			}
+		case e, ok := <-errC:
+			if !ok {
+				errC = nil
+				continue
+			}
+			core.Logger.Debug(e)
+		}
+	}
+	return nil
+}
+
+// crawlerOptions translates the command line flags into crawler options
+func crawlerOptions(opts *options, siteList []string) ([]core.CrawlerOption, error) {
+	formatName := opts.format
+	if opts.quiet {
+		formatName = "plain"
+	}
+	formatter, err := core.FormatterByName(formatName)
+	if err != nil {
+		return nil, err
+	}
+	crawlerOpts := []core.CrawlerOption{
+		core.WithDefaultColly(opts.depth),
+		core.WithOutput(os.Stdout),
+		core.WithOutputFormat(formatter),
+	}
+	if opts.output != "" {
+		file, err := os.OpenFile(opts.output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open output file: %w", err)
+		}
+		crawlerOpts = append(crawlerOpts, core.WithOutput(file))
+	}
+	if opts.auditLog != "" {
+		file, err := os.OpenFile(opts.auditLog, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open audit log: %w", err)
+		}
+		crawlerOpts = append(crawlerOpts, core.WithAuditLog(file))
+	}
+	if opts.bodyArchive != "" {
+		crawlerOpts = append(crawlerOpts, core.WithBodyArchive(opts.bodyArchive))
+	}
+	if opts.connectionInfo {
+		crawlerOpts = append(crawlerOpts, core.WithConnectionInfo())
+	}
+	if opts.title {
+		crawlerOpts = append(crawlerOpts, core.WithTitleExtraction())
+	}
+	if opts.favicon {
+		crawlerOpts = append(crawlerOpts, core.WithFaviconHash())
+	}
+	if opts.tech {
+		crawlerOpts = append(crawlerOpts, core.WithTechFingerprint())
+	}
+	if opts.securityHeaders {
+		crawlerOpts = append(crawlerOpts, core.WithSecurityHeaders())
+	}
+	if opts.cspDomains || opts.cspCrawl {
+		crawlerOpts = append(crawlerOpts, core.WithCSPDomains(opts.cspCrawl))
+	}
+	if opts.corsProbe {
+		crawlerOpts = append(crawlerOpts, core.WithCORSProbe())
+	}
+	if opts.secrets || len(opts.secretRules) > 0 {
+		rules := make([]core.SecretRule, 0, len(opts.secretRules))
+		for _, spec := range opts.secretRules {
+			name, pattern, ok := strings.Cut(spec, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid secret rule %s, expected name=regex", spec)
+			}
+			rules = append(rules, core.SecretRule{Name: name, Pattern: pattern})
+		}
+		crawlerOpts = append(crawlerOpts, core.WithSecretScanning(rules...))
+	}
+	if opts.backends {
+		crawlerOpts = append(crawlerOpts, core.WithBackendDetection())
+	}
+	if opts.comments {
+		crawlerOpts = append(crawlerOpts, core.WithCommentMining())
+	}
+	if opts.cssLinks {
+		crawlerOpts = append(crawlerOpts, core.WithCSSLinks())
+	}
+	if opts.apiRefs {
+		crawlerOpts = append(crawlerOpts, core.WithAPIRefs())
+	}
+	if opts.sourceMaps {
+		crawlerOpts = append(crawlerOpts, core.WithSourceMaps())
+	}
+	if opts.jsLiterals {
+		crawlerOpts = append(crawlerOpts, core.WithJSLiterals())
+	}
+	if opts.openAPI {
+		crawlerOpts = append(crawlerOpts, core.WithOpenAPI(opts.openAPIVisit))
+	}
+	if opts.webSockets || opts.wsProbe {
+		crawlerOpts = append(crawlerOpts, core.WithWebSockets(opts.wsProbe))
+	}
+	if opts.submitForms || opts.submitPost {
+		crawlerOpts = append(crawlerOpts, core.WithFormSubmission(opts.submitPost))
+	}
+	if opts.params || opts.paramsWordlist != "" {
+		crawlerOpts = append(crawlerOpts, core.WithParameterMining(opts.paramsWordlist))
+	}
+	if opts.patternDedup {
+		crawlerOpts = append(crawlerOpts, core.WithPatternDedup())
+	}
+	if opts.stripTracking {
+		crawlerOpts = append(crawlerOpts, core.WithStripQueryParams())
+	}
+	if len(opts.stripParams) > 0 {
+		crawlerOpts = append(crawlerOpts, core.WithStripQueryParams(opts.stripParams...))
+	}
+	if len(opts.keepParams) > 0 {
+		crawlerOpts = append(crawlerOpts, core.WithQueryAllowlist(opts.keepParams...))
+	}
+	if opts.ignoreQuery {
+		crawlerOpts = append(crawlerOpts, core.WithIgnoreQuery())
+	}
+	if opts.canonicalize || opts.stripFragment || opts.sortQuery {
+		steps := append(core.Canonicalizer{}, core.DefaultCanonicalizer...)
+		if opts.stripFragment {
+			steps = append(steps, core.StripFragment)
+		}
+		if opts.sortQuery {
+			steps = append(steps, core.SortQuery)
+		}
+		crawlerOpts = append(crawlerOpts, core.WithCanonicalizer(steps...))
+	}
+	if opts.unicodeURLs {
+		crawlerOpts = append(crawlerOpts, core.WithUnicodeURLs())
+	}
+	if opts.feeds {
+		crawlerOpts = append(crawlerOpts, core.WithFeeds())
+	}
+	if opts.crawlSubs {
+		crawlerOpts = append(crawlerOpts, core.WithCrawlDiscoveredSubdomains())
+	}
+	scope, err := newScope(opts, siteList)
+	if err != nil {
+		return nil, err
+	}
+	crawlerOpts = append(crawlerOpts, core.WithCrawlScope(scope))
+	if len(opts.depthPolicy) > 0 {
+		policy := make(map[core.OutputType]int, len(opts.depthPolicy))
+		for outputType, depth := range opts.depthPolicy {
+			policy[core.OutputType(outputType)] = depth
+		}
+		crawlerOpts = append(crawlerOpts, core.WithDepthPolicy(policy))
+	}
+	if len(opts.reportTypes) > 0 {
+		types := make([]core.OutputType, 0, len(opts.reportTypes))
+		for _, outputType := range opts.reportTypes {
+			types = append(types, core.OutputType(outputType))
+		}
+		crawlerOpts = append(crawlerOpts, core.WithReportTypes(types...))
+	}
+	for _, spec := range opts.sinks {
+		sink, err := newSink(spec)
+		if err != nil {
+			return nil, err
+		}
+		crawlerOpts = append(crawlerOpts, core.WithSink(sink))
+	}
+	if !opts.base {
+		if opts.js {
+			crawlerOpts = append(crawlerOpts, core.WithLinkFinder())
+		}
+		if opts.sitemap {
+			crawlerOpts = append(crawlerOpts, core.WithSitemap())
+			if len(opts.sitemapPaths) > 0 {
+				crawlerOpts = append(crawlerOpts, core.WithSitemapPaths(opts.sitemapPaths...))
+			}
+		}
+		if opts.robots {
+			crawlerOpts = append(crawlerOpts, core.WithRobot())
+		}
+		if opts.otherSource {
+			crawlerOpts = append(crawlerOpts, core.WithOtherSources())
+			if opts.sourceCache != "" {
+				crawlerOpts = append(crawlerOpts, core.WithSourceCache(opts.sourceCache, opts.sourceCacheTTL))
+			}
+		}
+	}
+	if opts.respectRobots {
+		crawlerOpts = append(crawlerOpts, core.WithRespectRobots())
+	}
+	if opts.cookieJar != "" {
+		crawlerOpts = append(crawlerOpts, core.WithCookieJarFile(opts.cookieJar))
+	}
+	if opts.burpReplay != "" {
+		crawlerOpts = append(crawlerOpts, core.WithBurpReplay(opts.burpReplay))
+	}
+	if opts.harImport != "" {
+		crawlerOpts = append(crawlerOpts, core.WithHARImport(opts.harImport))
+	}
+	if opts.harExport != "" {
+		crawlerOpts = append(crawlerOpts, core.WithHARExport(opts.harExport, opts.harBodies))
+	}
+	if opts.loginURL != "" {
+		crawlerOpts = append(crawlerOpts, core.WithLoginFlow(core.LoginFlow{URL: opts.loginURL, Fields: opts.loginFields, SuccessRegexp: opts.loginSuccess}))
+		if opts.sessionRefresh || opts.loggedOut != "" {
+			crawlerOpts = append(crawlerOpts, core.WithSessionRefresh(opts.loggedOut))
+		}
+	}
+	if opts.filterLength != "" {
+		crawlerOpts = append(crawlerOpts, core.WithFilterLength(opts.filterLength))
+	}
+
+	clientOpts := []core.HTTPClientConfigurator{
+		core.WithHTTPProxy(opts.proxy),
+		core.WithHTTPTimeout(opts.timeout),
+	}
+	var resolver core.Resolver
+	if opts.resolver != "" {
+		resolver = core.NewDNSServerResolver(opts.resolver)
+	}
+	if opts.doh != "" {
+		resolver = core.NewDoHResolver(opts.doh)
+	}
+	if opts.dnsCacheTTL > 0 {
+		resolver = core.NewDNSCache(resolver, opts.dnsCacheTTL, opts.dnsCacheSize)
+	}
+	if resolver != nil {
+		clientOpts = append(clientOpts, core.WithDNSResolver(resolver))
+	}
+	if len(opts.hostMap) > 0 {
+		clientOpts = append(clientOpts, core.WithHostMapping(opts.hostMap))
+	}
+	if opts.tlsVerify {
+		clientOpts = append(clientOpts, core.WithTLSVerify(true))
+	}
+	if opts.caCert != "" {
+		clientOpts = append(clientOpts, core.WithCACert(opts.caCert))
+	}
+	if opts.tlsFingerprint != "" {
+		clientOpts = append(clientOpts, core.WithTLSFingerprint(opts.tlsFingerprint))
+	}
+	if len(opts.proxyPool) > 0 {
+		clientOpts = append(clientOpts, core.WithProxyPool(opts.proxyPool, core.ProxyStrategy(opts.proxyStrategy)))
+	}
+	if opts.replay != "" {
+		clientOpts = append(clientOpts, core.WithReplay(opts.replay))
+	} else if opts.record != "" {
+		clientOpts = append(clientOpts, core.WithRecord(opts.record))
+	}
+	if opts.auth != "" {
+		user, password, _ := strings.Cut(opts.auth, ":")
+		clientOpts = append(clientOpts, core.WithHTTPAuth(user, password))
+		if len(opts.authHosts) > 0 {
+			clientOpts = append(clientOpts, core.WithHTTPAuthHosts(opts.authHosts...))
+		}
+		if opts.authInsecure {
+			clientOpts = append(clientOpts, core.WithInsecureBasicAuth())
+		}
+	}
+	if opts.ntlmAuth != "" {
+		user, password, _ := strings.Cut(opts.ntlmAuth, ":")
+		domain, name, found := strings.Cut(user, `\`)
+		if !found {
+			domain, name = "", user
+		}
+		clientOpts = append(clientOpts, core.WithNTLMAuth(domain, name, password))
+	}
+	if opts.bearer != "" {
+		clientOpts = append(clientOpts, core.WithBearerToken(opts.bearer))
+	}
+	if opts.awsSigV4 != "" {
+		region, service, _ := strings.Cut(opts.awsSigV4, ":")
+		clientOpts = append(clientOpts, core.WithAWSSigV4(core.AWSCredentialsFromEnv(), region, service))
+	}
+	if opts.noRedirect {
+		clientOpts = append(clientOpts, core.WithHTTPNoRedirect())
+	}
+	crawlerOpts = append(crawlerOpts, core.WithHTTPClientOpt(clientOpts...))
+	collyOpts := []core.CollyConfigurator{
+		core.WithUserAgent(opts.userAgent),
+		core.WithLimit(opts.concurrent, opts.delay, opts.randomDelay),
+		core.WithDefaultDisalowedRegexp(),
+	}
+	if opts.burp != "" {
+		collyOpts = append(collyOpts, core.WithBurpFile(opts.burp))
+	}
+	if opts.cookie != "" {
+		collyOpts = append(collyOpts, core.WithCookie(opts.cookie))
+	}
+	if len(opts.headers) > 0 {
+		collyOpts = append(collyOpts, core.WithHeader(opts.headers...))
+	}
+	if opts.config != "" {
+		configCrawlerOpts, configCollyOpts, err := core.LoadConfig(opts.config)
+		if err != nil {
+			return nil, err
+		}
+		crawlerOpts = append(crawlerOpts, configCrawlerOpts...)
+		collyOpts = append(collyOpts, configCollyOpts...)
+	}
+	return append(crawlerOpts, core.WithCollyConfig(collyOpts...)), nil
+}
+
+// newScope returns the scope of the crawl: the --scope regexes and the --include-host hosts or, when none is given,
+// the hostnames of the sites, with their subdomains for --subs
+func newScope(opts *options, siteList []string) (*core.Scope, error) {
+	scope := core.NewScope()
+	scope.RecordOutOfScope = !opts.dropOutOfScope
+	if err := scope.IncludeRegexp(opts.scope...); err != nil {
+		return nil, err
+	}
+	if err := scope.IncludeHost(opts.includeHosts...); err != nil {
+		return nil, err
+	}
+	if len(opts.scope)+len(opts.includeHosts) == 0 {
+		for _, s := range siteList {
+			u, err := url.Parse(s)
+			if err != nil || u.Hostname() == "" {
+				continue
+			}
+			hosts := []string{u.Hostname()}
+			if opts.subs {
+				hosts = append(hosts, "*."+u.Hostname())
+			}
+			if err := scope.IncludeHost(hosts...); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if opts.whitelist != "" {
+		if err := scope.IncludeRegexp(opts.whitelist); err != nil {
+			return nil, err
+		}
+	}
+	if opts.whitelistDomain != "" {
+		if err := scope.IncludeRegexp("http(s)?://" + opts.whitelistDomain); err != nil {
+			return nil, err
+		}
+	}
+	if opts.blacklist != "" {
+		if err := scope.ExcludeRegexp(opts.blacklist); err != nil {
+			return nil, err
+		}
+	}
+	if err := scope.ExcludeHost(opts.excludeHosts...); err != nil {
+		return nil, err
+	}
+	scope.IncludePath(opts.includePaths...)
+	scope.ExcludePath(opts.excludePaths...)
+	return scope, nil
+}
+
+func readSites(site string, sitesFile string) ([]string, error) {
+	siteList := []string{}
+	if site != "" {
+		siteList = append(siteList, site)
+	}
+	if sitesFile == "" {
+		return siteList, nil
+	}
+	file, err := os.Open(sitesFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sites file: %w", err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if s := strings.TrimSpace(scanner.Text()); s != "" {
+			siteList = append(siteList, s)
+		}
+	}
+	return siteList, scanner.Err()
+}
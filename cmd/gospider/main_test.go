@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestNewScope(t *testing.T) {
+	siteList := []string{"https://example.com/path"}
+	for _, tc := range []struct {
+		subs    bool
+		url     string
+		inScope bool
+	}{
+		{false, "https://example.com/a", true},
+		{false, "http://example.com:8080", true},
+		{false, "https://api.example.com/a", false},
+		{false, "https://example.com.evil.io/", false},
+		{true, "https://api.example.com/a", true},
+		{true, "https://notexample.com/a", false},
+	} {
+		scope, err := newScope(&options{subs: tc.subs}, siteList)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if scope.AllowsURL(tc.url) != tc.inScope {
+			t.Errorf("subs=%v: expected %s in scope to be %v", tc.subs, tc.url, tc.inScope)
+		}
+	}
+	scope, err := newScope(&options{scope: []string{`^https://other\.com/`}, excludePaths: []string{"/admin"}}, siteList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scope.AllowsURL("https://example.com/") || !scope.AllowsURL("https://other.com/a") || scope.AllowsURL("https://other.com/admin/users") {
+		t.Errorf("expected --scope to override the default scope and --exclude-path to apply")
+	}
+	if !scope.RecordOutOfScope {
+		t.Errorf("expected the urls out of scope to be recorded by default")
+	}
+	if _, err := newScope(&options{includeHosts: []string{"10.0.0.0/33"}}, siteList); err == nil {
+		t.Errorf("expected an error for an invalid CIDR range")
+	}
+}
+
+func TestNewSinkErrors(t *testing.T) {
+	for _, spec := range []string{"es://localhost:9200", "ftp://localhost/index", "kafka://localhost:9092/topic?encoding=xml"} {
+		if _, err := newSink(spec); err == nil {
+			t.Errorf("expected an error for sink %s", spec)
+		}
+	}
+}
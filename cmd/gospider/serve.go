@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/benji-bou/gospider/core"
+	"github.com/benji-bou/gospider/server"
+	"github.com/spf13/cobra"
+)
+
+func newServeCommand() *cobra.Command {
+	var listen, dir, token string
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run crawls as jobs managed through an HTTP API",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := server.New(dir)
+			if err != nil {
+				return err
+			}
+			if token == "" {
+				token = os.Getenv("GOSPIDER_TOKEN")
+			}
+			if token == "" {
+				core.Logger.Warn("Serving the crawl API without authentication, set --token to require a bearer token")
+			}
+			s.RequireToken(token)
+			httpServer := &http.Server{Addr: listen, Handler: s}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			go func() {
+				<-ctx.Done()
+				httpServer.Shutdown(context.Background())
+			}()
+			core.Logger.Infof("Serving crawl API on %s", listen)
+			if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&listen, "listen", "l", "127.0.0.1:8080", "Address of the HTTP API")
+	cmd.Flags().StringVar(&token, "token", "", "Bearer token required by the HTTP API (default $GOSPIDER_TOKEN)")
+	cmd.Flags().StringVar(&dir, "data", "gospider-jobs", "Directory persisting the jobs and their results")
+	return cmd
+}
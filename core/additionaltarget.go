@@ -0,0 +1,78 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+var (
+	// DefaultAdditionalTargetWorkers is the number of seeds whose sitemaps, robots.txt and sources are fetched at the same time
+	DefaultAdditionalTargetWorkers = 4
+	// DefaultAdditionalTargetTimeout bounds the sources fetch of a seed
+	DefaultAdditionalTargetTimeout = 5 * time.Minute
+)
+
+// additionalTargetPool fetches the additional targets of the seeds in the background, on a bounded number of workers,
+// so a slow sitemap or source doesn't hold the ingestion of the next seeds
+type additionalTargetPool struct {
+	crawler *Crawler
+	c       *colly.Collector
+	report  func(SpiderReport)
+	timeout time.Duration
+	sem     chan struct{}
+	wg      sync.WaitGroup
+}
+
+func (crawler *Crawler) newAdditionalTargetPool(c *colly.Collector, report func(SpiderReport)) *additionalTargetPool {
+	workers := crawler.additionalTargetWorkers
+	if workers <= 0 {
+		workers = DefaultAdditionalTargetWorkers
+	}
+	timeout := crawler.additionalTargetTimeout
+	if timeout <= 0 {
+		timeout = DefaultAdditionalTargetTimeout
+	}
+	return &additionalTargetPool{
+		crawler: crawler,
+		c:       c,
+		report:  report,
+		timeout: timeout,
+		sem:     make(chan struct{}, workers),
+	}
+}
+
+// add schedules the visit of the additional targets of seed, it doesn't wait for a worker
+func (pool *additionalTargetPool) add(ctx context.Context, seed string) {
+	pool.wg.Add(1)
+	go func() {
+		defer pool.wg.Done()
+		select {
+		case pool.sem <- struct{}{}:
+			defer func() { <-pool.sem }()
+		case <-ctx.Done():
+			return
+		}
+		ctx, cancel := context.WithTimeout(ctx, pool.timeout)
+		defer cancel()
+		for _, additionalSite := range pool.crawler.additionalTarget(ctx, seed, pool.report) {
+			pool.crawler.visit(pool.c, additionalSite, seed)
+		}
+	}()
+}
+
+// wait returns once the additional targets of every seed were scheduled
+func (pool *additionalTargetPool) wait() {
+	pool.wg.Wait()
+}
+
+// WithAdditionalTargetWorkers sets the number of seeds whose sitemaps, robots.txt and sources are fetched concurrently
+// and the timeout of each fetch. Zero values keep DefaultAdditionalTargetWorkers and DefaultAdditionalTargetTimeout
+func WithAdditionalTargetWorkers(workers int, timeout time.Duration) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.additionalTargetWorkers = workers
+		crawler.additionalTargetTimeout = timeout
+	}
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// apiRefMaxLength bounds the length of the string values considered as urls
+const apiRefMaxLength = 2048
+
+var apiRefRE = regexp.MustCompile(`^(?:https?://[^\s/?#]+[^\s]*|//[\w-]+(?:\.[\w-]+)+(?:/[^\s]*)?|\.{0,2}/[\w\-.~%!$&'()*+,;=:@]+(?:/[\w\-.~%!$&'()*+,;=:@]*)*(?:\?[^\s#]*)?(?:#[^\s]*)?)$`)
+
+// isAPIRef reports whether a string value looks like an absolute or relative url
+func isAPIRef(value string) bool {
+	return len(value) <= apiRefMaxLength && apiRefRE.MatchString(value)
+}
+
+// JSONURLs returns the sorted url-shaped string values of a JSON document, its keys excluded
+func JSONURLs(body []byte) ([]string, error) {
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	res := []string{}
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case string:
+			if value := strings.TrimSpace(v); isAPIRef(value) {
+				res = append(res, value)
+			}
+		case []any:
+			for _, item := range v {
+				walk(item)
+			}
+		case map[string]any:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+	walk(doc)
+	res = Unique(res)
+	sort.Strings(res)
+	return res, nil
+}
+
+// XMLURLs returns the url-shaped texts and attribute values of an XML document
+func XMLURLs(body []byte) ([]string, error) {
+	res := []string{}
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	dec.Strict = false
+	for {
+		token, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Unique(res), err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			for _, attr := range token.Attr {
+				if value := strings.TrimSpace(attr.Value); isAPIRef(value) {
+					res = append(res, value)
+				}
+			}
+		case xml.CharData:
+			if value := strings.TrimSpace(string(token)); isAPIRef(value) {
+				res = append(res, value)
+			}
+		}
+	}
+	return Unique(res), nil
+}
+
+// discoverAPIRefs emits the urls of a JSON or XML response as api-ref reports
+func (crawler *Crawler) discoverAPIRefs(emit func(SpiderReport), response *colly.Response) {
+	contentType := responseContentType(response)
+	var urls []string
+	var err error
+	switch {
+	case contentType == "application/json" || strings.HasSuffix(contentType, "+json"):
+		urls, err = JSONURLs(response.Body)
+	case contentType == "application/xml" || contentType == "text/xml" || strings.HasSuffix(contentType, "+xml"):
+		urls, err = XMLURLs(response.Body)
+	default:
+		return
+	}
+	if err != nil {
+		Logger.Debugf("Failed to parse %s response %s: %s", contentType, response.Request.URL, err)
+	}
+	for _, u := range urls {
+		emit(SpiderReport{
+			Output:     u,
+			OutputType: APIRef,
+			Source:     "body",
+			StatusCode: response.StatusCode,
+			Input:      response.Request.URL,
+			Seed:       requestSeed(response.Request),
+		})
+	}
+}
+
+// WithAPIRefs walks the JSON and XML responses and emits their url-shaped string values as api-ref reports,
+// which are resolved against the response url and crawled
+func WithAPIRefs() CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.apiRefs = true
+	}
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestJSONURLs(t *testing.T) {
+	body := `{"next": "/api/items?page=2", "self": "https://api.example.com/items", "/not/a/value": 1,
+"items": [{"avatar": "//cdn.example.com/a.png", "name": "John Doe", "type": "application/json", "date": "2024/01/02"}, "../docs"], "root": "/"}`
+	expected := []string{"../docs", "//cdn.example.com/a.png", "/api/items?page=2", "https://api.example.com/items"}
+	urls, err := JSONURLs([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(urls, expected) {
+		t.Errorf("expected %v, got %v", expected, urls)
+	}
+}
+
+func TestXMLURLs(t *testing.T) {
+	body := `<?xml version="1.0"?><response><link href="/api/v2/users"/><next>https://example.com/page/3</next><title>Users / all</title></response>`
+	expected := []string{"/api/v2/users", "https://example.com/page/3"}
+	urls, err := XMLURLs([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(urls, expected) {
+		t.Errorf("expected %v, got %v", expected, urls)
+	}
+}
+
+func TestAPIRefReports(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/users":
+			fmt.Fprint(w, `{"users": [{"profile": "/api/users/1"}], "next": "/api/users?page=2"}`)
+		case "/api/users/1":
+			fmt.Fprint(w, `{"avatar": "/static/1.png"}`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer ts.Close()
+
+	refs := []string{}
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(3), WithAPIRefs()), ts.URL+"/api/users") {
+		if r.OutputType == APIRef {
+			refs = append(refs, r.Output)
+		}
+	}
+	sort.Strings(refs)
+	expected := []string{ts.URL + "/api/users/1", ts.URL + "/api/users?page=2", ts.URL + "/static/1.png"}
+	if !reflect.DeepEqual(refs, expected) {
+		t.Errorf("expected the api refs %v, got %v", expected, refs)
+	}
+}
@@ -0,0 +1,152 @@
+package core
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// BodyArchiveIndex is the index file of a body archive, a JSON line per archived response
+const BodyArchiveIndex = "index.jsonl"
+
+// BodyArchiveEntry is a line of the index of a body archive
+type BodyArchiveEntry struct {
+	URL string `json:"url"`
+	// File is the name of the body in the archive directory, the sha256 of the url
+	File        string    `json:"file"`
+	Status      int       `json:"status"`
+	ContentType string    `json:"content_type,omitempty"`
+	Length      int       `json:"length"`
+	Seed        string    `json:"seed,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+// BodyArchive writes the visited response bodies in a directory, keyed by the hash of their url, and indexes them so
+// they can be analyzed later without crawling again. The body of an url visited again is replaced
+type BodyArchive struct {
+	dir  string
+	lock sync.Mutex
+}
+
+// NewBodyArchive returns a BodyArchive writing in dir, created when missing
+func NewBodyArchive(dir string) (*BodyArchive, error) {
+	dir = NormalizePath(dir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create body archive dir %s: %w", dir, err)
+	}
+	return &BodyArchive{dir: dir}, nil
+}
+
+func (archive *BodyArchive) Dir() string {
+	return archive.dir
+}
+
+// Add archives body under the url of entry, and appends entry to the index
+func (archive *BodyArchive) Add(entry BodyArchiveEntry, body []byte) error {
+	sum := sha256.Sum256([]byte(entry.URL))
+	entry.File = hex.EncodeToString(sum[:])
+	entry.Length = len(body)
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	path := filepath.Join(archive.dir, entry.File)
+	tmp, err := os.CreateTemp(archive.dir, entry.File+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %w", entry.URL, err)
+	}
+	_, err = tmp.Write(body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to archive %s: %w", entry.URL, err)
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	archive.lock.Lock()
+	defer archive.lock.Unlock()
+	index, err := os.OpenFile(filepath.Join(archive.dir, BodyArchiveIndex), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open body archive index: %w", err)
+	}
+	defer index.Close()
+	if _, err := index.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write body archive index: %w", err)
+	}
+	return nil
+}
+
+// ReadBodyArchiveIndex returns the entries of the archive in dir, the latest one of each url, in archiving order
+func ReadBodyArchiveIndex(dir string) ([]BodyArchiveEntry, error) {
+	file, err := os.Open(filepath.Join(NormalizePath(dir), BodyArchiveIndex))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open body archive index: %w", err)
+	}
+	defer file.Close()
+	all := []BodyArchiveEntry{}
+	latest := map[string]int{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		entry := BodyArchiveEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid body archive index line %s: %w", scanner.Text(), err)
+		}
+		latest[entry.URL] = len(all)
+		all = append(all, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	entries := make([]BodyArchiveEntry, 0, len(latest))
+	for i, entry := range all {
+		if latest[entry.URL] == i {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// instrument archives the body of every response of c
+func (archive *BodyArchive) instrument(c *colly.Collector) {
+	c.OnResponse(func(r *colly.Response) {
+		entry := BodyArchiveEntry{
+			URL:         r.Request.URL.String(),
+			Status:      r.StatusCode,
+			ContentType: r.Headers.Get("Content-Type"),
+			Seed:        requestSeed(r.Request),
+		}
+		if err := archive.Add(entry, r.Body); err != nil {
+			Logger.Errorf("%s", err)
+		}
+	})
+}
+
+// WithBodyArchive writes every visited response body in dir, named after the sha256 of its url, and indexes them in
+// dir/index.jsonl (see ReadBodyArchiveIndex), so secret scanning or grep can run later without crawling again.
+// The crawl fails when dir can't be created
+func WithBodyArchive(dir string) CrawlerOption {
+	return func(crawler *Crawler) {
+		archive, err := NewBodyArchive(dir)
+		if err != nil {
+			crawler.optionErrors = append(crawler.optionErrors, err)
+			return
+		}
+		crawler.bodyArchive = archive
+	}
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBodyArchive(t *testing.T) {
+	ts := newTestSite()
+	defer ts.Close()
+
+	dir := t.TempDir()
+	for i := 0; i < 2; i++ {
+		collectReports(NewCrawler(WithDefaultColly(3), WithBodyArchive(dir)), ts.URL)
+	}
+
+	entries, err := ReadBodyArchiveIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected the latest entry of 3 urls, got %+v", entries)
+	}
+	for _, entry := range entries {
+		body, err := os.ReadFile(filepath.Join(dir, entry.File))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.Status != 200 || !strings.HasPrefix(entry.ContentType, "text/html") || entry.Seed != ts.URL || len(body) != entry.Length {
+			t.Errorf("unexpected entry %+v", entry)
+		}
+		if entry.URL == ts.URL+"/b" && !strings.Contains(string(body), "leaf") {
+			t.Errorf("unexpected body of %s: %s", entry.URL, body)
+		}
+	}
+
+	// an archived body is in the way of the dir
+	if err := crawlError(t, NewCrawler(WithDefaultColly(3), WithBodyArchive(filepath.Join(dir, entries[0].File, "archive"))), ts.URL); err == nil {
+		t.Error("expected an unusable archive dir to fail the crawl")
+	}
+}
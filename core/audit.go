@@ -0,0 +1,96 @@
+package core
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// AuditRecord is a line of the audit log, written once the response of a request is read
+type AuditRecord struct {
+	Time          time.Time           `json:"time"`
+	Method        string              `json:"method"`
+	URL           string              `json:"url"`
+	Proto         string              `json:"proto"`
+	Headers       map[string][]string `json:"headers"`
+	Status        int                 `json:"status,omitempty"`
+	RequestBytes  int64               `json:"request_bytes"`
+	ResponseBytes int64               `json:"response_bytes"`
+	Duration      float64             `json:"duration_ms"`
+	Error         string              `json:"error,omitempty"`
+}
+
+// auditRedactedHeaders hold credentials, which don't belong in the audit log
+var auditRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// auditLog writes the audit records as JSON lines
+type auditLog struct {
+	lock sync.Mutex
+	enc  *json.Encoder
+}
+
+func (al *auditLog) write(record AuditRecord) {
+	al.lock.Lock()
+	defer al.lock.Unlock()
+	if err := al.enc.Encode(record); err != nil {
+		Logger.Errorf("Failed to write audit log: %s", err)
+	}
+}
+
+// auditTransport writes an audit record for every request
+type auditTransport struct {
+	next http.RoundTripper
+	log  *auditLog
+}
+
+func (at *auditTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	headers := req.Header.Clone()
+	for _, name := range auditRedactedHeaders {
+		if _, ok := headers[name]; ok {
+			headers[name] = []string{"[REDACTED]"}
+		}
+	}
+	record := AuditRecord{
+		Time:         start.UTC(),
+		Method:       req.Method,
+		URL:          req.URL.String(),
+		Proto:        req.Proto,
+		Headers:      headers,
+		RequestBytes: max(req.ContentLength, 0),
+	}
+	resp, err := at.next.RoundTrip(req)
+	if err != nil {
+		record.Duration = milliseconds(start, time.Now())
+		record.Error = err.Error()
+		at.log.write(record)
+		return nil, err
+	}
+	record.Status = resp.StatusCode
+	resp.Body = &harBody{ReadCloser: resp.Body, finish: func(size int64, _ []byte) {
+		record.ResponseBytes = size
+		record.Duration = milliseconds(start, time.Now())
+		at.log.write(record)
+	}}
+	return resp, nil
+}
+
+// auditTraffic wraps the transport of the collector so its traffic is written to the audit log
+func (crawler *Crawler) auditTraffic(c *colly.Collector) {
+	crawler.wrapTransport(c, func(next http.RoundTripper) http.RoundTripper {
+		return &auditTransport{next: next, log: &auditLog{enc: json.NewEncoder(crawler.auditLog)}}
+	})
+}
+
+// WithAuditLog writes to w a JSON line for every request sent by the crawler, e.g as evidence of the scope of a pentest:
+// its time, request line and headers, response status, byte counts and duration. The credentials of the Authorization,
+// Proxy-Authorization and Cookie headers are redacted
+func WithAuditLog(w io.Writer) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.auditLog = w
+	}
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	ts := newTestSite()
+	defer ts.Close()
+
+	log := &bytes.Buffer{}
+	collectReports(NewCrawler(WithDefaultColly(3), WithAuditLog(log), WithCollyConfig(WithCookie("session=s3cr3t"))), ts.URL+"/")
+
+	records := map[string]AuditRecord{}
+	scanner := bufio.NewScanner(log)
+	for scanner.Scan() {
+		record := AuditRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid audit record %s: %s", scanner.Text(), err)
+		}
+		records[record.URL] = record
+	}
+	for _, path := range []string{"/", "/a", "/b"} {
+		record, ok := records[ts.URL+path]
+		if !ok {
+			t.Errorf("expected an audit record for %s", path)
+			continue
+		}
+		if record.Method != "GET" || record.Status != 200 || record.ResponseBytes == 0 || record.Time.IsZero() {
+			t.Errorf("unexpected audit record for %s: %+v", path, record)
+		}
+		if cookie := record.Headers["Cookie"]; len(cookie) != 1 || cookie[0] != "[REDACTED]" {
+			t.Errorf("expected the cookie of %s to be redacted, got %v", path, cookie)
+		}
+	}
+}
+
+func TestAuditLogRestart(t *testing.T) {
+	ts := newTestSite()
+	defer ts.Close()
+
+	client := &http.Client{Transport: DefaultHTTPTransport.Clone()}
+	transport := client.Transport
+	log := &bytes.Buffer{}
+	crawler := NewCrawler(WithDefaultColly(3), WithHTTPClient(client), WithAuditLog(log))
+	collectReports(crawler, ts.URL+"/b")
+	collectReports(crawler, ts.URL+"/c")
+	if client.Transport != transport {
+		t.Errorf("expected the transport of the caller client to be left untouched, got %T", client.Transport)
+	}
+
+	records := map[string]int{}
+	scanner := bufio.NewScanner(log)
+	for scanner.Scan() {
+		record := AuditRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid audit record %s: %s", scanner.Text(), err)
+		}
+		records[record.URL]++
+	}
+	for _, path := range []string{"/b", "/c"} {
+		if records[ts.URL+path] != 1 {
+			t.Errorf("expected one audit record for %s, got %d", path, records[ts.URL+path])
+		}
+	}
+}
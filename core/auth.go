@@ -0,0 +1,370 @@
+package core
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// AuthScheme is an HTTP authentication scheme answered by WithHTTPAuth
+type AuthScheme string
+
+var (
+	// AuthBasic sends the credentials in clear, base64 encoded
+	AuthBasic AuthScheme = "basic"
+	// AuthDigest sends a hash of the credentials and of the server nonce (RFC 7616)
+	AuthDigest AuthScheme = "digest"
+)
+
+// digestChallenge is the last Digest challenge of a host, reused to authenticate its next requests
+type digestChallenge struct {
+	params map[string]string
+	nc     int
+}
+
+// httpAuthTransport answers the 401 challenges of the servers with credentials. Once a host challenged,
+// its next requests are authenticated upfront, the credentials are never sent to hosts which didn't ask for them.
+// Only the challenges of hosts, or of the hosts of the seeds when empty, are answered
+type httpAuthTransport struct {
+	next     http.RoundTripper
+	user     string
+	password string
+	schemes  []AuthScheme
+	hosts    []string
+	seeds    *seedHosts
+	// insecureBasic sends the Basic credentials over plain http
+	insecureBasic bool
+
+	lock    sync.Mutex
+	basic   map[string]bool
+	digests map[string]*digestChallenge
+	// insecure holds the hosts whose Basic challenge was ignored over plain http
+	insecure map[string]bool
+}
+
+func newHTTPAuthTransport(next http.RoundTripper) *httpAuthTransport {
+	return &httpAuthTransport{
+		next:     next,
+		basic:    make(map[string]bool),
+		digests:  make(map[string]*digestChallenge),
+		insecure: make(map[string]bool),
+	}
+}
+
+// seedHosts is the set of the hosts of the seeds of a crawl
+type seedHosts struct {
+	seeds sync.Map
+	hosts sync.Map
+}
+
+// add records the host of seed
+func (sh *seedHosts) add(seed string) {
+	if _, loaded := sh.seeds.LoadOrStore(seed, struct{}{}); loaded {
+		return
+	}
+	if u, err := url.Parse(seed); err == nil && u.Hostname() != "" {
+		sh.hosts.Store(strings.ToLower(u.Hostname()), struct{}{})
+	}
+}
+
+func (sh *seedHosts) contains(host string) bool {
+	_, ok := sh.hosts.Load(strings.ToLower(host))
+	return ok
+}
+
+// appliesTo reports whether the credentials may be sent to host
+func (at *httpAuthTransport) appliesTo(host string) bool {
+	if len(at.hosts) > 0 {
+		return hostIn(at.hosts, host)
+	}
+	return at.seeds != nil && at.seeds.contains(host)
+}
+
+// basicAllowed reports whether the Basic credentials may be sent with req
+func (at *httpAuthTransport) basicAllowed(req *http.Request) bool {
+	return req.URL.Scheme == "https" || at.insecureBasic
+}
+
+func (at *httpAuthTransport) supports(scheme AuthScheme) bool {
+	for _, s := range at.schemes {
+		if s == scheme {
+			return true
+		}
+	}
+	return false
+}
+
+// authorize sets the Authorization header of req when its host already challenged
+func (at *httpAuthTransport) authorize(req *http.Request) {
+	at.lock.Lock()
+	defer at.lock.Unlock()
+	if challenge, ok := at.digests[req.URL.Host]; ok {
+		challenge.nc++
+		req.Header.Set("Authorization", digestAuthorization(req, at.user, at.password, challenge.params, challenge.nc))
+	} else if at.basic[req.URL.Host] && at.basicAllowed(req) {
+		req.SetBasicAuth(at.user, at.password)
+	}
+}
+
+// challenge records the challenge of resp the host of req is now authenticated with, and returns its scheme ("" when none
+// is supported) and whether it is a Digest challenge whose nonce merely expired
+func (at *httpAuthTransport) challenge(req *http.Request, resp *http.Response) (AuthScheme, bool) {
+	host := req.URL.Host
+	at.lock.Lock()
+	defer at.lock.Unlock()
+	for _, header := range resp.Header.Values("WWW-Authenticate") {
+		for _, c := range splitAuthChallenges(header) {
+			scheme, params := parseAuthChallenge(c)
+			switch {
+			case strings.EqualFold(scheme, "digest") && at.supports(AuthDigest):
+				if _, ok := params["nonce"]; !ok {
+					continue
+				}
+				delete(at.basic, host)
+				at.digests[host] = &digestChallenge{params: params}
+				return AuthDigest, strings.EqualFold(params["stale"], "true")
+			case strings.EqualFold(scheme, "basic") && at.supports(AuthBasic):
+				if !at.basicAllowed(req) {
+					if !at.insecure[host] {
+						at.insecure[host] = true
+						Logger.Warnf("Not sending the Basic credentials to %s over plain http", host)
+					}
+					continue
+				}
+				delete(at.digests, host)
+				at.basic[host] = true
+				return AuthBasic, false
+			}
+		}
+	}
+	return "", false
+}
+
+func (at *httpAuthTransport) replaceWrapped(replace transportReplacer) error {
+	return replaceNext(&at.next, replace)
+}
+
+func (at *httpAuthTransport) unwrap() http.RoundTripper {
+	return at.next
+}
+
+func (at *httpAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" || !at.appliesTo(req.URL.Hostname()) {
+		return at.next.RoundTrip(req)
+	}
+	authorized := req.Clone(req.Context())
+	at.authorize(authorized)
+	resp, err := at.next.RoundTrip(authorized)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	// a request with a body is only replayed when it can be rewound
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+	// rejected credentials are not sent twice with the same scheme, unless the Digest nonce expired
+	sent, _, _ := strings.Cut(authorized.Header.Get("Authorization"), " ")
+	scheme, stale := at.challenge(req, resp)
+	if scheme == "" || (strings.EqualFold(sent, string(scheme)) && !stale) {
+		return resp, nil
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	at.authorize(retry)
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+	return at.next.RoundTrip(retry)
+}
+
+// splitAuthChallenges splits a WWW-Authenticate header holding several challenges, on the commas preceding a scheme
+func splitAuthChallenges(header string) []string {
+	var challenges []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(header); i++ {
+		switch header[i] {
+		case '"':
+			quoted = !quoted
+		case '\\':
+			i++
+		case ',':
+			if quoted {
+				continue
+			}
+			// a new challenge starts with a token followed by a space, a parameter with a token followed by =
+			rest := strings.TrimLeft(header[i+1:], " ")
+			token := strings.IndexAny(rest, " =")
+			if token > 0 && rest[token] == ' ' {
+				challenges = append(challenges, strings.TrimSpace(header[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(challenges, strings.TrimSpace(header[start:]))
+}
+
+// parseAuthChallenge returns the scheme and the lower-cased parameters of a challenge
+func parseAuthChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := make(map[string]string)
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimLeft(rest, ", ") {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimLeft(value, " ")
+		if strings.HasPrefix(value, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(value) && value[i] != '"'; i++ {
+				if value[i] == '\\' && i+1 < len(value) {
+					i++
+				}
+				b.WriteByte(value[i])
+			}
+			params[key] = b.String()
+			rest = value[min(i+1, len(value)):]
+		} else {
+			end := strings.IndexByte(value, ',')
+			if end < 0 {
+				end = len(value)
+			}
+			params[key] = strings.TrimSpace(value[:end])
+			rest = value[end:]
+		}
+	}
+	return scheme, params
+}
+
+// digestAuthorization answers the Digest challenge params for req, nc being the number of requests sent with its nonce
+func digestAuthorization(req *http.Request, user string, password string, params map[string]string, nc int) string {
+	algorithm := params["algorithm"]
+	var newHash func() hash.Hash = md5.New
+	if strings.HasPrefix(strings.ToUpper(algorithm), "SHA-256") {
+		newHash = sha256.New
+	}
+	h := func(s string) string {
+		sum := newHash()
+		sum.Write([]byte(s))
+		return hex.EncodeToString(sum.Sum(nil))
+	}
+	cnonceRaw := make([]byte, 8)
+	rand.Read(cnonceRaw)
+	cnonce := hex.EncodeToString(cnonceRaw)
+	ncValue := fmt.Sprintf("%08x", nc)
+	uri := req.URL.RequestURI()
+
+	ha1 := h(user + ":" + params["realm"] + ":" + password)
+	if strings.HasSuffix(strings.ToLower(algorithm), "-sess") {
+		ha1 = h(ha1 + ":" + params["nonce"] + ":" + cnonce)
+	}
+	ha2 := h(req.Method + ":" + uri)
+	qop := ""
+	for _, q := range strings.Split(params["qop"], ",") {
+		if strings.TrimSpace(q) == "auth" {
+			qop = "auth"
+		}
+	}
+	var response string
+	if qop != "" {
+		response = h(strings.Join([]string{ha1, params["nonce"], ncValue, cnonce, qop, ha2}, ":"))
+	} else {
+		response = h(ha1 + ":" + params["nonce"] + ":" + ha2)
+	}
+
+	fields := []string{
+		fmt.Sprintf("username=%q", user),
+		fmt.Sprintf("realm=%q", params["realm"]),
+		fmt.Sprintf("nonce=%q", params["nonce"]),
+		fmt.Sprintf("uri=%q", uri),
+		fmt.Sprintf("response=%q", response),
+	}
+	if algorithm != "" {
+		fields = append(fields, "algorithm="+algorithm)
+	}
+	if opaque, ok := params["opaque"]; ok {
+		fields = append(fields, fmt.Sprintf("opaque=%q", opaque))
+	}
+	if qop != "" {
+		fields = append(fields, "qop="+qop, "nc="+ncValue, fmt.Sprintf("cnonce=%q", cnonce))
+	}
+	return "Digest " + strings.Join(fields, ", ")
+}
+
+// clientHTTPAuthTransport returns the authentication transport of client, wrapping its transport when it has none yet
+func clientHTTPAuthTransport(client *http.Client) *httpAuthTransport {
+	if at, ok := client.Transport.(*httpAuthTransport); ok {
+		return at
+	}
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	at := newHTTPAuthTransport(next)
+	client.Transport = at
+	return at
+}
+
+// scopeCredentials restricts the credentials of the authentication transports of client to the hosts of seeds,
+// unless they were given their own hosts
+func scopeCredentials(client *http.Client, seeds *seedHosts) {
+	for _, rt := range clientTransports(client) {
+		if at, ok := rt.(*httpAuthTransport); ok {
+			at.seeds = seeds
+		}
+	}
+}
+
+// WithHTTPAuth answers the 401 challenges of the servers with user and password, using schemes (Basic and Digest when empty).
+// Only the challenges of the hosts of the seeds are answered, see WithHTTPAuthHosts, and the Basic credentials are
+// only sent over https, see WithInsecureBasicAuth
+func WithHTTPAuth(user string, password string, schemes ...AuthScheme) HTTPClientConfigurator {
+	if len(schemes) == 0 {
+		schemes = []AuthScheme{AuthBasic, AuthDigest}
+	}
+	return func(client *http.Client) error {
+		at := clientHTTPAuthTransport(client)
+		at.user, at.password, at.schemes = user, password, schemes
+		return nil
+	}
+}
+
+// WithHTTPAuthHosts answers the challenges of hosts with the credentials of WithHTTPAuth instead of the ones of the hosts of the seeds
+func WithHTTPAuthHosts(hosts ...string) HTTPClientConfigurator {
+	return func(client *http.Client) error {
+		at := clientHTTPAuthTransport(client)
+		at.hosts = append(at.hosts, hosts...)
+		return nil
+	}
+}
+
+// WithInsecureBasicAuth lets WithHTTPAuth send the Basic credentials, readable by anyone on the path, over plain http
+func WithInsecureBasicAuth() HTTPClientConfigurator {
+	return func(client *http.Client) error {
+		clientHTTPAuthTransport(client).insecureBasic = true
+		return nil
+	}
+}
+
+// WithBasicAuth answers the Basic 401 challenges of the servers with user and password
+func WithBasicAuth(user string, password string) HTTPClientConfigurator {
+	return WithHTTPAuth(user, password, AuthBasic)
+}
+
+// WithDigestAuth answers the Digest 401 challenges of the servers with user and password
+func WithDigestAuth(user string, password string) HTTPClientConfigurator {
+	return WithHTTPAuth(user, password, AuthDigest)
+}
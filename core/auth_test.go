@@ -0,0 +1,162 @@
+package core
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestParseAuthChallenges(t *testing.T) {
+	challenges := splitAuthChallenges(`Digest realm="a, b", qop="auth,auth-int", nonce="n1", Basic realm="c"`)
+	if len(challenges) != 2 {
+		t.Fatalf("expected 2 challenges, got %q", challenges)
+	}
+	scheme, params := parseAuthChallenge(challenges[0])
+	if scheme != "Digest" || params["realm"] != "a, b" || params["qop"] != "auth,auth-int" || params["nonce"] != "n1" {
+		t.Errorf("unexpected digest challenge %s %v", scheme, params)
+	}
+	if scheme, params := parseAuthChallenge(challenges[1]); scheme != "Basic" || params["realm"] != "c" {
+		t.Errorf("unexpected basic challenge %s %v", scheme, params)
+	}
+}
+
+func TestHTTPAuth(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/basic":
+			if user, pass, ok := r.BasicAuth(); ok && user == "admin" && pass == "secret" {
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Basic realm="crawl"`)
+		case "/digest":
+			_, params := parseAuthChallenge(r.Header.Get("Authorization"))
+			ha1 := md5Hex("admin:crawl:secret")
+			ha2 := md5Hex(r.Method + ":" + params["uri"])
+			expected := md5Hex(fmt.Sprintf("%s:n1:%s:%s:auth:%s", ha1, params["nc"], params["cnonce"], ha2))
+			if params["response"] != "" && params["response"] == expected && params["opaque"] == "o1" {
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Digest realm="crawl", qop="auth", nonce="n1", opaque="o1"`)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	client := &http.Client{}
+	WithHTTPAuth("admin", "secret")(client)
+	WithHTTPAuthHosts("127.0.0.1")(client)
+	WithInsecureBasicAuth()(client)
+	for _, path := range []string{"/basic", "/digest"} {
+		for i := 0; i < 2; i++ {
+			resp, err := client.Get(ts.URL + path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("%s: expected to be authenticated, got %d", path, resp.StatusCode)
+			}
+		}
+	}
+	// the host is authenticated upfront after a challenge, the digest one replacing the basic one
+	if requests != 6 {
+		t.Errorf("expected 6 requests, got %d", requests)
+	}
+
+	client = &http.Client{}
+	WithBasicAuth("admin", "wrong")(client)
+	WithHTTPAuthHosts("127.0.0.1")(client)
+	requests = 0
+	resp, err := client.Get(ts.URL + "/digest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized || requests != 1 {
+		t.Errorf("expected a basic only client to ignore digest challenges, got %d after %d requests", resp.StatusCode, requests)
+	}
+}
+
+// newBasicAuthSite serves the pages to admin:secret and counts the requests carrying credentials
+func newBasicAuthSite(tls bool, credentials *atomic.Int32) *httptest.Server {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			credentials.Add(1)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="crawl"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><a href="/private">private</a></body></html>`)
+	})
+	if tls {
+		return httptest.NewTLSServer(handler)
+	}
+	return httptest.NewServer(handler)
+}
+
+func TestHTTPAuthSeedHosts(t *testing.T) {
+	var credentials atomic.Int32
+	ts := newBasicAuthSite(false, &credentials)
+	defer ts.Close()
+
+	crawler := NewCrawler(WithDefaultColly(2), WithHTTPClientOpt(WithHTTPAuth("admin", "secret"), WithInsecureBasicAuth()))
+	visited := false
+	for _, r := range collectReports(crawler, ts.URL+"/") {
+		visited = visited || r.Output == ts.URL+"/private"
+	}
+	if !visited {
+		t.Error("expected the seed host to be authenticated")
+	}
+
+	// the same server reached through another host name isn't a seed host
+	credentials.Store(0)
+	other := strings.Replace(ts.URL, "127.0.0.1", "localhost", 1)
+	resp, err := crawler.httpClient.Get(other + "/private")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized || credentials.Load() != 0 {
+		t.Errorf("expected no credentials to be sent to %s, got %s after %d authenticated requests", other, resp.Status, credentials.Load())
+	}
+}
+
+func TestHTTPAuthBasicOverHTTP(t *testing.T) {
+	var credentials atomic.Int32
+	ts := newBasicAuthSite(false, &credentials)
+	defer ts.Close()
+	tlsSite := newBasicAuthSite(true, &credentials)
+	defer tlsSite.Close()
+
+	for _, site := range []*httptest.Server{ts, tlsSite} {
+		client := site.Client()
+		WithBasicAuth("admin", "secret")(client)
+		WithHTTPAuthHosts("127.0.0.1")(client)
+		resp, err := client.Get(site.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if tls := site == tlsSite; (resp.StatusCode == http.StatusOK) != tls {
+			t.Errorf("expected the Basic credentials to be sent over https only, got %s from %s", resp.Status, site.URL)
+		}
+	}
+	if credentials.Load() != 1 {
+		t.Errorf("expected a single authenticated request, got %d", credentials.Load())
+	}
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+var (
+	FirebaseDatabaseRE = regexp.MustCompile(`(?i)\b([a-z0-9-]+)\.firebaseio\.com|\b([a-z0-9-]+)\.[a-z0-9-]+\.firebasedatabase\.app`)
+	FirebaseAppRE      = regexp.MustCompile(`(?i)\b([a-z0-9-]+)\.firebaseapp\.com`)
+	SupabaseRE         = regexp.MustCompile(`(?i)\b([a-z0-9]{20})\.supabase\.co\b`)
+	backendProjects    = []struct {
+		outputType OutputType
+		re         *regexp.Regexp
+	}{{FirebaseDatabase, FirebaseDatabaseRE}, {FirebaseApp, FirebaseAppRE}, {Supabase, SupabaseRE}}
+)
+
+// BackendProject is the url of a Firebase or Supabase project, OutputType is its service
+type BackendProject struct {
+	URL        string
+	Project    string
+	OutputType OutputType
+}
+
+// GetBackendProjects returns the Firebase realtime databases and hosting, and the Supabase projects of source
+func GetBackendProjects(source string) []BackendProject {
+	var res []BackendProject
+	for _, backend := range backendProjects {
+		for _, match := range backend.re.FindAllStringSubmatch(source, -1) {
+			project := ""
+			for _, group := range match[1:] {
+				project += group
+			}
+			res = append(res, BackendProject{URL: strings.ToLower(match[0]), Project: strings.ToLower(project), OutputType: backend.outputType})
+		}
+	}
+	return res
+}
+
+// detectBackends emits the Firebase and Supabase projects referenced by body
+func (crawler *Crawler) detectBackends(emit func(SpiderReport), request *colly.Request, body string) {
+	for _, backend := range GetBackendProjects(body) {
+		emit(SpiderReport{
+			Output:     backend.URL,
+			OutputType: backend.OutputType,
+			Source:     "body",
+			Input:      request.URL,
+			Seed:       requestSeed(request),
+		}.WithMetadata("project", backend.Project))
+	}
+}
+
+// WithBackendDetection emits a report for every Firebase realtime database, Firebase hosting and Supabase project
+// referenced by the bodies, as exposed Firebase databases are a common high-impact finding
+func WithBackendDetection() CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.backendDetection = true
+	}
+}
@@ -0,0 +1,42 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGetBackendProjects(t *testing.T) {
+	source := `firebase.initializeApp({authDomain: "shop-prod.firebaseapp.com", databaseURL: "https://Shop-Prod.firebaseio.com"});
+const db = "https://shop-eu.europe-west1.firebasedatabase.app";
+const supabase = createClient("https://abcdefghijklmnopqrst.supabase.co", key);`
+	expected := []BackendProject{
+		{URL: "shop-prod.firebaseio.com", Project: "shop-prod", OutputType: FirebaseDatabase},
+		{URL: "shop-eu.europe-west1.firebasedatabase.app", Project: "shop-eu", OutputType: FirebaseDatabase},
+		{URL: "shop-prod.firebaseapp.com", Project: "shop-prod", OutputType: FirebaseApp},
+		{URL: "abcdefghijklmnopqrst.supabase.co", Project: "abcdefghijklmnopqrst", OutputType: Supabase},
+	}
+	if found := GetBackendProjects(source); !reflect.DeepEqual(found, expected) {
+		t.Errorf("expected %+v, got %+v", expected, found)
+	}
+}
+
+func TestBackendReports(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><script>var config = {databaseURL: "https://acme-dev.firebaseio.com"}</script></body></html>`)
+	}))
+	defer ts.Close()
+
+	reports := []SpiderReport{}
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(1), WithBackendDetection()), ts.URL) {
+		if r.OutputType == FirebaseDatabase {
+			reports = append(reports, r)
+		}
+	}
+	if len(reports) != 1 || reports[0].Output != "acme-dev.firebaseio.com" || reports[0].Metadata["project"] != "acme-dev" {
+		t.Errorf("expected the acme-dev firebase database, got %+v", reports)
+	}
+}
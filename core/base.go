@@ -0,0 +1,90 @@
+package core
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// baseContextKey holds the <base href> of an html response, so the urls found before colly parses the document
+// resolve like the ones of the html callbacks
+const baseContextKey = "gospider.base"
+
+var (
+	baseHrefRE   = regexp.MustCompile(`(?is)<base\s(?:[^>]*?\s)?href\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	refreshURLRE = regexp.MustCompile(`(?is)^\s*(?:\d[\d.]*|\.[\d.]*)\s*[;,]?\s*(?:url\s*=\s*)?(.*)$`)
+)
+
+// BaseHref returns the href of the first <base> element of an html page, empty when the page declares none
+func BaseHref(body string) string {
+	m := baseHrefRE.FindStringSubmatch(body)
+	if m == nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.Join(m[1:], ""))
+}
+
+// RefreshURL returns the target of a refresh directive, the content of a <meta http-equiv="refresh"> or of a Refresh
+// header: "0; url='/next'" gives /next. It returns an empty string when the directive only reloads the page
+func RefreshURL(content string) string {
+	m := refreshURLRE.FindStringSubmatch(content)
+	if m == nil {
+		return ""
+	}
+	u := strings.TrimSpace(m[1])
+	if len(u) > 1 && (u[0] == '"' || u[0] == '\'') {
+		if end := strings.IndexByte(u[1:], u[0]); end >= 0 {
+			u = u[1 : end+1]
+		} else {
+			u = u[1:]
+		}
+	}
+	return strings.TrimSpace(u)
+}
+
+// setDocumentBase records the <base href> of an html response in its context
+func setDocumentBase(response *colly.Response, body string) {
+	if !strings.Contains(responseContentType(response), "html") {
+		return
+	}
+	if href := BaseHref(body); href != "" {
+		if base, err := response.Request.URL.Parse(href); err == nil {
+			response.Ctx.Put(baseContextKey, base.String())
+		}
+	}
+}
+
+// absoluteURL resolves u like request.AbsoluteURL, against the <base href> of the document when it declares one
+func absoluteURL(request *colly.Request, u string) string {
+	href := request.Ctx.Get(baseContextKey)
+	if href == "" || strings.HasPrefix(u, "#") {
+		return request.AbsoluteURL(u)
+	}
+	base, err := url.Parse(href)
+	if err != nil {
+		return request.AbsoluteURL(u)
+	}
+	abs, err := base.Parse(u)
+	if err != nil {
+		return ""
+	}
+	abs.Fragment = ""
+	return abs.String()
+}
+
+// discoverRefresh emits the target of a refresh directive as a ref
+func discoverRefresh(emit func(SpiderReport), request *colly.Request, directive string, source string) {
+	target := RefreshURL(directive)
+	if target == "" {
+		return
+	}
+	emit(SpiderReport{
+		Output:     absoluteURL(request, target),
+		OutputType: Ref,
+		Source:     source,
+		Input:      request.URL,
+		Seed:       requestSeed(request),
+	})
+}
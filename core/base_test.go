@@ -0,0 +1,69 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestRefreshURL(t *testing.T) {
+	tests := map[string]string{
+		"0; url=/next":           "/next",
+		"5;URL='login.php?a=1'":  "login.php?a=1",
+		` 3 , url="/quoted" `:    "/quoted",
+		"0;https://example.com/": "https://example.com/",
+		"30":                     "",
+		"url=/missing-delay":     "",
+	}
+	for directive, expected := range tests {
+		if u := RefreshURL(directive); u != expected {
+			t.Errorf("expected %q for %q, got %q", expected, directive, u)
+		}
+	}
+	if href := BaseHref(`<head><BASE target="_top" HREF='/app/'></head>`); href != "/app/" {
+		t.Errorf("expected the base href, got %q", href)
+	}
+	if href := BaseHref(`<base data-href="/no/">`); href != "" {
+		t.Errorf("expected no base href, got %q", href)
+	}
+}
+
+func TestBaseAndRefresh(t *testing.T) {
+	var lock sync.Mutex
+	visited := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		visited = append(visited, r.URL.Path)
+		lock.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><head><base href="/app/"><meta http-equiv="Refresh" content="0; url=home.jsp"></head>
+<body><a href="page.jsp">page</a><form action="search.jsp"><input name="q"></form></body></html>`)
+		case "/app/home.jsp":
+			w.Header().Set("Refresh", "1; url=/final.jsp")
+		}
+	}))
+	defer ts.Close()
+
+	var form string
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(3)), ts.URL) {
+		if r.OutputType == Form {
+			form = r.Output
+		}
+	}
+	if form != ts.URL+"/app/search.jsp" {
+		t.Errorf("expected the form action to resolve against the base, got %s", form)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	sort.Strings(visited)
+	expected := []string{"/", "/app/home.jsp", "/app/page.jsp", "/final.jsp"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("expected the visits %v, got %v", expected, visited)
+	}
+}
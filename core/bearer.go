@@ -0,0 +1,179 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenRefresher returns a new bearer token, e.g by calling the token endpoint of an OAuth server
+type TokenRefresher func(ctx context.Context) (string, error)
+
+// BearerTokenRefreshMargin is how long before the expiration of a JWT it is refreshed
+var BearerTokenRefreshMargin = 30 * time.Second
+
+// bearerTransport authenticates the requests with a bearer token, refreshed on 401 and before its expiration
+type bearerTransport struct {
+	next  http.RoundTripper
+	hosts []string
+
+	lock      sync.Mutex
+	token     string
+	expires   time.Time
+	refresher TokenRefresher
+}
+
+// jwtExpiration returns the exp claim of token, the zero time when it isn't a JWT or doesn't expire
+func jwtExpiration(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}
+	}
+	claims := struct {
+		Exp json.Number `json:"exp"`
+	}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}
+	}
+	exp, err := claims.Exp.Float64()
+	if err != nil || exp <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(exp), 0)
+}
+
+func (bt *bearerTransport) setToken(token string) {
+	bt.token = token
+	bt.expires = jwtExpiration(token)
+}
+
+// currentToken returns the token, refreshed first when it expires within BearerTokenRefreshMargin
+func (bt *bearerTransport) currentToken(ctx context.Context) string {
+	bt.lock.Lock()
+	defer bt.lock.Unlock()
+	if bt.refresher != nil && (bt.token == "" || (!bt.expires.IsZero() && time.Until(bt.expires) < BearerTokenRefreshMargin)) {
+		bt.refresh(ctx)
+	}
+	return bt.token
+}
+
+// refreshAfterRejection refreshes the token rejected by a 401, unless another request already did
+func (bt *bearerTransport) refreshAfterRejection(ctx context.Context, rejected string) string {
+	bt.lock.Lock()
+	defer bt.lock.Unlock()
+	if bt.refresher != nil && bt.token == rejected {
+		bt.refresh(ctx)
+	}
+	return bt.token
+}
+
+func (bt *bearerTransport) refresh(ctx context.Context) {
+	token, err := bt.refresher(ctx)
+	if err != nil {
+		Logger.Errorf("Failed to refresh bearer token: %s", err)
+		return
+	}
+	Logger.Debugf("Bearer token refreshed")
+	bt.setToken(token)
+}
+
+// hostIn reports whether host is one of hosts, any host being in an empty list
+func hostIn(hosts []string, host string) bool {
+	if len(hosts) == 0 {
+		return true
+	}
+	for _, h := range hosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+func (bt *bearerTransport) replaceWrapped(replace transportReplacer) error {
+	return replaceNext(&bt.next, replace)
+}
+
+func (bt *bearerTransport) unwrap() http.RoundTripper {
+	return bt.next
+}
+
+func (bt *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" || !hostIn(bt.hosts, req.URL.Hostname()) {
+		return bt.next.RoundTrip(req)
+	}
+	token := bt.currentToken(req.Context())
+	if token == "" {
+		return bt.next.RoundTrip(req)
+	}
+	authorized := req.Clone(req.Context())
+	authorized.Header.Set("Authorization", "Bearer "+token)
+	resp, err := bt.next.RoundTrip(authorized)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || bt.refresher == nil {
+		return resp, err
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+	refreshed := bt.refreshAfterRejection(req.Context(), token)
+	if refreshed == token {
+		return resp, nil
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	retry.Header.Set("Authorization", "Bearer "+refreshed)
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+	return bt.next.RoundTrip(retry)
+}
+
+// clientBearerTransport returns the bearer transport of client, wrapping its transport when it has none yet
+func clientBearerTransport(client *http.Client) *bearerTransport {
+	if bt, ok := client.Transport.(*bearerTransport); ok {
+		return bt
+	}
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	bt := &bearerTransport{next: next}
+	client.Transport = bt
+	return bt
+}
+
+// WithBearerToken authenticates the requests to hosts, every host when empty, with the bearer token, e.g a JWT
+func WithBearerToken(token string, hosts ...string) HTTPClientConfigurator {
+	return func(client *http.Client) error {
+		bt := clientBearerTransport(client)
+		bt.lock.Lock()
+		defer bt.lock.Unlock()
+		bt.setToken(token)
+		bt.hosts = append(bt.hosts, hosts...)
+		return nil
+	}
+}
+
+// WithTokenRefresher gets a new bearer token from refresher when a request is rejected with a 401, and before a JWT
+// expires. Without WithBearerToken, the first token is requested from refresher
+func WithTokenRefresher(refresher TokenRefresher) HTTPClientConfigurator {
+	return func(client *http.Client) error {
+		bt := clientBearerTransport(client)
+		bt.lock.Lock()
+		defer bt.lock.Unlock()
+		bt.refresher = refresher
+		return nil
+	}
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func testJWT(exp time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":"crawler","exp":%d}`, exp.Unix())))
+	return "eyJhbGciOiJIUzI1NiJ9." + payload + ".c2lnbmF0dXJl"
+}
+
+func TestBearerTokenRefresh(t *testing.T) {
+	valid := "fresh"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+valid {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer ts.Close()
+
+	refreshes := 0
+	refresher := func(ctx context.Context) (string, error) {
+		refreshes++
+		return valid, nil
+	}
+	client := &http.Client{}
+	WithBearerToken("stale")(client)
+	WithTokenRefresher(refresher)(client)
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected the refreshed token to be accepted, got %d", resp.StatusCode)
+		}
+	}
+	if refreshes != 1 {
+		t.Errorf("expected a single refresh, got %d", refreshes)
+	}
+
+	// an expiring JWT is refreshed before being sent
+	soon := time.Now().Add(10 * time.Second)
+	expiring := testJWT(soon)
+	if exp := jwtExpiration(expiring); exp.Unix() != soon.Unix() {
+		t.Errorf("unexpected JWT expiration %s", exp)
+	}
+	valid = testJWT(time.Now().Add(time.Hour))
+	client = &http.Client{}
+	WithBearerToken(expiring)(client)
+	WithTokenRefresher(refresher)(client)
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || refreshes != 2 {
+		t.Errorf("expected the expiring JWT to be refreshed upfront, got %d after %d refreshes", resp.StatusCode, refreshes)
+	}
+
+	// the token is only sent to its hosts
+	client = &http.Client{}
+	WithBearerToken(valid, "api.example.com")(client)
+	resp, err = client.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected the token not to be sent to %s", ts.URL)
+	}
+}
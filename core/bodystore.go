@@ -0,0 +1,89 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// BodyStore keeps response bodies out of the reports. Put returns a reference which can later be passed to Get
+type BodyStore interface {
+	Put(body []byte) (string, error)
+	Get(ref string) ([]byte, error)
+}
+
+// DiskBodyStore is a content addressed BodyStore: bodies are written once in dir, named after their sha256
+type DiskBodyStore struct {
+	dir string
+	// temp is set when dir was created by the store, which removes it on Close
+	temp bool
+}
+
+// NewDiskBodyStore returns a DiskBodyStore writing in dir. If dir is empty a new temporary directory is created,
+// and removed with the bodies on Close
+func NewDiskBodyStore(dir string) (*DiskBodyStore, error) {
+	if dir == "" {
+		tmp, err := os.MkdirTemp("", "gospider-bodies-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create body store temp dir: %w", err)
+		}
+		return &DiskBodyStore{dir: tmp, temp: true}, nil
+	}
+	if err := os.MkdirAll(NormalizePath(dir), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create body store dir %s: %w", dir, err)
+	}
+	return &DiskBodyStore{dir: NormalizePath(dir)}, nil
+}
+
+func (store *DiskBodyStore) Dir() string {
+	return store.dir
+}
+
+func (store *DiskBodyStore) Put(body []byte) (string, error) {
+	sum := sha256.Sum256(body)
+	ref := hex.EncodeToString(sum[:])
+	path := filepath.Join(store.dir, ref)
+	if _, err := os.Stat(path); err == nil {
+		return ref, nil
+	}
+	tmp, err := os.CreateTemp(store.dir, ref+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to store body: %w", err)
+	}
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to store body: %w", err)
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to store body: %w", err)
+	}
+	return ref, nil
+}
+
+// Close removes the temporary directory created by the store, if any. The bodies of a dir given to NewDiskBodyStore are kept
+func (store *DiskBodyStore) Close() error {
+	if !store.temp {
+		return nil
+	}
+	if err := os.RemoveAll(store.dir); err != nil {
+		return fmt.Errorf("failed to remove body store temp dir %s: %w", store.dir, err)
+	}
+	return nil
+}
+
+func (store *DiskBodyStore) Get(ref string) ([]byte, error) {
+	if _, err := hex.DecodeString(ref); err != nil || len(ref) != sha256.Size*2 {
+		return nil, fmt.Errorf("invalid body reference %s", ref)
+	}
+	body, err := os.ReadFile(filepath.Join(store.dir, ref))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("body %s not found in store", ref)
+	}
+	return body, err
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiskBodyStore(t *testing.T) {
+	store, err := NewDiskBodyStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := SpiderReport{Output: "https://example.com", OutputType: Url}.withBody("<html>hello</html>", store)
+	if report.Body != "" || report.BodyRef == "" {
+		t.Fatalf("expected body to be stored on disk, got %+v", report)
+	}
+	body, err := report.LoadBody()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "<html>hello</html>" {
+		t.Errorf("unexpected loaded body %s", body)
+	}
+	if _, err := store.Get("../../etc/passwd"); err == nil {
+		t.Error("expected invalid reference error")
+	}
+}
+
+func TestDiskBodiesCleanup(t *testing.T) {
+	ts := newTestSite()
+	defer ts.Close()
+
+	for _, dir := range []string{"", t.TempDir()} {
+		crawler := NewCrawler(WithDefaultColly(3), WithDiskBodies(dir))
+		store := crawler.bodyStore.(*DiskBodyStore)
+		stored := 0
+		for _, r := range collectReports(crawler, ts.URL) {
+			if r.BodyRef != "" {
+				stored++
+			}
+		}
+		_, err := os.Stat(store.Dir())
+		if stored == 0 || os.IsNotExist(err) != (dir == "") {
+			t.Errorf("expected the bodies to be removed at the end of the crawl only from a temporary dir, got %d bodies and %v for %q", stored, err, dir)
+		}
+	}
+
+	// a file is in the way of the dir
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := crawlError(t, NewCrawler(WithDefaultColly(3), WithDiskBodies(filepath.Join(file, "bodies"))), ts.URL); err == nil {
+		t.Error("expected an unusable body dir to fail the crawl")
+	}
+}
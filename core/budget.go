@@ -0,0 +1,123 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+var ErrBudgetExhausted = errors.New("crawl budget exhausted")
+
+// crawlBudget bounds the number of visits, the downloaded bytes and the duration of a crawl.
+// Once a limit is reached no new visit is scheduled, in-flight and queued requests complete
+type crawlBudget struct {
+	maxVisits   int
+	maxBytes    int64
+	maxDuration time.Duration
+
+	lock      sync.Mutex
+	visits    int
+	bytes     int64
+	startedAt time.Time
+	reason    string
+}
+
+func (cb *crawlBudget) start() {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	if cb.startedAt.IsZero() {
+		cb.startedAt = time.Now()
+	}
+}
+
+// exhaustedLocked returns the reason why the budget is exhausted, the caller must hold the lock
+func (cb *crawlBudget) exhaustedLocked() string {
+	if cb.reason != "" {
+		return cb.reason
+	}
+	switch {
+	case cb.maxBytes > 0 && cb.bytes >= cb.maxBytes:
+		cb.reason = fmt.Sprintf("max bytes of %d reached", cb.maxBytes)
+	case cb.maxDuration > 0 && !cb.startedAt.IsZero() && time.Since(cb.startedAt) >= cb.maxDuration:
+		cb.reason = fmt.Sprintf("max duration of %s reached", cb.maxDuration)
+	}
+	if cb.reason != "" {
+		Logger.Warnf("Crawl budget exhausted: %s", cb.reason)
+	}
+	return cb.reason
+}
+
+// exhausted returns the reason why the budget is exhausted, an empty string otherwise
+func (cb *crawlBudget) exhausted() string {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	return cb.exhaustedLocked()
+}
+
+// reserve takes a visit from the budget, it returns false when the budget is exhausted
+func (cb *crawlBudget) reserve() bool {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	if cb.exhaustedLocked() != "" {
+		return false
+	}
+	// the visit budget is only exhausted by a visit exceeding it
+	if cb.maxVisits > 0 && cb.visits >= cb.maxVisits {
+		cb.reason = fmt.Sprintf("max visits of %d reached", cb.maxVisits)
+		Logger.Warnf("Crawl budget exhausted: %s", cb.reason)
+		return false
+	}
+	cb.visits++
+	return true
+}
+
+// release gives back a visit which could not be scheduled
+func (cb *crawlBudget) release() {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	cb.visits--
+}
+
+func (cb *crawlBudget) instrument(c *colly.Collector) {
+	c.OnResponse(func(r *colly.Response) {
+		cb.lock.Lock()
+		defer cb.lock.Unlock()
+		cb.bytes += int64(len(r.Body))
+	})
+	c.OnError(func(r *colly.Response, err error) {
+		cb.lock.Lock()
+		defer cb.lock.Unlock()
+		cb.bytes += int64(len(r.Body))
+	})
+}
+
+func (crawler *Crawler) getBudget() *crawlBudget {
+	if crawler.budget == nil {
+		crawler.budget = &crawlBudget{}
+	}
+	return crawler.budget
+}
+
+// WithMaxVisits stops scheduling new visits once n requests were scheduled
+func WithMaxVisits(n int) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.getBudget().maxVisits = n
+	}
+}
+
+// WithMaxBytes stops scheduling new visits once n response bytes were downloaded
+func WithMaxBytes(n int64) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.getBudget().maxBytes = n
+	}
+}
+
+// WithMaxCrawlDuration stops scheduling new visits once the crawl has been running for d
+func WithMaxCrawlDuration(d time.Duration) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.getBudget().maxDuration = d
+	}
+}
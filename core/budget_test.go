@@ -0,0 +1,53 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCrawlBudget(t *testing.T) {
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "text/html")
+		// every page links to 5 new pages
+		links := ""
+		for i := 0; i < 5; i++ {
+			links += fmt.Sprintf(`<a href="%s/%d">%d</a>`, strings.TrimSuffix(r.URL.Path, "/"), i, i)
+		}
+		fmt.Fprintf(w, `<html><body>%s</body></html>`, links)
+	}))
+	defer ts.Close()
+
+	for name, tc := range map[string]struct {
+		opt         CrawlerOption
+		maxRequests int32
+	}{
+		"visits":   {WithMaxVisits(4), 4},
+		"bytes":    {WithMaxBytes(100), 30},
+		"duration": {WithMaxCrawlDuration(time.Nanosecond), 1},
+	} {
+		requests.Store(0)
+		reports := collectReports(NewCrawler(WithDefaultColly(5), tc.opt), ts.URL)
+		if got := requests.Load(); got > tc.maxRequests {
+			t.Errorf("%s: expected at most %d requests, got %d", name, tc.maxRequests, got)
+		}
+		last := reports[len(reports)-1]
+		if last.OutputType != BudgetExhausted || !strings.Contains(last.Output, name) {
+			t.Errorf("%s: expected a final budget-exhausted report, got %s %s", name, last.OutputType, last.Output)
+		}
+	}
+
+	site := newTestSite()
+	defer site.Close()
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(3), WithMaxVisits(3)), site.URL) {
+		if r.OutputType == BudgetExhausted {
+			t.Error("unexpected budget-exhausted report for a crawl within budget")
+		}
+	}
+}
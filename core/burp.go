@@ -0,0 +1,188 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// burpRequest is a raw request saved from Burp, replayed as a crawl seed by WithBurpReplay
+type burpRequest struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+// burpSkippedHeaders are the headers bound to a request, not merged in the session headers
+var burpSkippedHeaders = map[string]bool{
+	"Host":              true,
+	"Content-Length":    true,
+	"Content-Type":      true,
+	"Connection":        true,
+	"Accept-Encoding":   true,
+	"Transfer-Encoding": true,
+}
+
+// readBurpFile parses a raw HTTP request saved from Burp. The raw request has no scheme,
+// https is assumed unless the host is on port 80
+func readBurpFile(path string) (burpRequest, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return burpRequest{}, fmt.Errorf("failed to open Burp File: %w", err)
+	}
+	// Burp saves the requests with bare \n line endings
+	raw = bytes.ReplaceAll(bytes.ReplaceAll(raw, []byte("\r\n"), []byte("\n")), []byte("\n"), []byte("\r\n"))
+	req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(raw)))
+	if err != nil {
+		return burpRequest{}, fmt.Errorf("failed to Parse Raw Request in %s: %w", path, err)
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return burpRequest{}, fmt.Errorf("failed to read the body of %s: %w", path, err)
+	}
+	u := req.URL
+	if u.Host == "" {
+		u.Host = req.Host
+	}
+	if u.Scheme == "" {
+		u.Scheme = "https"
+		if _, port, err := net.SplitHostPort(u.Host); err == nil && port == "80" {
+			u.Scheme = "http"
+		}
+	}
+	return burpRequest{Method: req.Method, URL: u.String(), Header: req.Header, Body: body}, nil
+}
+
+// readBurpRequests parses the raw request of path, or the ones of the files of the directory path sorted by name
+func readBurpRequests(path string) ([]burpRequest, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Burp File: %w", err)
+	}
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Burp directory %s: %w", path, err)
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			if entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), ".") {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+		sort.Strings(files)
+	}
+	requests := make([]burpRequest, 0, len(files))
+	for _, file := range files {
+		req, err := readBurpFile(file)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, req)
+	}
+	return requests, nil
+}
+
+// burpSessionHeaders merges the headers and the cookies of requests, the last request winning
+func burpSessionHeaders(requests []burpRequest) (http.Header, string) {
+	headers := http.Header{}
+	cookies := map[string]*http.Cookie{}
+	cookieNames := []string{}
+	for _, req := range requests {
+		for name, values := range req.Header {
+			if burpSkippedHeaders[name] || name == "Cookie" {
+				continue
+			}
+			headers.Set(name, strings.TrimSpace(values[0]))
+		}
+		for _, cookie := range (&http.Request{Header: req.Header}).Cookies() {
+			if _, ok := cookies[cookie.Name]; !ok {
+				cookieNames = append(cookieNames, cookie.Name)
+			}
+			cookies[cookie.Name] = cookie
+		}
+	}
+	merged := make([]*http.Cookie, 0, len(cookieNames))
+	for _, name := range cookieNames {
+		merged = append(merged, cookies[name])
+	}
+	return headers, GetRawCookie(merged)
+}
+
+// burpSession sets the merged headers and cookies of requests on every request of c
+func burpSession(c *colly.Collector, requests []burpRequest) {
+	headers, cookie := burpSessionHeaders(requests)
+	c.OnRequest(func(r *colly.Request) {
+		if cookie != "" {
+			r.Headers.Set("Cookie", cookie)
+		}
+		for k, v := range headers {
+			r.Headers.Set(k, v[0])
+		}
+	})
+}
+
+// WithBurpFile sets the headers and the cookies of a raw request saved from Burp on every request.
+// burpFile may be a directory of raw requests, whose headers and cookies are merged
+func WithBurpFile(burpFile string) CollyConfigurator {
+	return func(c *colly.Collector) error {
+		requests, err := readBurpRequests(burpFile)
+		if err != nil {
+			return err
+		}
+		burpSession(c, requests)
+		return nil
+	}
+}
+
+// replayBurpRequests sends the requests saved from Burp as seeds, with their method, headers and body,
+// until the crawl is stopped or its budget exhausted
+func (crawler *Crawler) replayBurpRequests(c *colly.Collector) error {
+	requests, err := readBurpRequests(crawler.burpReplay)
+	if err != nil {
+		return err
+	}
+	burpSession(c, requests)
+	for _, req := range requests {
+		if crawler.control.isStopped() || (crawler.budget != nil && !crawler.budget.reserve()) {
+			return nil
+		}
+		header := http.Header{}
+		for name, values := range req.Header {
+			if name != "Content-Length" && name != "Connection" && name != "Accept-Encoding" {
+				header[name] = values
+			}
+		}
+		ctx := colly.NewContext()
+		ctx.Put(seedContextKey, req.URL)
+		crawler.metrics.queueDepth.Inc()
+		if err := c.Request(req.Method, req.URL, bytes.NewReader(req.Body), ctx, header); err != nil {
+			Logger.Errorf("Failed to replay %s %s: %s", req.Method, req.URL, err)
+			crawler.metrics.queueDepth.Dec()
+			if crawler.budget != nil {
+				crawler.budget.release()
+			}
+		}
+	}
+	return nil
+}
+
+// WithBurpReplay replays the raw requests saved from Burp in path, a file or a directory, as crawl seeds:
+// each one is sent with its method, headers and body, and the crawl follows the links of the responses.
+// The merged headers and cookies of the requests (see WithBurpFile) are set on every request of the crawl
+func WithBurpReplay(path string) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.burpReplay = NormalizePath(path)
+	}
+}
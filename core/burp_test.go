@@ -0,0 +1,66 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBurpReplay(t *testing.T) {
+	var lock sync.Mutex
+	seen := map[string]string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		lock.Lock()
+		seen[r.URL.Path] = fmt.Sprintf("%s %s session=%s token=%s", r.Method, body, cookieValue(r, "session"), r.Header.Get("X-Token"))
+		lock.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/search" {
+			fmt.Fprint(w, `<html><body><a href="/result">result</a></body></html>`)
+			return
+		}
+		fmt.Fprint(w, `<html><body>leaf</body></html>`)
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	host := strings.TrimPrefix(ts.URL, "http://")
+	requests := map[string]string{
+		"1.txt": "GET " + ts.URL + "/home HTTP/1.1\nHost: " + host + "\nCookie: session=old\nX-Token: abc\n\n",
+		"2.txt": "POST " + ts.URL + "/search HTTP/1.1\nHost: " + host + "\nCookie: session=s3cr3t\nContent-Type: application/x-www-form-urlencoded\nContent-Length: 5\n\nq=foo",
+	}
+	for name, raw := range requests {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(raw), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	collectReports(NewCrawler(WithDefaultColly(2), WithBurpReplay(dir)))
+
+	expected := map[string]string{
+		"/home":   "GET  session=s3cr3t token=abc",
+		"/search": "POST q=foo session=s3cr3t token=abc",
+		"/result": "GET  session=s3cr3t token=abc",
+	}
+	for path, want := range expected {
+		if seen[path] != want {
+			t.Errorf("expected %q for %s, got %q", want, path, seen[path])
+		}
+	}
+}
+
+func cookieValue(r *http.Request, name string) string {
+	cookies := []string{}
+	for _, cookie := range r.Cookies() {
+		if cookie.Name == name {
+			cookies = append(cookies, cookie.Value)
+		}
+	}
+	return strings.Join(cookies, ",")
+}
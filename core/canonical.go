@@ -0,0 +1,162 @@
+package core
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// URLNormalizer rewrites an absolute url in place, as a step of a Canonicalizer
+type URLNormalizer func(u *url.URL)
+
+// Canonicalizer is the pipeline of normalizers applied to the urls found while crawling, once resolved against their
+// page, before they are deduplicated, matched against the scope and visited
+type Canonicalizer []URLNormalizer
+
+// DefaultCanonicalizer are the normalizations preserving the semantics of the urls (RFC 3986 6.2.2 and 6.2.3)
+var DefaultCanonicalizer = Canonicalizer{LowercaseSchemeHost, PunycodeHost, RemoveDefaultPort, ResolveDotSegments, NormalizePercentEncoding}
+
+// Resolve resolves ref against base and applies the pipeline. It returns an empty string when ref is not a valid url
+func (cz Canonicalizer) Resolve(base *url.URL, ref string) string {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	for _, normalize := range cz {
+		normalize(u)
+	}
+	return u.String()
+}
+
+// Canonicalize applies the pipeline to the absolute url u, returned as is when it is not a valid url
+func (cz Canonicalizer) Canonicalize(u string) string {
+	if len(cz) == 0 {
+		return u
+	}
+	if res := cz.Resolve(nil, u); res != "" {
+		return res
+	}
+	return u
+}
+
+// LowercaseSchemeHost lowercases the scheme and the host of u
+func LowercaseSchemeHost(u *url.URL) {
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+}
+
+var defaultPorts = map[string]string{"http": "80", "https": "443", "ws": "80", "wss": "443", "ftp": "21"}
+
+// RemoveDefaultPort removes the port of u when it is the default one of its scheme
+func RemoveDefaultPort(u *url.URL) {
+	if port := u.Port(); port != "" && defaultPorts[strings.ToLower(u.Scheme)] == port {
+		host := u.Hostname()
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		u.Host = host
+	}
+}
+
+// ResolveDotSegments removes the . and .. segments of the path of u
+func ResolveDotSegments(u *url.URL) {
+	if u.Opaque != "" || !strings.Contains(u.EscapedPath(), ".") {
+		return
+	}
+	resolved := u.ResolveReference(&url.URL{})
+	u.Path, u.RawPath = resolved.Path, resolved.RawPath
+}
+
+// StripFragment removes the fragment of u
+func StripFragment(u *url.URL) {
+	u.Fragment, u.RawFragment = "", ""
+}
+
+// SortQuery sorts the query parameters of u by name, keeping the order of the values of a parameter
+func SortQuery(u *url.URL) {
+	if u.RawQuery == "" {
+		return
+	}
+	parts := strings.Split(u.RawQuery, "&")
+	sort.SliceStable(parts, func(i, j int) bool {
+		ni, _, _ := strings.Cut(parts[i], "=")
+		nj, _, _ := strings.Cut(parts[j], "=")
+		return ni < nj
+	})
+	u.RawQuery = strings.Join(parts, "&")
+}
+
+// StripQuery removes the query of u
+func StripQuery(u *url.URL) {
+	u.RawQuery, u.ForceQuery = "", false
+}
+
+// NormalizePercentEncoding decodes the percent-encoded unreserved characters of the path and the query of u, and
+// uppercases the hexadecimal digits of the other escapes: /%7euser/%2fa becomes /~user/%2Fa
+func NormalizePercentEncoding(u *url.URL) {
+	if escaped := u.EscapedPath(); strings.Contains(escaped, "%") {
+		escaped = normalizeEscapes(escaped)
+		if path, err := url.PathUnescape(escaped); err == nil {
+			u.Path, u.RawPath = path, escaped
+		}
+	}
+	if strings.Contains(u.RawQuery, "%") {
+		u.RawQuery = normalizeEscapes(u.RawQuery)
+	}
+}
+
+func isUnreserved(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func unhex(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// normalizeEscapes decodes the escaped unreserved characters of s and uppercases its other escapes
+func normalizeEscapes(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		hi, okHi := unhex(s[i+1])
+		lo, okLo := unhex(s[i+2])
+		if !okHi || !okLo {
+			b.WriteByte(s[i])
+			continue
+		}
+		if c := hi<<4 | lo; isUnreserved(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteString(strings.ToUpper(s[i : i+3]))
+		}
+		i += 2
+	}
+	return b.String()
+}
+
+// WithCanonicalizer normalizes the urls found while crawling with normalizers, DefaultCanonicalizer when none is
+// given, before they are reported, deduplicated and visited. Add StripFragment, SortQuery or StripQuery to the
+// default steps to merge more urls, e.g WithCanonicalizer(append(DefaultCanonicalizer, SortQuery)...).
+// Without this option, only PunycodeHost is applied
+func WithCanonicalizer(normalizers ...URLNormalizer) CrawlerOption {
+	return func(crawler *Crawler) {
+		if len(normalizers) == 0 {
+			normalizers = DefaultCanonicalizer
+		}
+		crawler.canonicalizer = append(Canonicalizer{}, normalizers...)
+	}
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCanonicalizer(t *testing.T) {
+	base, _ := url.Parse("https://example.com/docs/guide/")
+	tests := []struct {
+		cz       Canonicalizer
+		ref      string
+		expected string
+	}{
+		{nil, "../api?b=1&a=2#top", "https://example.com/docs/api?b=1&a=2#top"},
+		{DefaultCanonicalizer, "HTTPS://WWW.Example.COM:443/a/./b/../c", "https://www.example.com/a/c"},
+		{DefaultCanonicalizer, "http://example.com:8080/", "http://example.com:8080/"},
+		{DefaultCanonicalizer, "http://[::1]:80/x", "http://[::1]/x"},
+		{DefaultCanonicalizer, "/%7euser/%2fa%2Db?q=%7e%3d", "https://example.com/~user/%2Fa-b?q=~%3D"},
+		{append(DefaultCanonicalizer, StripFragment, SortQuery), "/search?q=go&page=2&lang=en&q=rust#results", "https://example.com/search?lang=en&page=2&q=go&q=rust"},
+		{Canonicalizer{StripQuery}, "/list?sort=asc", "https://example.com/list"},
+		{DefaultCanonicalizer, "http://%zz", ""},
+	}
+	for _, test := range tests {
+		if u := test.cz.Resolve(base, test.ref); u != test.expected {
+			t.Errorf("expected %s for %s, got %s", test.expected, test.ref, u)
+		}
+	}
+}
+
+func TestCanonicalizerDedup(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/" {
+			fmt.Fprint(w, `<html><body><a href="/a/./page">one</a><a href="/b/../a/page">two</a><a href="/a/%70age">three</a></body></html>`)
+			return
+		}
+		hits.Add(1)
+	}))
+	defer ts.Close()
+
+	refs := 0
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(2), WithCanonicalizer()), ts.URL) {
+		if r.OutputType == Ref {
+			refs++
+			if r.Output != ts.URL+"/a/page" {
+				t.Errorf("expected the canonical url, got %s", r.Output)
+			}
+		}
+	}
+	if refs != 1 || hits.Load() != 1 {
+		t.Errorf("expected the 3 links to be reported and visited once, got %d reports and %d visits", refs, hits.Load())
+	}
+}
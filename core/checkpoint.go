@@ -0,0 +1,212 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+const (
+	checkpointFile          = "checkpoint.json"
+	checkpointURLContextKey = "gospider.checkpoint.url"
+	defaultCheckpointPeriod = time.Minute
+)
+
+// checkpointState is the saved state of a crawl
+type checkpointState struct {
+	Interval time.Duration   `json:"interval"`
+	Pending  []FrontierEntry `json:"pending"`
+	Visited  []string        `json:"visited"`
+	Seen     []string        `json:"seen"`
+}
+
+// checkpoint tracks the pending requests, the visited urls and the reported outputs of a crawl
+// and periodically saves them in dir so the crawl can be resumed with ResumeCrawl
+type checkpoint struct {
+	dir      string
+	interval time.Duration
+
+	lock    sync.Mutex
+	pending map[string]FrontierEntry
+	visited map[string]struct{}
+	seen    map[string]struct{}
+	resumed []FrontierEntry
+}
+
+func newCheckpoint(dir string, interval time.Duration) *checkpoint {
+	if interval <= 0 {
+		interval = defaultCheckpointPeriod
+	}
+	return &checkpoint{
+		dir:      NormalizePath(dir),
+		interval: interval,
+		pending:  make(map[string]FrontierEntry),
+		visited:  make(map[string]struct{}),
+		seen:     make(map[string]struct{}),
+	}
+}
+
+// schedule records u, found at depth, as pending. It returns false when u was already visited
+func (cp *checkpoint) schedule(u string, seed string, depth int) bool {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	if _, ok := cp.visited[u]; ok {
+		return false
+	}
+	cp.visited[u] = struct{}{}
+	cp.pending[u] = FrontierEntry{URL: u, Seed: seed, Depth: depth}
+	return true
+}
+
+// postpone records u as pending without visiting it, for urls discovered after the crawl was stopped
+func (cp *checkpoint) postpone(u string, seed string, depth int) {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	if _, ok := cp.visited[u]; !ok {
+		cp.pending[u] = FrontierEntry{URL: u, Seed: seed, Depth: depth}
+	}
+}
+
+func (cp *checkpoint) done(u string) {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	delete(cp.pending, u)
+}
+
+func (cp *checkpoint) requestDone(r *colly.Request) {
+	if u := r.Ctx.Get(checkpointURLContextKey); u != "" {
+		cp.done(u)
+	}
+}
+
+// requestPostponed marks r, aborted because the crawl was stopped, as done for this crawl and still pending,
+// so that a resumed crawl visits it once
+func (cp *checkpoint) requestPostponed(r *colly.Request) {
+	if u := r.Ctx.Get(checkpointURLContextKey); u != "" {
+		cp.lock.Lock()
+		defer cp.lock.Unlock()
+		delete(cp.visited, u)
+		cp.pending[u] = FrontierEntry{URL: u, Seed: requestSeed(r), Depth: requestDepth(r)}
+	}
+}
+
+// retry forgets that u was visited, so that the retry queue visits it again in a resumed crawl
+func (cp *checkpoint) retry(u string) {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	delete(cp.visited, u)
+}
+
+func (cp *checkpoint) reported(output string) {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	cp.seen[output] = struct{}{}
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	res := make([]string, 0, len(set))
+	for k := range set {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func (cp *checkpoint) save() error {
+	cp.lock.Lock()
+	state := checkpointState{
+		Interval: cp.interval,
+		Pending:  make([]FrontierEntry, 0, len(cp.pending)),
+		Visited:  sortedKeys(cp.visited),
+		Seen:     sortedKeys(cp.seen),
+	}
+	for _, entry := range cp.pending {
+		// urls still pending are visited again on resume, at the depth they were found
+		state.Pending = append(state.Pending, entry)
+	}
+	cp.lock.Unlock()
+	sort.Slice(state.Pending, func(i, j int) bool { return state.Pending[i].URL < state.Pending[j].URL })
+
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to serialize checkpoint: %w", err)
+	}
+	if err := os.MkdirAll(cp.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to save checkpoint in %s: %w", cp.dir, err)
+	}
+	path := filepath.Join(cp.dir, checkpointFile)
+	if err := os.WriteFile(path+".tmp", raw, 0o644); err != nil {
+		return fmt.Errorf("failed to save checkpoint in %s: %w", cp.dir, err)
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// run saves the checkpoint every interval until stopC is closed
+func (cp *checkpoint) run(stopC <-chan struct{}) {
+	ticker := time.NewTicker(cp.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := cp.save(); err != nil {
+				Logger.Error(err)
+			}
+		case <-stopC:
+			return
+		}
+	}
+}
+
+// takeResumed returns the pending requests restored from a checkpoint, once
+func (cp *checkpoint) takeResumed() []FrontierEntry {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	res := cp.resumed
+	cp.resumed = nil
+	for _, entry := range res {
+		delete(cp.visited, entry.URL)
+	}
+	return res
+}
+
+// WithCheckpoint saves the pending requests, the visited urls and the reported outputs in dir every interval
+// and at the end of the crawl. An interrupted crawl can then be continued with ResumeCrawl.
+// The failed urls aren't part of the checkpoint: the retry queue (see WithPersistentRetryQueue) outlives a single crawl
+// and is shared by the following ones, so it stays in its own file. A resumed crawl still visits its entries again
+func WithCheckpoint(dir string, interval time.Duration) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.checkpoint = newCheckpoint(dir, interval)
+	}
+}
+
+// ResumeCrawl returns a crawler continuing the crawl checkpointed in dir: calling Start without site visits
+// the requests which were pending, skips the already visited urls and does not report the outputs again.
+// opts should configure the crawler as the interrupted one was
+func ResumeCrawl(dir string, opts ...CrawlerOption) (*Crawler, error) {
+	raw, err := os.ReadFile(filepath.Join(NormalizePath(dir), checkpointFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	state := checkpointState{}
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint in %s: %w", dir, err)
+	}
+	crawler := NewCrawler(append(opts, WithCheckpoint(dir, state.Interval))...)
+	cp := crawler.checkpoint
+	for _, u := range state.Visited {
+		cp.visited[u] = struct{}{}
+	}
+	for _, output := range state.Seen {
+		cp.seen[output] = struct{}{}
+		crawler.set.Duplicate(output)
+	}
+	cp.resumed = state.Pending
+	Logger.Infof("Resuming crawl with %d pending requests and %d visited urls", len(state.Pending), len(state.Visited))
+	return crawler, nil
+}
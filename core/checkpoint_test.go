@@ -0,0 +1,176 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	dir := t.TempDir()
+	lock := sync.Mutex{}
+	requests := map[string]int{}
+	var crawler *Crawler
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests[r.URL.Path]++
+		lock.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><body><a href="/a">a</a></body></html>`)
+		case "/a":
+			// the crawl is killed while /a is being crawled
+			crawler.Stop()
+			fmt.Fprint(w, `<html><body><a href="/b">b</a><a href="/c">c</a></body></html>`)
+		default:
+			fmt.Fprint(w, `<html><body><a href="/">home</a><a href="/a">a</a></body></html>`)
+		}
+	}))
+	defer ts.Close()
+
+	crawler = NewCrawler(WithDefaultColly(5), WithCheckpoint(dir, time.Hour))
+	first := collectReports(crawler, ts.URL+"/")
+	if requests["/b"] != 0 || requests["/c"] != 0 {
+		t.Fatalf("expected the crawl to stop before /b and /c, got %v", requests)
+	}
+
+	resumed, err := ResumeCrawl(dir, WithDefaultColly(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second := collectReports(resumed)
+	for _, path := range []string{"/", "/a", "/b", "/c"} {
+		if requests[path] != 1 {
+			t.Errorf("expected %s to be requested once, got %d", path, requests[path])
+		}
+	}
+	seen := map[string]bool{}
+	for _, r := range first {
+		seen[r.Output] = true
+	}
+	for _, r := range second {
+		if seen[r.Output] {
+			t.Errorf("expected %s not to be reported again after resume", r.Output)
+		}
+	}
+}
+
+func TestCheckpointResumeDepth(t *testing.T) {
+	dir := t.TempDir()
+	lock := sync.Mutex{}
+	requests := map[string]int{}
+	var crawler *Crawler
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests[r.URL.Path]++
+		lock.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><body><a href="/a">a</a></body></html>`)
+		case "/a":
+			crawler.Stop()
+			fmt.Fprint(w, `<html><body><a href="/b">b</a></body></html>`)
+		default:
+			fmt.Fprint(w, `<html><body><a href="/deeper">deeper</a></body></html>`)
+		}
+	}))
+	defer ts.Close()
+
+	policy := WithDepthPolicy(map[OutputType]int{Ref: 3})
+	crawler = NewCrawler(WithDefaultColly(5), policy, WithCheckpoint(dir, time.Hour))
+	collectReports(crawler, ts.URL+"/")
+	raw, err := os.ReadFile(filepath.Join(dir, checkpointFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := checkpointState{}
+	if err := json.Unmarshal(raw, &state); err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Pending) != 1 || state.Pending[0].URL != ts.URL+"/b" || state.Pending[0].Depth != 3 {
+		t.Fatalf("expected /b to be pending at depth 3, got %v", state.Pending)
+	}
+
+	resumed, err := ResumeCrawl(dir, WithDefaultColly(5), policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collectReports(resumed)
+	if requests["/b"] != 1 {
+		t.Errorf("expected /b to be requested once, got %d", requests["/b"])
+	}
+	if requests["/deeper"] != 0 {
+		t.Errorf("expected the resumed /b to keep its depth and its links not to be followed, got %d requests", requests["/deeper"])
+	}
+}
+
+func TestCheckpointAbortedRequests(t *testing.T) {
+	dir := t.TempDir()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><a href="/item/1">1</a><a href="/item/2">2</a><a href="/item/3">3</a></body></html>`)
+	}))
+	defer ts.Close()
+
+	collectReports(NewCrawler(WithDefaultColly(3), WithCheckpoint(dir, time.Hour), WithCollyConfig(WithPatternVisitCap(1))), ts.URL)
+	raw, err := os.ReadFile(filepath.Join(dir, checkpointFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := checkpointState{}
+	if err := json.Unmarshal(raw, &state); err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Pending) != 0 {
+		t.Errorf("expected the requests aborted by the pattern cap not to be pending, got %v", state.Pending)
+	}
+}
+
+func TestCheckpointRetryQueue(t *testing.T) {
+	dir := t.TempDir()
+	var failing atomic.Bool
+	failing.Store(true)
+	var flaky atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><body><a href="/flaky">flaky</a></body></html>`)
+		case "/flaky":
+			flaky.Add(1)
+			if failing.Load() {
+				w.WriteHeader(http.StatusBadGateway)
+			}
+		}
+	}))
+	defer ts.Close()
+
+	queue := filepath.Join(t.TempDir(), "retry.json")
+	collectReports(NewCrawler(WithDefaultColly(3), WithCheckpoint(dir, time.Hour), WithPersistentRetryQueue(queue, 3)), ts.URL+"/")
+
+	failing.Store(false)
+	resumed, err := ResumeCrawl(dir, WithDefaultColly(3), WithPersistentRetryQueue(queue, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	collectReports(resumed)
+	if flaky.Load() != 2 {
+		t.Errorf("expected the failed url to be visited again by the resumed crawl, got %d requests", flaky.Load())
+	}
+	q, err := LoadRetryQueue(queue, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries := q.Entries(); len(entries) != 0 {
+		t.Errorf("expected the retry queue to be drained, got %+v", entries)
+	}
+}
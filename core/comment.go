@@ -0,0 +1,90 @@
+package core
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// commentMaxOutput bounds the size of the comment text reported as Output
+const commentMaxOutput = 256
+
+var (
+	htmlCommentRE       = regexp.MustCompile(`(?s)<!--(.*?)-->`)
+	commentURLRE        = regexp.MustCompile(`(?i)\bhttps?://[^\s"'<>()\x60]+`)
+	commentPathRE       = regexp.MustCompile(`(?:^|[\s"'=(])(/[\w.~%-]+(?:/[\w.~%-]*)*(?:\?[^\s"'<>()\x60]*)?)`)
+	commentCredentialRE = regexp.MustCompile(`(?i)\b(?:pass(?:word|wd)?|pwd|secret|api[_-]?key|token|user(?:name)?|login)\s*[:=]\s*[^\s,;]+`)
+	commentMarkerRE     = regexp.MustCompile(`\b(?:TODO|FIXME|XXX|HACK|BUG)\b`)
+)
+
+// HTMLComment is an html comment holding developer leftovers: urls and paths, credentials-looking strings or
+// TODO/FIXME markers
+type HTMLComment struct {
+	Text        string   `json:"text"`
+	URLs        []string `json:"urls,omitempty"`
+	Credentials []string `json:"credentials,omitempty"`
+	Markers     []string `json:"markers,omitempty"`
+}
+
+// ParseHTMLComments returns the comments of an html body holding urls, paths, credentials or markers.
+// The conditional comments of Internet Explorer are ignored
+func ParseHTMLComments(body string) []HTMLComment {
+	comments := []HTMLComment{}
+	for _, m := range htmlCommentRE.FindAllStringSubmatch(body, -1) {
+		text := strings.TrimSpace(m[1])
+		if text == "" || strings.HasPrefix(text, "[if") || strings.HasPrefix(text, "<![endif]") {
+			continue
+		}
+		comment := HTMLComment{Text: text}
+		comment.URLs = append(comment.URLs, commentURLRE.FindAllString(text, -1)...)
+		for _, path := range commentPathRE.FindAllStringSubmatch(text, -1) {
+			comment.URLs = append(comment.URLs, path[1])
+		}
+		comment.URLs = Unique(comment.URLs)
+		comment.Credentials = Unique(commentCredentialRE.FindAllString(text, -1))
+		comment.Markers = Unique(commentMarkerRE.FindAllString(text, -1))
+		if len(comment.URLs)+len(comment.Credentials)+len(comment.Markers) > 0 {
+			comments = append(comments, comment)
+		}
+	}
+	return comments
+}
+
+// mineComments emits the interesting comments of an html response, and the urls they hold as refs
+func (crawler *Crawler) mineComments(emit func(SpiderReport), response *colly.Response, body string) {
+	if responseContentType(response) != "text/html" {
+		return
+	}
+	for _, comment := range ParseHTMLComments(body) {
+		output := strings.Join(strings.Fields(comment.Text), " ")
+		if len(output) > commentMaxOutput {
+			output = Snippet(output, 0, commentMaxOutput, 0)
+		}
+		emit(SpiderReport{
+			Output:     output,
+			OutputType: Comment,
+			Source:     "body",
+			StatusCode: response.StatusCode,
+			Input:      response.Request.URL,
+			Seed:       requestSeed(response.Request),
+		}.WithMetadata("comment", comment))
+		for _, u := range comment.URLs {
+			emit(SpiderReport{
+				Output:     absoluteURL(response.Request, u),
+				OutputType: Ref,
+				Source:     "comment",
+				Input:      response.Request.URL,
+				Seed:       requestSeed(response.Request),
+			})
+		}
+	}
+}
+
+// WithCommentMining emits a comment report for every html comment holding urls, paths, credentials-looking strings
+// or TODO/FIXME markers, with the findings in the report Metadata. The urls and paths are crawled
+func WithCommentMining() CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.comments = true
+	}
+}
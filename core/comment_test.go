@@ -0,0 +1,54 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestParseHTMLComments(t *testing.T) {
+	body := `<html><!-- Google Tag Manager --><!--[if lt IE 9]><script src="/ie.js"></script><![endif]-->
+<!-- TODO: remove the debug console at /admin/debug?verbose=1 before release -->
+<!-- staging: https://staging.example.com/login user: admin password=Winter2024! -->
+<body></body></html>`
+	expected := []HTMLComment{
+		{Text: "TODO: remove the debug console at /admin/debug?verbose=1 before release", URLs: []string{"/admin/debug?verbose=1"}, Markers: []string{"TODO"}},
+		{Text: "staging: https://staging.example.com/login user: admin password=Winter2024!", URLs: []string{"https://staging.example.com/login"}, Credentials: []string{"user: admin", "password=Winter2024!"}},
+	}
+	if comments := ParseHTMLComments(body); !reflect.DeepEqual(comments, expected) {
+		t.Errorf("expected %+v, got %+v", expected, comments)
+	}
+}
+
+func TestCommentReports(t *testing.T) {
+	var lock sync.Mutex
+	visited := map[string]bool{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		visited[r.URL.Path] = true
+		lock.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/" {
+			fmt.Fprint(w, `<html><body><!-- FIXME old api still served at /api/v1/internal --></body></html>`)
+		}
+	}))
+	defer ts.Close()
+
+	comments := []string{}
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(2), WithCommentMining()), ts.URL) {
+		if r.OutputType == Comment {
+			comments = append(comments, r.Output)
+		}
+	}
+	if expected := []string{"FIXME old api still served at /api/v1/internal"}; !reflect.DeepEqual(comments, expected) {
+		t.Errorf("expected the comments %v, got %v", expected, comments)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if !visited["/api/v1/internal"] {
+		t.Errorf("expected the path of the comment to be crawled, got %v", visited)
+	}
+}
@@ -0,0 +1,151 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// DefaultCommonCrawlEndpoint is the Common Crawl index server
+var DefaultCommonCrawlEndpoint = "https://index.commoncrawl.org"
+
+// CommonCrawlSource fetches the urls indexed by Common Crawl. Every page of the selected monthly indexes is read,
+// and urls found in several indexes are only returned once
+type CommonCrawlSource struct {
+	IncludeSubs bool
+	// Indexes are the ids of the searched indexes, e.g "CC-MAIN-2024-10". When empty the Latest indexes are searched
+	Indexes []string
+	// Latest is the number of most recent indexes searched when Indexes is empty, 1 when 0
+	Latest int
+	// Endpoint is the index server, DefaultCommonCrawlEndpoint when empty
+	Endpoint string
+}
+
+type commonCrawlIndex struct {
+	ID     string `json:"id"`
+	CDXAPI string `json:"cdx-api"`
+}
+
+func (s CommonCrawlSource) endpoint() string {
+	if s.Endpoint == "" {
+		return DefaultCommonCrawlEndpoint
+	}
+	return s.Endpoint
+}
+
+func (s CommonCrawlSource) Fetch(ctx context.Context, domain string) ([]string, error) {
+	indexes, err := s.indexes(ctx)
+	if err != nil {
+		return []string{}, err
+	}
+	res := []string{}
+	seen := make(map[string]bool)
+	for _, index := range indexes {
+		urls, err := s.fetchIndex(ctx, index, domain)
+		for _, u := range urls {
+			if !seen[u] {
+				seen[u] = true
+				res = append(res, u)
+			}
+		}
+		if err != nil {
+			return res, fmt.Errorf("failed to search common crawl index %s: %w", index, err)
+		}
+	}
+	return res, nil
+}
+
+// indexes returns the cdx api urls of the searched indexes
+func (s CommonCrawlSource) indexes(ctx context.Context) ([]string, error) {
+	if len(s.Indexes) > 0 {
+		res := make([]string, 0, len(s.Indexes))
+		for _, id := range s.Indexes {
+			res = append(res, s.endpoint()+"/"+id+"-index")
+		}
+		return res, nil
+	}
+	resp, err := sourceGet(ctx, s.endpoint()+"/collinfo.json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("common crawl collinfo answered %s", resp.Status)
+	}
+	// collinfo lists the indexes from the most recent
+	collinfo := []commonCrawlIndex{}
+	if err := json.NewDecoder(resp.Body).Decode(&collinfo); err != nil {
+		return nil, fmt.Errorf("failed to parse common crawl collinfo: %w", err)
+	}
+	latest := s.Latest
+	if latest <= 0 {
+		latest = 1
+	}
+	res := []string{}
+	for _, index := range collinfo {
+		if len(res) == latest {
+			break
+		}
+		if index.CDXAPI != "" {
+			res = append(res, index.CDXAPI)
+		}
+	}
+	return res, nil
+}
+
+// fetchIndex returns the urls of domain in every page of the index at cdxAPI
+func (s CommonCrawlSource) fetchIndex(ctx context.Context, cdxAPI string, domain string) ([]string, error) {
+	target := domain + "/*"
+	if s.IncludeSubs {
+		target = "*." + target
+	}
+	params := url.Values{}
+	params.Set("url", target)
+	params.Set("output", "json")
+	params.Set("fl", "url")
+
+	pages := struct {
+		Pages int `json:"pages"`
+	}{}
+	resp, err := sourceGet(ctx, cdxAPI+"?"+params.Encode()+"&showNumPages=true")
+	if err != nil {
+		return nil, err
+	}
+	err = json.NewDecoder(resp.Body).Decode(&pages)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the number of pages: %w", err)
+	}
+
+	res := []string{}
+	for page := 0; page < pages.Pages; page++ {
+		params.Set("page", fmt.Sprint(page))
+		resp, err := sourceGet(ctx, cdxAPI+"?"+params.Encode())
+		if err != nil {
+			return res, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return res, fmt.Errorf("page %d answered %s", page, resp.Status)
+		}
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			line := struct {
+				URL string `json:"url"`
+			}{}
+			if err := json.Unmarshal(sc.Bytes(), &line); err != nil || line.URL == "" {
+				continue
+			}
+			res = append(res, line.URL)
+		}
+		err = sc.Err()
+		resp.Body.Close()
+		if err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,47 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCommonCrawlSource(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case r.URL.Path == "/collinfo.json":
+			fmt.Fprintf(w, `[{"id":"CC-MAIN-2024-10","cdx-api":"%[1]s/CC-MAIN-2024-10-index"},{"id":"CC-MAIN-2023-50","cdx-api":"%[1]s/CC-MAIN-2023-50-index"},{"id":"CC-MAIN-2023-40","cdx-api":"%[1]s/CC-MAIN-2023-40-index"}]`, srv.URL)
+		case q.Get("showNumPages") == "true":
+			fmt.Fprint(w, `{"pages": 2, "pageSize": 5, "blocks": 10}`)
+		case r.URL.Path == "/CC-MAIN-2024-10-index":
+			fmt.Fprintf(w, "{\"url\": \"https://example.com/%s\"}\n{\"url\": \"https://example.com/shared\"}\nnot json\n", q.Get("page"))
+		case r.URL.Path == "/CC-MAIN-2023-50-index":
+			fmt.Fprint(w, "{\"url\": \"https://example.com/shared\"}\n{\"url\": \"https://example.com/old\"}\n")
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	urls, err := CommonCrawlSource{Latest: 2, Endpoint: srv.URL}.Fetch(context.Background(), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"https://example.com/0", "https://example.com/shared", "https://example.com/1", "https://example.com/old"}
+	if fmt.Sprint(urls) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, urls)
+	}
+
+	urls, err = CommonCrawlSource{Indexes: []string{"CC-MAIN-2023-50"}, Endpoint: srv.URL}.Fetch(context.Background(), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 2 {
+		t.Errorf("expected the urls of the selected index only, got %v", urls)
+	}
+}
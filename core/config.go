@@ -0,0 +1,155 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the declarative description of a crawl, see LoadConfig
+type Config struct {
+	Depth     int               `yaml:"depth" toml:"depth" json:"depth,omitempty"`
+	Scope     []string          `yaml:"scope" toml:"scope" json:"scope,omitempty"`
+	Blacklist []string          `yaml:"blacklist" toml:"blacklist" json:"blacklist,omitempty"`
+	Headers   map[string]string `yaml:"headers" toml:"headers" json:"headers,omitempty"`
+	Cookie    string            `yaml:"cookie" toml:"cookie" json:"cookie,omitempty"`
+	UserAgent string            `yaml:"user_agent" toml:"user_agent" json:"user_agent,omitempty"`
+	Proxy     string            `yaml:"proxy" toml:"proxy" json:"proxy,omitempty"`
+	Limits    ConfigLimits      `yaml:"limits" toml:"limits" json:"limits,omitempty"`
+	Sources   ConfigSources     `yaml:"sources" toml:"sources" json:"sources,omitempty"`
+	Login     *LoginFlow        `yaml:"login" toml:"login" json:"login,omitempty"`
+	// Rules are the extraction rules of the crawl, see WithExtractionRules
+	Rules []ExtractionRule `yaml:"rules" toml:"rules" json:"rules,omitempty"`
+	// Templates are the record templates of the crawl, see WithRecordTemplates
+	Templates []RecordTemplate `yaml:"templates" toml:"templates" json:"templates,omitempty"`
+}
+
+type ConfigLimits struct {
+	Concurrent  int  `yaml:"concurrent" toml:"concurrent" json:"concurrent,omitempty"`
+	Delay       int  `yaml:"delay" toml:"delay" json:"delay,omitempty"`
+	RandomDelay int  `yaml:"random_delay" toml:"random_delay" json:"random_delay,omitempty"`
+	Timeout     int  `yaml:"timeout" toml:"timeout" json:"timeout,omitempty"`
+	NoRedirect  bool `yaml:"no_redirect" toml:"no_redirect" json:"no_redirect,omitempty"`
+}
+
+type ConfigSources struct {
+	Sitemap      bool     `yaml:"sitemap" toml:"sitemap" json:"sitemap,omitempty"`
+	SitemapPaths []string `yaml:"sitemap_paths" toml:"sitemap_paths" json:"sitemap_paths,omitempty"`
+	Robots       bool     `yaml:"robots" toml:"robots" json:"robots,omitempty"`
+	OtherSources bool     `yaml:"other_sources" toml:"other_sources" json:"other_sources,omitempty"`
+	LinkFinder   bool     `yaml:"linkfinder" toml:"linkfinder" json:"linkfinder,omitempty"`
+	PWA          bool     `yaml:"pwa" toml:"pwa" json:"pwa,omitempty"`
+
+	// URLScanAPIKey authenticates the urlscan.io source, instead of the URLSCAN_API_KEY environment variable
+	URLScanAPIKey string `yaml:"urlscan_api_key" toml:"urlscan_api_key" json:"urlscan_api_key,omitempty"`
+}
+
+// sources returns the default sources, authenticated with the configured API keys
+func (cs ConfigSources) sources() []Source {
+	sources := DefaultSources(true)
+	for i, source := range sources {
+		if _, ok := source.(URLScanSource); ok && cs.URLScanAPIKey != "" {
+			sources[i] = URLScanSource{APIKey: cs.URLScanAPIKey}
+		}
+	}
+	return sources
+}
+
+// LoadConfig reads a YAML (.yaml, .yml) or TOML (.toml) crawl configuration and returns the matching options.
+// Unknown keys are rejected so typos don't silently change a crawl
+func LoadConfig(path string) ([]CrawlerOption, []CollyConfigurator, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+	cfg := Config{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(raw))
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(raw), &cfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return nil, nil, fmt.Errorf("failed to parse config %s: unknown key %s", path, undecoded[0])
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported config format %s, expected .yaml, .yml or .toml", ext)
+	}
+	crawlerOpts, collyOpts := cfg.Options()
+	return crawlerOpts, collyOpts, nil
+}
+
+// Options maps the configuration to crawler and colly options
+func (cfg Config) Options() ([]CrawlerOption, []CollyConfigurator) {
+	crawlerOpts := []CrawlerOption{WithDefaultColly(cfg.Depth)}
+	if cfg.Sources.Sitemap {
+		crawlerOpts = append(crawlerOpts, WithSitemap())
+	}
+	if len(cfg.Sources.SitemapPaths) > 0 {
+		crawlerOpts = append(crawlerOpts, WithSitemapPaths(cfg.Sources.SitemapPaths...))
+	}
+	if cfg.Sources.Robots {
+		crawlerOpts = append(crawlerOpts, WithRobot())
+	}
+	if cfg.Sources.OtherSources {
+		crawlerOpts = append(crawlerOpts, WithSource(cfg.Sources.sources()...))
+	}
+	if cfg.Sources.LinkFinder {
+		crawlerOpts = append(crawlerOpts, WithLinkFinder())
+	}
+	if cfg.Sources.PWA {
+		crawlerOpts = append(crawlerOpts, WithPWADiscovery())
+	}
+	if cfg.Login != nil {
+		crawlerOpts = append(crawlerOpts, WithLoginFlow(*cfg.Login))
+	}
+	if len(cfg.Rules) > 0 {
+		crawlerOpts = append(crawlerOpts, WithExtractionRules(cfg.Rules...))
+	}
+	if len(cfg.Templates) > 0 {
+		crawlerOpts = append(crawlerOpts, WithRecordTemplates(cfg.Templates...))
+	}
+
+	clientOpts := []HTTPClientConfigurator{WithHTTPProxy(cfg.Proxy), WithHTTPTimeout(cfg.Limits.Timeout)}
+	if cfg.Limits.NoRedirect {
+		clientOpts = append(clientOpts, WithHTTPNoRedirect())
+	}
+	crawlerOpts = append(crawlerOpts, WithHTTPClientOpt(clientOpts...))
+	collyOpts := []CollyConfigurator{}
+	if cfg.Limits.Concurrent > 0 || cfg.Limits.Delay > 0 || cfg.Limits.RandomDelay > 0 {
+		collyOpts = append(collyOpts, WithLimit(cfg.Limits.Concurrent, cfg.Limits.Delay, cfg.Limits.RandomDelay))
+	}
+	if cfg.UserAgent != "" {
+		collyOpts = append(collyOpts, WithUserAgent(cfg.UserAgent))
+	}
+	if cfg.Cookie != "" {
+		collyOpts = append(collyOpts, WithCookie(cfg.Cookie))
+	}
+	if len(cfg.Headers) > 0 {
+		headers := make([]string, 0, len(cfg.Headers))
+		for k, v := range cfg.Headers {
+			headers = append(headers, k+": "+v)
+		}
+		sort.Strings(headers)
+		collyOpts = append(collyOpts, WithHeader(headers...))
+	}
+	for _, scope := range cfg.Scope {
+		collyOpts = append(collyOpts, WithScope(scope))
+	}
+	for _, blacklist := range cfg.Blacklist {
+		collyOpts = append(collyOpts, WithDisallowedRegexFilter(blacklist))
+	}
+	return crawlerOpts, collyOpts
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gocolly/colly/v2"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"crawl.yaml": `
+depth: 2
+scope: ['^https?://example\.com']
+blacklist: ['\.pdf$']
+headers:
+  X-Test: yaml
+limits:
+  concurrent: 2
+  timeout: 5
+sources:
+  sitemap: true
+login:
+  url: https://example.com/login
+  fields:
+    user: admin
+rules:
+  - name: price
+    css: span.price
+    type: price
+templates:
+  - name: product
+    root: div.product
+    fields:
+      - name: title
+        css: h2
+`,
+		"crawl.toml": `
+depth = 2
+scope = ['^https?://example\.com']
+blacklist = ['\.pdf$']
+
+[headers]
+X-Test = "toml"
+
+[limits]
+concurrent = 2
+timeout = 5
+
+[sources]
+sitemap = true
+
+[login]
+url = "https://example.com/login"
+fields = { user = "admin" }
+
+[[rules]]
+name = "price"
+css = "span.price"
+type = "price"
+
+[[templates]]
+name = "product"
+root = "div.product"
+fields = [{ name = "title", css = "h2" }]
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		crawlerOpts, collyOpts, err := LoadConfig(path)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		crawler := NewCrawler(crawlerOpts...)
+		if !crawler.sitemap || crawler.robot {
+			t.Errorf("%s: unexpected sources sitemap=%v robot=%v", name, crawler.sitemap, crawler.robot)
+		}
+		if crawler.loginFlow == nil || crawler.loginFlow.Fields["user"] != "admin" {
+			t.Errorf("%s: expected a login flow, got %+v", name, crawler.loginFlow)
+		}
+		if len(crawler.extractionRules) != 1 || crawler.extractionRules[0].OutputType != "price" {
+			t.Errorf("%s: expected a price rule, got %+v", name, crawler.extractionRules)
+		}
+		if len(crawler.recordTemplates) != 1 || len(crawler.recordTemplates[0].Fields) != 1 {
+			t.Errorf("%s: expected a product template, got %+v", name, crawler.recordTemplates)
+		}
+		c := colly.NewCollector()
+		for _, o := range collyOpts {
+			if err := o(c); err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+		}
+		if len(c.URLFilters) != 1 || len(c.DisallowedURLFilters) != 1 {
+			t.Errorf("%s: expected scope and blacklist filters, got %v and %v", name, c.URLFilters, c.DisallowedURLFilters)
+		}
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"unknown.yaml": "dept: 2\n",
+		"unknown.toml": "dept = 2\n",
+		"crawl.json":   "{}",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := LoadConfig(path); err == nil {
+			t.Errorf("expected an error loading %s", name)
+		}
+	}
+}
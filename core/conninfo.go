@@ -0,0 +1,113 @@
+package core
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// ConnectionMetadataKey is the report Metadata key of the ConnectionInfo of the url reports, see WithConnectionInfo
+const ConnectionMetadataKey = "connection"
+
+// ConnectionInfo describes the connection a response was served on
+type ConnectionInfo struct {
+	RemoteAddr  string `json:"remote_addr"`
+	TLSVersion  string `json:"tls_version,omitempty"`
+	CipherSuite string `json:"cipher_suite,omitempty"`
+	// The certificate fields describe the leaf certificate of the server
+	CertSubject  string   `json:"cert_subject,omitempty"`
+	CertIssuer   string   `json:"cert_issuer,omitempty"`
+	CertSANs     []string `json:"cert_san,omitempty"`
+	CertNotAfter string   `json:"cert_not_after,omitempty"`
+}
+
+func newConnectionInfo(remoteAddr string, state *tls.ConnectionState) *ConnectionInfo {
+	info := &ConnectionInfo{RemoteAddr: remoteAddr}
+	if state == nil {
+		return info
+	}
+	info.TLSVersion = tls.VersionName(state.Version)
+	info.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+	if len(state.PeerCertificates) > 0 {
+		cert := state.PeerCertificates[0]
+		info.CertSubject = cert.Subject.String()
+		info.CertIssuer = cert.Issuer.String()
+		info.CertSANs = append(info.CertSANs, cert.DNSNames...)
+		for _, ip := range cert.IPAddresses {
+			info.CertSANs = append(info.CertSANs, ip.String())
+		}
+		info.CertNotAfter = cert.NotAfter.UTC().Format(time.RFC3339)
+	}
+	return info
+}
+
+// connectionTracker records the connection of the responses until they are reported, keyed by their url
+type connectionTracker struct {
+	infos sync.Map
+}
+
+// take returns and forgets the connection of the response to r, nil when unknown
+func (ct *connectionTracker) take(r *colly.Request) *ConnectionInfo {
+	if ct == nil || r == nil {
+		return nil
+	}
+	info, ok := ct.infos.LoadAndDelete(r.URL.String())
+	if !ok {
+		return nil
+	}
+	return info.(*ConnectionInfo)
+}
+
+// connectionTransport records the remote address and the TLS state of the connections of the responses
+type connectionTransport struct {
+	next    http.RoundTripper
+	tracker *connectionTracker
+}
+
+func (ct *connectionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var lock sync.Mutex
+	remoteAddr := ""
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			lock.Lock()
+			defer lock.Unlock()
+			remoteAddr = info.Conn.RemoteAddr().String()
+		},
+	}
+	resp, err := ct.next.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if err != nil {
+		return resp, err
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	ct.tracker.infos.Store(req.URL.String(), newConnectionInfo(remoteAddr, resp.TLS))
+	return resp, nil
+}
+
+// trackConnections wraps the transport of the collector so its connections are recorded
+func (crawler *Crawler) trackConnections(c *colly.Collector) {
+	crawler.connections = &connectionTracker{}
+	crawler.wrapTransport(c, func(next http.RoundTripper) http.RoundTripper {
+		return &connectionTransport{next: next, tracker: crawler.connections}
+	})
+}
+
+// withConnection returns a copy of the receiver with connection in its Metadata, unless it is nil
+func (ov SpiderReport) withConnection(connection *ConnectionInfo) SpiderReport {
+	if connection == nil {
+		return ov
+	}
+	return ov.WithMetadata(ConnectionMetadataKey, *connection)
+}
+
+// WithConnectionInfo adds to the url reports the ConnectionInfo of their response (see ConnectionMetadataKey): the
+// remote address, the TLS version and cipher suite, and the subject, SANs and expiration of the server certificate
+func WithConnectionInfo() CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.connectionInfo = true
+	}
+}
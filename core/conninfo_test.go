@@ -0,0 +1,42 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConnectionInfo(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body>secure</body></html>`)
+	}))
+	defer ts.Close()
+
+	reports := collectReports(NewCrawler(WithDefaultColly(1), WithConnectionInfo()), ts.URL)
+	found := false
+	for _, r := range reports {
+		if r.OutputType != Url {
+			continue
+		}
+		found = true
+		info, ok := r.Metadata[ConnectionMetadataKey].(ConnectionInfo)
+		if !ok {
+			t.Fatalf("expected connection info in %+v", r.Metadata)
+		}
+		if info.RemoteAddr != strings.TrimPrefix(ts.URL, "https://") {
+			t.Errorf("unexpected remote address %s", info.RemoteAddr)
+		}
+		if !strings.HasPrefix(info.TLSVersion, "TLS 1.") || info.CipherSuite == "" {
+			t.Errorf("unexpected TLS state %+v", info)
+		}
+		if !strings.Contains(info.CertSubject, "Acme Co") || info.CertNotAfter == "" || len(info.CertSANs) == 0 {
+			t.Errorf("unexpected certificate %+v", info)
+		}
+	}
+	if !found {
+		t.Fatal("expected an url report")
+	}
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+var genericContentTypes = map[string]bool{
+	"":                         true,
+	"application/octet-stream": true,
+	"binary/octet-stream":      true,
+	"text/plain":               true,
+}
+
+// SniffContentType returns the media type (without parameters) of a response.
+// The Content-Type header is trusted unless it is absent or generic, in which case the type is sniffed from the body magic bytes
+func SniffContentType(header http.Header, body []byte) string {
+	contentType := ""
+	if header != nil {
+		contentType = header.Get("Content-Type")
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	if !genericContentTypes[mediaType] || len(body) == 0 {
+		return mediaType
+	}
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return "application/json"
+	}
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(body))
+	if genericContentTypes[sniffed] && mediaType != "" {
+		return mediaType
+	}
+	return sniffed
+}
+
+func responseContentType(response *colly.Response) string {
+	if response.Headers == nil {
+		return SniffContentType(nil, response.Body)
+	}
+	return SniffContentType(*response.Headers, response.Body)
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"errors"
+	"sync"
+)
+
+var ErrCrawlerStopped = errors.New("crawler stopped")
+
+// crawlControl holds the paused/stopped state of a Crawler. Requests wait on it before being sent
+type crawlControl struct {
+	lock    sync.Mutex
+	cond    *sync.Cond
+	paused  bool
+	stopped bool
+	stopC   chan struct{}
+}
+
+func newCrawlControl() *crawlControl {
+	cc := &crawlControl{stopC: make(chan struct{})}
+	cc.cond = sync.NewCond(&cc.lock)
+	return cc
+}
+
+// wait blocks while the crawl is paused. It returns false when the crawl is stopped
+func (cc *crawlControl) wait() bool {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+	for cc.paused && !cc.stopped {
+		cc.cond.Wait()
+	}
+	return !cc.stopped
+}
+
+func (cc *crawlControl) setPaused(paused bool) {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+	cc.paused = paused
+	cc.cond.Broadcast()
+}
+
+func (cc *crawlControl) stop() {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+	if !cc.stopped {
+		cc.stopped = true
+		close(cc.stopC)
+	}
+	cc.cond.Broadcast()
+}
+
+func (cc *crawlControl) isStopped() bool {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+	return cc.stopped
+}
+
+// Pause freezes the crawl: queued and new requests wait until Resume is called, in-flight requests complete
+func (crawler *Crawler) Pause() {
+	Logger.Info("Pausing crawl")
+	crawler.control.setPaused(true)
+}
+
+// Resume restarts a crawl frozen by Pause
+func (crawler *Crawler) Resume() {
+	Logger.Info("Resuming crawl")
+	crawler.control.setPaused(false)
+}
+
+// Stop ends the crawl: no new request is sent, in-flight requests are drained and the report channels are closed.
+// A stopped crawler can't be restarted
+func (crawler *Crawler) Stop() {
+	Logger.Info("Stopping crawl")
+	crawler.control.stop()
+}
+
+func (crawler *Crawler) IsPaused() bool {
+	crawler.control.lock.Lock()
+	defer crawler.control.lock.Unlock()
+	return crawler.control.paused
+}
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCrawlerPauseResumeStop(t *testing.T) {
+	var hits atomic.Int32
+	site := newTestSite()
+	defer site.Close()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		site.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	crawler := NewCrawler(WithDefaultColly(3))
+	crawler.Pause()
+	done := make(chan []SpiderReport)
+	go func() { done <- collectReports(crawler, ts.URL) }()
+	time.Sleep(100 * time.Millisecond)
+	if hits.Load() != 0 {
+		t.Fatalf("expected no request while paused, got %d", hits.Load())
+	}
+	crawler.Resume()
+	select {
+	case reports := <-done:
+		if len(reports) == 0 {
+			t.Error("expected reports after resume")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("crawl did not complete after resume")
+	}
+
+	stopped := NewCrawler(WithDefaultColly(3))
+	stopped.Pause()
+	go func() { done <- collectReports(stopped, ts.URL) }()
+	time.Sleep(50 * time.Millisecond)
+	stopped.Stop()
+	select {
+	case reports := <-done:
+		if len(reports) != 0 {
+			t.Errorf("expected no report from a crawl stopped while paused, got %d", len(reports))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("crawl did not complete after stop")
+	}
+}
@@ -0,0 +1,249 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// persistentJar is a cookie jar remembering its cookies so they can be saved, which cookiejar.Jar can't list
+type persistentJar struct {
+	jar *cookiejar.Jar
+
+	lock    sync.Mutex
+	cookies map[string]*http.Cookie
+}
+
+func newPersistentJar() *persistentJar {
+	jar, _ := cookiejar.New(nil)
+	return &persistentJar{jar: jar, cookies: make(map[string]*http.Cookie)}
+}
+
+func (pj *persistentJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	pj.jar.SetCookies(u, cookies)
+	pj.lock.Lock()
+	defer pj.lock.Unlock()
+	for _, cookie := range cookies {
+		stored := *cookie
+		// a cookie without domain only belongs to the host which set it
+		if stored.Domain == "" {
+			stored.Domain = u.Hostname()
+		} else if !strings.HasPrefix(stored.Domain, ".") {
+			stored.Domain = "." + stored.Domain
+		}
+		if stored.Path == "" || !strings.HasPrefix(stored.Path, "/") {
+			stored.Path = "/"
+		}
+		if stored.MaxAge > 0 {
+			stored.Expires = time.Now().Add(time.Duration(stored.MaxAge) * time.Second)
+		}
+		key := stored.Domain + ";" + stored.Path + ";" + stored.Name
+		if stored.MaxAge < 0 || (!stored.Expires.IsZero() && stored.Expires.Before(time.Now())) {
+			delete(pj.cookies, key)
+			continue
+		}
+		pj.cookies[key] = &stored
+	}
+}
+
+func (pj *persistentJar) Cookies(u *url.URL) []*http.Cookie {
+	return pj.jar.Cookies(u)
+}
+
+// all returns the unexpired cookies of the jar
+func (pj *persistentJar) all() []*http.Cookie {
+	pj.lock.Lock()
+	defer pj.lock.Unlock()
+	cookies := make([]*http.Cookie, 0, len(pj.cookies))
+	for _, cookie := range pj.cookies {
+		if cookie.Expires.IsZero() || cookie.Expires.After(time.Now()) {
+			cookies = append(cookies, cookie)
+		}
+	}
+	return cookies
+}
+
+// add loads cookie, its domain starting with a dot when it is sent to the subdomains
+func (pj *persistentJar) add(cookie *http.Cookie) {
+	u := &url.URL{Scheme: "http", Host: strings.TrimPrefix(cookie.Domain, "."), Path: cookie.Path}
+	if cookie.Secure {
+		u.Scheme = "https"
+	}
+	if !strings.HasPrefix(cookie.Domain, ".") {
+		// cookiejar stores a cookie without domain as host-only
+		hostOnly := *cookie
+		hostOnly.Domain = ""
+		cookie = &hostOnly
+	}
+	pj.SetCookies(u, []*http.Cookie{cookie})
+}
+
+// isJSONCookieFile reports whether path holds JSON cookies rather than Netscape ones
+func isJSONCookieFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
+
+// load reads the cookies of path, a Netscape cookies.txt or a JSON array of cookies. A missing file is an empty jar
+func (pj *persistentJar) load(path string) error {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read cookie jar %s: %w", path, err)
+	}
+	var cookies []*http.Cookie
+	if isJSONCookieFile(path) {
+		if err := json.Unmarshal(raw, &cookies); err != nil {
+			return fmt.Errorf("failed to parse cookie jar %s: %w", path, err)
+		}
+	} else if cookies, err = parseNetscapeCookies(string(raw)); err != nil {
+		return fmt.Errorf("failed to parse cookie jar %s: %w", path, err)
+	}
+	for _, cookie := range cookies {
+		pj.add(cookie)
+	}
+	return nil
+}
+
+// save writes the cookies to path, in the format its extension selects
+func (pj *persistentJar) save(path string) error {
+	cookies := pj.all()
+	var raw []byte
+	if isJSONCookieFile(path) {
+		var err error
+		if raw, err = json.MarshalIndent(cookies, "", "  "); err != nil {
+			return err
+		}
+	} else {
+		raw = []byte(formatNetscapeCookies(cookies))
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0600); err != nil {
+		return fmt.Errorf("failed to save cookie jar %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to save cookie jar %s: %w", path, err)
+	}
+	return nil
+}
+
+const netscapeHttpOnlyPrefix = "#HttpOnly_"
+
+// parseNetscapeCookies parses the cookies.txt format of curl and browsers extensions:
+// domain, include subdomains, path, secure, expiration (unix time, 0 for session cookies), name and value
+func parseNetscapeCookies(raw string) ([]*http.Cookie, error) {
+	var cookies []*http.Cookie
+	scanner := bufio.NewScanner(strings.NewReader(raw))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := strings.HasPrefix(text, netscapeHttpOnlyPrefix)
+		text = strings.TrimPrefix(text, netscapeHttpOnlyPrefix)
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("line %d: expected 7 tab separated fields, got %d", line, len(fields))
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid expiration %s", line, fields[4])
+		}
+		cookie := &http.Cookie{
+			Domain:   fields[0],
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    fields[6],
+			HttpOnly: httpOnly,
+		}
+		if strings.EqualFold(fields[1], "TRUE") && !strings.HasPrefix(cookie.Domain, ".") {
+			cookie.Domain = "." + cookie.Domain
+		} else if strings.EqualFold(fields[1], "FALSE") {
+			cookie.Domain = strings.TrimPrefix(cookie.Domain, ".")
+		}
+		if expires > 0 {
+			cookie.Expires = time.Unix(expires, 0)
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies, scanner.Err()
+}
+
+func formatNetscapeCookies(cookies []*http.Cookie) string {
+	var b strings.Builder
+	b.WriteString("# Netscape HTTP Cookie File\n")
+	flag := func(v bool) string {
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	for _, cookie := range cookies {
+		if cookie.HttpOnly {
+			b.WriteString(netscapeHttpOnlyPrefix)
+		}
+		var expires int64
+		if !cookie.Expires.IsZero() {
+			expires = cookie.Expires.Unix()
+		}
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", cookie.Domain, flag(strings.HasPrefix(cookie.Domain, ".")), cookie.Path,
+			flag(cookie.Secure), expires, cookie.Name, cookie.Value)
+	}
+	return b.String()
+}
+
+// cookieJar returns the cookie jar of the collector, set on c and the crawler client when missing
+func (crawler *Crawler) cookieJar(c *colly.Collector) http.CookieJar {
+	if crawler.httpClient.Jar == nil {
+		var jar http.CookieJar
+		if crawler.cookieJarFile != "" {
+			jar = newPersistentJar()
+		} else {
+			jar, _ = cookiejar.New(nil)
+		}
+		c.SetCookieJar(jar)
+		crawler.httpClient.Jar = jar
+	}
+	return crawler.httpClient.Jar
+}
+
+// loadCookieJar loads the cookies of the cookie jar file in the jar of the collector
+func (crawler *Crawler) loadCookieJar(c *colly.Collector) error {
+	jar, ok := crawler.cookieJar(c).(*persistentJar)
+	if !ok {
+		return fmt.Errorf("the cookie jar file %s can't be used with the cookie jar of the http client", crawler.cookieJarFile)
+	}
+	return jar.load(crawler.cookieJarFile)
+}
+
+// saveCookieJar saves the cookies of the crawl to the cookie jar file
+func (crawler *Crawler) saveCookieJar() error {
+	jar, ok := crawler.httpClient.Jar.(*persistentJar)
+	if !ok {
+		return nil
+	}
+	return jar.save(crawler.cookieJarFile)
+}
+
+// WithCookieJarFile loads the cookies of path before crawling and saves the cookies of the crawl to it afterwards,
+// so sessions survive between crawls and can be shared with other tools.
+// A path ending with .json holds a JSON array of cookies, any other one a Netscape cookies.txt
+func WithCookieJarFile(path string) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.cookieJarFile = NormalizePath(path)
+	}
+}
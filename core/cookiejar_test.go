@@ -0,0 +1,70 @@
+package core
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNetscapeCookies(t *testing.T) {
+	raw := "# Netscape HTTP Cookie File\n" +
+		".example.com\tTRUE\t/\tTRUE\t0\tsession\ts1\n" +
+		"#HttpOnly_api.example.com\tFALSE\t/v1\tFALSE\t4102444800\ttoken\tt1\n"
+	cookies, err := parseNetscapeCookies(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cookies) != 2 || cookies[0].Domain != ".example.com" || !cookies[0].Secure ||
+		cookies[1].Domain != "api.example.com" || !cookies[1].HttpOnly || cookies[1].Expires.Unix() != 4102444800 {
+		t.Fatalf("unexpected cookies %+v", cookies)
+	}
+	if formatted := formatNetscapeCookies(cookies); formatted != raw {
+		t.Errorf("expected the cookies to be formatted back, got %q", formatted)
+	}
+
+	jar := newPersistentJar()
+	for _, cookie := range cookies {
+		jar.add(cookie)
+	}
+	if got := jar.Cookies(&url.URL{Scheme: "https", Host: "www.example.com", Path: "/"}); len(got) != 1 || got[0].Name != "session" {
+		t.Errorf("expected the domain cookie to be sent to the subdomains, got %v", got)
+	}
+	if got := jar.Cookies(&url.URL{Scheme: "http", Host: "api.example.com", Path: "/v1/users"}); len(got) != 1 || got[0].Name != "token" {
+		t.Errorf("expected the host cookie to be sent to its host, got %v", got)
+	}
+	jar.SetCookies(&url.URL{Scheme: "http", Host: "api.example.com"}, []*http.Cookie{{Name: "token", Path: "/v1", MaxAge: -1}})
+	if len(jar.all()) != 1 {
+		t.Errorf("expected a deleted cookie to be forgotten, got %v", jar.all())
+	}
+}
+
+func TestCookieJarFile(t *testing.T) {
+	ts := newLoginSite()
+	defer ts.Close()
+	for _, name := range []string{"cookies.txt", "cookies.json"} {
+		path := filepath.Join(t.TempDir(), name)
+		flow := LoginFlow{URL: ts.URL + "/login", Fields: map[string]string{"user": "admin", "password": "secret"}}
+		collectReports(NewCrawler(WithDefaultColly(1), WithLoginFlow(flow), WithCookieJarFile(path)), ts.URL+"/home")
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(raw), "s3ss10n") {
+			t.Fatalf("%s: expected the session cookie to be saved, got %s", name, raw)
+		}
+
+		// the saved session is reused without logging in
+		found := false
+		for _, r := range collectReports(NewCrawler(WithDefaultColly(2), WithCookieJarFile(path)), ts.URL+"/private") {
+			if strings.HasSuffix(r.Output, "/secret") {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s: expected the private page to be crawled with the saved session", name)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// corsProbeOrigin is the foreign origin sent by the CORS probes
+const corsProbeOrigin = "https://cors-probe.example"
+
+var apiPathRE = regexp.MustCompile(`(?i)/(?:api|graphql|rest|v\d+)(?:/|$)`)
+
+// CORSPolicy is the CORS behavior of an endpoint for a probed Origin
+type CORSPolicy struct {
+	Origin           string `json:"origin"`
+	AllowOrigin      string `json:"allow_origin"`
+	AllowCredentials bool   `json:"allow_credentials"`
+	// Issue flags the dangerous configurations, empty when the probed origin is not trusted
+	Issue string `json:"issue,omitempty"`
+}
+
+// newCORSPolicy returns the policy of the CORS headers of a response to a request sent with origin
+func newCORSPolicy(origin string, headers http.Header) CORSPolicy {
+	policy := CORSPolicy{
+		Origin:           origin,
+		AllowOrigin:      strings.TrimSpace(headers.Get("Access-Control-Allow-Origin")),
+		AllowCredentials: strings.EqualFold(strings.TrimSpace(headers.Get("Access-Control-Allow-Credentials")), "true"),
+	}
+	switch {
+	case policy.AllowOrigin == "*" && policy.AllowCredentials:
+		policy.Issue = "wildcard origin with credentials"
+	case policy.AllowOrigin == "*":
+		policy.Issue = "wildcard origin"
+	case policy.AllowOrigin == origin && origin == "null" && policy.AllowCredentials:
+		policy.Issue = "null origin with credentials"
+	case policy.AllowOrigin == origin && origin == "null":
+		policy.Issue = "null origin"
+	case strings.EqualFold(policy.AllowOrigin, origin) && policy.AllowCredentials:
+		policy.Issue = "reflected origin with credentials"
+	case strings.EqualFold(policy.AllowOrigin, origin):
+		policy.Issue = "reflected origin"
+	}
+	return policy
+}
+
+// isAPIEndpoint reports whether a response looks like an API endpoint: a JSON response, an api-like path
+// or a response already sending CORS headers
+func isAPIEndpoint(response *colly.Response) bool {
+	contentType := responseContentType(response)
+	if contentType == "application/json" || strings.HasSuffix(contentType, "+json") || apiPathRE.MatchString(response.Request.URL.Path) {
+		return true
+	}
+	return response.Headers != nil && response.Headers.Get("Access-Control-Allow-Origin") != ""
+}
+
+// ProbeCORS requests u with a foreign and a null Origin, and returns the CORS policies the endpoint answered with
+func ProbeCORS(client *http.Client, u string) ([]CORSPolicy, error) {
+	policies := []CORSPolicy{}
+	for _, origin := range []string{corsProbeOrigin, "null"} {
+		req, err := http.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Origin", origin)
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+		if policy := newCORSPolicy(origin, resp.Header); policy.AllowOrigin != "" {
+			policies = append(policies, policy)
+		}
+	}
+	return policies, nil
+}
+
+// probeCORS emits the CORS policies of the endpoint of response, once per path
+func (crawler *Crawler) probeCORS(emit func(SpiderReport), response *colly.Response) {
+	u := *response.Request.URL
+	u.RawQuery, u.Fragment = "", ""
+	if !isAPIEndpoint(response) || crawler.probedCORS.Duplicate(u.String()) {
+		return
+	}
+	policies, err := ProbeCORS(crawler.helperClient(10*time.Second), response.Request.URL.String())
+	if err != nil {
+		Logger.Debugf("Failed to probe the CORS policy of %s: %s", response.Request.URL, err)
+		return
+	}
+	for _, policy := range policies {
+		summary := policy.Issue
+		if summary == "" {
+			summary = "allows " + policy.AllowOrigin
+		}
+		emit(SpiderReport{
+			Output:     response.Request.URL.String() + " " + summary,
+			OutputType: CORS,
+			Source:     "cors-probe",
+			StatusCode: response.StatusCode,
+			Input:      response.Request.URL,
+			Seed:       requestSeed(response.Request),
+		}.WithMetadata("cors", policy))
+	}
+}
+
+// WithCORSProbe requests again the API endpoints (JSON responses, api-like paths and responses with CORS headers)
+// with a foreign and a null Origin, and emits a cors report for every policy they answer with, flagging the wildcard
+// and reflected origins allowed with credentials
+func WithCORSProbe() CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.corsProbe = true
+	}
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestNewCORSPolicy(t *testing.T) {
+	tests := []struct {
+		origin      string
+		allowOrigin string
+		credentials string
+		issue       string
+	}{
+		{corsProbeOrigin, "*", "true", "wildcard origin with credentials"},
+		{corsProbeOrigin, "*", "", "wildcard origin"},
+		{corsProbeOrigin, corsProbeOrigin, "true", "reflected origin with credentials"},
+		{"null", "null", "", "null origin"},
+		{corsProbeOrigin, "https://app.example.com", "true", ""},
+	}
+	for _, test := range tests {
+		headers := http.Header{}
+		headers.Set("Access-Control-Allow-Origin", test.allowOrigin)
+		headers.Set("Access-Control-Allow-Credentials", test.credentials)
+		if policy := newCORSPolicy(test.origin, headers); policy.Issue != test.issue {
+			t.Errorf("expected the issue %q for %+v, got %q", test.issue, test, policy.Issue)
+		}
+	}
+}
+
+func TestCORSProbe(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><body><a href="/api/users?page=1">users</a><a href="/about">about</a></body></html>`)
+		case "/api/users":
+			if origin := r.Header.Get("Origin"); origin != "" && origin != "null" {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `[]`)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+	}))
+	defer ts.Close()
+
+	outputs := []string{}
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(2), WithCORSProbe()), ts.URL) {
+		if r.OutputType == CORS {
+			outputs = append(outputs, r.Output)
+		}
+	}
+	sort.Strings(outputs)
+	expected := []string{
+		ts.URL + "/about wildcard origin",
+		ts.URL + "/api/users?page=1 reflected origin with credentials",
+	}
+	if !reflect.DeepEqual(outputs, expected) {
+		t.Errorf("expected the cors reports %v, got %v", expected, outputs)
+	}
+}
@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -10,14 +11,19 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/benji-bou/chantools"
 	"github.com/benji-bou/gospider/stringset"
 	"github.com/gocolly/colly/v2"
 	"github.com/gocolly/colly/v2/extensions"
-	sitemap "github.com/oxffaa/gopher-parse-sitemap"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var DefaultHTTPTransport = &http.Transport{
@@ -41,14 +47,132 @@ type Crawler struct {
 	// LinkFinderCollector *colly.Collector
 	Output io.Writer
 
+	formatter  Formatter
+	outputLock sync.Mutex
+	sinks      []Sink
+
 	collectorOpt         []colly.CollectorOption
 	collyConfigrationOpt []CollyConfigurator
-
-	set *stringset.StringFilter
+	// optionErrors are the errors of the options which couldn't be applied, returned by Start
+	optionErrors []error
+
+	set DedupStore
+	// visited is the colly storage of set when it is one
+	visited  *revisitStorage
+	frontier Frontier
+
+	// httpClient is the client of the collector, used to fetch robots.txt and sitemaps through the same transport
+	httpClient *http.Client
+	// seedHosts are the hosts of the seeds, the only ones the credentials of WithHTTPAuth are sent to by default
+	seedHosts *seedHosts
+	// collectorClient is the copy of httpClient whose transport is wrapped for the current collector, nil when not wrapped
+	collectorClient *http.Client
+
+	checkpoint *checkpoint
+	budget     *crawlBudget
+
+	robotsPolicy *robotsPolicy
+
+	sitemapPaths      []string
+	sitemapMaxBytes   int64
+	sitemapMaxEntries int
+	// discoveredSitemaps dedups the sitemaps found while crawling
+	discoveredSitemaps *stringset.StringFilter
+	// discoveredFavicons dedups the favicons fetched while crawling
+	discoveredFavicons *stringset.StringFilter
+	favicon            bool
+	techRules          []techFingerprint
+	// analyzedOrigins dedups the origins whose security headers were analyzed
+	analyzedOrigins *stringset.StringFilter
+	securityHeaders bool
+	cspDomains      bool
+	crawlCSPDomains bool
+	// visitedCSPHosts dedups the hosts found in the CSPs which were visited
+	visitedCSPHosts *stringset.StringFilter
+	corsProbe       bool
+	// probedCORS dedups the endpoints whose CORS policy was probed
+	probedCORS       *stringset.StringFilter
+	backendDetection bool
+	comments         bool
+	cssLinks         bool
+	apiRefs          bool
+	sourceMaps       bool
+	// discoveredSourceMaps dedups the source maps fetched while crawling
+	discoveredSourceMaps *stringset.StringFilter
+	jsLiterals           bool
+	openAPI              bool
+	visitOpenAPI         bool
+	// probedOpenAPIHosts dedups the origins whose spec paths were probed, openAPISpecs the specs parsed
+	probedOpenAPIHosts *stringset.StringFilter
+	openAPISpecs       *stringset.StringFilter
+	webSockets         bool
+	probeWebSockets    bool
+	// probedWebSockets dedups the websocket endpoints probed while crawling
+	probedWebSockets *stringset.StringFilter
+	formSubmission   bool
+	submitPostForms  bool
+	// submittedForms dedups the POST forms submitted, by action and body
+	submittedForms *stringset.StringFilter
+	paramMiner     *paramMiner
+	patternDedup   *patternDedup
+	queryNorm      *queryNormalizer
+	canonicalizer  Canonicalizer
+	unicodeURLs    bool
+	feeds          bool
+	feedPaths      []string
+	// discoveredFeeds dedups the feeds read while crawling, probedFeedHosts the origins whose feed paths were probed
+	discoveredFeeds *stringset.StringFilter
+	probedFeedHosts *stringset.StringFilter
+	crawlSubdomains bool
+	// crawledSubdomains dedups the subdomains visited by WithCrawlDiscoveredSubdomains
+	crawledSubdomains *stringset.StringFilter
+	scope             *Scope
+	depthPolicy       *depthPolicy
+
+	maxRetries   int
+	retryBackoff time.Duration
+
+	additionalTargetWorkers int
+	additionalTargetTimeout time.Duration
+
+	loginFlow        *LoginFlow
+	sessionRefresh   bool
+	sessionLoggedOut *regexp.Regexp
+	cookieJarFile    string
+	burpReplay       string
+	harImport        string
+	harExport        string
+	harExportBodies  bool
+	harWriter        *harWriter
+	auditLog         io.Writer
 
 	sitemap            bool
 	robot              bool
-	othersources       bool
+	sources            []Source
+	sourceCacheDir     string
+	sourceCacheTTL     time.Duration
+	language           bool
+	title              bool
+	pwa                bool
+	linkfinder         bool
+	bodyMatchers       []bodyMatcher
+	matchContext       int
+	secretScanners     []secretScanner
+	bodyStore          BodyStore
+	bodyArchive        *BodyArchive
+	redirects          *redirectTracker
+	connectionInfo     bool
+	connections        *connectionTracker
+	jobMetadata        map[string]string
+	reportTypes        map[OutputType]bool
+	retryQueue         *RetryQueue
+	throttle           *throttleTransport
+	control            *crawlControl
+	metrics            *crawlMetrics
+	metricsServer      *http.Server
+	tracer             trace.Tracer
+	extractionRules    []ExtractionRule
+	recordTemplates    []RecordTemplate
 	filterLength_slice []int
 }
 
@@ -57,7 +181,29 @@ func NewCrawler(opt ...CrawlerOption) *Crawler {
 		collectorOpt:         make([]colly.CollectorOption, 0),
 		collyConfigrationOpt: make([]CollyConfigurator, 0),
 		set:                  stringset.NewStringFilter(),
+		httpClient:           &http.Client{Transport: DefaultHTTPTransport.Clone()},
+		seedHosts:            &seedHosts{},
+		canonicalizer:        Canonicalizer{PunycodeHost},
+		discoveredSitemaps:   stringset.NewStringFilter(),
+		discoveredFavicons:   stringset.NewStringFilter(),
+		analyzedOrigins:      stringset.NewStringFilter(),
+		visitedCSPHosts:      stringset.NewStringFilter(),
+		probedCORS:           stringset.NewStringFilter(),
+		discoveredSourceMaps: stringset.NewStringFilter(),
+		probedOpenAPIHosts:   stringset.NewStringFilter(),
+		openAPISpecs:         stringset.NewStringFilter(),
+		probedWebSockets:     stringset.NewStringFilter(),
+		submittedForms:       stringset.NewStringFilter(),
+		discoveredFeeds:      stringset.NewStringFilter(),
+		probedFeedHosts:      stringset.NewStringFilter(),
+		crawledSubdomains:    stringset.NewStringFilter(),
+		bodyMatchers:         make([]bodyMatcher, 0),
+		extractionRules:      make([]ExtractionRule, 0),
+		recordTemplates:      make([]RecordTemplate, 0),
 		filterLength_slice:   make([]int, 0),
+		control:              newCrawlControl(),
+		metrics:              newCrawlMetrics(),
+		tracer:               defaultTracer(),
 	}
 
 	for _, o := range opt {
@@ -66,28 +212,364 @@ func NewCrawler(opt ...CrawlerOption) *Crawler {
 	return crawler
 }
 
-func (crawler *Crawler) handleResult(c chan<- SpiderReport, output SpiderReport) {
+func (crawler *Crawler) handleResult(ctx context.Context, c chan<- SpiderReport, errC chan<- error, output SpiderReport) {
 
 	if output.Output == "" {
 		return
 	}
-	if !crawler.set.Duplicate(output.Output) {
-		c <- output
+	key := output.Output
+	if output.dedupKey != "" {
+		key = output.dedupKey
+	}
+	duplicate := crawler.set.Duplicate(key)
+	if store, ok := crawler.set.(FallibleDedupStore); ok {
+		crawler.handleError(errC, store.Err())
+	}
+	if !duplicate {
+		if crawler.checkpoint != nil {
+			crawler.checkpoint.reported(key)
+		}
+		crawler.publish(ctx, c, errC, output)
+	}
+}
+
+// publish sends a report to the Output, the sinks and the report channel, unless its type is filtered out
+func (crawler *Crawler) publish(ctx context.Context, c chan<- SpiderReport, errC chan<- error, output SpiderReport) {
+	output.Job = crawler.jobMetadata
+	if crawler.paramMiner != nil {
+		crawler.paramMiner.observe(output)
+	}
+	if len(crawler.reportTypes) > 0 && !crawler.reportTypes[output.OutputType] {
+		return
+	}
+	crawler.metrics.reports.WithLabelValues(string(output.OutputType)).Inc()
+	crawler.writeOutput(output)
+	for _, sink := range crawler.sinks {
+		sinkCtx, span := crawler.tracer.Start(ctx, "crawl.sink.send", trace.WithAttributes(
+			attribute.String("sink", fmt.Sprintf("%T", sink)),
+			attribute.String("report.type", string(output.OutputType)),
+		))
+		if err := sink.Send(sinkCtx, output); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			crawler.handleError(errC, fmt.Errorf("failed to send %s report %s to sink: %w", output.OutputType, output.Output, err))
+		}
+		span.End()
+	}
+	c <- output
+}
+
+func (crawler *Crawler) closeSinks(errC chan<- error) {
+	for _, sink := range crawler.sinks {
+		if err := sink.Close(); err != nil {
+			crawler.handleError(errC, fmt.Errorf("failed to close sink: %w", err))
+		}
+	}
+}
+
+// writeOutput writes the formatted report to the crawler Output, if any
+func (crawler *Crawler) writeOutput(output SpiderReport) {
+	if crawler.Output == nil {
+		return
+	}
+	formatter := crawler.formatter
+	if formatter == nil {
+		formatter = TextFormatter{}
+	}
+	raw, err := formatter.Format(output)
+	if err != nil {
+		Logger.Error(err)
+		return
+	}
+	crawler.outputLock.Lock()
+	defer crawler.outputLock.Unlock()
+	if _, err := crawler.Output.Write(raw); err != nil {
+		Logger.Errorf("Failed to write output: %s", err)
+	}
+}
+
+func (crawler *Crawler) handleError(c chan<- error, err error) {
+	if err == nil {
+		return
+	}
+	if len(crawler.jobMetadata) > 0 {
+		err = &JobError{Err: err, Metadata: crawler.jobMetadata}
+	}
+	c <- err
+}
+
+func (crawler *Crawler) matchBody(emit func(SpiderReport), request *colly.Request, body string) {
+	for _, matcher := range crawler.bodyMatchers {
+		for _, report := range matcher.Match(request.URL, body, crawler.matchContext) {
+			report.Seed = requestSeed(request)
+			emit(report)
+		}
+	}
+	for _, scanner := range crawler.secretScanners {
+		for _, report := range scanner.Scan(request.URL, body, crawler.matchContext) {
+			report.Seed = requestSeed(request)
+			emit(report)
+		}
+	}
+}
+
+// findLinks emits the endpoints found by LinkFinder in javascript, json and source map responses
+func (crawler *Crawler) findLinks(emit func(SpiderReport), response *colly.Response, body string) {
+	contentType := responseContentType(response)
+	if !isLinkFinderTarget(response.Request.URL.String(), contentType) {
+		return
+	}
+	source := body
+	if crawler.jsLiterals && (strings.Contains(contentType, "javascript") || GetExtType(response.Request.URL.String()) == ".js") {
+		// the literals are read from the raw script, as decoding its url escapes turns the + of concatenations into spaces
+		source = jsLiteralsSource(string(response.Body))
+	}
+	paths, err := LinkFinder(source)
+	if err != nil {
+		Logger.Error(err)
+		return
+	}
+	for _, relPath := range paths {
+		rebuildURL := FixUrl(response.Request.URL, relPath)
+		if rebuildURL == "" {
+			continue
+		}
+		report := SpiderReport{
+			Output:     rebuildURL,
+			OutputType: LinkFinderOutput,
+			Source:     "body",
+			Input:      response.Request.URL,
+			Seed:       requestSeed(response.Request),
+		}
+		if crawler.matchContext > 0 {
+			if offset := strings.Index(body, relPath); offset >= 0 {
+				report.Offset = offset
+				report.Snippet = Snippet(body, offset, offset+len(relPath), crawler.matchContext)
+			}
+		}
+		emit(report)
+	}
+}
+
+// discoverPWA emits the registered service workers of a response and,
+// when the response is itself a service worker or a web app manifest, the routes it declares
+func (crawler *Crawler) discoverPWA(emit func(SpiderReport), request *colly.Request, body string) {
+	for _, sw := range ServiceWorkerRegistrations(body) {
+		emit(SpiderReport{
+			Output:     absoluteURL(request, sw),
+			OutputType: ServiceWorker,
+			Source:     "body",
+			Input:      request.URL,
+			Seed:       requestSeed(request),
+		})
+	}
+	routes, source := []string{}, ""
+	if manifestRoutes, ok := ManifestRoutes(body); ok {
+		routes, source = manifestRoutes, "manifest"
+	} else if IsServiceWorkerScript(body) {
+		routes, source = ServiceWorkerRoutes(body), "service-worker"
+	}
+	for _, route := range routes {
+		emit(SpiderReport{
+			Output:     absoluteURL(request, route),
+			OutputType: Ref,
+			Source:     source,
+			Input:      request.URL,
+			Seed:       requestSeed(request),
+		})
+	}
+}
+
+// visit schedules u on c, tagging the request with the seed it originates from.
+// When a frontier is set, u is pushed to it instead
+func (crawler *Crawler) visit(c *colly.Collector, u string, seed string) error {
+	return crawler.visitAt(c, u, seed, 1)
+}
+
+// visitAt visits u as a page found at depth
+func (crawler *Crawler) visitAt(c *colly.Collector, u string, seed string, depth int) error {
+	if !crawler.scope.AllowsURL(u) {
+		Logger.Debugf("Skipping %s out of scope", u)
+		return ErrOutOfScope
+	}
+	if crawler.control.isStopped() {
+		if crawler.checkpoint != nil {
+			crawler.checkpoint.postpone(u, seed, depth)
+		}
+		return ErrCrawlerStopped
+	}
+	if crawler.budget != nil && crawler.budget.exhausted() != "" {
+		if crawler.checkpoint != nil {
+			crawler.checkpoint.postpone(u, seed, depth)
+		}
+		return ErrBudgetExhausted
+	}
+	if crawler.frontier != nil {
+		return crawler.frontier.Push(context.Background(), FrontierEntry{URL: u, Seed: seed, Depth: depth})
+	}
+	return crawler.request(c, u, seed, depth, false)
+}
+
+func (crawler *Crawler) request(c *colly.Collector, u string, seed string, depth int, fromFrontier bool) error {
+	// entry is the url pushed to the frontier, acknowledged once the request is done
+	entry := u
+	crawler.seedHosts.add(seed)
+	u = crawler.canonicalizer.Canonicalize(u)
+	if crawler.queryNorm != nil {
+		normalized, duplicate := crawler.queryNorm.apply(u)
+		if duplicate {
+			Logger.Debugf("Skipping %s visited with another query", u)
+			return ErrDuplicateQuery
+		}
+		u = normalized
+	}
+	if crawler.robotsPolicy != nil && !crawler.robotsPolicy.allowed(c, u) {
+		Logger.Debugf("Skipping %s disallowed by robots.txt", u)
+		return ErrDisallowedByRobots
+	}
+	if crawler.patternDedup != nil {
+		if parsed, err := url.Parse(u); err == nil && !crawler.patternDedup.allow(parsed) {
+			Logger.Debugf("Skipping %s sharing the pattern of a visited url", u)
+			return ErrDuplicatePattern
+		}
+	}
+	ctx := colly.NewContext()
+	ctx.Put(seedContextKey, seed)
+	if depth > 1 {
+		ctx.Put(depthContextKey, strconv.Itoa(depth))
+	}
+	if fromFrontier {
+		ctx.Put(frontierContextKey, entry)
+	}
+	if crawler.checkpoint != nil {
+		if !crawler.checkpoint.schedule(u, seed, depth) {
+			return colly.ErrAlreadyVisited
+		}
+		ctx.Put(checkpointURLContextKey, u)
+	}
+	if crawler.budget != nil && !crawler.budget.reserve() {
+		if crawler.checkpoint != nil {
+			crawler.checkpoint.done(u)
+			crawler.checkpoint.postpone(u, seed, depth)
+		}
+		return ErrBudgetExhausted
+	}
+	crawler.metrics.queueDepth.Inc()
+	err := c.Request("GET", u, nil, ctx, nil)
+	if err != nil {
+		if crawler.budget != nil {
+			crawler.budget.release()
+		}
+		crawler.metrics.queueDepth.Dec()
+		if crawler.checkpoint != nil {
+			crawler.checkpoint.done(u)
+		}
 	}
+	return err
 }
 
 func (crawler *Crawler) provisionCollector() (*colly.Collector, error) {
 	c := colly.NewCollector(crawler.collectorOpt...)
+	crawler.collectorClient = nil
+	scopeCredentials(crawler.httpClient, crawler.seedHosts)
+	// registered first so the following error handlers know whether the request is retried
+	c.OnError(func(r *colly.Response, err error) {
+		crawler.planRetry(r)
+	})
 	for _, configColly := range crawler.collyConfigrationOpt {
 		err := configColly(c)
 		if err != nil {
 			return nil, fmt.Errorf("failed to configure new colly.Collector: %w", err)
 		}
 	}
+	if err := crawler.setStorage(c); err != nil {
+		return nil, err
+	}
+	if crawler.throttle != nil {
+		crawler.throttleTraffic(c)
+	}
+	if crawler.harExport != "" {
+		if err := crawler.recordHAR(c); err != nil {
+			return nil, err
+		}
+	}
+	if crawler.auditLog != nil {
+		crawler.auditTraffic(c)
+	}
+	if crawler.connectionInfo {
+		crawler.trackConnections(c)
+	}
 	extensions.Referer(c)
+	crawler.redirects = &redirectTracker{}
+	crawler.redirects.instrument(crawler, c)
+	crawler.metrics.instrument(c)
+	crawler.traceRequests(c)
+	if crawler.budget != nil {
+		crawler.budget.instrument(c)
+	}
+	if crawler.depthPolicy != nil {
+		crawler.depthPolicy.instrument(c)
+	}
+	if crawler.bodyArchive != nil {
+		crawler.bodyArchive.instrument(c)
+	}
+	if crawler.robotsPolicy != nil {
+		crawler.robotsPolicy.client = crawler.helperClient(10 * time.Second)
+		crawler.robotsPolicy.instrument(c)
+	}
+	c.OnScraped(func(r *colly.Response) {
+		crawler.requestDone(r.Request)
+	})
+	c.OnError(func(r *colly.Response, err error) {
+		if isRetrying(r.Request) {
+			return
+		}
+		crawler.requestDone(r.Request)
+	})
 	return c, nil
 }
 
+// requestDone acknowledges r to the frontier and to the checkpoint once processed or aborted
+func (crawler *Crawler) requestDone(r *colly.Request) {
+	crawler.frontierDone(r)
+	if crawler.checkpoint != nil {
+		crawler.checkpoint.requestDone(r)
+	}
+}
+
+// abortRequest aborts r from an OnRequest callback. colly fires neither OnScraped nor OnError for an aborted request,
+// so r is flagged for the crawler to acknowledge it as done
+func abortRequest(r *colly.Request) {
+	r.Abort()
+	r.Ctx.Put(abortedContextKey, "1")
+}
+
+// helperClient returns a client sharing the transport and the cookies of the collector, for the requests made outside of colly
+func (crawler *Crawler) helperClient(timeout time.Duration) *http.Client {
+	transport := crawler.httpClient.Transport
+	if crawler.collectorClient != nil {
+		transport = crawler.collectorClient.Transport
+	}
+	return &http.Client{Transport: transport, Jar: crawler.httpClient.Jar, Timeout: timeout}
+}
+
+// wrapTransport switches the collector to a copy of the crawler client, made once per collector, and wraps its
+// transport with wrap. The crawler client, which may be the one of the caller, is left untouched so that a
+// crawler started again wraps the original transport
+func (crawler *Crawler) wrapTransport(c *colly.Collector, wrap func(next http.RoundTripper) http.RoundTripper) {
+	crawler.cookieJar(c)
+	if crawler.collectorClient == nil {
+		client := *crawler.httpClient
+		crawler.collectorClient = &client
+	}
+	next := crawler.collectorClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	crawler.collectorClient.Transport = wrap(next)
+	c.SetClient(crawler.collectorClient)
+}
+
 func (crawler *Crawler) getTarget(site string) (*url.URL, string, error) {
 	target, err := url.Parse(site)
 	domain := ""
@@ -97,193 +579,601 @@ func (crawler *Crawler) getTarget(site string) (*url.URL, string, error) {
 	return target, domain, err
 }
 
-func (crawler *Crawler) configCollectorListener(ctx context.Context, c *colly.Collector) <-chan SpiderReport {
-	return chantools.New(func(oC chan<- SpiderReport, params ...any) {
+// configCollectorListener registers the extraction callbacks on c. Reports are passed to emit from the colly callbacks
+// so that visits they trigger are scheduled before the originating request is marked as done
+func (crawler *Crawler) configCollectorListener(ctx context.Context, c *colly.Collector, emit func(SpiderReport)) {
+	var isDone atomic.Bool
+	// Handle web app manifest, before [href] so the manifest report wins deduplication
+	crawler.onHTML(c, `link[rel~="manifest"][href]`, func(e *colly.HTMLElement) {
+		if isDone.Load() || !crawler.pwa {
+			return
+		}
+		emit(SpiderReport{
+			Output:     e.Request.AbsoluteURL(e.Attr("href")),
+			OutputType: Manifest,
+			Source:     "body",
+			Input:      e.Request.URL,
+			Seed:       requestSeed(e.Request),
+		})
+	})
 
-		c := params[0].(*colly.Collector)
-		ctx := params[1].(context.Context)
-		isDone := false
-		c.OnHTML("[href]", func(e *colly.HTMLElement) {
-			if isDone {
-				e.Request.Abort()
-				return
-			}
-			urlString := e.Request.AbsoluteURL(e.Attr("href"))
-			oC <- SpiderReport{
-				Output:     urlString,
-				OutputType: Ref,
-				Source:     "body",
-				Input:      e.Request.URL,
+	// Handle the icons of the page
+	crawler.onHTML(c, `link[rel~="icon"][href]`, func(e *colly.HTMLElement) {
+		if isDone.Load() || !crawler.favicon {
+			return
+		}
+		crawler.discoverFavicon(emit, e.Request, e.Request.AbsoluteURL(e.Attr("href")))
+	})
+
+	// Handle the stylesheets and style attributes of the page
+	crawler.onHTML(c, `style, [style*="url("]`, func(e *colly.HTMLElement) {
+		if isDone.Load() || !crawler.cssLinks {
+			return
+		}
+		if e.Name == "style" {
+			crawler.discoverCSSURLs(emit, e.Request, e.Text)
+		}
+		crawler.discoverCSSURLs(emit, e.Request, e.Attr("style"))
+	})
+
+	// Handle sitemaps declared in the page, which can live outside the usual paths
+	crawler.onHTML(c, `link[rel~="sitemap"][href]`, func(e *colly.HTMLElement) {
+		if isDone.Load() || !crawler.sitemap {
+			return
+		}
+		crawler.discoverSitemap(emit, e.Request, e.Request.AbsoluteURL(e.Attr("href")))
+	})
+
+	// Handle the feeds advertised by the page
+	crawler.onHTML(c, `link[rel~="alternate"][type][href]`, func(e *colly.HTMLElement) {
+		if isDone.Load() || !crawler.feeds || !feedContentTypes[strings.ToLower(strings.TrimSpace(e.Attr("type")))] {
+			return
+		}
+		crawler.discoverFeed(emit, e.Request, e.Request.AbsoluteURL(e.Attr("href")))
+	})
+
+	// Handle canonical, alternate and resource hint links, before [href] so the typed report wins deduplication
+	crawler.onHTML(c, `link[rel][href]`, func(e *colly.HTMLElement) {
+		if isDone.Load() {
+			return
+		}
+		discoverLinkElement(emit, e)
+	})
+
+	// Handle meta refresh redirects, frequent on legacy apps
+	crawler.onHTML(c, `meta[http-equiv][content]`, func(e *colly.HTMLElement) {
+		if isDone.Load() || !strings.EqualFold(strings.TrimSpace(e.Attr("http-equiv")), "refresh") {
+			return
+		}
+		discoverRefresh(emit, e.Request, e.Attr("content"), "meta-refresh")
+	})
+
+	crawler.onHTML(c, "[href]", func(e *colly.HTMLElement) {
+		if isDone.Load() {
+			e.Request.Abort()
+			return
+		}
+		// <base href> isn't a link, it only changes how the other ones resolve
+		if e.Name == "base" {
+			return
+		}
+		href := e.Attr("href")
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(href)), "javascript:") {
+			for _, target := range JSNavigationTargets(href) {
+				emit(SpiderReport{
+					Output:     e.Request.AbsoluteURL(target),
+					OutputType: Ref,
+					Source:     "body",
+					Input:      e.Request.URL,
+					Seed:       requestSeed(e.Request),
+				})
 			}
+			return
+		}
+		urlString := e.Request.AbsoluteURL(href)
+		emit(SpiderReport{
+			Output:     urlString,
+			OutputType: Ref,
+			Source:     "body",
+			Input:      e.Request.URL,
+			Seed:       requestSeed(e.Request),
 		})
+	})
 
-		// Handle form
-		c.OnHTML("form[action]", func(e *colly.HTMLElement) {
-			if isDone {
-				e.Request.Abort()
-				return
+	// Handle inline event handlers (onclick, onsubmit...)
+	crawler.onHTML(c, inlineEventHandlerSelector, func(e *colly.HTMLElement) {
+		if isDone.Load() {
+			e.Request.Abort()
+			return
+		}
+		for _, handler := range inlineEventHandlers(e) {
+			for _, target := range JSNavigationTargets(handler) {
+				emit(SpiderReport{
+					Output:     e.Request.AbsoluteURL(target),
+					OutputType: Ref,
+					Source:     "body",
+					Input:      e.Request.URL,
+					Seed:       requestSeed(e.Request),
+				})
 			}
+		}
+	})
 
-			formUrl := e.Request.URL.String()
-			oC <- SpiderReport{
-				Output:     formUrl,
-				OutputType: Form,
-				Source:     "body",
-				Input:      e.Request.URL,
-			}
+	// Handle form, reported by action with its method and fields in the Metadata
+	crawler.onHTML(c, "form", func(e *colly.HTMLElement) {
+		if isDone.Load() {
+			e.Request.Abort()
+			return
+		}
 
-		})
+		form := ParseHTMLForm(e.Request.URL, e.DOM)
+		emit(SpiderReport{
+			Output:     form.Action,
+			OutputType: Form,
+			Source:     "body",
+			Input:      e.Request.URL,
+			Seed:       requestSeed(e.Request),
+		}.WithMetadata("form", form))
+		if crawler.formSubmission {
+			crawler.submitForm(c, e.Request, form)
+		}
+	})
 
-		// Find Upload Form
-		c.OnHTML(`input[type="file"]`, func(e *colly.HTMLElement) {
-			if isDone {
-				e.Request.Abort()
-				return
-			}
+	// Find Upload Form
+	crawler.onHTML(c, `input[type="file"]`, func(e *colly.HTMLElement) {
+		if isDone.Load() {
+			e.Request.Abort()
+			return
+		}
 
-			uploadUrl := e.Request.URL.String()
-			oC <- SpiderReport{
-				Output:     uploadUrl,
-				OutputType: Upload,
-				Source:     "body",
-				Input:      e.Request.URL,
-			}
+		uploadUrl := e.Request.URL.String()
+		emit(SpiderReport{
+			Output:     uploadUrl,
+			OutputType: Upload,
+			Source:     "body",
+			Input:      e.Request.URL,
+			Seed:       requestSeed(e.Request),
 		})
+	})
 
-		// Handle js files
-		c.OnHTML("[src]", func(e *colly.HTMLElement) {
-			if isDone {
-				e.Request.Abort()
-				return
-			}
+	// Handle js files
+	crawler.onHTML(c, "[src]", func(e *colly.HTMLElement) {
+		if isDone.Load() {
+			e.Request.Abort()
+			return
+		}
+
+		jsFileUrl := e.Request.AbsoluteURL(e.Attr("src"))
+		emit(SpiderReport{
+			Output:     jsFileUrl,
+			OutputType: Src,
+			Source:     "body",
+			Input:      e.Request.URL,
+			Seed:       requestSeed(e.Request),
+		})
+	})
 
-			jsFileUrl := e.Request.AbsoluteURL(e.Attr("src"))
-			oC <- SpiderReport{
-				Output:     jsFileUrl,
+	// Handle responsive images, picture and media sources, video posters and lazy loaded images
+	crawler.onHTML(c, mediaSelector, func(e *colly.HTMLElement) {
+		if isDone.Load() {
+			e.Request.Abort()
+			return
+		}
+		for _, u := range mediaURLs(e) {
+			emit(SpiderReport{
+				Output:     e.Request.AbsoluteURL(u),
 				OutputType: Src,
 				Source:     "body",
 				Input:      e.Request.URL,
-			}
-		})
+				Seed:       requestSeed(e.Request),
+			})
+		}
+	})
 
-		c.OnResponse(func(response *colly.Response) {
-			if isDone {
-				return
+	// Handle user defined extraction rules
+	for _, rule := range crawler.extractionRules {
+		rule.Register(c, func(report SpiderReport) {
+			if !isDone.Load() {
+				emit(report)
 			}
-
-			respStr := DecodeChars(string(response.Body))
-			if len(crawler.filterLength_slice) == 0 || !contains(crawler.filterLength_slice, len(respStr)) {
-				// Verify which link is working
-				u := response.Request.URL.String()
-				oC <- SpiderReport{
-					Output:     u,
-					OutputType: Url,
-					Source:     "body",
-					StatusCode: response.StatusCode,
-					Body:       respStr,
-					Input:      response.Request.URL,
-				}
+		})
+	}
+	for _, tpl := range crawler.recordTemplates {
+		tpl.Register(c, func(report SpiderReport) {
+			if !isDone.Load() {
+				emit(report)
 			}
 		})
+	}
 
-		c.OnError(func(response *colly.Response, err error) {
-			if isDone {
-
-				return
-			}
+	c.OnResponse(func(response *colly.Response) {
+		if isDone.Load() {
+			return
+		}
+		redirects := crawler.redirects.chain(response.Request)
+		connection := crawler.connections.take(response.Request)
+		if crawler.retryQueue != nil {
+			crawler.retryQueue.Done(response.Request.URL.String())
+		}
 
-			// Logger.Debugf("Error request: %s - Status code: %v - Error: %s", response.Request.URL.String(), response.StatusCode, err)
-			/*
-				1xx Informational
-				2xx Success
-				3xx Redirection
-				4xx Client Error
-				5xx Server Error
-			*/
-			if response.StatusCode == 404 || response.StatusCode == 429 || response.StatusCode < 100 || response.StatusCode >= 500 {
-				return
-			}
-			respStr := DecodeChars(string(response.Body))
+		respStr := DecodeChars(string(response.Body))
+		setDocumentBase(response, respStr)
+		if len(crawler.filterLength_slice) == 0 || !contains(crawler.filterLength_slice, len(respStr)) {
+			// Verify which link is working
 			u := response.Request.URL.String()
-			oC <- SpiderReport{
-				Output:     u,
-				OutputType: Url,
-				Source:     "body",
-				StatusCode: response.StatusCode,
-				Body:       respStr,
-				Err:        err,
-				Input:      response.Request.URL,
+			report := SpiderReport{
+				Output:      u,
+				OutputType:  Url,
+				Source:      "body",
+				StatusCode:  response.StatusCode,
+				Input:       response.Request.URL,
+				Seed:        requestSeed(response.Request),
+				ContentType: responseContentType(response),
+				Retries:     requestRetries(response.Request),
+			}.withResponse(response, redirects).withConnection(connection).withBody(respStr, crawler.bodyStore)
+			if crawler.language {
+				report.Language = DetectLanguage(respStr)
 			}
-		})
-		c.OnRequest(func(r *colly.Request) {
-			slog.Info("new Request", "request", r.URL.String())
-			if isDone {
-
-				slog.Info("cancelling request due to end of work trigerred", "request", r.URL.String())
-				r.Abort()
+			if crawler.title {
+				report.Title = ExtractTitle(respStr)
 			}
-		})
-		<-ctx.Done()
-		isDone = true
-		c.Wait()
+			emit(report)
+		}
+		crawler.matchBody(emit, response.Request, respStr)
+		if refresh := response.Headers.Get("Refresh"); refresh != "" {
+			discoverRefresh(emit, response.Request, refresh, "header")
+		}
+		if crawler.sitemap && response.Request.URL.Path == "/robots.txt" {
+			crawler.discoverRobotsSitemaps(emit, response.Request, respStr)
+		}
+		if crawler.linkfinder {
+			crawler.findLinks(emit, response, respStr)
+		}
+		if crawler.pwa {
+			crawler.discoverPWA(emit, response.Request, respStr)
+		}
+		if crawler.favicon {
+			u := response.Request.URL
+			crawler.discoverFavicon(emit, response.Request, u.Scheme+"://"+u.Host+"/favicon.ico")
+		}
+		if len(crawler.techRules) > 0 {
+			crawler.discoverTech(emit, response, respStr)
+		}
+		if crawler.securityHeaders {
+			crawler.analyzeSecurityHeaders(emit, response)
+		}
+		if crawler.cspDomains {
+			crawler.discoverCSPDomains(c, emit, response, respStr)
+		}
+		if crawler.corsProbe {
+			crawler.probeCORS(emit, response)
+		}
+		if crawler.backendDetection {
+			crawler.detectBackends(emit, response.Request, respStr)
+		}
+		if crawler.comments {
+			crawler.mineComments(emit, response, respStr)
+		}
+		if crawler.cssLinks && (responseContentType(response) == "text/css" || strings.HasSuffix(response.Request.URL.Path, ".css")) {
+			crawler.discoverCSSURLs(emit, response.Request, respStr)
+		}
+		if crawler.apiRefs {
+			crawler.discoverAPIRefs(emit, response)
+		}
+		if crawler.sourceMaps {
+			crawler.discoverSourceMap(emit, response, respStr)
+		}
+		if crawler.openAPI {
+			crawler.discoverOpenAPI(c, emit, response)
+		}
+		if crawler.webSockets {
+			crawler.discoverWebSockets(emit, response.Request, respStr)
+		}
+		if crawler.feeds {
+			crawler.probeFeeds(emit, response.Request)
+		}
+	})
+
+	c.OnError(func(response *colly.Response, err error) {
+		redirects := crawler.redirects.chain(response.Request)
+		connection := crawler.connections.take(response.Request)
+		// last error handler, the retry is sent once the others ran
+		if isRetrying(response.Request) {
+			crawler.retry(response, err)
+			return
+		}
+		if isDone.Load() {
+
+			return
+		}
+		if retries := requestRetries(response.Request); retries > 0 && isRetryable(response.StatusCode) {
+			Logger.Warnf("Giving up %s after %d retries: %s", response.Request.URL, retries, err)
+		}
+		if crawler.retryQueue != nil && isRetryable(response.StatusCode) {
+			crawler.retryQueue.Push(response.Request.URL.String(), requestSeed(response.Request), err)
+		}
 
-	}, chantools.WithParam[SpiderReport](c), chantools.WithParam[SpiderReport](ctx))
+		// Logger.Debugf("Error request: %s - Status code: %v - Error: %s", response.Request.URL.String(), response.StatusCode, err)
+		/*
+			1xx Informational
+			2xx Success
+			3xx Redirection
+			4xx Client Error
+			5xx Server Error
+		*/
+		if response.StatusCode == 404 || response.StatusCode == 429 || response.StatusCode < 100 || response.StatusCode >= 500 {
+			return
+		}
+		respStr := DecodeChars(string(response.Body))
+		u := response.Request.URL.String()
+		emit(SpiderReport{
+			Output:      u,
+			OutputType:  Url,
+			Source:      "body",
+			StatusCode:  response.StatusCode,
+			Err:         err,
+			Input:       response.Request.URL,
+			Seed:        requestSeed(response.Request),
+			ContentType: responseContentType(response),
+			Retries:     requestRetries(response.Request),
+		}.withResponse(response, redirects).withConnection(connection).withBody(respStr, crawler.bodyStore))
+		crawler.matchBody(emit, response.Request, respStr)
+	})
+	// registered last so it sees the requests aborted by the previous OnRequest callbacks
+	c.OnRequest(func(r *colly.Request) {
+		slog.Info("new Request", "request", r.URL.String())
+		if r.Ctx.Get(abortedContextKey) != "" {
+			crawler.requestDone(r)
+			return
+		}
+		if !crawler.control.wait() {
+			isDone.Store(true)
+		}
+		if isDone.Load() {
 
-	// Handle url
+			slog.Info("cancelling request due to end of work trigerred", "request", r.URL.String())
+			r.Abort()
+			crawler.frontierDone(r)
+			if crawler.checkpoint != nil {
+				crawler.checkpoint.requestPostponed(r)
+			}
+		}
+	})
+	go func() {
+		<-ctx.Done()
+		isDone.Store(true)
+	}()
 }
 
-func (crawler *Crawler) start(ctx context.Context, handleSiteIngestionBehavior func(c *colly.Collector, errC chan<- error)) (<-chan SpiderReport, <-chan error) {
+func (crawler *Crawler) start(ctx context.Context, handleSiteIngestionBehavior func(c *colly.Collector, report func(SpiderReport), errC chan<- error)) (<-chan SpiderReport, <-chan error) {
 
 	return chantools.NewWithErr(func(outputC chan<- SpiderReport, errC chan<- error, params ...any) {
 		ctx := params[0].(context.Context)
 		ctx, cancel := context.WithCancel(ctx)
 		defer cancel()
+		if err := errors.Join(crawler.optionErrors...); err != nil {
+			crawler.handleError(errC, err)
+			return
+		}
+		if store, ok := crawler.bodyStore.(io.Closer); ok {
+			defer func() {
+				crawler.handleError(errC, store.Close())
+			}()
+		}
+		if crawler.metricsServer != nil {
+			stopMetrics, err := crawler.serveMetrics()
+			if err != nil {
+				crawler.handleError(errC, err)
+				return
+			}
+			defer stopMetrics()
+		}
+		if crawler.budget != nil {
+			crawler.budget.start()
+		}
 		c, err := crawler.provisionCollector()
 		if err != nil {
-			errC <- fmt.Errorf("failed to provision collector: %w", err)
+			crawler.handleError(errC, fmt.Errorf("failed to provision collector: %w", err))
 
 			return
 		}
-		chantools.ForEach(crawler.configCollectorListener(ctx, c), func(value SpiderReport) {
-			value = value.FixUrl()
-			crawler.handleResult(outputC, value)
-			for _, next := range value.KeepCrawling() {
-				c.Visit(next)
+		if crawler.throttle != nil {
+			crawler.throttle.onReduce = func(host string, rate float64, limit int) {
+				crawler.publish(ctx, outputC, errC, SpiderReport{
+					Output:     host,
+					OutputType: Throttled,
+					Source:     "throttle",
+				}.WithMetadata("5xx_rate", rate).WithMetadata("concurrency", limit))
+			}
+		}
+		if crawler.harWriter != nil {
+			defer func() {
+				crawler.handleError(errC, crawler.harWriter.close())
+			}()
+		}
+		if crawler.cookieJarFile != "" {
+			if err := crawler.loadCookieJar(c); err != nil {
+				crawler.handleError(errC, err)
+				return
+			}
+			defer func() {
+				crawler.handleError(errC, crawler.saveCookieJar())
+			}()
+		}
+		if crawler.loginFlow != nil {
+			if err := crawler.login(ctx, c); err != nil {
+				crawler.handleError(errC, err)
+				return
+			}
+			if crawler.sessionRefresh {
+				crawler.watchSession(c)
+			}
+		} else if crawler.sessionRefresh {
+			Logger.Warnf("The session can't be refreshed without a login flow")
+		}
+		crawler.configCollectorListener(ctx, c, func(value SpiderReport) {
+			value = value.Canonicalize(crawler.canonicalizer)
+			if crawler.unicodeURLs {
+				value = value.withUnicodeURL()
+			}
+			if !crawler.scope.allowsReport(value) {
+				if crawler.scope.RecordOutOfScope {
+					crawler.handleResult(ctx, outputC, errC, value.WithMetadata("out_of_scope", true))
+				}
+				return
+			}
+			crawler.handleResult(ctx, outputC, errC, value)
+			if crawler.depthPolicy == nil {
+				for _, next := range value.KeepCrawling() {
+					crawler.visit(c, next, value.Seed)
+				}
+			} else if depth := crawler.depthPolicy.depthOf(value.Input) + 1; crawler.depthPolicy.allows(value.OutputType, depth, c.MaxDepth) {
+				for _, next := range value.KeepCrawling() {
+					crawler.visitAt(c, next, value.Seed, depth)
+				}
+			}
+			if crawler.crawlSubdomains && value.OutputType == Domain {
+				crawler.crawlSubdomain(c, value)
 			}
 		})
-		handleSiteIngestionBehavior(c, errC)
+		if crawler.checkpoint != nil {
+			stopCheckpointC := make(chan struct{})
+			go crawler.checkpoint.run(stopCheckpointC)
+			defer func() {
+				close(stopCheckpointC)
+				if err := crawler.checkpoint.save(); err != nil {
+					crawler.handleError(errC, err)
+				}
+			}()
+			for _, entry := range crawler.checkpoint.takeResumed() {
+				crawler.revisit(entry.URL)
+				crawler.visitAt(c, entry.URL, entry.Seed, max(entry.Depth, 1))
+			}
+		}
+		if crawler.retryQueue != nil {
+			stopRetryQueueC := make(chan struct{})
+			go crawler.retryQueue.run(stopRetryQueueC)
+			defer func() {
+				close(stopRetryQueueC)
+				if err := crawler.retryQueue.Save(); err != nil {
+					crawler.handleError(errC, err)
+				}
+			}()
+			for _, entry := range crawler.retryQueue.Entries() {
+				if crawler.checkpoint != nil {
+					crawler.checkpoint.retry(entry.URL)
+				}
+				crawler.revisit(entry.URL)
+				crawler.visit(c, entry.URL, entry.Seed)
+			}
+		}
+		if crawler.harImport != "" {
+			if err := crawler.importHAR(c); err != nil {
+				crawler.handleError(errC, err)
+			}
+		}
+		if crawler.burpReplay != "" {
+			if err := crawler.replayBurpRequests(c); err != nil {
+				crawler.handleError(errC, err)
+			}
+		}
+		handleSiteIngestionBehavior(c, func(value SpiderReport) {
+			crawler.publish(ctx, outputC, errC, value)
+		}, errC)
+		if crawler.frontier != nil {
+			crawler.drainFrontier(ctx, c, errC)
+		}
 		c.Wait()
+		crawler.reportDeadLetters(ctx, outputC, errC)
+		crawler.reportBudget(ctx, outputC, errC)
+		crawler.reportParameters(ctx, outputC, errC)
+		crawler.reportPatterns(ctx, outputC, errC)
+		crawler.closeSinks(errC)
 	}, chantools.WithParam[SpiderReport](ctx))
 
 }
 
-func (crawler *Crawler) additionalTarget(site string) []string {
+// reportDeadLetters emits a dead-letter report for every url of the retry queue which exhausted its attempts.
+// They are then dropped from the queue, so the next crawls don't report them again
+func (crawler *Crawler) reportDeadLetters(ctx context.Context, c chan<- SpiderReport, errC chan<- error) {
+	if crawler.retryQueue == nil {
+		return
+	}
+	dead := crawler.retryQueue.TakeDead()
+	if len(dead) > 0 {
+		Logger.Warnf("%d urls exhausted their retry attempts", len(dead))
+	}
+	for _, entry := range dead {
+		crawler.publish(ctx, c, errC, SpiderReport{
+			Output:     entry.URL,
+			OutputType: DeadLetter,
+			Source:     "retry-queue",
+			Err:        errors.New(entry.LastError),
+			Seed:       entry.Seed,
+		})
+	}
+}
+
+// reportBudget emits a budget-exhausted report when the crawl ended because of its budget
+func (crawler *Crawler) reportBudget(ctx context.Context, c chan<- SpiderReport, errC chan<- error) {
+	if crawler.budget == nil {
+		return
+	}
+	if reason := crawler.budget.exhausted(); reason != "" {
+		crawler.publish(ctx, c, errC, SpiderReport{
+			Output:     reason,
+			OutputType: BudgetExhausted,
+			Source:     "budget",
+		})
+	}
+}
+
+// additionalTarget returns the urls to visit besides site, found in its sitemaps, robots.txt and sources.
+// Sitemap entries are also sent to report as sitemap reports
+func (crawler *Crawler) additionalTarget(ctx context.Context, site string, report func(SpiderReport)) []string {
 	u, err := url.Parse(site)
 	res := []string{}
 	if err != nil {
 		return res
 	}
+	sitemapEntries := []SitemapEntry{}
 	if crawler.sitemap {
-		res = append(res, crawler.parseSiteMap(u)...)
+		sitemapEntries = append(sitemapEntries, crawler.parseSiteMap(u)...)
 	}
 	if crawler.robot {
 		robotsRes, err := crawler.parseRobots(u)
 		if err != nil {
 			slog.Warn("additional site from robots failed", "error", err)
 
-		} else {
-			res = append(res, robotsRes...)
+		}
+		for _, entry := range robotsRes {
+			res = append(res, entry.URL)
+			if entry.Directive == RobotsSitemap && !crawler.discoveredSitemaps.Duplicate(entry.URL) {
+				sitemapEntries = append(sitemapEntries, crawler.readSitemap(crawler.newSitemapReader(), entry.URL)...)
+			}
 		}
 	}
-	if crawler.othersources {
-		res = append(res, crawler.parseOtherSources(u)...)
+	for _, entry := range sitemapEntries {
+		if !crawler.scope.AllowsURL(entry.Loc) {
+			if crawler.scope.RecordOutOfScope {
+				report(entry.report(site).WithMetadata("out_of_scope", true))
+			}
+			continue
+		}
+		report(entry.report(site))
+		res = append(res, entry.Loc)
+	}
+	if len(crawler.sources) > 0 {
+		res = append(res, crawler.fetchSources(ctx, u.Hostname())...)
 	}
 	return res
 }
 
 func (crawler *Crawler) StreamScrawl(ctx context.Context, siteC <-chan string) (<-chan SpiderReport, <-chan error) {
 
-	return crawler.start(ctx, func(c *colly.Collector, errC chan<- error) {
+	return crawler.start(ctx, func(c *colly.Collector, report func(SpiderReport), errC chan<- error) {
+		pool := crawler.newAdditionalTargetPool(c, report)
+		defer pool.wait()
 	L:
 		for {
 			select {
@@ -291,15 +1181,13 @@ func (crawler *Crawler) StreamScrawl(ctx context.Context, siteC <-chan string) (
 				if !ok {
 					break L
 				}
-				e := c.Visit(s)
-				for _, additionalSite := range crawler.additionalTarget(s) {
-					c.Visit(additionalSite)
-				}
-				if e != nil {
-					errC <- e
-				}
+				e := crawler.visit(c, s, s)
+				pool.add(ctx, s)
+				crawler.handleError(errC, e)
 			case <-ctx.Done():
 				break L
+			case <-crawler.control.stopC:
+				break L
 			}
 		}
 
@@ -307,229 +1195,60 @@ func (crawler *Crawler) StreamScrawl(ctx context.Context, siteC <-chan string) (
 }
 
 func (crawler *Crawler) Start(site ...string) (<-chan SpiderReport, <-chan error) {
-	return crawler.start(context.Background(), func(c *colly.Collector, errC chan<- error) {
+	ctx := context.Background()
+	return crawler.start(ctx, func(c *colly.Collector, report func(SpiderReport), errC chan<- error) {
+		pool := crawler.newAdditionalTargetPool(c, report)
+		defer pool.wait()
 		for _, s := range site {
-			c.Visit(s)
-			for _, additionalSite := range crawler.additionalTarget(s) {
-				c.Visit(additionalSite)
+			if crawler.control.isStopped() {
+				break
 			}
-
+			crawler.visit(c, s, s)
+			pool.add(ctx, s)
 		}
 	})
 }
 
-func (crawler *Crawler) parseSiteMap(target *url.URL) []string {
-	sitemapUrls := []string{"/sitemap.xml", "/sitemap_news.xml", "/sitemap_index.xml", "/sitemap-index.xml", "/sitemapindex.xml",
-		"/sitemap-news.xml", "/post-sitemap.xml", "/page-sitemap.xml", "/portfolio-sitemap.xml", "/home_slider-sitemap.xml", "/category-sitemap.xml",
-		"/author-sitemap.xml"}
-
-	res := []string{}
+func (crawler *Crawler) parseSiteMap(target *url.URL) []SitemapEntry {
+	sitemapPaths := DefaultSitemapPaths
+	if len(crawler.sitemapPaths) > 0 {
+		sitemapPaths = crawler.sitemapPaths
+	}
 
-	for _, path := range sitemapUrls {
-		sitemap.ParseFromSite(target.String()+path, func(entry sitemap.Entry) error {
-			url := entry.GetLocation()
-			res = append(res, url)
-			return nil
-		})
+	res := []SitemapEntry{}
+	sr := crawler.newSitemapReader()
+	for _, path := range sitemapPaths {
+		crawler.discoveredSitemaps.Duplicate(target.String() + path)
+		res = append(res, crawler.readSitemap(sr, target.String()+path)...)
 	}
 	return res
 }
 
-func (crawler *Crawler) parseRobots(target *url.URL) ([]string, error) {
-	robotsURL := target.String() + "/robots.txt"
-	res := []string{}
-	resp, err := http.Get(robotsURL)
+// readSitemap returns the entries listed by the sitemap at sitemapURL
+func (crawler *Crawler) readSitemap(sr *sitemapReader, sitemapURL string) []SitemapEntry {
+	entries, err := sr.read(sitemapURL)
 	if err != nil {
-		return []string{}, err
-	}
-	if resp.StatusCode == 200 {
-		Logger.Infof("Found robots.txt: %s", robotsURL)
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return []string{}, err
-		}
-		lines := strings.Split(string(body), "\n")
-
-		var re = regexp.MustCompile(".*llow: ")
-		for _, line := range lines {
-			if strings.Contains(line, "llow: ") {
-				url := re.ReplaceAllString(line, "")
-				url = FixUrl(target, url)
-				if url == "" {
-					continue
-				}
-				res = append(res, url)
-			}
-		}
+		Logger.Debugf("Failed to read sitemap %s: %s", sitemapURL, err)
 	}
-	return res, nil
+	return entries
 }
 
-func (crawler *Crawler) parseOtherSources(target *url.URL) []string {
-	urls := OtherSources(target.Hostname(), true)
-	res := make([]string, 0, len(urls))
-	for _, url := range urls {
-		url = strings.TrimSpace(url)
-		if len(url) == 0 {
-			continue
-		}
-		res = append(res, url)
+// parseRobots returns the urls found in the robots.txt of target: the paths of the Allow/Disallow rules,
+// cut at their first wildcard, and the declared sitemaps
+func (crawler *Crawler) parseRobots(target *url.URL) ([]RobotsEntry, error) {
+	robotsURL := target.String() + "/robots.txt"
+	resp, err := crawler.helperClient(10 * time.Second).Get(robotsURL)
+	if err != nil {
+		return []RobotsEntry{}, err
 	}
-	return res
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return []RobotsEntry{}, nil
+	}
+	Logger.Infof("Found robots.txt: %s", robotsURL)
+	robots, err := ParseRobots(io.LimitReader(resp.Body, 500*1024))
+	if err != nil {
+		return []RobotsEntry{}, fmt.Errorf("failed to parse %s: %w", robotsURL, err)
+	}
+	return robots.Entries(target), nil
 }
-
-// Setup link finder
-// func (crawler *Crawler) setupLinkFinder() {
-// 	crawler.LinkFinderCollector.OnResponse(func(response *colly.Response) {
-// 		if response.StatusCode == 404 || response.StatusCode == 429 || response.StatusCode < 100 {
-// 			return
-// 		}
-
-// 		respStr := string(response.Body)
-
-// 		if len(crawler.filterLength_slice) == 0 || !contains(crawler.filterLength_slice, len(respStr)) {
-
-// 			// Verify which link is working
-// 			u := response.Request.URL.String()
-// 			outputFormat := fmt.Sprintf("[url] - [code-%d] - %s", response.StatusCode, u)
-
-// 			if crawler.length {
-// 				outputFormat = fmt.Sprintf("[url] - [code-%d] - [len_%d] - %s", response.StatusCode, len(respStr), u)
-// 			}
-// 			fmt.Println(outputFormat)
-
-// 			if crawler.Output != nil {
-// 				crawler.Output.Write([]byte(outputFormat))
-// 			}
-
-// 			if InScope(response.Request.URL, crawler.C.URLFilters) {
-
-// 				crawler.findSubdomains(respStr)
-// 				crawler.findAWSS3(respStr)
-
-// 				paths, err := LinkFinder(respStr)
-// 				if err != nil {
-// 					Logger.Error(err)
-// 					return
-// 				}
-
-// 				currentPathURL, err := url.Parse(u)
-// 				currentPathURLerr := false
-// 				if err != nil {
-// 					currentPathURLerr = true
-// 				}
-
-// 				for _, relPath := range paths {
-// 					var outputFormat string
-// 					// JS Regex Result
-// 					if crawler.JsonOutput {
-// 						sout := SpiderReport{
-// 							Input:      crawler.Input,
-// 							Source:     response.Request.URL.String(),
-// 							OutputType: "linkfinder",
-// 							Output:     relPath,
-// 						}
-// 						if data, err := jsoniter.MarshalToString(sout); err == nil {
-// 							outputFormat = data
-// 						}
-// 					} else if !crawler.Quiet {
-// 						outputFormat = fmt.Sprintf("[linkfinder] - [from: %s] - %s", response.Request.URL.String(), relPath)
-// 					}
-// 					fmt.Println(outputFormat)
-
-// 					if crawler.Output != nil {
-// 						crawler.Output.Write([]byte(outputFormat))
-// 					}
-// 					rebuildURL := ""
-// 					if !currentPathURLerr {
-// 						rebuildURL = FixUrl(currentPathURL, relPath)
-// 					} else {
-// 						rebuildURL = FixUrl(crawler.site, relPath)
-// 					}
-
-// 					if rebuildURL == "" {
-// 						continue
-// 					}
-
-// 					// Try to request JS path
-// 					// Try to generate URLs with main site
-// 					fileExt := GetExtType(rebuildURL)
-// 					if fileExt == ".js" || fileExt == ".xml" || fileExt == ".json" || fileExt == ".map" {
-// 						crawler.feedLinkfinder(rebuildURL, "linkfinder", "javascript")
-// 					} else if !crawler.urlSet.Duplicate(rebuildURL) {
-
-// 						if crawler.JsonOutput {
-// 							sout := SpiderReport{
-// 								Input:      crawler.Input,
-// 								Source:     response.Request.URL.String(),
-// 								OutputType: "linkfinder",
-// 								Output:     rebuildURL,
-// 							}
-// 							if data, err := jsoniter.MarshalToString(sout); err == nil {
-// 								outputFormat = data
-// 							}
-// 						} else if !crawler.Quiet {
-// 							outputFormat = fmt.Sprintf("[linkfinder] - %s", rebuildURL)
-// 						}
-
-// 						fmt.Println(outputFormat)
-
-// 						if crawler.Output != nil {
-// 							crawler.Output.Write([]byte(outputFormat))
-// 						}
-// 						_ = crawler.C.Visit(rebuildURL)
-// 					}
-
-// 					// Try to generate URLs with the site where Javascript file host in (must be in main or sub domain)
-
-// 					urlWithJSHostIn := FixUrl(crawler.site, relPath)
-// 					if urlWithJSHostIn != "" {
-// 						fileExt := GetExtType(urlWithJSHostIn)
-// 						if fileExt == ".js" || fileExt == ".xml" || fileExt == ".json" || fileExt == ".map" {
-// 							crawler.feedLinkfinder(urlWithJSHostIn, "linkfinder", "javascript")
-// 						} else {
-// 							if crawler.urlSet.Duplicate(urlWithJSHostIn) {
-// 								continue
-// 							} else {
-
-// 								if crawler.JsonOutput {
-// 									sout := SpiderReport{
-// 										Input:      crawler.Input,
-// 										Source:     response.Request.URL.String(),
-// 										OutputType: "linkfinder",
-// 										Output:     urlWithJSHostIn,
-// 									}
-// 									if data, err := jsoniter.MarshalToString(sout); err == nil {
-// 										outputFormat = data
-// 									}
-// 								} else if !crawler.Quiet {
-// 									outputFormat = fmt.Sprintf("[linkfinder] - %s", urlWithJSHostIn)
-// 								}
-// 								fmt.Println(outputFormat)
-
-// 								if crawler.Output != nil {
-// 									crawler.Output.Write([]byte(outputFormat))
-// 								}
-// 								_ = crawler.C.Visit(urlWithJSHostIn) //not print care for lost link
-// 							}
-// 						}
-
-// 					}
-
-// 				}
-
-// 				if crawler.raw {
-
-// 					outputFormat := fmt.Sprintf("[Raw] - \n%s\n", respStr) //PRINTCLEAN RAW for link visited only
-// 					if !crawler.Quiet {
-// 						fmt.Println(outputFormat)
-// 					}
-
-// 					if crawler.Output != nil {
-// 						crawler.Output.Write([]byte(outputFormat))
-// 					}
-// 				}
-// 			}
-// 		}
-// 	})
-// }
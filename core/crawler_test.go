@@ -0,0 +1,315 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestSite() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><body><a href="/a">a</a></body></html>`)
+		case "/a":
+			fmt.Fprint(w, `<html><body><a href="/b">b</a></body></html>`)
+		default:
+			fmt.Fprint(w, `<html><body>leaf</body></html>`)
+		}
+	}))
+}
+
+func collectReports(crawler *Crawler, site ...string) []SpiderReport {
+	reports := []SpiderReport{}
+	outputC, errC := crawler.Start(site...)
+	for outputC != nil || errC != nil {
+		select {
+		case r, ok := <-outputC:
+			if !ok {
+				outputC = nil
+				continue
+			}
+			reports = append(reports, r)
+		case _, ok := <-errC:
+			if !ok {
+				errC = nil
+			}
+		}
+	}
+	return reports
+}
+
+// crawlError starts crawler on site and returns the error failing the crawl, which must report nothing
+func crawlError(t *testing.T, crawler *Crawler, site ...string) error {
+	t.Helper()
+	var err error
+	outputC, errC := crawler.Start(site...)
+	for outputC != nil || errC != nil {
+		select {
+		case r, ok := <-outputC:
+			if !ok {
+				outputC = nil
+				continue
+			}
+			t.Errorf("expected no report from a failed crawl, got %+v", r)
+		case e, ok := <-errC:
+			if !ok {
+				errC = nil
+				continue
+			}
+			err = e
+		}
+	}
+	return err
+}
+
+func TestCrawlerSeed(t *testing.T) {
+	ts := newTestSite()
+	defer ts.Close()
+
+	reports := collectReports(NewCrawler(WithDefaultColly(3)), ts.URL)
+	if len(reports) == 0 {
+		t.Fatal("expected reports")
+	}
+	for _, r := range reports {
+		if r.Seed != ts.URL {
+			t.Errorf("expected seed %s on %s report %s, got %q", ts.URL, r.OutputType, r.Output, r.Seed)
+		}
+	}
+}
+
+func TestCrawlerJobMetadata(t *testing.T) {
+	ts := newTestSite()
+	defer ts.Close()
+
+	crawler := NewCrawler(WithDefaultColly(3), WithJobMetadata(map[string]string{"job": "42"}))
+	for _, r := range collectReports(crawler, ts.URL) {
+		if r.Job["job"] != "42" {
+			t.Errorf("expected job metadata on %s report %s", r.OutputType, r.Output)
+		}
+	}
+	err := &JobError{Err: fmt.Errorf("boom"), Metadata: map[string]string{"job": "42", "customer": "acme"}}
+	if err.Error() != "[customer=acme job=42] boom" {
+		t.Errorf("unexpected job error message %s", err)
+	}
+}
+
+type memorySink struct {
+	lock    sync.Mutex
+	reports []SpiderReport
+	closed  bool
+}
+
+func (ms *memorySink) Send(ctx context.Context, report SpiderReport) error {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	ms.reports = append(ms.reports, report)
+	return nil
+}
+
+func (ms *memorySink) Close() error {
+	ms.closed = true
+	return nil
+}
+
+func TestCrawlerSink(t *testing.T) {
+	ts := newTestSite()
+	defer ts.Close()
+
+	sink := &memorySink{}
+	reports := collectReports(NewCrawler(WithDefaultColly(3), WithSink(sink)), ts.URL)
+	if !sink.closed {
+		t.Error("expected sink to be closed at the end of the crawl")
+	}
+	if len(sink.reports) != len(reports) {
+		t.Errorf("expected the sink to receive the %d reports, got %d", len(reports), len(sink.reports))
+	}
+}
+
+func TestCrawlerReportTypes(t *testing.T) {
+	var visited sync.Map
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		visited.Store(r.URL.Path, true)
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><body><a href="/a">a</a><form action="/search"><input name="q"></form></body></html>`)
+		case "/a":
+			fmt.Fprint(w, `<html><body><a href="/b">b</a></body></html>`)
+		}
+	}))
+	defer ts.Close()
+
+	sink := &memorySink{}
+	reports := collectReports(NewCrawler(WithDefaultColly(3), WithReportTypes(Url, Form), WithSink(sink)), ts.URL)
+	if len(reports) == 0 || len(sink.reports) != len(reports) {
+		t.Fatalf("expected the sink to receive the %d reports, got %d", len(reports), len(sink.reports))
+	}
+	for _, r := range reports {
+		if r.OutputType != Url && r.OutputType != Form {
+			t.Errorf("expected no %s report, got %s", r.OutputType, r.Output)
+		}
+	}
+	if _, ok := visited.Load("/b"); !ok {
+		t.Error("expected the filtered out refs to be crawled")
+	}
+}
+
+func TestCrawlerAdditionalTargetTimeout(t *testing.T) {
+	ts := newTestSite()
+	defer ts.Close()
+
+	slow := SourceFunc(func(ctx context.Context, domain string) ([]string, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	fast := SourceFunc(func(ctx context.Context, domain string) ([]string, error) {
+		return []string{ts.URL + "/from-source"}, nil
+	})
+	crawler := NewCrawler(WithDefaultColly(1), WithSource(slow, fast), WithAdditionalTargetWorkers(1, 100*time.Millisecond))
+	start := time.Now()
+	reports := collectReports(crawler, ts.URL)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected the slow source to time out, the crawl took %s", elapsed)
+	}
+	found := false
+	for _, r := range reports {
+		found = found || r.Output == ts.URL+"/from-source"
+	}
+	if !found {
+		t.Error("expected the url of the fast source to be crawled")
+	}
+}
+
+func TestCrawlerMetrics(t *testing.T) {
+	ts := newTestSite()
+	defer ts.Close()
+
+	crawler := NewCrawler(WithDefaultColly(3))
+	collectReports(crawler, ts.URL)
+	families, err := crawler.Metrics().Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]float64{}
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			if m.GetCounter() != nil {
+				values[f.GetName()] += m.GetCounter().GetValue()
+			}
+			if m.GetGauge() != nil {
+				values[f.GetName()] += m.GetGauge().GetValue()
+			}
+		}
+	}
+	if values["gospider_requests_total"] != 3 {
+		t.Errorf("expected 3 requests, got %v", values["gospider_requests_total"])
+	}
+	if values["gospider_queue_depth"] != 0 {
+		t.Errorf("expected empty queue at the end of the crawl, got %v", values["gospider_queue_depth"])
+	}
+	if values["gospider_reports_total"] == 0 {
+		t.Error("expected reports to be counted")
+	}
+}
+
+func TestCrawlerMetricsListener(t *testing.T) {
+	ts := newTestSite()
+	defer ts.Close()
+
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputC, errC := NewCrawler(WithDefaultColly(1), WithMetricsListener(busy.Addr().String())).Start(ts.URL)
+	failed := false
+	for outputC != nil || errC != nil {
+		select {
+		case _, ok := <-outputC:
+			if !ok {
+				outputC = nil
+			}
+		case err, ok := <-errC:
+			if !ok {
+				errC = nil
+				continue
+			}
+			failed = failed || err != nil
+		}
+	}
+	if !failed {
+		t.Error("expected an error when the metrics address is in use")
+	}
+	addr := busy.Addr().String()
+	busy.Close()
+
+	crawler := NewCrawler(WithDefaultColly(1), WithMetricsListener(addr))
+	outputC, errC = crawler.Start(ts.URL)
+	scraped := false
+	for outputC != nil || errC != nil {
+		select {
+		case _, ok := <-outputC:
+			if !ok {
+				outputC = nil
+				continue
+			}
+			if !scraped {
+				scraped = true
+				resp, err := http.Get("http://" + addr + "/metrics")
+				if err != nil {
+					t.Fatalf("expected the metrics to be served during the crawl: %s", err)
+				}
+				resp.Body.Close()
+			}
+		case _, ok := <-errC:
+			if !ok {
+				errC = nil
+			}
+		}
+	}
+	if _, err := http.Get("http://" + addr + "/metrics"); err == nil {
+		t.Error("expected the metrics listener to be shut down at the end of the crawl")
+	}
+}
+
+func TestCrawlerTracing(t *testing.T) {
+	ts := newTestSite()
+	defer ts.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	collectReports(NewCrawler(WithDefaultColly(3), WithSink(&memorySink{}), WithTracerProvider(provider)), ts.URL)
+
+	counts := map[string]int{}
+	requests := map[trace.SpanID]bool{}
+	for _, span := range recorder.Ended() {
+		counts[span.Name()]++
+		if span.Name() == "crawl.request" {
+			requests[span.SpanContext().SpanID()] = true
+		}
+	}
+	if counts["crawl.request"] != 3 {
+		t.Errorf("expected 3 request spans, got %d", counts["crawl.request"])
+	}
+	if counts["crawl.extract [href]"] == 0 {
+		t.Error("expected extraction handler spans")
+	}
+	if counts["crawl.sink.send"] == 0 {
+		t.Error("expected sink spans")
+	}
+	for _, span := range recorder.Ended() {
+		if span.Name() == "crawl.extract [href]" && !requests[span.Parent().SpanID()] {
+			t.Errorf("expected extraction span to be a child of a request span")
+		}
+	}
+}
@@ -1,12 +1,10 @@
 package core
 
 import (
-	"bufio"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"os"
 	"regexp"
 	"strconv"
 	"strings"
@@ -18,7 +16,7 @@ import (
 
 type CrawlerOption func(crawler *Crawler)
 type CollyConfigurator func(c *colly.Collector) error
-type HTTPClientConfigurator func(client *http.Client)
+type HTTPClientConfigurator func(client *http.Client) error
 
 func WithCollyConfig(opt ...CollyConfigurator) CrawlerOption {
 	return func(crawler *Crawler) {
@@ -37,6 +35,33 @@ func WithOutput(writer ...io.Writer) CrawlerOption {
 	}
 }
 
+// WithOutputFormat sets the Formatter used to write reports to Output. Defaults to TextFormatter
+func WithOutputFormat(formatter Formatter) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.formatter = formatter
+	}
+}
+
+// WithSink forwards every emitted report to sinks. The sinks are closed at the end of the crawl
+func WithSink(sinks ...Sink) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.sinks = append(crawler.sinks, sinks...)
+	}
+}
+
+// WithReportTypes publishes the reports of these types only, to the Output, the sinks and the report channel.
+// The reports of the other types are still crawled and mined, they just aren't sent
+func WithReportTypes(types ...OutputType) CrawlerOption {
+	return func(crawler *Crawler) {
+		if crawler.reportTypes == nil {
+			crawler.reportTypes = make(map[OutputType]bool, len(types))
+		}
+		for _, t := range types {
+			crawler.reportTypes[t] = true
+		}
+	}
+}
+
 func WithFilterLength(filterLength string) CrawlerOption {
 	return func(crawler *Crawler) {
 		lengthArgs := strings.Split(filterLength, ",")
@@ -67,9 +92,139 @@ func WithRobot() CrawlerOption {
 	}
 }
 
+// WithOtherSources crawls the urls the DefaultSources know for the sites domains
 func WithOtherSources() CrawlerOption {
+	return WithSource(DefaultSources(true)...)
+}
+
+// WithSource crawls the urls the sources know for the sites domains
+func WithSource(sources ...Source) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.sources = append(crawler.sources, sources...)
+	}
+}
+
+// WithLanguageDetection fills the Language field of Url reports (see DetectLanguage)
+func WithLanguageDetection() CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.language = true
+	}
+}
+
+// WithTitleExtraction fills the Title field of Url reports (see ExtractTitle)
+func WithTitleExtraction() CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.title = true
+	}
+}
+
+// WithLinkFinder scans javascript, json and source map responses for endpoints,
+// emits them as `linkfinder` reports and crawls them
+func WithLinkFinder() CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.linkfinder = true
+	}
+}
+
+// WithPWADiscovery fetches registered service workers and web app manifests and crawls the routes they declare
+func WithPWADiscovery() CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.pwa = true
+	}
+}
+
+// WithBodyMatcher emits a `match` report, tagged with name, for every value matching pattern in a response body.
+// An invalid pattern fails Start
+func WithBodyMatcher(name string, pattern string) CrawlerOption {
+	return func(crawler *Crawler) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			crawler.optionErrors = append(crawler.optionErrors, fmt.Errorf("invalid body matcher %s: %w", name, err))
+			return
+		}
+		crawler.bodyMatchers = append(crawler.bodyMatchers, bodyMatcher{name: name, re: re})
+	}
+}
+
+// WithMatchContext attaches the byte offset and size bytes of surrounding context to match reports
+func WithMatchContext(size int) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.matchContext = size
+	}
+}
+
+// WithBodyStore stores response bodies in store instead of carrying them in the reports.
+// Reports then hold a BodyRef and the body is accessible through SpiderReport.LoadBody.
+// A store implementing io.Closer is closed at the end of the crawl
+func WithBodyStore(store BodyStore) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.bodyStore = store
+	}
+}
+
+// WithDiskBodies stores response bodies in dir, see WithBodyStore. When dir is empty the bodies are stored in a
+// temporary directory, removed at the end of the crawl. The crawl fails when dir can't be created
+func WithDiskBodies(dir string) CrawlerOption {
+	return func(crawler *Crawler) {
+		store, err := NewDiskBodyStore(dir)
+		if err != nil {
+			crawler.optionErrors = append(crawler.optionErrors, err)
+			return
+		}
+		crawler.bodyStore = store
+	}
+}
+
+// WithJobMetadata attaches metadata (job id, customer, engagement...) to every emitted report and error (see JobError)
+func WithJobMetadata(metadata map[string]string) CrawlerOption {
+	return func(crawler *Crawler) {
+		if crawler.jobMetadata == nil {
+			crawler.jobMetadata = make(map[string]string, len(metadata))
+		}
+		for k, v := range metadata {
+			crawler.jobMetadata[k] = v
+		}
+	}
+}
+
+// WithPersistentRetryQueue persists urls failing with retryable errors (network errors, 429, 5xx) in path
+// and visits them again on the next crawl. After maxAttempts failures an url is reported as a dead-letter.
+// The crawl fails when path can't be read or parsed, rather than overwriting the queue it holds
+func WithPersistentRetryQueue(path string, maxAttempts int) CrawlerOption {
+	return func(crawler *Crawler) {
+		q, err := LoadRetryQueue(path, maxAttempts)
+		if err != nil {
+			crawler.optionErrors = append(crawler.optionErrors, err)
+			return
+		}
+		crawler.retryQueue = q
+	}
+}
+
+// WithExtractionRules registers custom CSS/XPath extraction rules, reporting the values of each page.
+// An invalid rule fails the crawl
+func WithExtractionRules(rules ...ExtractionRule) CrawlerOption {
+	return func(crawler *Crawler) {
+		for _, rule := range rules {
+			if err := rule.Validate(); err != nil {
+				crawler.optionErrors = append(crawler.optionErrors, err)
+				continue
+			}
+			crawler.extractionRules = append(crawler.extractionRules, rule)
+		}
+	}
+}
+
+// WithRecordTemplates registers templates assembling multiple extracted fields into `record` reports. An invalid template fails the crawl
+func WithRecordTemplates(templates ...RecordTemplate) CrawlerOption {
 	return func(crawler *Crawler) {
-		crawler.othersources = true
+		for _, tpl := range templates {
+			if err := tpl.Validate(); err != nil {
+				crawler.optionErrors = append(crawler.optionErrors, err)
+				continue
+			}
+			crawler.recordTemplates = append(crawler.recordTemplates, tpl)
+		}
 	}
 }
 
@@ -81,6 +236,8 @@ func WithDefaultColly(maxDepth int) CrawlerOption {
 	)
 }
 
+// WithScope restricts the visits of the collector to the urls matching the scope regex. See Scope and WithCrawlScope
+// for hosts, wildcards, CIDR ranges and path prefixes, also applied to the reports, the sitemaps and the sources
 func WithScope(scope string) CollyConfigurator {
 	return WithRegexpFilter(scope)
 }
@@ -132,50 +289,29 @@ func WithLimit(concurrent int, delay int, randomDelay int) CollyConfigurator {
 	}
 }
 
-func WithHTTPClient(client *http.Client) CollyConfigurator {
-	return func(c *colly.Collector) error {
-		c.SetClient(client)
-		return nil
+// WithHTTPClient sets the client of the collector, also used by the crawler to fetch robots.txt and sitemaps
+func WithHTTPClient(client *http.Client) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.httpClient = client
+		crawler.collyConfigrationOpt = append(crawler.collyConfigrationOpt, func(c *colly.Collector) error {
+			c.SetClient(client)
+			return nil
+		})
 	}
 }
 
-func WithHTTPClientOpt(opt ...HTTPClientConfigurator) CollyConfigurator {
-	return func(c *colly.Collector) error {
+// WithHTTPClientOpt sets a new client on the crawler. Its transport is a clone of DefaultHTTPTransport,
+// so the configurators of a crawler never alter the transport of another. The errors of the configurators fail the crawl
+func WithHTTPClientOpt(opt ...HTTPClientConfigurator) CrawlerOption {
+	return func(crawler *Crawler) {
 		client := &http.Client{}
-		client.Transport = DefaultHTTPTransport
+		client.Transport = DefaultHTTPTransport.Clone()
 		for _, o := range opt {
-			o(client)
-		}
-		return WithHTTPClient(client)(c)
-	}
-}
-
-func WithBurpFile(burpFile string) CollyConfigurator {
-	return func(c *colly.Collector) error {
-		bF, err := os.Open(burpFile)
-		if err != nil {
-			return fmt.Errorf("Failed to open Burp File: %w", err)
-		} else {
-			rd := bufio.NewReader(bF)
-			req, err := http.ReadRequest(rd)
-			if err != nil {
-				return fmt.Errorf("failed to Parse Raw Request in %s: %w", burpFile, err)
-			} else {
-				// Set cookie
-				c.OnRequest(func(r *colly.Request) {
-					r.Headers.Add("Cookie", GetRawCookie(req.Cookies()))
-				})
-
-				// Set headers
-				c.OnRequest(func(r *colly.Request) {
-					for k, v := range req.Header {
-						r.Headers.Set(strings.TrimSpace(k), strings.TrimSpace(v[0]))
-					}
-				})
-
+			if err := o(client); err != nil {
+				crawler.optionErrors = append(crawler.optionErrors, err)
 			}
 		}
-		return nil
+		WithHTTPClient(client)(crawler)
 	}
 }
 
@@ -216,34 +352,119 @@ func WithUserAgent(randomUA string) CollyConfigurator {
 	}
 }
 
+// transportWrapper is a transport wrapping another one, e.g to authenticate the requests. The configurators of the
+// transport sending the requests reach it through the wrappers, so that they apply whatever their order
+type transportWrapper interface {
+	// replaceWrapped replaces the transport sending the requests under the wrapper by replace(transport)
+	replaceWrapped(replace transportReplacer) error
+}
+
+type transportReplacer func(transport http.RoundTripper) (http.RoundTripper, error)
+
+// transportUnwrapper is a transportWrapper sending the requests through a single transport
+type transportUnwrapper interface {
+	unwrap() http.RoundTripper
+}
+
+// clientTransports returns the transports of client, from the outermost wrapper to the transport sending the requests
+func clientTransports(client *http.Client) []http.RoundTripper {
+	res := []http.RoundTripper{}
+	for rt := client.Transport; rt != nil; {
+		res = append(res, rt)
+		unwrapper, ok := rt.(transportUnwrapper)
+		if !ok {
+			break
+		}
+		rt = unwrapper.unwrap()
+	}
+	return res
+}
+
+// replaceTransport replaces rt, or the transport sending the requests under it when it is a transportWrapper,
+// by replace(transport)
+func replaceTransport(rt http.RoundTripper, replace transportReplacer) (http.RoundTripper, error) {
+	if wrapper, ok := rt.(transportWrapper); ok {
+		return rt, wrapper.replaceWrapped(replace)
+	}
+	return replace(rt)
+}
+
+// replaceNext replaces the transport sending the requests under *next, the transport wrapped by a transportWrapper
+func replaceNext(next *http.RoundTripper, replace transportReplacer) error {
+	rt, err := replaceTransport(*next, replace)
+	if err != nil {
+		return err
+	}
+	*next = rt
+	return nil
+}
+
+// configureTransport applies configure to a copy of the *http.Transport sending the requests of client, under the
+// transports wrapping it. It fails when the requests are sent by another kind of transport
+func configureTransport(client *http.Client, configure func(transport *http.Transport) error) error {
+	return replaceNext(&client.Transport, func(rt http.RoundTripper) (http.RoundTripper, error) {
+		var transport *http.Transport
+		switch t := rt.(type) {
+		case nil:
+			transport = DefaultHTTPTransport.Clone()
+		case *http.Transport:
+			transport = t.Clone()
+		case *replayTransport:
+			// the replayed responses don't go through the network
+			return rt, nil
+		default:
+			return nil, fmt.Errorf("can't configure the %T transport of the client", rt)
+		}
+		if err := configure(transport); err != nil {
+			return nil, err
+		}
+		return transport, nil
+	})
+}
+
+// WithHTTPProxy routes the requests through an HTTP(S) proxy, or a SOCKS5 one with the socks5 and socks5h schemes
 func WithHTTPProxy(proxy string) HTTPClientConfigurator {
-	return func(client *http.Client) {
-		if proxy != "" {
-			Logger.Infof("Proxy: %s", proxy)
-			pU, err := url.Parse(proxy)
+	return func(client *http.Client) error {
+		if proxy == "" {
+			return nil
+		}
+		Logger.Infof("Proxy: %s", proxy)
+		pU, err := url.Parse(proxy)
+		if err != nil {
+			Logger.Error("Failed to set proxy")
+			return nil
+		}
+		return configureTransport(client, func(transport *http.Transport) error {
+			if !isSOCKSProxy(pU) {
+				transport.Proxy = http.ProxyURL(pU)
+				return nil
+			}
+			dial, err := socksDialContext(pU)
 			if err != nil {
-				Logger.Error("Failed to set proxy")
-			} else {
-				DefaultHTTPTransport.Proxy = http.ProxyURL(pU)
-				client.Transport = DefaultHTTPTransport
+				Logger.Errorf("Failed to set proxy: %s", err)
+				return nil
 			}
-		}
+			transport.Proxy = nil
+			transport.DialContext = dial
+			return nil
+		})
 	}
 }
 
 func WithHTTPTimeout(timeout int) HTTPClientConfigurator {
-	return func(client *http.Client) {
+	return func(client *http.Client) error {
 		if timeout == 0 {
 			Logger.Info("Your input timeout is 0. Gospider will set it to 10 seconds")
 			client.Timeout = 10 * time.Second
 		} else {
 			client.Timeout = time.Duration(timeout) * time.Second
 		}
+		return nil
 	}
 }
 
 func WithHTTPNoRedirect() HTTPClientConfigurator {
-	return func(client *http.Client) {
+	return func(client *http.Client) error {
 		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
 			nextLocation := req.Response.Header.Get("Location")
 			Logger.Debugf("Found Redirect: %s", nextLocation)
@@ -257,5 +478,6 @@ func WithHTTPNoRedirect() HTTPClientConfigurator {
 			}
 			return http.ErrUseLastResponse
 		}
+		return nil
 	}
 }
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+var cspHostRE = regexp.MustCompile(`^[a-z0-9-]+(?:\.[a-z0-9-]+)+$`)
+
+// cspHostDirectives are the directives, besides the fetch directives (*-src), whose sources are origins
+var cspHostDirectives = map[string]bool{
+	"frame-ancestors": true,
+	"form-action":     true,
+	"base-uri":        true,
+	"navigate-to":     true,
+	"report-uri":      true,
+}
+
+// cspSourceHost returns the host of a CSP source expression, without its wildcard label.
+// It is empty for the keywords, schemes, nonces and hashes
+func cspSourceHost(source string) string {
+	source = strings.ToLower(source)
+	if strings.HasPrefix(source, "'") || !strings.Contains(source, ".") {
+		return ""
+	}
+	if _, after, ok := strings.Cut(source, "://"); ok {
+		source = after
+	}
+	if i := strings.IndexAny(source, "/?#"); i >= 0 {
+		source = source[:i]
+	}
+	if i := strings.LastIndex(source, ":"); i >= 0 {
+		source = source[:i]
+	}
+	source = strings.TrimPrefix(source, "*.")
+	if !cspHostRE.MatchString(source) {
+		return ""
+	}
+	return source
+}
+
+// CSPHosts returns the sorted hosts referenced by a Content-Security-Policy, the wildcard sources (*.example.com) giving
+// their parent domain
+func CSPHosts(policy string) []string {
+	hosts := []string{}
+	for name, sources := range parseCSP(policy) {
+		if !strings.HasSuffix(name, "-src") && !cspHostDirectives[name] {
+			continue
+		}
+		for _, source := range sources {
+			if host := cspSourceHost(source); host != "" {
+				hosts = append(hosts, host)
+			}
+		}
+	}
+	hosts = Unique(hosts)
+	sort.Strings(hosts)
+	return hosts
+}
+
+// responsePolicies returns the Content-Security-Policy of a response, from its headers (enforced and report-only)
+// and the meta tags of its html body
+func responsePolicies(headers http.Header, body string) []string {
+	policies := append(headers.Values("Content-Security-Policy"), headers.Values("Content-Security-Policy-Report-Only")...)
+	for _, tag := range metaTagRE.FindAllString(body, -1) {
+		attrs := tagAttributes(tag)
+		if strings.EqualFold(attrs["http-equiv"], "Content-Security-Policy") {
+			policies = append(policies, attrs["content"])
+		}
+	}
+	return policies
+}
+
+// discoverCSPDomains emits the hosts of the Content-Security-Policy of response as domain reports.
+// When crawlCSPDomains is set, the hosts sharing the domain of the response are visited
+func (crawler *Crawler) discoverCSPDomains(c *colly.Collector, emit func(SpiderReport), response *colly.Response, body string) {
+	var headers http.Header
+	if response.Headers != nil {
+		headers = *response.Headers
+	}
+	domain := GetDomain(response.Request.URL)
+	for _, policy := range responsePolicies(headers, body) {
+		for _, host := range CSPHosts(policy) {
+			emit(SpiderReport{
+				Output:     host,
+				OutputType: Domain,
+				Source:     "csp",
+				StatusCode: response.StatusCode,
+				Input:      response.Request.URL,
+				Seed:       requestSeed(response.Request),
+			})
+			if !crawler.crawlCSPDomains || domain == "" || GetDomain(&url.URL{Host: host}) != domain || crawler.visitedCSPHosts.Duplicate(host) {
+				continue
+			}
+			if err := crawler.visit(c, response.Request.URL.Scheme+"://"+host+"/", requestSeed(response.Request)); err != nil {
+				Logger.Debugf("Failed to visit %s found in the CSP of %s: %s", host, response.Request.URL, err)
+			}
+		}
+	}
+}
+
+// WithCSPDomains emits a domain report for every host referenced by the Content-Security-Policy headers and meta tags,
+// which routinely reveal internal and staging hosts. When crawl is set, the hosts of the domain of the page
+// referencing them are crawled too, within the scope of the collector
+func WithCSPDomains(crawl bool) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.cspDomains = true
+		crawler.crawlCSPDomains = crawl
+	}
+}
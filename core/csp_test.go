@@ -0,0 +1,64 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestCSPHosts(t *testing.T) {
+	policy := "default-src 'self' https://*.example.com:443; script-src 'nonce-abc' cdn.example.net https: 'unsafe-inline'; " +
+		"img-src data: staging.internal.example.org/img/; report-uri https://csp.example.com/report; plugin-types application/pdf; report-to csp-endpoint"
+	expected := []string{"cdn.example.net", "csp.example.com", "example.com", "staging.internal.example.org"}
+	if hosts := CSPHosts(policy); !reflect.DeepEqual(hosts, expected) {
+		t.Errorf("expected %v, got %v", expected, hosts)
+	}
+}
+
+func TestCSPDomains(t *testing.T) {
+	var lock sync.Mutex
+	hosts := map[string]bool{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		hosts[r.Host] = true
+		lock.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		if r.Host == "www.crawl.test" {
+			w.Header().Set("Content-Security-Policy", "default-src 'self' api.crawl.test cdn.other.test")
+			w.Write([]byte(`<html><head><meta http-equiv="Content-Security-Policy" content="img-src *.img.crawl.test"></head></html>`))
+		}
+	}))
+	defer ts.Close()
+	tsURL, _ := url.Parse(ts.URL)
+	mapping := map[string]string{"www.crawl.test": tsURL.Host, "api.crawl.test": tsURL.Host, "img.crawl.test": tsURL.Host, "cdn.other.test": tsURL.Host}
+
+	for _, crawl := range []bool{false, true} {
+		lock.Lock()
+		hosts = map[string]bool{}
+		lock.Unlock()
+		crawler := NewCrawler(WithDefaultColly(2), WithHTTPClientOpt(WithHostMapping(mapping)), WithCSPDomains(crawl))
+		domains := []string{}
+		for _, r := range collectReports(crawler, "http://www.crawl.test/") {
+			if r.OutputType == Domain && r.Source == "csp" {
+				domains = append(domains, r.Output)
+			}
+		}
+		sort.Strings(domains)
+		if expected := []string{"api.crawl.test", "cdn.other.test", "img.crawl.test"}; !reflect.DeepEqual(domains, expected) {
+			t.Errorf("expected the domains %v, got %v", expected, domains)
+		}
+		expected := map[string]bool{"www.crawl.test": true}
+		if crawl {
+			expected["api.crawl.test"], expected["img.crawl.test"] = true, true
+		}
+		lock.Lock()
+		if !reflect.DeepEqual(hosts, expected) {
+			t.Errorf("expected the crawled hosts %v when crawl is %t, got %v", expected, crawl, hosts)
+		}
+		lock.Unlock()
+	}
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+var (
+	cssURLRE    = regexp.MustCompile(`(?i)url\(\s*(?:"([^"]*)"|'([^']*)'|([^)"'\s]*))\s*\)`)
+	cssImportRE = regexp.MustCompile(`(?i)@import\s+(?:"([^"]*)"|'([^']*)')`)
+)
+
+// CSSURLs returns the urls referenced by a stylesheet through url(...) and @import, the data and fragment urls excluded
+func CSSURLs(css string) []string {
+	res := []string{}
+	for _, re := range []*regexp.Regexp{cssImportRE, cssURLRE} {
+		for _, m := range re.FindAllStringSubmatch(css, -1) {
+			u := strings.TrimSpace(strings.Join(m[1:], ""))
+			lower := strings.ToLower(u)
+			if u == "" || strings.HasPrefix(u, "#") || strings.HasPrefix(lower, "data:") || strings.HasPrefix(lower, "javascript:") || strings.HasPrefix(lower, "about:") {
+				continue
+			}
+			res = append(res, u)
+		}
+	}
+	return Unique(res)
+}
+
+// discoverCSSURLs emits the urls of a stylesheet as refs, resolved against request
+func (crawler *Crawler) discoverCSSURLs(emit func(SpiderReport), request *colly.Request, css string) {
+	for _, u := range CSSURLs(css) {
+		emit(SpiderReport{
+			Output:     request.AbsoluteURL(u),
+			OutputType: Ref,
+			Source:     "css",
+			Input:      request.URL,
+			Seed:       requestSeed(request),
+		})
+	}
+}
+
+// WithCSSLinks crawls the urls referenced by the stylesheets, the <style> blocks and the style attributes through
+// url(...) and @import, as background images and fonts often reveal extra directories
+func WithCSSLinks() CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.cssLinks = true
+	}
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestCSSURLs(t *testing.T) {
+	css := `@import "theme.css"; @import url('print.css') print;
+.hero { background: url( "../img/hero.jpg" ) no-repeat, url(data:image/png;base64,AAAA); }
+@font-face { src: url(/fonts/inter.woff2) format("woff2"), url(#icons); }`
+	expected := []string{"theme.css", "print.css", "../img/hero.jpg", "/fonts/inter.woff2"}
+	if urls := CSSURLs(css); !reflect.DeepEqual(urls, expected) {
+		t.Errorf("expected %v, got %v", expected, urls)
+	}
+}
+
+func TestCSSLinks(t *testing.T) {
+	var lock sync.Mutex
+	visited := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		visited = append(visited, r.URL.Path)
+		lock.Unlock()
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><head><link rel="stylesheet" href="/static/css/site.css"><style>body { background: url(/img/bg.png) }</style></head>
+<body><div style="background-image: url('/uploads/banner.jpg')"></div></body></html>`)
+		case "/static/css/site.css":
+			w.Header().Set("Content-Type", "text/css")
+			fmt.Fprint(w, `@font-face { src: url(../fonts/a.woff2) }`)
+		}
+	}))
+	defer ts.Close()
+
+	collectReports(NewCrawler(WithDefaultColly(3), WithCSSLinks()), ts.URL)
+	lock.Lock()
+	defer lock.Unlock()
+	sort.Strings(visited)
+	expected := []string{"/", "/img/bg.png", "/static/css/site.css", "/static/fonts/a.woff2", "/uploads/banner.jpg"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("expected the visits %v, got %v", expected, visited)
+	}
+}
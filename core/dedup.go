@@ -0,0 +1,92 @@
+package core
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/gocolly/colly/v2/storage"
+)
+
+// DedupStore records the outputs already reported by a crawl. Duplicate reports whether output
+// was seen before and records it otherwise. The default store is an in-memory stringset.StringFilter
+type DedupStore interface {
+	Duplicate(output string) bool
+}
+
+// FallibleDedupStore is a DedupStore which can fail to record an output, e.g a database. Duplicate then reports the
+// output as new so that it isn't lost, and Err returns the failures since its previous call, or nil
+type FallibleDedupStore interface {
+	DedupStore
+	Err() error
+}
+
+// WithDedupStore replaces the in-memory output dedup by store, e.g a persistent store from the store package.
+// When store is also a colly storage.Storage, as the ones of the store package, the visited urls and the cookies are
+// kept in it too, so a restarted crawl doesn't request the urls again. The urls in flight are only requested again
+// when the crawl is resumed with WithCheckpoint or WithPersistentRetryQueue.
+// The failures of a FallibleDedupStore are sent on the error channel. The caller owns store and closes it after the crawl
+func WithDedupStore(store DedupStore) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.set = store
+	}
+}
+
+// revisitStorage is the colly storage of a persistent DedupStore. The urls in flight when a crawl stopped were recorded
+// as visited, so the ones resumed from a checkpoint or a retry queue are let through once
+type revisitStorage struct {
+	storage.Storage
+
+	lock     sync.Mutex
+	revisits map[uint64]bool
+}
+
+func newRevisitStorage(s storage.Storage) *revisitStorage {
+	return &revisitStorage{Storage: s, revisits: make(map[uint64]bool)}
+}
+
+// revisit lets the next GET request of u through, hashed like colly does
+func (rs *revisitStorage) revisit(u string) {
+	h := fnv.New64a()
+	h.Write([]byte(u))
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	rs.revisits[h.Sum64()] = true
+}
+
+func (rs *revisitStorage) IsVisited(requestID uint64) (bool, error) {
+	rs.lock.Lock()
+	revisit := rs.revisits[requestID]
+	delete(rs.revisits, requestID)
+	rs.lock.Unlock()
+	if revisit {
+		return false, nil
+	}
+	return rs.Storage.IsVisited(requestID)
+}
+
+// setStorage makes the DedupStore of the crawl the storage of c when it is a colly storage, to persist the visited urls
+// and the cookies too. The jar of the http client or of the cookie jar file is kept over the stored cookies
+func (crawler *Crawler) setStorage(c *colly.Collector) error {
+	s, ok := crawler.set.(storage.Storage)
+	if !ok {
+		return nil
+	}
+	jar := crawler.httpClient.Jar
+	crawler.visited = newRevisitStorage(s)
+	if err := c.SetStorage(crawler.visited); err != nil {
+		return fmt.Errorf("failed to init the storage: %w", err)
+	}
+	if jar != nil || crawler.cookieJarFile != "" {
+		c.SetCookieJar(jar)
+	}
+	return nil
+}
+
+// revisit lets the next request of u through the persistent storage, see revisitStorage
+func (crawler *Crawler) revisit(u string) {
+	if crawler.visited != nil {
+		crawler.visited.revisit(crawler.canonicalizer.Canonicalize(u))
+	}
+}
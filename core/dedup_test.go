@@ -0,0 +1,70 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/benji-bou/gospider/stringset"
+	"github.com/gocolly/colly/v2/storage"
+)
+
+// storageDedup is a DedupStore which is also a colly storage, like the persistent ones of the store package
+type storageDedup struct {
+	*stringset.StringFilter
+	*storage.InMemoryStorage
+}
+
+func newStorageDedup() storageDedup {
+	return storageDedup{stringset.NewStringFilter(), &storage.InMemoryStorage{}}
+}
+
+// brokenStore is a FallibleDedupStore failing to record every output
+type brokenStore struct {
+	lock   sync.Mutex
+	failed bool
+}
+
+func (bs *brokenStore) Duplicate(output string) bool {
+	bs.lock.Lock()
+	defer bs.lock.Unlock()
+	bs.failed = true
+	return false
+}
+
+func (bs *brokenStore) Err() error {
+	bs.lock.Lock()
+	defer bs.lock.Unlock()
+	if !bs.failed {
+		return nil
+	}
+	bs.failed = false
+	return errors.New("store unavailable")
+}
+
+func TestFallibleDedupStore(t *testing.T) {
+	ts := newTestSite()
+	defer ts.Close()
+
+	outputC, errC := NewCrawler(WithDefaultColly(3), WithDedupStore(&brokenStore{})).Start(ts.URL)
+	reports, failures := 0, 0
+	for outputC != nil || errC != nil {
+		select {
+		case _, ok := <-outputC:
+			if !ok {
+				outputC = nil
+				continue
+			}
+			reports++
+		case _, ok := <-errC:
+			if !ok {
+				errC = nil
+				continue
+			}
+			failures++
+		}
+	}
+	if reports == 0 || failures == 0 {
+		t.Errorf("expected the reports to be kept and the store failures to be reported, got %d reports and %d failures", reports, failures)
+	}
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/gocolly/colly/v2"
+)
+
+const depthContextKey = "gospider.depth"
+
+// requestDepth is the crawl depth of r, 1 for the seeds and the requests which weren't scheduled by the crawler
+func requestDepth(r *colly.Request) int {
+	if r == nil || r.Ctx == nil {
+		return 1
+	}
+	if depth, err := strconv.Atoi(r.Ctx.Get(depthContextKey)); err == nil && depth > 0 {
+		return depth
+	}
+	return 1
+}
+
+// depthPolicy limits the depth of the followed urls per OutputType. colly requests every url scheduled by the crawler
+// at depth 1, so the depth of a page is tracked from its response until it is scraped and its reports are followed
+// one level deeper
+type depthPolicy struct {
+	limits map[OutputType]int
+	// pages holds the depth of the pages being scraped, by url
+	pages sync.Map
+}
+
+func (p *depthPolicy) instrument(c *colly.Collector) {
+	c.OnResponse(func(r *colly.Response) {
+		p.pages.Store(r.Request.URL.String(), requestDepth(r.Request))
+	})
+	c.OnScraped(func(r *colly.Response) {
+		p.pages.Delete(r.Request.URL.String())
+	})
+}
+
+// depthOf is the depth of the page served for input, 1 when it isn't being scraped
+func (p *depthPolicy) depthOf(input *url.URL) int {
+	if input != nil {
+		if depth, ok := p.pages.Load(input.String()); ok {
+			return depth.(int)
+		}
+	}
+	return 1
+}
+
+// allows reports whether an url of type ot can be followed at depth, up to the limit of ot or to maxDepth when ot
+// has none. A limit of 0 is unlimited
+func (p *depthPolicy) allows(ot OutputType, depth int, maxDepth int) bool {
+	if limit, ok := p.limits[ot]; ok {
+		maxDepth = limit
+	}
+	return maxDepth <= 0 || depth <= maxDepth
+}
+
+// WithDepthPolicy limits the depth of the followed urls per type of report, the seeds being at depth 1.
+// {Ref: 5, LinkFinderOutput: 2} follows the links up to depth 5 but the urls found by LinkFinder up to depth 2.
+// The types missing from policy are limited by the MaxDepth of the collector, and a depth of 0 is unlimited
+func WithDepthPolicy(policy map[OutputType]int) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.depthPolicy = &depthPolicy{limits: policy}
+	}
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestDepthPolicy(t *testing.T) {
+	var lock sync.Mutex
+	visited := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		visited = append(visited, r.URL.Path)
+		lock.Unlock()
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><body><a href="/1">1</a><script src="/app.js"></script></body></html>`)
+		case "/1", "/2", "/3":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, `<html><body><a href="/%c">next</a></body></html>`, r.URL.Path[1]+1)
+		case "/app.js":
+			w.Header().Set("Content-Type", "application/javascript")
+			fmt.Fprint(w, `fetch("/api/first.js")`)
+		case "/api/first.js":
+			w.Header().Set("Content-Type", "application/javascript")
+			fmt.Fprint(w, `fetch("/api/second.js")`)
+		}
+	}))
+	defer ts.Close()
+
+	crawler := NewCrawler(WithDefaultColly(0), WithLinkFinder(), WithDepthPolicy(map[OutputType]int{Ref: 3, LinkFinderOutput: 3}))
+	collectReports(crawler, ts.URL)
+	lock.Lock()
+	defer lock.Unlock()
+	sort.Strings(visited)
+	if expected := []string{"/", "/1", "/2", "/api/first.js", "/app.js"}; !reflect.DeepEqual(visited, expected) {
+		t.Errorf("expected the visits %v, got %v", expected, visited)
+	}
+}
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"container/list"
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultDNSCacheTTL is how long a resolved host is cached when no TTL is given
+	DefaultDNSCacheTTL = 5 * time.Minute
+	// DefaultDNSCacheMaxEntries is the number of hosts cached when no maximum is given
+	DefaultDNSCacheMaxEntries = 10000
+)
+
+type dnsCacheEntry struct {
+	host    string
+	ips     []net.IPAddr
+	expires time.Time
+}
+
+// DNSCache caches the addresses resolved by Resolver for TTL, evicting the least recently used hosts beyond MaxEntries.
+// Failed lookups are not cached
+type DNSCache struct {
+	resolver   Resolver
+	ttl        time.Duration
+	maxEntries int
+
+	lock    sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+// NewDNSCache caches the lookups of resolver, the system resolver when nil.
+// ttl and maxEntries default to DefaultDNSCacheTTL and DefaultDNSCacheMaxEntries when not positive
+func NewDNSCache(resolver Resolver, ttl time.Duration, maxEntries int) *DNSCache {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	if ttl <= 0 {
+		ttl = DefaultDNSCacheTTL
+	}
+	if maxEntries <= 0 {
+		maxEntries = DefaultDNSCacheMaxEntries
+	}
+	return &DNSCache{
+		resolver:   resolver,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// LookupIPAddr returns the cached addresses of host, resolving it when missing or expired
+func (cache *DNSCache) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if ips, ok := cache.get(host); ok {
+		return ips, nil
+	}
+	ips, err := cache.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	cache.set(host, ips)
+	return ips, nil
+}
+
+func (cache *DNSCache) get(host string) ([]net.IPAddr, bool) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	elem, ok := cache.entries[host]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*dnsCacheEntry)
+	if time.Now().After(entry.expires) {
+		cache.lru.Remove(elem)
+		delete(cache.entries, host)
+		return nil, false
+	}
+	cache.lru.MoveToFront(elem)
+	return entry.ips, true
+}
+
+func (cache *DNSCache) set(host string, ips []net.IPAddr) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	entry := &dnsCacheEntry{host: host, ips: ips, expires: time.Now().Add(cache.ttl)}
+	if elem, ok := cache.entries[host]; ok {
+		elem.Value = entry
+		cache.lru.MoveToFront(elem)
+		return
+	}
+	cache.entries[host] = cache.lru.PushFront(entry)
+	for cache.lru.Len() > cache.maxEntries {
+		oldest := cache.lru.Back()
+		cache.lru.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*dnsCacheEntry).host)
+	}
+}
+
+// Len returns the number of cached hosts
+func (cache *DNSCache) Len() int {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	return cache.lru.Len()
+}
+
+// WithDNSCache caches the host names resolved by the system resolver, see NewDNSCache.
+// Use WithDNSResolver(NewDNSCache(resolver, ttl, maxEntries)) to cache another resolver
+func WithDNSCache(ttl time.Duration, maxEntries int) HTTPClientConfigurator {
+	return WithDNSResolver(NewDNSCache(nil, ttl, maxEntries))
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+type countingResolver struct {
+	lock    sync.Mutex
+	lookups map[string]int
+}
+
+func (r *countingResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.lookups[host]++
+	return []net.IPAddr{{IP: net.IPv4(127, 0, 0, 1)}}, nil
+}
+
+func TestDNSCache(t *testing.T) {
+	resolver := &countingResolver{lookups: make(map[string]int)}
+	cache := NewDNSCache(resolver, 50*time.Millisecond, 2)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if _, err := cache.LookupIPAddr(ctx, "a.test"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if resolver.lookups["a.test"] != 1 {
+		t.Errorf("expected a.test to be resolved once, got %d", resolver.lookups["a.test"])
+	}
+
+	cache.LookupIPAddr(ctx, "b.test")
+	cache.LookupIPAddr(ctx, "a.test")
+	cache.LookupIPAddr(ctx, "c.test")
+	if cache.Len() != 2 {
+		t.Errorf("expected 2 cached hosts, got %d", cache.Len())
+	}
+	// b.test was the least recently used
+	cache.LookupIPAddr(ctx, "b.test")
+	if resolver.lookups["b.test"] != 2 || resolver.lookups["a.test"] != 1 {
+		t.Errorf("expected b.test to be evicted, got %v", resolver.lookups)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	cache.LookupIPAddr(ctx, "b.test")
+	if resolver.lookups["b.test"] != 3 {
+		t.Errorf("expected b.test to expire, got %d lookups", resolver.lookups["b.test"])
+	}
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"math/bits"
+	"net/http"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// faviconMaxBytes bounds the size of the fetched favicons
+const faviconMaxBytes = 1024 * 1024
+
+// murmur3 returns the 32 bits MurmurHash3 of data with seed 0
+func murmur3(data []byte) uint32 {
+	const c1, c2 = 0xcc9e2d51, 0x1b873593
+	h := uint32(0)
+	n := len(data) / 4
+	for i := 0; i < n; i++ {
+		k := binary.LittleEndian.Uint32(data[i*4:])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+	tail := data[n*4:]
+	k := uint32(0)
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
+
+// FaviconHash returns the Shodan favicon hash of icon (http.favicon.hash): the signed MurmurHash3 of its base64
+// encoding, wrapped every 76 characters as by Python base64.encodebytes
+func FaviconHash(icon []byte) int32 {
+	encoded := base64.StdEncoding.EncodeToString(icon)
+	wrapped := make([]byte, 0, len(encoded)+len(encoded)/76+1)
+	for len(encoded) > 76 {
+		wrapped = append(wrapped, encoded[:76]...)
+		wrapped = append(wrapped, '\n')
+		encoded = encoded[76:]
+	}
+	wrapped = append(wrapped, encoded...)
+	wrapped = append(wrapped, '\n')
+	return int32(murmur3(wrapped))
+}
+
+// discoverFavicon emits the hash of the favicon at iconURL, unless it was already fetched
+func (crawler *Crawler) discoverFavicon(emit func(SpiderReport), request *colly.Request, iconURL string) {
+	if iconURL == "" || crawler.discoveredFavicons.Duplicate(iconURL) {
+		return
+	}
+	resp, err := crawler.helperClient(10 * time.Second).Get(iconURL)
+	if err != nil {
+		Logger.Debugf("Failed to fetch favicon %s: %s", iconURL, err)
+		return
+	}
+	defer resp.Body.Close()
+	icon, err := io.ReadAll(io.LimitReader(resp.Body, faviconMaxBytes))
+	if err != nil || resp.StatusCode != http.StatusOK || len(icon) == 0 {
+		return
+	}
+	emit(SpiderReport{
+		Output:      iconURL,
+		OutputType:  Favicon,
+		Source:      "favicon",
+		StatusCode:  resp.StatusCode,
+		Input:       request.URL,
+		Seed:        requestSeed(request),
+		ContentType: SniffContentType(resp.Header, icon),
+	}.WithMetadata("mmh3", FaviconHash(icon)))
+}
+
+// WithFaviconHash emits a favicon report for /favicon.ico of every host and the icons linked by the pages, with their
+// Shodan favicon hash in the "mmh3" Metadata, to pivot to Shodan or Censys
+func WithFaviconHash() CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.favicon = true
+	}
+}
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMurmur3(t *testing.T) {
+	tests := map[string]uint32{"": 0, "hello": 0x248bfa47, "foo": 0xf6a5c420, "The quick brown fox jumps over the lazy dog": 0x2e4ff723}
+	for data, expected := range tests {
+		if h := murmur3([]byte(data)); h != expected {
+			t.Errorf("expected murmur3 %x for %q, got %x", expected, data, h)
+		}
+	}
+}
+
+func TestFaviconReports(t *testing.T) {
+	icons := map[string][]byte{"/favicon.ico": []byte("\x00\x00\x01\x00ico"), "/static/icon.png": []byte("\x89PNG\r\n\x1a\npng")}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if icon, ok := icons[r.URL.Path]; ok {
+			w.Write(icon)
+			return
+		}
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><link rel="shortcut icon" href="/static/icon.png"></head><body><a href="/">home</a></body></html>`)
+	}))
+	defer ts.Close()
+
+	found := map[string]any{}
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(2), WithFaviconHash()), ts.URL) {
+		if r.OutputType == Favicon {
+			if _, ok := found[r.Output]; ok {
+				t.Errorf("expected a single report for %s", r.Output)
+			}
+			found[r.Output] = r.Metadata["mmh3"]
+		}
+	}
+	for path, icon := range icons {
+		if found[ts.URL+path] != FaviconHash(icon) {
+			t.Errorf("expected the hash %d of %s, got %v", FaviconHash(icon), path, found[ts.URL+path])
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package core
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// DefaultFeedPaths are the paths probed for RSS and Atom feeds on each origin, see WithFeeds
+var DefaultFeedPaths = []string{"/feed", "/feed/", "/rss", "/rss.xml", "/feed.xml", "/atom.xml", "/index.xml", "/index.rss",
+	"/feeds/posts/default", "/?feed=rss2"}
+
+// feedMaxBytes bounds the size of a feed
+const feedMaxBytes = 10 * 1024 * 1024
+
+// feedContentTypes are the types of the feeds advertised by <link rel="alternate">
+var feedContentTypes = map[string]bool{
+	"application/rss+xml":  true,
+	"application/atom+xml": true,
+	"application/rdf+xml":  true,
+}
+
+// FeedItem is an item of a RSS feed or an entry of an Atom feed
+type FeedItem struct {
+	Link      string `json:"link"`
+	Title     string `json:"title,omitempty"`
+	Published string `json:"published,omitempty"`
+}
+
+type feedLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Text string `xml:",chardata"`
+}
+
+// feedEntry holds the fields of both the RSS items and the Atom entries
+type feedEntry struct {
+	Title     string     `xml:"title"`
+	Links     []feedLink `xml:"link"`
+	GUID      string     `xml:"guid"`
+	PubDate   string     `xml:"pubDate"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Date      string     `xml:"date"`
+}
+
+// link returns the url of the entry: the text of the RSS <link>, the alternate link of an Atom entry, or the guid
+// when it is an url
+func (entry feedEntry) link() string {
+	for _, l := range entry.Links {
+		if text := strings.TrimSpace(l.Text); text != "" {
+			return text
+		}
+	}
+	for _, l := range entry.Links {
+		if rel := strings.TrimSpace(l.Rel); rel == "" || rel == "alternate" {
+			if href := strings.TrimSpace(l.Href); href != "" {
+				return href
+			}
+		}
+	}
+	if guid := strings.TrimSpace(entry.GUID); strings.HasPrefix(guid, "http") {
+		return guid
+	}
+	return ""
+}
+
+// ParseFeed returns the items of a RSS 2.0, RSS 1.0 or Atom feed. It fails when body is not a feed
+func ParseFeed(body []byte) ([]FeedItem, error) {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	dec.Strict = false
+	items := []FeedItem{}
+	isFeed := false
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse feed: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if !isFeed {
+			// the root element tells the feeds from the other xml documents
+			if name := start.Name.Local; name != "rss" && name != "feed" && name != "RDF" {
+				return nil, fmt.Errorf("not a feed: <%s> root element", name)
+			}
+			isFeed = true
+			continue
+		}
+		if start.Name.Local != "item" && start.Name.Local != "entry" {
+			continue
+		}
+		entry := feedEntry{}
+		if err := dec.DecodeElement(&entry, &start); err != nil {
+			return nil, fmt.Errorf("failed to parse feed: %w", err)
+		}
+		item := FeedItem{Link: entry.link(), Title: strings.TrimSpace(entry.Title)}
+		for _, date := range []string{entry.PubDate, entry.Published, entry.Date, entry.Updated} {
+			if date = strings.TrimSpace(date); date != "" {
+				item.Published = date
+				break
+			}
+		}
+		if item.Link != "" {
+			items = append(items, item)
+		}
+	}
+	if !isFeed {
+		return nil, errors.New("not a feed: empty document")
+	}
+	return items, nil
+}
+
+// fetchFeed fetches and parses the feed at feedURL
+func (crawler *Crawler) fetchFeed(feedURL string) ([]FeedItem, error) {
+	resp, err := crawler.helperClient(30 * time.Second).Get(feedURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, feedMaxBytes))
+	if err != nil {
+		return nil, err
+	}
+	return ParseFeed(raw)
+}
+
+// discoverFeed emits the items of the feed at feedURL, found while crawling request, unless it was already read
+func (crawler *Crawler) discoverFeed(emit func(SpiderReport), request *colly.Request, feedURL string) {
+	if feedURL == "" || crawler.discoveredFeeds.Duplicate(feedURL) {
+		return
+	}
+	items, err := crawler.fetchFeed(feedURL)
+	if err != nil {
+		Logger.Debugf("No feed at %s: %s", feedURL, err)
+		return
+	}
+	input, err := url.Parse(feedURL)
+	if err != nil {
+		return
+	}
+	for _, item := range items {
+		link, err := input.Parse(item.Link)
+		if err != nil {
+			continue
+		}
+		emit(SpiderReport{
+			Output:     link.String(),
+			OutputType: Feed,
+			Source:     "feed",
+			Input:      input,
+			Seed:       requestSeed(request),
+		}.WithMetadata("feed", feedURL).WithMetadata("item", item))
+	}
+}
+
+// probeFeeds reads the feeds at the feed paths of the origin of request the first time it is seen
+func (crawler *Crawler) probeFeeds(emit func(SpiderReport), request *colly.Request) {
+	origin := request.URL.Scheme + "://" + request.URL.Host
+	if crawler.probedFeedHosts.Duplicate(origin) {
+		return
+	}
+	for _, path := range crawler.feedPaths {
+		crawler.discoverFeed(emit, request, origin+path)
+	}
+}
+
+// WithFeeds reads the RSS and Atom feeds advertised by <link rel="alternate"> and the ones at paths on every origin,
+// DefaultFeedPaths when paths is empty, and crawls the urls of their items, reported as feed
+func WithFeeds(paths ...string) CrawlerOption {
+	return func(crawler *Crawler) {
+		if len(paths) == 0 {
+			paths = DefaultFeedPaths
+		}
+		crawler.feeds = true
+		crawler.feedPaths = paths
+	}
+}
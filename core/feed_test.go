@@ -0,0 +1,90 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestParseFeed(t *testing.T) {
+	tests := []struct {
+		feed     string
+		expected []FeedItem
+	}{
+		{`<?xml version="1.0"?><rss version="2.0"><channel><link>https://example.com/</link>
+<item><title>First</title><link>https://example.com/posts/1</link><pubDate>Mon, 02 Jan 2006 15:04:05 GMT</pubDate></item>
+<item><guid isPermaLink="true">https://example.com/posts/2</guid></item>
+<item><title>No link</title><guid>tag:example.com,2006:3</guid></item>
+</channel></rss>`, []FeedItem{
+			{Link: "https://example.com/posts/1", Title: "First", Published: "Mon, 02 Jan 2006 15:04:05 GMT"},
+			{Link: "https://example.com/posts/2"},
+		}},
+		{`<feed xmlns="http://www.w3.org/2005/Atom"><link rel="self" href="/atom.xml"/>
+<entry><title>Atom</title><link rel="enclosure" href="/a.mp3"/><link href="/posts/atom"/><updated>2006-01-02T15:04:05Z</updated></entry>
+</feed>`, []FeedItem{{Link: "/posts/atom", Title: "Atom", Published: "2006-01-02T15:04:05Z"}}},
+		{`<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<item rdf:about="https://example.com/rdf"><link>https://example.com/rdf</link><dc:date>2006-01-02</dc:date></item></rdf:RDF>`,
+			[]FeedItem{{Link: "https://example.com/rdf", Published: "2006-01-02"}}},
+	}
+	for _, test := range tests {
+		items, err := ParseFeed([]byte(test.feed))
+		if err != nil || !reflect.DeepEqual(items, test.expected) {
+			t.Errorf("expected %+v, got %+v (%v)", test.expected, items, err)
+		}
+	}
+	for _, body := range []string{`<html><body>not a feed</body></html>`, `<urlset><url><loc>https://example.com/</loc></url></urlset>`, ``} {
+		if _, err := ParseFeed([]byte(body)); err == nil {
+			t.Errorf("expected %q not to be a feed", body)
+		}
+	}
+}
+
+func TestFeeds(t *testing.T) {
+	var lock sync.Mutex
+	visited := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		visited = append(visited, r.URL.Path)
+		lock.Unlock()
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><head><link rel="alternate" type="application/rss+xml" href="/blog/rss.xml"></head></html>`)
+		case "/blog/rss.xml":
+			w.Header().Set("Content-Type", "application/rss+xml")
+			fmt.Fprint(w, `<rss version="2.0"><channel><item><title>Hello</title><link>/blog/hello</link></item></channel></rss>`)
+		case "/atom.xml":
+			w.Header().Set("Content-Type", "application/atom+xml")
+			fmt.Fprint(w, `<feed xmlns="http://www.w3.org/2005/Atom"><entry><link href="/news/today"/></entry></feed>`)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+		}
+	}))
+	defer ts.Close()
+
+	items := []string{}
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(2), WithFeeds("/atom.xml")), ts.URL) {
+		if r.OutputType == Feed {
+			items = append(items, r.Output[len(ts.URL):])
+		}
+	}
+	sort.Strings(items)
+	if expected := []string{"/blog/hello", "/news/today"}; !reflect.DeepEqual(items, expected) {
+		t.Errorf("expected the feed items %v, got %v", expected, items)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	for _, path := range []string{"/blog/hello", "/news/today"} {
+		found := false
+		for _, v := range visited {
+			found = found || v == path
+		}
+		if !found {
+			t.Errorf("expected the feed item %s to be visited, got %v", path, visited)
+		}
+	}
+}
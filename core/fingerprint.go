@@ -0,0 +1,94 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	utls "github.com/refraction-networking/utls"
+)
+
+// tlsFingerprints are the browser ClientHellos WithTLSFingerprint can mimic
+var tlsFingerprints = map[string]utls.ClientHelloID{
+	"chrome":  utls.HelloChrome_Auto,
+	"firefox": utls.HelloFirefox_Auto,
+	"safari":  utls.HelloSafari_Auto,
+	"edge":    utls.HelloEdge_Auto,
+	"ios":     utls.HelloIOS_Auto,
+	"random":  utls.HelloRandomizedNoALPN,
+}
+
+// utlsDialer opens TLS connections with the ClientHello of a browser, on top of the transport dialer
+type utlsDialer struct {
+	helloID utls.ClientHelloID
+	dial    dialContextFunc
+	// tlsConfig is the TLS configuration of the transport, its verification settings are kept
+	tlsConfig *tls.Config
+}
+
+func (ud *utlsDialer) DialTLSContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	conn, err := ud.dial(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	config := &utls.Config{ServerName: host, InsecureSkipVerify: ud.tlsConfig.InsecureSkipVerify, RootCAs: ud.tlsConfig.RootCAs}
+	uconn := utls.UClient(conn, config, utls.HelloCustom)
+	spec, err := ud.spec()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := uconn.ApplyPreset(&spec); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to apply %s fingerprint: %w", ud.helloID.Str(), err)
+	}
+	if err := uconn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return uconn, nil
+}
+
+// spec returns the ClientHello of the fingerprint. The transport speaks HTTP/1.1 over the connection,
+// so only http/1.1 is offered through ALPN, which keeps the JA3 fingerprint as it only covers the extension types
+func (ud *utlsDialer) spec() (utls.ClientHelloSpec, error) {
+	spec, err := utls.UTLSIdToSpec(ud.helloID)
+	if err != nil {
+		return spec, fmt.Errorf("unknown tls fingerprint %s: %w", ud.helloID.Str(), err)
+	}
+	for _, ext := range spec.Extensions {
+		if alpn, ok := ext.(*utls.ALPNExtension); ok {
+			alpn.AlpnProtocols = []string{"http/1.1"}
+		}
+	}
+	return spec, nil
+}
+
+// WithTLSFingerprint sends the TLS ClientHello of a browser instead of the Go one, which WAFs and CDNs fingerprint (JA3)
+// to block crawlers. fingerprint is one of chrome, firefox, safari, edge, ios or random.
+// It must be set after WithTLSVerify and WithCACert, whose settings are read when it is applied
+func WithTLSFingerprint(fingerprint string) HTTPClientConfigurator {
+	return func(client *http.Client) error {
+		helloID, ok := tlsFingerprints[strings.ToLower(fingerprint)]
+		if !ok {
+			return fmt.Errorf("unknown tls fingerprint %s", fingerprint)
+		}
+		return configureTransport(client, func(transport *http.Transport) error {
+			ud := &utlsDialer{helloID: helloID, dial: transportDialContext(transport), tlsConfig: transportTLSConfig(transport)}
+			transport.DialTLSContext = ud.DialTLSContext
+			// HTTP proxies tunnel TLS through CONNECT, which bypasses DialTLSContext
+			if transport.Proxy != nil {
+				Logger.Warnf("The TLS fingerprint is not applied through HTTP proxies")
+			}
+			return nil
+		})
+	}
+}
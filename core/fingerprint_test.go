@@ -0,0 +1,45 @@
+package core
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// isGREASE reports whether v is a GREASE value, sent by Chrome and never by Go
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+func TestTLSFingerprint(t *testing.T) {
+	var lock sync.Mutex
+	var ciphers []uint16
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.TLS = &tls.Config{GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		lock.Lock()
+		ciphers = hello.CipherSuites
+		lock.Unlock()
+		return nil, nil
+	}}
+	ts.StartTLS()
+	defer ts.Close()
+
+	client := &http.Client{Transport: DefaultHTTPTransport.Clone()}
+	WithTLSFingerprint("chrome")(client)
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	lock.Lock()
+	defer lock.Unlock()
+	if len(ciphers) == 0 || !isGREASE(ciphers[0]) {
+		t.Errorf("expected a chrome ClientHello starting with a GREASE cipher suite, got %x", ciphers)
+	}
+
+	if err := crawlError(t, NewCrawler(WithDefaultColly(1), WithHTTPClientOpt(WithTLSFingerprint("netscape"))), ts.URL); err == nil {
+		t.Error("expected an unknown fingerprint to fail the crawl")
+	}
+}
@@ -0,0 +1,112 @@
+package core
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var csrfFieldRE = regexp.MustCompile(`(?i)csrf|xsrf|authenticity_?token|requestverificationtoken|anti-?forgery|^_?token$|nonce`)
+
+// FormField is a named field of a form, Value being its default value
+type FormField struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value,omitempty"`
+	// Options are the values of a select, or of the radio buttons sharing the field name
+	Options  []string `json:"options,omitempty"`
+	Required bool     `json:"required,omitempty"`
+	Hidden   bool     `json:"hidden,omitempty"`
+	// CSRF flags the fields named like an anti-CSRF token
+	CSRF bool `json:"csrf,omitempty"`
+}
+
+// HTMLForm is a form of a page, its action resolved against the page url
+type HTMLForm struct {
+	Action  string      `json:"action"`
+	Method  string      `json:"method"`
+	Enctype string      `json:"enctype"`
+	Fields  []FormField `json:"fields,omitempty"`
+	// CSRF is set when a field of the form looks like an anti-CSRF token
+	CSRF bool `json:"csrf,omitempty"`
+}
+
+// ParseHTMLForm returns the action, method, encoding and fields of form, found in page. The action resolves against
+// the <base href> of the document, the form without action being submitted to page
+func ParseHTMLForm(page *url.URL, form *goquery.Selection) HTMLForm {
+	res := HTMLForm{
+		Action:  page.String(),
+		Method:  http.MethodGet,
+		Enctype: "application/x-www-form-urlencoded",
+		Fields:  []FormField{},
+	}
+	if action := strings.TrimSpace(form.AttrOr("action", "")); action != "" {
+		base := page
+		if href, ok := form.Closest("html").Find("base[href]").First().Attr("href"); ok {
+			if u, err := page.Parse(strings.TrimSpace(href)); err == nil {
+				base = u
+			}
+		}
+		if u, err := base.Parse(action); err == nil {
+			res.Action = u.String()
+		}
+	}
+	if strings.EqualFold(form.AttrOr("method", ""), http.MethodPost) {
+		res.Method = http.MethodPost
+	}
+	if enctype := strings.ToLower(form.AttrOr("enctype", "")); enctype == "multipart/form-data" || enctype == "text/plain" {
+		res.Enctype = enctype
+	}
+	// radios indexes the radio fields by name, the buttons sharing a name being a single field
+	radios := map[string]int{}
+	form.Find("input[name], textarea[name], select[name], button[name]").Each(func(_ int, field *goquery.Selection) {
+		f := FormField{Name: field.AttrOr("name", "")}
+		_, f.Required = field.Attr("required")
+		switch goquery.NodeName(field) {
+		case "select":
+			f.Type = "select"
+			field.Find("option").Each(func(_ int, option *goquery.Selection) {
+				value, ok := option.Attr("value")
+				if !ok {
+					value = strings.TrimSpace(option.Text())
+				}
+				f.Options = append(f.Options, value)
+				if _, selected := option.Attr("selected"); selected || len(f.Options) == 1 {
+					f.Value = value
+				}
+			})
+		case "textarea":
+			f.Type = "textarea"
+			f.Value = field.Text()
+		case "button":
+			f.Type = strings.ToLower(field.AttrOr("type", "submit"))
+			f.Value = field.AttrOr("value", "")
+		default:
+			f.Type = strings.ToLower(field.AttrOr("type", "text"))
+			f.Value = field.AttrOr("value", "")
+			f.Hidden = f.Type == "hidden"
+			if f.Type == "radio" {
+				_, checked := field.Attr("checked")
+				if i, ok := radios[f.Name]; ok {
+					res.Fields[i].Options = append(res.Fields[i].Options, f.Value)
+					if checked {
+						res.Fields[i].Value = f.Value
+					}
+					return
+				}
+				radios[f.Name] = len(res.Fields)
+				f.Options = []string{f.Value}
+				if !checked {
+					f.Value = ""
+				}
+			}
+		}
+		f.CSRF = csrfFieldRE.MatchString(f.Name)
+		res.CSRF = res.CSRF || f.CSRF
+		res.Fields = append(res.Fields, f)
+	})
+	return res
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestParseHTMLForm(t *testing.T) {
+	page, _ := url.Parse("https://example.com/account/")
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<html><body>
+<form action="../profile/update" method="post" enctype="multipart/form-data">
+  <input type="hidden" name="csrf_token" value="t0k3n">
+  <input name="nickname" value="bob" required>
+  <input type="radio" name="plan" value="free">
+  <input type="radio" name="plan" value="pro" checked>
+  <select name="country"><option value="fr">France</option><option selected>Italy</option></select>
+  <textarea name="bio">hello</textarea>
+  <input type="file" name="avatar">
+  <button name="save" value="1">Save</button>
+</form>
+<form><input name="q"></form>
+</body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	forms := doc.Find("form")
+	expected := HTMLForm{
+		Action:  "https://example.com/profile/update",
+		Method:  http.MethodPost,
+		Enctype: "multipart/form-data",
+		Fields: []FormField{
+			{Name: "csrf_token", Type: "hidden", Value: "t0k3n", Hidden: true, CSRF: true},
+			{Name: "nickname", Type: "text", Value: "bob", Required: true},
+			{Name: "plan", Type: "radio", Value: "pro", Options: []string{"free", "pro"}},
+			{Name: "country", Type: "select", Value: "Italy", Options: []string{"fr", "Italy"}},
+			{Name: "bio", Type: "textarea", Value: "hello"},
+			{Name: "avatar", Type: "file"},
+			{Name: "save", Type: "submit", Value: "1"},
+		},
+		CSRF: true,
+	}
+	if form := ParseHTMLForm(page, forms.First()); !reflect.DeepEqual(form, expected) {
+		t.Errorf("expected %+v, got %+v", expected, form)
+	}
+	expected = HTMLForm{
+		Action:  page.String(),
+		Method:  http.MethodGet,
+		Enctype: "application/x-www-form-urlencoded",
+		Fields:  []FormField{{Name: "q", Type: "text"}},
+	}
+	if form := ParseHTMLForm(page, forms.Last()); !reflect.DeepEqual(form, expected) {
+		t.Errorf("expected %+v, got %+v", expected, form)
+	}
+}
+
+func TestFormReports(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><form action="/login" method="POST"><input type="hidden" name="authenticity_token" value="x"><input name="user"><input type="password" name="pass"></form></body></html>`)
+	}))
+	defer ts.Close()
+
+	var forms []SpiderReport
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(1)), ts.URL) {
+		if r.OutputType == Form {
+			forms = append(forms, r)
+		}
+	}
+	if len(forms) != 1 || forms[0].Output != ts.URL+"/login" {
+		t.Fatalf("expected a form report for %s/login, got %v", ts.URL, forms)
+	}
+	form, ok := forms[0].Metadata["form"].(HTMLForm)
+	if !ok || form.Method != http.MethodPost || len(form.Fields) != 3 || !form.CSRF {
+		t.Errorf("expected the POST form with its csrf token in the Metadata, got %v", forms[0].Metadata)
+	}
+}
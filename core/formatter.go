@@ -0,0 +1,122 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Formatter serializes a SpiderReport before it is written to the crawler Output
+type Formatter interface {
+	Format(report SpiderReport) ([]byte, error)
+}
+
+// FormatterFunc is a function implementing Formatter
+type FormatterFunc func(report SpiderReport) ([]byte, error)
+
+func (f FormatterFunc) Format(report SpiderReport) ([]byte, error) {
+	return f(report)
+}
+
+// JSONLFormatter writes one JSON object per line
+type JSONLFormatter struct{}
+
+func (JSONLFormatter) Format(report SpiderReport) ([]byte, error) {
+	raw, err := json.Marshal(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format %s report %s: %w", report.OutputType, report.Output, err)
+	}
+	return append(raw, '\n'), nil
+}
+
+// PlainFormatter writes the bare report output, one per line
+type PlainFormatter struct{}
+
+func (PlainFormatter) Format(report SpiderReport) ([]byte, error) {
+	return []byte(report.Output + "\n"), nil
+}
+
+// TextFormatter writes grep friendly lines: `[type] - [code-200] - output`
+type TextFormatter struct{}
+
+func (TextFormatter) Format(report SpiderReport) ([]byte, error) {
+	parts := []string{fmt.Sprintf("[%s]", report.OutputType)}
+	if report.StatusCode != 0 {
+		parts = append(parts, fmt.Sprintf("[code-%d]", report.StatusCode))
+	}
+	if report.Matcher != "" {
+		parts = append(parts, fmt.Sprintf("[%s]", report.Matcher))
+	}
+	parts = append(parts, report.Output)
+	return []byte(strings.Join(parts, " - ") + "\n"), nil
+}
+
+// FormatterByName returns the built-in formatter named name: `jsonl` (or `json`), `plain` (or `url`) and `text` (or `pretty`)
+func FormatterByName(name string) (Formatter, error) {
+	switch strings.ToLower(name) {
+	case "jsonl", "json":
+		return JSONLFormatter{}, nil
+	case "plain", "url":
+		return PlainFormatter{}, nil
+	case "text", "pretty", "":
+		return TextFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %s", name)
+	}
+}
+
+// MarshalJSON flattens the input url and the error of the report, and writes its durations in milliseconds
+func (ov SpiderReport) MarshalJSON() ([]byte, error) {
+	type report SpiderReport
+	input, errMsg := "", ""
+	if ov.Input != nil {
+		input = ov.Input.String()
+	}
+	if ov.Err != nil {
+		errMsg = ov.Err.Error()
+	}
+	return json.Marshal(struct {
+		report
+		Input    string  `json:"input,omitempty"`
+		Err      string  `json:"error,omitempty"`
+		TTFB     float64 `json:"ttfb_ms,omitempty"`
+		Duration float64 `json:"duration_ms,omitempty"`
+	}{report: report(ov), Input: input, Err: errMsg, TTFB: durationMilliseconds(ov.TTFB), Duration: durationMilliseconds(ov.Duration)})
+}
+
+// UnmarshalJSON reads back a report written by MarshalJSON
+func (ov *SpiderReport) UnmarshalJSON(raw []byte) error {
+	type report SpiderReport
+	decoded := struct {
+		*report
+		Input    string  `json:"input,omitempty"`
+		Err      string  `json:"error,omitempty"`
+		TTFB     float64 `json:"ttfb_ms,omitempty"`
+		Duration float64 `json:"duration_ms,omitempty"`
+	}{report: (*report)(ov)}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return err
+	}
+	ov.Input = nil
+	if decoded.Input != "" {
+		input, err := url.Parse(decoded.Input)
+		if err != nil {
+			return fmt.Errorf("invalid report input %s: %w", decoded.Input, err)
+		}
+		ov.Input = input
+	}
+	ov.Err = nil
+	if decoded.Err != "" {
+		ov.Err = errors.New(decoded.Err)
+	}
+	ov.TTFB = time.Duration(decoded.TTFB * float64(time.Millisecond))
+	ov.Duration = time.Duration(decoded.Duration * float64(time.Millisecond))
+	return nil
+}
+
+func durationMilliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
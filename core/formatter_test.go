@@ -0,0 +1,62 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestFormatters(t *testing.T) {
+	input, _ := url.Parse("https://example.com/")
+	report := SpiderReport{
+		Output:     "https://example.com/login",
+		OutputType: Url,
+		StatusCode: 403,
+		Source:     "body",
+		Input:      input,
+		Err:        errors.New("Forbidden"),
+	}
+	raw, err := JSONLFormatter{}.Format(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := string(raw)
+	for _, expected := range []string{`"output":"https://example.com/login"`, `"input":"https://example.com/"`, `"error":"Forbidden"`} {
+		if !strings.Contains(line, expected) {
+			t.Errorf("expected %s in %s", expected, line)
+		}
+	}
+	if !strings.HasSuffix(line, "}\n") {
+		t.Errorf("expected a single json line, got %s", line)
+	}
+	if raw, _ := (PlainFormatter{}).Format(report); string(raw) != "https://example.com/login\n" {
+		t.Errorf("unexpected plain output %s", raw)
+	}
+	if raw, _ := (TextFormatter{}).Format(report); string(raw) != "[url] - [code-403] - https://example.com/login\n" {
+		t.Errorf("unexpected text output %s", raw)
+	}
+	if _, err := FormatterByName("xml"); err == nil {
+		t.Error("expected unknown format error")
+	}
+}
+
+func TestSpiderReportJSONRoundTrip(t *testing.T) {
+	input, _ := url.Parse("https://example.com/")
+	report := SpiderReport{Output: "https://example.com/a", OutputType: Ref, Input: input, Err: errors.New("boom"), Seed: "https://example.com"}
+	raw, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := SpiderReport{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Output != report.Output || decoded.OutputType != Ref || decoded.Seed != report.Seed {
+		t.Errorf("unexpected decoded report %+v", decoded)
+	}
+	if decoded.Input == nil || decoded.Input.String() != input.String() || decoded.Err == nil || decoded.Err.Error() != "boom" {
+		t.Errorf("expected input and error to be decoded, got %v and %v", decoded.Input, decoded.Err)
+	}
+}
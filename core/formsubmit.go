@@ -0,0 +1,124 @@
+package core
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// unsafeFormRE flags the forms whose action or fields suggest a side effect (deletion, payment, logout...)
+var unsafeFormRE = regexp.MustCompile(`(?i)delete|remove|destroy|logout|log-out|signout|sign-out|unsubscribe|payment|checkout|purchase|transfer|withdraw|password|passwd`)
+
+// cannedFormValues are the benign values submitted in the empty fields, by input type
+var cannedFormValues = map[string]string{
+	"email":          "test@example.com",
+	"url":            "https://example.com",
+	"tel":            "5555555555",
+	"number":         "1",
+	"range":          "1",
+	"date":           "2024-01-01",
+	"datetime-local": "2024-01-01T00:00",
+	"month":          "2024-01",
+	"week":           "2024-W01",
+	"time":           "12:00",
+	"color":          "#000000",
+}
+
+// FormValues returns the values submitted for form: the default value of its fields, a canned value for the empty ones
+// and the first option of the selects and radios without one. ok is false when the form is not safe to submit: it holds a
+// password or a file field, or its action or fields look like a deletion, a payment or a logout
+func FormValues(form HTMLForm) (values url.Values, ok bool) {
+	if unsafeFormRE.MatchString(form.Action) {
+		return nil, false
+	}
+	values = url.Values{}
+	for _, field := range form.Fields {
+		if field.Type == "password" || field.Type == "file" || unsafeFormRE.MatchString(field.Name) {
+			return nil, false
+		}
+		value := field.Value
+		switch field.Type {
+		case "submit", "button", "image", "reset":
+			continue
+		case "checkbox":
+			if value == "" {
+				value = "on"
+			}
+		case "select", "radio":
+			if value == "" && len(field.Options) > 0 {
+				value = field.Options[0]
+			}
+		case "hidden":
+		default:
+			if value == "" {
+				if value = cannedFormValues[field.Type]; value == "" {
+					value = "test"
+				}
+			}
+		}
+		values.Add(field.Name, value)
+	}
+	return values, true
+}
+
+// submitForm submits form when it is safe, GET forms as a visit of the url holding its values and, when enabled, POST
+// forms with their values url encoded. The collector filters keep the submissions within the crawl scope
+func (crawler *Crawler) submitForm(c *colly.Collector, request *colly.Request, form HTMLForm) {
+	if form.Method == http.MethodPost && !crawler.submitPostForms {
+		return
+	}
+	values, ok := FormValues(form)
+	if !ok {
+		Logger.Debugf("Skipping unsafe form %s %s of %s", form.Method, form.Action, request.URL)
+		return
+	}
+	u, err := url.Parse(form.Action)
+	if err != nil {
+		return
+	}
+	u.Fragment = ""
+	if form.Method == http.MethodGet {
+		u.RawQuery = values.Encode()
+		if err := crawler.visit(c, u.String(), requestSeed(request)); err != nil {
+			Logger.Debugf("Failed to submit form %s of %s: %s", u, request.URL, err)
+		}
+		return
+	}
+	body := values.Encode()
+	if crawler.submittedForms.Duplicate(u.String() + " " + body) {
+		return
+	}
+	if crawler.robotsPolicy != nil && !crawler.robotsPolicy.allowed(c, u.String()) {
+		return
+	}
+	if crawler.control.isStopped() || (crawler.budget != nil && !crawler.budget.reserve()) {
+		return
+	}
+	ctx := colly.NewContext()
+	ctx.Put(seedContextKey, requestSeed(request))
+	header := http.Header{}
+	header.Set("Content-Type", "application/x-www-form-urlencoded")
+	header.Set("Referer", request.URL.String())
+	crawler.metrics.queueDepth.Inc()
+	if err := c.Request(http.MethodPost, u.String(), strings.NewReader(body), ctx, header); err != nil {
+		Logger.Debugf("Failed to submit form %s of %s: %s", u, request.URL, err)
+		crawler.metrics.queueDepth.Dec()
+		if crawler.budget != nil {
+			crawler.budget.release()
+		}
+	}
+}
+
+// WithFormSubmission submits the safe GET forms found while crawling with their default values and benign canned values
+// for the empty fields, to reach the result pages and parameterized urls no link leads to. When post is set, the safe
+// POST forms are submitted too. The forms holding password or file fields, or whose action or fields look like a
+// deletion, a payment or a logout, are never submitted (see FormValues)
+func WithFormSubmission(post bool) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.formSubmission = true
+		crawler.submitPostForms = post
+	}
+}
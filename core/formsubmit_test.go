@@ -0,0 +1,75 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestFormValues(t *testing.T) {
+	form := HTMLForm{
+		Action: "https://example.com/search",
+		Method: http.MethodGet,
+		Fields: []FormField{
+			{Name: "q", Type: "search"},
+			{Name: "mail", Type: "email"},
+			{Name: "sort", Type: "select", Value: "date", Options: []string{"relevance", "date"}},
+			{Name: "lang", Type: "radio", Options: []string{"en", "fr"}},
+			{Name: "token", Type: "hidden", Value: "abc", Hidden: true, CSRF: true},
+			{Name: "go", Type: "submit", Value: "Search"},
+		},
+	}
+	values, ok := FormValues(form)
+	expected := url.Values{"q": {"test"}, "mail": {"test@example.com"}, "sort": {"date"}, "lang": {"en"}, "token": {"abc"}}
+	if !ok || !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %v, got %v (%t)", expected, values, ok)
+	}
+
+	for _, unsafe := range []HTMLForm{
+		{Action: "https://example.com/login", Fields: []FormField{{Name: "user", Type: "text"}, {Name: "pass", Type: "password"}}},
+		{Action: "https://example.com/upload", Fields: []FormField{{Name: "doc", Type: "file"}}},
+		{Action: "https://example.com/account/delete", Fields: []FormField{{Name: "id", Type: "hidden", Value: "1"}}},
+		{Action: "https://example.com/account", Fields: []FormField{{Name: "action", Type: "hidden", Value: "x"}, {Name: "remove_all", Type: "checkbox"}}},
+	} {
+		if _, ok := FormValues(unsafe); ok {
+			t.Errorf("expected form %s to be unsafe", unsafe.Action)
+		}
+	}
+}
+
+func TestFormSubmission(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		mu.Lock()
+		requests[r.Method+" "+r.URL.Path] = r.Form.Encode()
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/" {
+			fmt.Fprint(w, `<html><body>
+<form action="/search"><input name="q"></form>
+<form action="/contact" method="post"><input type="email" name="from"><textarea name="message"></textarea></form>
+<form action="/account/delete" method="post"><input type="hidden" name="id" value="1"></form>
+</body></html>`)
+		}
+	}))
+	defer ts.Close()
+
+	collectReports(NewCrawler(WithDefaultColly(2), WithFormSubmission(true)), ts.URL)
+	mu.Lock()
+	defer mu.Unlock()
+	if query, ok := requests["GET /search"]; !ok || query != "q=test" {
+		t.Errorf("expected the search form to be submitted with q=test, got %v", requests)
+	}
+	if body, ok := requests["POST /contact"]; !ok || body != "from=test%40example.com&message=test" {
+		t.Errorf("expected the contact form to be posted with canned values, got %v", requests)
+	}
+	if _, ok := requests["POST /account/delete"]; ok {
+		t.Errorf("expected the delete form not to be submitted")
+	}
+}
@@ -0,0 +1,239 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	frontierContextKey = "gospider.frontier"
+	abortedContextKey  = "gospider.aborted"
+)
+
+// frontierPollInterval is the wait between two polls of an empty frontier while other requests are in flight
+var frontierPollInterval = 200 * time.Millisecond
+
+// FrontierEntry is an url waiting in a Frontier
+type FrontierEntry struct {
+	URL   string `json:"url"`
+	Seed  string `json:"seed,omitempty"`
+	Depth int    `json:"depth,omitempty"`
+}
+
+// Frontier is the queue of urls to visit and the set of already seen urls and outputs of a crawl,
+// shared by the crawlers working on it
+type Frontier interface {
+	// Duplicate reports whether output was already seen, recording it otherwise
+	Duplicate(output string) bool
+	// Push queues entry unless its url was already pushed
+	Push(ctx context.Context, entry FrontierEntry) error
+	// Pop dequeues an entry, ok is false when the queue is empty
+	Pop(ctx context.Context) (entry FrontierEntry, ok bool, err error)
+	// Done marks a popped entry as processed
+	Done(ctx context.Context, entry FrontierEntry) error
+	// Pending returns the number of entries queued or being processed
+	Pending(ctx context.Context) (int64, error)
+}
+
+// pushScript records the url as visited and queues the entry only when it was not visited yet
+var pushScript = redis.NewScript(`
+if redis.call('SADD', KEYS[1], ARGV[1]) == 1 then
+	redis.call('INCR', KEYS[2])
+	redis.call('LPUSH', KEYS[3], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// doneScript removes the entry from the processing list of the instance, and counts it as done unless it was requeued
+var doneScript = redis.NewScript(`
+if redis.call('LREM', KEYS[1], 1, ARGV[1]) == 1 then
+	redis.call('DECR', KEYS[2])
+	return 1
+end
+return 0
+`)
+
+// reapScript requeues the entries being processed by the instances whose lease expired
+var reapScript = redis.NewScript(`
+local expired = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+for _, instance in ipairs(expired) do
+	local processing = ARGV[2] .. instance
+	while redis.call('LMOVE', processing, KEYS[2], 'RIGHT', 'RIGHT') do end
+	redis.call('ZREM', KEYS[1], instance)
+end
+return #expired
+`)
+
+// RedisFrontierLease is how long the entries popped by an instance are kept for it without news from it. An instance
+// renews its lease whenever it polls the queue, the entries of a crashed instance are requeued once its lease expired
+var RedisFrontierLease = 30 * time.Second
+
+// RedisFrontier is a Frontier stored in Redis under namespace, letting several gospider instances crawl the same scope.
+// The popped entries are moved to a processing list of the instance until done, so none is lost when it crashes
+type RedisFrontier struct {
+	client    redis.UniversalClient
+	namespace string
+	// instance identifies the processing list and the lease of the frontier
+	instance string
+
+	lock sync.Mutex
+	// popped are the raw entries being processed, by url
+	popped map[string]string
+	errs   []error
+}
+
+func NewRedisFrontier(client redis.UniversalClient, namespace string) *RedisFrontier {
+	instance := make([]byte, 8)
+	rand.Read(instance)
+	return &RedisFrontier{client: client, namespace: namespace, instance: hex.EncodeToString(instance), popped: make(map[string]string)}
+}
+
+func (rf *RedisFrontier) key(name string) string {
+	return rf.namespace + ":" + name
+}
+
+func (rf *RedisFrontier) Duplicate(output string) bool {
+	added, err := rf.client.SAdd(context.Background(), rf.key("seen"), output).Result()
+	if err != nil {
+		rf.lock.Lock()
+		defer rf.lock.Unlock()
+		rf.errs = append(rf.errs, fmt.Errorf("failed to check seen output %s in frontier: %w", output, err))
+		return false
+	}
+	return added == 0
+}
+
+// Err returns the failures of Duplicate since the previous call, or nil
+func (rf *RedisFrontier) Err() error {
+	rf.lock.Lock()
+	defer rf.lock.Unlock()
+	err := errors.Join(rf.errs...)
+	rf.errs = nil
+	return err
+}
+
+func (rf *RedisFrontier) Push(ctx context.Context, entry FrontierEntry) error {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	keys := []string{rf.key("visited"), rf.key("pending"), rf.key("queue")}
+	if err := pushScript.Run(ctx, rf.client, keys, entry.URL, raw).Err(); err != nil {
+		return fmt.Errorf("failed to push %s to frontier: %w", entry.URL, err)
+	}
+	return nil
+}
+
+// Pop renews the lease of the instance and requeues the entries of the expired ones before moving an entry
+// to the processing list of the instance
+func (rf *RedisFrontier) Pop(ctx context.Context) (FrontierEntry, bool, error) {
+	entry := FrontierEntry{}
+	now := time.Now()
+	lease := redis.Z{Score: float64(now.Add(RedisFrontierLease).UnixMilli()), Member: rf.instance}
+	if err := rf.client.ZAdd(ctx, rf.key("leases"), lease).Err(); err != nil {
+		return entry, false, fmt.Errorf("failed to renew frontier lease: %w", err)
+	}
+	keys := []string{rf.key("leases"), rf.key("queue")}
+	if err := reapScript.Run(ctx, rf.client, keys, now.UnixMilli(), rf.key("processing:")).Err(); err != nil {
+		return entry, false, fmt.Errorf("failed to requeue the expired frontier entries: %w", err)
+	}
+	raw, err := rf.client.LMove(ctx, rf.key("queue"), rf.key("processing:"+rf.instance), "RIGHT", "LEFT").Result()
+	if errors.Is(err, redis.Nil) {
+		return entry, false, nil
+	}
+	if err != nil {
+		return entry, false, fmt.Errorf("failed to pop from frontier: %w", err)
+	}
+	if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+		return entry, false, fmt.Errorf("invalid frontier entry %s: %w", raw, err)
+	}
+	rf.lock.Lock()
+	rf.popped[entry.URL] = raw
+	rf.lock.Unlock()
+	return entry, true, nil
+}
+
+func (rf *RedisFrontier) Done(ctx context.Context, entry FrontierEntry) error {
+	rf.lock.Lock()
+	raw, ok := rf.popped[entry.URL]
+	delete(rf.popped, entry.URL)
+	rf.lock.Unlock()
+	if !ok {
+		return fmt.Errorf("%s wasn't popped from the frontier", entry.URL)
+	}
+	keys := []string{rf.key("processing:" + rf.instance), rf.key("pending")}
+	return doneScript.Run(ctx, rf.client, keys, raw).Err()
+}
+
+func (rf *RedisFrontier) Pending(ctx context.Context) (int64, error) {
+	pending, err := rf.client.Get(ctx, rf.key("pending")).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return pending, err
+}
+
+// WithFrontier replaces the in-memory request queue and output dedup by frontier
+func WithFrontier(frontier Frontier) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.frontier = frontier
+		crawler.set = frontier
+	}
+}
+
+// WithDistributedFrontier shares the request queue, the visited urls and the seen outputs in Redis under namespace,
+// so multiple gospider instances crawl the same scope cooperatively. Each instance crawls until the shared queue is drained.
+// The instances of a crawl share the namespace, distinct crawls sharing a Redis need distinct ones
+func WithDistributedFrontier(redisOpts *redis.Options, namespace string) CrawlerOption {
+	return WithFrontier(NewRedisFrontier(redis.NewClient(redisOpts), namespace))
+}
+
+// frontierDone acknowledges r to the frontier when it was popped from it
+func (crawler *Crawler) frontierDone(r *colly.Request) {
+	if crawler.frontier == nil || r.Ctx.Get(frontierContextKey) == "" {
+		return
+	}
+	entry := FrontierEntry{URL: r.Ctx.Get(frontierContextKey), Seed: requestSeed(r), Depth: requestDepth(r)}
+	if err := crawler.frontier.Done(context.Background(), entry); err != nil {
+		Logger.Errorf("Failed to acknowledge %s to frontier: %s", r.URL, err)
+	}
+}
+
+// drainFrontier requests the frontier entries until no entry is left to process by any crawler
+func (crawler *Crawler) drainFrontier(ctx context.Context, c *colly.Collector, errC chan<- error) {
+	for ctx.Err() == nil && !crawler.control.isStopped() {
+		entry, ok, err := crawler.frontier.Pop(ctx)
+		if err != nil {
+			crawler.handleError(errC, err)
+		}
+		if ok {
+			if err := crawler.request(c, entry.URL, entry.Seed, entry.Depth, true); err != nil {
+				crawler.frontier.Done(ctx, entry)
+			}
+			continue
+		}
+		if err == nil {
+			pending, err := crawler.frontier.Pending(ctx)
+			if err != nil {
+				crawler.handleError(errC, fmt.Errorf("failed to read frontier: %w", err))
+			} else if pending <= 0 {
+				return
+			}
+		}
+		select {
+		case <-ctx.Done():
+		case <-crawler.control.stopC:
+		case <-time.After(frontierPollInterval):
+		}
+	}
+}
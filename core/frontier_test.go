@@ -0,0 +1,125 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestDistributedFrontier(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	lock := sync.Mutex{}
+	requests := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests[r.URL.Path]++
+		lock.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><a href="/a">a</a><a href="/b">b</a><a href="/c">c</a></body></html>`)
+	}))
+	defer ts.Close()
+
+	wg := sync.WaitGroup{}
+	reports := make([][]SpiderReport, 2)
+	for i := range reports {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			crawler := NewCrawler(WithDefaultColly(3), WithDistributedFrontier(&redis.Options{Addr: mr.Addr()}, "test"))
+			reports[i] = collectReports(crawler, ts.URL)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, path := range []string{"/", "/a", "/b", "/c"} {
+		if requests[path] != 1 {
+			t.Errorf("expected %s to be requested once across crawlers, got %d", path, requests[path])
+		}
+	}
+	seen := map[string]int{}
+	for _, r := range append(reports[0], reports[1]...) {
+		seen[r.Output]++
+	}
+	for output, count := range seen {
+		if count > 1 {
+			t.Errorf("expected %s to be reported once across crawlers, got %d", output, count)
+		}
+	}
+	if pending, _ := NewRedisFrontier(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "test").Pending(context.Background()); pending != 0 {
+		t.Errorf("expected drained frontier, got %d pending", pending)
+	}
+}
+
+func TestFrontierAbortedRequests(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><a href="/item/1">1</a><a href="/item/2">2</a><a href="/item/3">3</a></body></html>`)
+	}))
+	defer ts.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		crawler := NewCrawler(WithDefaultColly(3), WithDistributedFrontier(&redis.Options{Addr: mr.Addr()}, "test"), WithCollyConfig(WithPatternVisitCap(1)))
+		collectReports(crawler, ts.URL)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the crawl to end with the requests aborted by the pattern cap acknowledged")
+	}
+	if pending, _ := NewRedisFrontier(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "test").Pending(context.Background()); pending != 0 {
+		t.Errorf("expected drained frontier, got %d pending", pending)
+	}
+}
+
+func TestRedisFrontierLease(t *testing.T) {
+	mr := miniredis.RunT(t)
+	lease := RedisFrontierLease
+	RedisFrontierLease = 100 * time.Millisecond
+	defer func() { RedisFrontierLease = lease }()
+
+	ctx := context.Background()
+	crashed := NewRedisFrontier(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "test")
+	alive := NewRedisFrontier(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "test")
+	if err := crashed.Push(ctx, FrontierEntry{URL: "https://example.com/"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := crashed.Pop(ctx); !ok || err != nil {
+		t.Fatalf("expected an entry, got %v %v", ok, err)
+	}
+	if _, ok, _ := alive.Pop(ctx); ok {
+		t.Fatal("expected the entry to be kept for the instance which popped it")
+	}
+
+	time.Sleep(2 * RedisFrontierLease)
+	entry, ok, err := alive.Pop(ctx)
+	if !ok || err != nil || entry.URL != "https://example.com/" {
+		t.Fatalf("expected the entry of the crashed instance to be requeued, got %+v %v %v", entry, ok, err)
+	}
+	if err := alive.Done(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+	// the late acknowledgement of the requeued entry isn't counted twice
+	crashed.Done(ctx, entry)
+	if pending, _ := alive.Pending(ctx); pending != 0 {
+		t.Errorf("expected drained frontier, got %d pending", pending)
+	}
+
+	if alive.Duplicate("output") || alive.Err() != nil {
+		t.Error("expected a new output")
+	}
+	mr.Close()
+	if alive.Duplicate("output") || alive.Err() == nil {
+		t.Error("expected the redis failure to be reported")
+	}
+}
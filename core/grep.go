@@ -7,7 +7,18 @@ import (
 
 const SUBRE = `(?i)(([a-zA-Z0-9]{1}|[_a-zA-Z0-9]{1}[_a-zA-Z0-9-]{0,61}[a-zA-Z0-9]{1})[.]{1})+`
 
-var AWSS3 = regexp.MustCompile(`(?i)[a-z0-9.-]+\.s3\.amazonaws\.com|[a-z0-9.-]+\.s3-[a-z0-9-]\.amazonaws\.com|[a-z0-9.-]+\.s3-website[.-](eu|ap|us|ca|sa|cn)|//s3\.amazonaws\.com/[a-z0-9._-]+|//s3-[a-z0-9-]+\.amazonaws\.com/[a-z0-9._-]+`)
+var AWSS3 = regexp.MustCompile(`(?i)[a-z0-9.-]+\.s3\.amazonaws\.com|[a-z0-9.-]+\.s3[.-][a-z0-9-]+\.amazonaws\.com|[a-z0-9.-]+\.s3-website[.-](eu|ap|us|ca|sa|cn)|//s3\.amazonaws\.com/[a-z0-9._-]+|//s3-[a-z0-9-]+\.amazonaws\.com/[a-z0-9._-]+`)
+
+var (
+	GCPStorage   = regexp.MustCompile(`(?i)[a-z0-9._-]+\.storage\.googleapis\.com|//storage\.(?:googleapis|cloud\.google)\.com/[a-z0-9._-]+|gs://[a-z0-9._-]+`)
+	AzureBlob    = regexp.MustCompile(`(?i)[a-z0-9]{3,24}\.blob\.core\.windows\.net(?:/[a-z0-9-]{3,63})?`)
+	DOSpaces     = regexp.MustCompile(`(?i)[a-z0-9.-]+\.[a-z]{3}[0-9]\.(?:cdn\.)?digitaloceanspaces\.com|//[a-z]{3}[0-9]\.digitaloceanspaces\.com/[a-z0-9._-]+`)
+	AlibabaOSS   = regexp.MustCompile(`(?i)[a-z0-9.-]+\.oss(?:-accelerate)?(?:-[a-z0-9-]+)?\.aliyuncs\.com`)
+	cloudStorage = []struct {
+		outputType OutputType
+		re         *regexp.Regexp
+	}{{S3, AWSS3}, {GCPBucket, GCPStorage}, {AzureContainer, AzureBlob}, {DOSpace, DOSpaces}, {OSSBucket, AlibabaOSS}}
+)
 
 // SubdomainRegex returns a Regexp object initialized to match
 // subdomain names that end with the domain provided by the parameter.
@@ -33,3 +44,20 @@ func GetAWSS3(source string) []string {
 	}
 	return aws
 }
+
+// CloudStorageURL is a cloud storage url, OutputType is its provider
+type CloudStorageURL struct {
+	URL        string
+	OutputType OutputType
+}
+
+// GetCloudStorage returns the AWS S3, Google Cloud Storage, Azure Blob, DigitalOcean Spaces and Alibaba OSS urls of source
+func GetCloudStorage(source string) []CloudStorageURL {
+	var res []CloudStorageURL
+	for _, storage := range cloudStorage {
+		for _, match := range storage.re.FindAllString(source, -1) {
+			res = append(res, CloudStorageURL{URL: DecodeChars(match), OutputType: storage.outputType})
+		}
+	}
+	return res
+}
@@ -0,0 +1,24 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetCloudStorage(t *testing.T) {
+	source := `<img src="https://assets.s3.eu-west-1.amazonaws.com/logo.png">
+<script src="https://storage.googleapis.com/static-bucket/app.js"></script>
+<a href="https://backups.blob.core.windows.net/exports/db.sql">db</a>
+fetch("https://media.nyc3.digitaloceanspaces.com/x.jpg")
+var oss = "https://files.oss-cn-hangzhou.aliyuncs.com/doc.pdf"`
+	expected := []CloudStorageURL{
+		{URL: "assets.s3.eu-west-1.amazonaws.com", OutputType: S3},
+		{URL: "//storage.googleapis.com/static-bucket", OutputType: GCPBucket},
+		{URL: "backups.blob.core.windows.net/exports", OutputType: AzureContainer},
+		{URL: "media.nyc3.digitaloceanspaces.com", OutputType: DOSpace},
+		{URL: "files.oss-cn-hangzhou.aliyuncs.com", OutputType: OSSBucket},
+	}
+	if found := GetCloudStorage(source); !reflect.DeepEqual(found, expected) {
+		t.Errorf("expected %+v, got %+v", expected, found)
+	}
+}
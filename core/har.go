@@ -0,0 +1,220 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// harFile is an HTTP Archive, see http://www.softwareishard.com/blog/har-12-spec/
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+	// Comment holds the error of the failed requests
+	Comment string `json:"comment,omitempty"`
+}
+
+type harCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// harTimings are in milliseconds, -1 when not applicable
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+func (cookie harCookie) httpCookie() *http.Cookie {
+	c := &http.Cookie{
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		Path:     cookie.Path,
+		Domain:   cookie.Domain,
+		HttpOnly: cookie.HTTPOnly,
+		Secure:   cookie.Secure,
+	}
+	if expires, err := time.Parse(time.RFC3339, cookie.Expires); err == nil {
+		c.Expires = expires
+	}
+	return c
+}
+
+// isHARSessionHeader reports whether the request header name carries the session, e.g an API key or a CSRF token.
+// The other headers, e.g Referer or User-Agent, belong to the browser which recorded the HAR
+func isHARSessionHeader(name string) bool {
+	return name == "Authorization" || strings.HasPrefix(name, "X-")
+}
+
+// harSession is the session recorded in a HAR: the urls to seed and the session headers of each host
+type harSession struct {
+	seeds   []string
+	headers map[string]http.Header
+}
+
+// importHAR sets the cookies of the entries of path in jar, the latest ones winning, and returns its session
+func importHAR(path string, jar http.CookieJar) (*harSession, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open HAR file: %w", err)
+	}
+	har := harFile{}
+	if err := json.Unmarshal(raw, &har); err != nil {
+		return nil, fmt.Errorf("failed to parse HAR file %s: %w", path, err)
+	}
+	session := &harSession{headers: make(map[string]http.Header)}
+	seen := make(map[string]bool)
+	for _, entry := range har.Log.Entries {
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		u.Fragment = ""
+		if entry.Request.Method == http.MethodGet && !seen[u.String()] {
+			seen[u.String()] = true
+			session.seeds = append(session.seeds, u.String())
+		}
+
+		header := http.Header{}
+		for _, h := range entry.Request.Headers {
+			// HTTP/2 pseudo headers, e.g :authority
+			if !strings.HasPrefix(h.Name, ":") {
+				header.Add(h.Name, h.Value)
+			}
+		}
+		for name, values := range header {
+			if isHARSessionHeader(name) {
+				if session.headers[u.Host] == nil {
+					session.headers[u.Host] = http.Header{}
+				}
+				session.headers[u.Host].Set(name, values[0])
+			}
+		}
+
+		cookies := make([]*http.Cookie, 0, len(entry.Request.Cookies))
+		for _, cookie := range entry.Request.Cookies {
+			cookies = append(cookies, &http.Cookie{Name: cookie.Name, Value: cookie.Value, Path: "/"})
+		}
+		if len(cookies) == 0 {
+			for _, cookie := range (&http.Request{Header: header}).Cookies() {
+				cookie.Path = "/"
+				cookies = append(cookies, cookie)
+			}
+		}
+		for _, cookie := range entry.Response.Cookies {
+			cookies = append(cookies, cookie.httpCookie())
+		}
+		if len(cookies) > 0 {
+			jar.SetCookies(u, cookies)
+		}
+	}
+	return session, nil
+}
+
+// importHAR loads the session of the HAR file in the collector and seeds its urls
+func (crawler *Crawler) importHAR(c *colly.Collector) error {
+	session, err := importHAR(crawler.harImport, crawler.cookieJar(c))
+	if err != nil {
+		return err
+	}
+	c.OnRequest(func(r *colly.Request) {
+		for name, values := range session.headers[r.URL.Host] {
+			r.Headers.Set(name, values[0])
+		}
+	})
+	Logger.Infof("Imported %d urls from %s", len(session.seeds), crawler.harImport)
+	for _, seed := range session.seeds {
+		if crawler.control.isStopped() {
+			break
+		}
+		crawler.visit(c, seed, seed)
+	}
+	return nil
+}
+
+// WithHARImport seeds the urls of the GET requests recorded in the HAR file at path, e.g exported from the browser
+// devtools, and reuses its session: the cookies land in the cookie jar, and the Authorization and X- headers are set on
+// the requests to the host they were recorded for. The requests with other methods aren't replayed as they may change
+// the state of the target
+func WithHARImport(path string) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.harImport = NormalizePath(path)
+	}
+}
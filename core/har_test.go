@@ -0,0 +1,62 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestHARImport(t *testing.T) {
+	var lock sync.Mutex
+	seen := map[string]string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		seen[r.Method+" "+r.URL.Path] = fmt.Sprintf("session=%s token=%s key=%s referer=%s",
+			cookieValue(r, "session"), cookieValue(r, "token"), r.Header.Get("X-Api-Key"), r.Header.Get("Referer"))
+		lock.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/page" {
+			fmt.Fprint(w, `<html><body><a href="/next">next</a></body></html>`)
+			return
+		}
+		fmt.Fprint(w, `<html><body>leaf</body></html>`)
+	}))
+	defer ts.Close()
+
+	har := harFile{Log: harLog{Version: "1.2", Entries: []harEntry{
+		{Request: harRequest{Method: "GET", URL: ts.URL + "/page#top",
+			Cookies: []harCookie{{Name: "session", Value: "abc"}},
+			Headers: []harNameValue{{Name: ":authority", Value: "localhost"}, {Name: "x-api-key", Value: "k3y"}, {Name: "referer", Value: "https://browser.test/"}}}},
+		{Request: harRequest{Method: "POST", URL: ts.URL + "/login"},
+			Response: harResponse{Status: 302, Cookies: []harCookie{{Name: "token", Value: "t0k3n", Path: "/"}}}},
+		{Request: harRequest{Method: "GET", URL: "https://other.test/", Headers: []harNameValue{{Name: "Authorization", Value: "Bearer other"}}}},
+	}}}
+	raw, err := json.Marshal(har)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "session.har")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	collectReports(NewCrawler(WithDefaultColly(2), WithHARImport(path)))
+
+	expected := map[string]string{
+		"GET /page": "session=abc token=t0k3n key=k3y referer=",
+		"GET /next": "session=abc token=t0k3n key=k3y referer=",
+	}
+	for request, want := range expected {
+		if seen[request] != want {
+			t.Errorf("expected %q for %s, got %q", want, request, seen[request])
+		}
+	}
+	if _, ok := seen["POST /login"]; ok {
+		t.Error("expected the POST request not to be replayed")
+	}
+}
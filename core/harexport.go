@@ -0,0 +1,313 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// harWriter streams the entries of a HAR file, so the traffic of a crawl isn't kept in memory
+type harWriter struct {
+	path   string
+	bodies bool
+
+	lock    sync.Mutex
+	file    *os.File
+	w       *bufio.Writer
+	entries int
+	err     error
+}
+
+func newHARWriter(path string, bodies bool) (*harWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HAR file: %w", err)
+	}
+	creator, _ := json.Marshal(harCreator{Name: CLIName, Version: VERSION})
+	hw := &harWriter{path: path, bodies: bodies, file: file, w: bufio.NewWriter(file)}
+	_, hw.err = fmt.Fprintf(hw.w, `{"log":{"version":"1.2","creator":%s,"entries":[`, creator)
+	return hw, nil
+}
+
+func (hw *harWriter) add(entry harEntry) {
+	raw, err := json.Marshal(entry)
+	hw.lock.Lock()
+	defer hw.lock.Unlock()
+	if hw.err != nil {
+		return
+	}
+	if err != nil {
+		hw.err = err
+		return
+	}
+	if hw.entries > 0 {
+		hw.w.WriteString(",")
+	}
+	hw.w.WriteString("\n")
+	_, hw.err = hw.w.Write(raw)
+	hw.entries++
+}
+
+// close terminates the HAR file, reporting the first write error
+func (hw *harWriter) close() error {
+	hw.lock.Lock()
+	defer hw.lock.Unlock()
+	if hw.err == nil {
+		_, hw.err = hw.w.WriteString("\n]}}\n")
+	}
+	if hw.err == nil {
+		hw.err = hw.w.Flush()
+	}
+	if err := hw.file.Close(); hw.err == nil {
+		hw.err = err
+	}
+	if hw.err != nil {
+		return fmt.Errorf("failed to write HAR file %s: %w", hw.path, hw.err)
+	}
+	return nil
+}
+
+// harTrace records the timings of a request
+type harTrace struct {
+	lock                                          sync.Mutex
+	start, dnsStart, dnsDone, connectStart        time.Time
+	connectDone, tlsStart, tlsDone, wrote, header time.Time
+	remoteAddr                                    string
+}
+
+func (ht *harTrace) set(t *time.Time) {
+	ht.lock.Lock()
+	defer ht.lock.Unlock()
+	if t.IsZero() {
+		*t = time.Now()
+	}
+}
+
+func (ht *harTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { ht.set(&ht.dnsStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { ht.set(&ht.dnsDone) },
+		ConnectStart:      func(string, string) { ht.set(&ht.connectStart) },
+		ConnectDone:       func(string, string, error) { ht.set(&ht.connectDone) },
+		TLSHandshakeStart: func() { ht.set(&ht.tlsStart) },
+		TLSHandshakeDone:  func(_ tls.ConnectionState, _ error) { ht.set(&ht.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			ht.lock.Lock()
+			defer ht.lock.Unlock()
+			if addr, ok := info.Conn.RemoteAddr().(*net.TCPAddr); ok {
+				ht.remoteAddr = addr.IP.String()
+			}
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { ht.set(&ht.wrote) },
+		GotFirstResponseByte: func() { ht.set(&ht.header) },
+	}
+}
+
+// milliseconds returns the duration between from and to in milliseconds, -1 when one of them is unknown
+func milliseconds(from time.Time, to time.Time) float64 {
+	if from.IsZero() || to.IsZero() {
+		return -1
+	}
+	return float64(to.Sub(from)) / float64(time.Millisecond)
+}
+
+// timings returns the HAR timings of the request, the response being read at end
+func (ht *harTrace) timings(end time.Time) harTimings {
+	ht.lock.Lock()
+	defer ht.lock.Unlock()
+	sent := ht.start
+	if !ht.connectDone.IsZero() {
+		sent = ht.connectDone
+	}
+	if ht.tlsDone.After(sent) {
+		sent = ht.tlsDone
+	}
+	return harTimings{
+		Blocked: -1,
+		DNS:     milliseconds(ht.dnsStart, ht.dnsDone),
+		// the connect time includes the TLS handshake
+		Connect: milliseconds(ht.connectStart, sent),
+		SSL:     milliseconds(ht.tlsStart, ht.tlsDone),
+		Send:    max(milliseconds(sent, ht.wrote), 0),
+		Wait:    max(milliseconds(ht.wrote, ht.header), 0),
+		Receive: max(milliseconds(ht.header, end), 0),
+	}
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	headers := []harNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			headers = append(headers, harNameValue{Name: name, Value: value})
+		}
+	}
+	return headers
+}
+
+func harCookies(cookies []*http.Cookie) []harCookie {
+	res := make([]harCookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		hc := harCookie{Name: cookie.Name, Value: cookie.Value, Path: cookie.Path, Domain: cookie.Domain, HTTPOnly: cookie.HttpOnly, Secure: cookie.Secure}
+		if !cookie.Expires.IsZero() {
+			hc.Expires = cookie.Expires.UTC().Format(time.RFC3339)
+		}
+		res = append(res, hc)
+	}
+	return res
+}
+
+// harText returns the HAR text and encoding of body, base64 encoded when it isn't valid UTF-8
+func harText(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// harBody calls finish with the size of a response body, and its content when kept, once it is closed
+type harBody struct {
+	io.ReadCloser
+	size   int64
+	body   *bytes.Buffer
+	finish func(size int64, body []byte)
+	once   sync.Once
+}
+
+func (hb *harBody) Read(p []byte) (int, error) {
+	n, err := hb.ReadCloser.Read(p)
+	hb.size += int64(n)
+	if hb.body != nil {
+		hb.body.Write(p[:n])
+	}
+	return n, err
+}
+
+func (hb *harBody) Close() error {
+	err := hb.ReadCloser.Close()
+	hb.once.Do(func() {
+		var body []byte
+		if hb.body != nil {
+			body = hb.body.Bytes()
+		}
+		hb.finish(hb.size, body)
+	})
+	return err
+}
+
+// harTransport writes every request and its response to a HAR file
+type harTransport struct {
+	next   http.RoundTripper
+	writer *harWriter
+}
+
+func (ht *harTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := &harTrace{start: time.Now()}
+	traced := req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace()))
+	entry := harEntry{
+		StartedDateTime: trace.start,
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Cookies:     harCookies(req.Cookies()),
+			Headers:     harHeaders(req.Header),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    req.ContentLength,
+		},
+	}
+	if entry.Request.HTTPVersion == "" {
+		entry.Request.HTTPVersion = "HTTP/1.1"
+	}
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: name, Value: value})
+		}
+	}
+	if ht.writer.bodies && req.Body != nil && req.Body != http.NoBody {
+		payload, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		traced.Body = io.NopCloser(bytes.NewReader(payload))
+		text, _ := harText(payload)
+		entry.Request.PostData = &harPostData{MimeType: req.Header.Get("Content-Type"), Text: text}
+		entry.Request.BodySize = int64(len(payload))
+	}
+
+	resp, err := ht.next.RoundTrip(traced)
+	if err != nil {
+		entry.Response = harResponse{Cookies: []harCookie{}, Headers: []harNameValue{}, HeadersSize: -1, BodySize: -1, Comment: err.Error()}
+		entry.Timings = trace.timings(time.Now())
+		entry.Time = milliseconds(trace.start, time.Now())
+		ht.writer.add(entry)
+		return nil, err
+	}
+	entry.Response = harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Cookies:     harCookies(resp.Cookies()),
+		Headers:     harHeaders(resp.Header),
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+	}
+	body := &harBody{ReadCloser: resp.Body, finish: func(size int64, content []byte) {
+		end := time.Now()
+		entry.Response.BodySize = resp.ContentLength
+		if resp.ContentLength < 0 || resp.Uncompressed {
+			entry.Response.BodySize = size
+		}
+		entry.Response.Content = harContent{Size: size, MimeType: resp.Header.Get("Content-Type")}
+		if content != nil {
+			entry.Response.Content.Text, entry.Response.Content.Encoding = harText(content)
+		}
+		trace.lock.Lock()
+		entry.ServerIPAddress = trace.remoteAddr
+		trace.lock.Unlock()
+		entry.Timings = trace.timings(end)
+		entry.Time = milliseconds(trace.start, end)
+		ht.writer.add(entry)
+	}}
+	if ht.writer.bodies {
+		body.body = &bytes.Buffer{}
+	}
+	resp.Body = body
+	return resp, nil
+}
+
+// recordHAR opens the HAR file and wraps the transport of the collector so its traffic is recorded
+func (crawler *Crawler) recordHAR(c *colly.Collector) error {
+	writer, err := newHARWriter(crawler.harExport, crawler.harExportBodies)
+	if err != nil {
+		return err
+	}
+	crawler.harWriter = writer
+	crawler.wrapTransport(c, func(next http.RoundTripper) http.RoundTripper {
+		return &harTransport{next: next, writer: writer}
+	})
+	return nil
+}
+
+// WithHARExport writes every request of the crawl and its response, with their headers and timings, to a HAR file
+// which browser devtools, Burp or HAR analyzers can load. The request and response bodies are only written with bodies
+func WithHARExport(path string, bodies bool) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.harExport = NormalizePath(path)
+		crawler.harExportBodies = bodies
+	}
+}
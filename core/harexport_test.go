@@ -0,0 +1,49 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHARExport(t *testing.T) {
+	ts := newTestSite()
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "crawl.har")
+	collectReports(NewCrawler(WithDefaultColly(3), WithHARExport(path, true)), ts.URL+"/")
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	har := harFile{}
+	if err := json.Unmarshal(raw, &har); err != nil {
+		t.Fatalf("invalid HAR file: %s\n%s", err, raw)
+	}
+	if har.Log.Version != "1.2" || har.Log.Creator.Name != CLIName {
+		t.Errorf("unexpected HAR log %+v", har.Log)
+	}
+	entries := map[string]harEntry{}
+	for _, entry := range har.Log.Entries {
+		entries[entry.Request.URL] = entry
+	}
+	for _, path := range []string{"/", "/a", "/b"} {
+		entry, ok := entries[ts.URL+path]
+		if !ok {
+			t.Errorf("expected a HAR entry for %s", path)
+			continue
+		}
+		if entry.Request.Method != "GET" || entry.Response.Status != 200 || entry.ServerIPAddress != "127.0.0.1" {
+			t.Errorf("unexpected HAR entry for %s: %+v", path, entry)
+		}
+		if !strings.Contains(entry.Response.Content.Text, "<html>") || entry.Response.Content.Size != int64(len(entry.Response.Content.Text)) {
+			t.Errorf("expected the body of %s, got %+v", path, entry.Response.Content)
+		}
+		if entry.Timings.Wait < 0 || entry.Time <= 0 {
+			t.Errorf("unexpected timings for %s: %+v", path, entry.Timings)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// splitHostPort splits host into its hostname and its port, the port being empty when host has none
+func splitHostPort(host string) (string, string) {
+	if hostname, port, err := net.SplitHostPort(host); err == nil {
+		return hostname, port
+	}
+	return host, ""
+}
+
+func joinHostPort(hostname, port string) string {
+	if port == "" {
+		return hostname
+	}
+	return net.JoinHostPort(hostname, port)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// ASCIIHost returns host, with its port if any, with its internationalized labels in punycode: bücher.de gives
+// xn--bcher-kva.de. host is returned as is when it is not a valid domain name
+func ASCIIHost(host string) string {
+	if isASCII(host) {
+		return host
+	}
+	hostname, port := splitHostPort(host)
+	ascii, err := idna.Lookup.ToASCII(hostname)
+	if err != nil {
+		return host
+	}
+	return joinHostPort(ascii, port)
+}
+
+// UnicodeHost returns host, with its port if any, with its punycode labels decoded: xn--bcher-kva.de gives bücher.de.
+// host is returned as is when it is not a valid domain name
+func UnicodeHost(host string) string {
+	if !strings.Contains(strings.ToLower(host), "xn--") {
+		return host
+	}
+	hostname, port := splitHostPort(host)
+	unicode, err := idna.Display.ToUnicode(hostname)
+	if err != nil {
+		return host
+	}
+	return joinHostPort(unicode, port)
+}
+
+// PunycodeHost converts the internationalized host of u to punycode, so the unicode and the punycode forms of an url
+// are deduplicated and matched against the scope as the same url
+func PunycodeHost(u *url.URL) {
+	u.Host = ASCIIHost(u.Host)
+}
+
+// UnicodeURL returns u with its punycode host decoded, or an empty string when u has no punycode host
+func UnicodeURL(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
+	unicode := UnicodeHost(parsed.Host)
+	if unicode == parsed.Host {
+		return ""
+	}
+	scheme, rest, ok := strings.Cut(u, "://")
+	if !ok {
+		return ""
+	}
+	return scheme + "://" + strings.Replace(rest, parsed.Host, unicode, 1)
+}
+
+// withUnicodeURL adds the unicode form of the url of report in its Metadata, when its host is internationalized
+func (ov SpiderReport) withUnicodeURL() SpiderReport {
+	if !ov.OutputType.isURL() {
+		return ov
+	}
+	if unicode := UnicodeURL(ov.Output); unicode != "" {
+		return ov.WithMetadata("unicode_url", unicode)
+	}
+	return ov
+}
+
+// WithUnicodeURLs adds the unicode form of the internationalized urls in the "unicode_url" Metadata of their report,
+// the Output holding the punycode form
+func WithUnicodeURLs() CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.unicodeURLs = true
+	}
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func TestIDNHost(t *testing.T) {
+	tests := []struct {
+		unicode string
+		ascii   string
+	}{
+		{"bücher.de", "xn--bcher-kva.de"},
+		{"bücher.de:8080", "xn--bcher-kva.de:8080"},
+		{"例え.テスト", "xn--r8jz45g.xn--zckzah"},
+		{"example.com", "example.com"},
+		{"[::1]:80", "[::1]:80"},
+	}
+	for _, test := range tests {
+		if host := ASCIIHost(test.unicode); host != test.ascii {
+			t.Errorf("expected %s for %s, got %s", test.ascii, test.unicode, host)
+		}
+		if host := UnicodeHost(test.ascii); host != test.unicode {
+			t.Errorf("expected %s for %s, got %s", test.unicode, test.ascii, host)
+		}
+	}
+	if u := UnicodeURL("https://xn--bcher-kva.de/xn--a?q=1"); u != "https://bücher.de/xn--a?q=1" {
+		t.Errorf("expected the unicode url, got %s", u)
+	}
+	if u := UnicodeURL("https://example.com/"); u != "" {
+		t.Errorf("expected no unicode url for an ascii host, got %s", u)
+	}
+	if u := DefaultCanonicalizer.Canonicalize("HTTP://BÜCHER.de:80/"); u != "http://xn--bcher-kva.de/" {
+		t.Errorf("expected the punycode url, got %s", u)
+	}
+}
+
+func TestUnicodeURLs(t *testing.T) {
+	var lock sync.Mutex
+	visited := map[string]bool{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		visited[r.Host+r.URL.Path] = true
+		lock.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/" {
+			w.Write([]byte(`<html><body><a href="http://bücher.example/page">page</a></body></html>`))
+		}
+	}))
+	defer ts.Close()
+	tsURL, _ := url.Parse(ts.URL)
+	mapping := map[string]string{"xn--bcher-kva.example": tsURL.Host}
+
+	crawler := NewCrawler(WithDefaultColly(2), WithHTTPClientOpt(WithHostMapping(mapping)), WithUnicodeURLs())
+	found := false
+	for _, r := range collectReports(crawler, "http://xn--bcher-kva.example/") {
+		if r.OutputType == Ref {
+			found = true
+			if r.Output != "http://xn--bcher-kva.example/page" || r.Metadata["unicode_url"] != "http://bücher.example/page" {
+				t.Errorf("expected the link in punycode with its unicode form, got %s %v", r.Output, r.Metadata)
+			}
+		}
+	}
+	if !found {
+		t.Error("expected the link to be reported")
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if !visited["xn--bcher-kva.example/page"] {
+		t.Errorf("expected the link to be visited in punycode, got %v", visited)
+	}
+}
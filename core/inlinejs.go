@@ -0,0 +1,39 @@
+package core
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+var jsNavigationRE = regexp.MustCompile(`(?i)(?:location(?:\.href)?\s*=\s*|location\.(?:assign|replace)\s*\(\s*|window\.open\s*\(\s*|\.navigate\s*\(\s*)["'\x60]([^"'\x60]+)["'\x60]`)
+
+const inlineEventHandlerSelector = "[onclick], [ondblclick], [onmousedown], [onmouseup], [onmouseover], [onsubmit], [onchange], [onload], [onfocus], [onblur], [onkeydown], [onkeyup]"
+
+// JSNavigationTargets returns the urls a javascript snippet navigates to
+// (`location.href = ...`, `location.assign(...)`, `window.open(...)` and similar)
+func JSNavigationTargets(code string) []string {
+	targets := []string{}
+	for _, m := range jsNavigationRE.FindAllStringSubmatch(code, -1) {
+		target := strings.TrimSpace(m[1])
+		if target == "" || strings.HasPrefix(strings.ToLower(target), "javascript:") {
+			continue
+		}
+		targets = append(targets, target)
+	}
+	return Unique(targets)
+}
+
+// inlineEventHandlers returns the code of every `on*` attribute of e
+func inlineEventHandlers(e *colly.HTMLElement) []string {
+	res := []string{}
+	for _, node := range e.DOM.Nodes {
+		for _, attr := range node.Attr {
+			if strings.HasPrefix(strings.ToLower(attr.Key), "on") {
+				res = append(res, attr.Val)
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,30 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// JobError decorates the errors sent by the crawler with the job metadata set by WithJobMetadata
+type JobError struct {
+	Err      error
+	Metadata map[string]string
+}
+
+func (je *JobError) Error() string {
+	keys := make([]string, 0, len(je.Metadata))
+	for k := range je.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	meta := make([]string, 0, len(keys))
+	for _, k := range keys {
+		meta = append(meta, fmt.Sprintf("%s=%s", k, je.Metadata[k]))
+	}
+	return fmt.Sprintf("[%s] %s", strings.Join(meta, " "), je.Err)
+}
+
+func (je *JobError) Unwrap() error {
+	return je.Err
+}
@@ -0,0 +1,265 @@
+package core
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// jsRegexKeywords are the keywords after which a slash starts a regular expression literal
+var jsRegexKeywords = map[string]bool{
+	"return": true, "typeof": true, "case": true, "do": true, "else": true, "in": true, "instanceof": true,
+	"new": true, "void": true, "delete": true, "throw": true, "yield": true, "await": true,
+}
+
+func isJSIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= utf8.RuneSelf
+}
+
+// unescapeJS decodes the escape sequences of the raw content of a javascript string literal
+func unescapeJS(raw string) string {
+	if !strings.Contains(raw, `\`) {
+		return raw
+	}
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '\\' || i+1 == len(raw) {
+			b.WriteByte(raw[i])
+			continue
+		}
+		i++
+		switch c := raw[i]; c {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		case '0':
+			b.WriteByte(0)
+		case '\n':
+			// line continuation
+		case 'x', 'u':
+			// skip is the length of the sequence after the x or the u
+			hex, skip := "", 0
+			switch {
+			case c == 'x' && i+2 < len(raw):
+				hex, skip = raw[i+1:i+3], 2
+			case c == 'u' && i+1 < len(raw) && raw[i+1] == '{':
+				if end := strings.IndexByte(raw[i:], '}'); end > 0 {
+					hex, skip = raw[i+2:i+end], end
+				}
+			case c == 'u' && i+4 < len(raw):
+				hex, skip = raw[i+1:i+5], 4
+			}
+			code, err := strconv.ParseUint(hex, 16, 32)
+			if hex == "" || err != nil {
+				b.WriteByte(c)
+				continue
+			}
+			b.WriteRune(rune(code))
+			i += skip
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// jsLiteralScanner extracts the string literals of a javascript source
+type jsLiteralScanner struct {
+	src string
+	i   int
+	res []string
+	// regexAllowed is set when a slash at the current position starts a regular expression literal
+	regexAllowed bool
+	// joinable is set when the last token is a literal, concat when it is followed by a +
+	joinable bool
+	concat   bool
+}
+
+// literal records a string literal, appended to the previous one when they are concatenated
+func (s *jsLiteralScanner) literal(value string, joinable bool) {
+	if s.concat && len(s.res) > 0 {
+		s.res[len(s.res)-1] += value
+	} else {
+		s.res = append(s.res, value)
+	}
+	s.joinable, s.concat, s.regexAllowed = joinable, false, false
+}
+
+// token records any other token, regexAllowed tells whether a slash may follow it as a regular expression literal
+func (s *jsLiteralScanner) token(regexAllowed bool) {
+	s.joinable, s.concat, s.regexAllowed = false, false, regexAllowed
+}
+
+// quoted returns the raw content of the string literal starting at s.i, which is moved after it
+func (s *jsLiteralScanner) quoted(quote byte) string {
+	start := s.i + 1
+	for s.i = start; s.i < len(s.src); s.i++ {
+		switch s.src[s.i] {
+		case '\\':
+			s.i++
+		case quote:
+			s.i++
+			return s.src[start : s.i-1]
+		case '\n':
+			if quote != '`' {
+				return s.src[start:s.i]
+			}
+		}
+	}
+	return s.src[start:]
+}
+
+// template records the static parts of the template literal starting at s.i
+func (s *jsLiteralScanner) template() {
+	parts := []string{}
+	start := s.i + 1
+	for s.i = start; s.i < len(s.src); s.i++ {
+		switch s.src[s.i] {
+		case '\\':
+			s.i++
+		case '`':
+			parts = append(parts, s.src[start:s.i])
+			s.i++
+			s.templateParts(parts)
+			return
+		case '$':
+			if s.i+1 < len(s.src) && s.src[s.i+1] == '{' {
+				parts = append(parts, s.src[start:s.i])
+				depth := 0
+				for ; s.i < len(s.src); s.i++ {
+					if s.src[s.i] == '{' {
+						depth++
+					} else if s.src[s.i] == '}' {
+						if depth--; depth == 0 {
+							break
+						}
+					}
+				}
+				start = s.i + 1
+			}
+		}
+	}
+	s.templateParts(append(parts, s.src[min(start, len(s.src)):]))
+}
+
+func (s *jsLiteralScanner) templateParts(parts []string) {
+	if len(parts) == 1 {
+		s.literal(unescapeJS(parts[0]), true)
+		return
+	}
+	for _, part := range parts {
+		if part != "" {
+			s.literal(unescapeJS(part), false)
+		}
+	}
+	s.token(false)
+}
+
+// skipRegex moves s.i after the regular expression literal starting at s.i
+func (s *jsLiteralScanner) skipRegex() {
+	inClass := false
+	for s.i++; s.i < len(s.src); s.i++ {
+		switch s.src[s.i] {
+		case '\\':
+			s.i++
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '\n':
+			return
+		case '/':
+			if !inClass {
+				for s.i++; s.i < len(s.src) && isJSIdentByte(s.src[s.i]); s.i++ {
+				}
+				return
+			}
+		}
+	}
+}
+
+func (s *jsLiteralScanner) scan() []string {
+	s.regexAllowed = true
+	for s.i < len(s.src) {
+		c := s.src[s.i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			s.i++
+		case c == '/' && strings.HasPrefix(s.src[s.i:], "//"):
+			if end := strings.IndexByte(s.src[s.i:], '\n'); end >= 0 {
+				s.i += end
+			} else {
+				s.i = len(s.src)
+			}
+		case c == '/' && strings.HasPrefix(s.src[s.i:], "/*"):
+			if end := strings.Index(s.src[s.i+2:], "*/"); end >= 0 {
+				s.i += end + 4
+			} else {
+				s.i = len(s.src)
+			}
+		case c == '/' && s.regexAllowed:
+			s.skipRegex()
+			s.token(false)
+		case c == '"' || c == '\'':
+			s.literal(unescapeJS(s.quoted(c)), true)
+		case c == '`':
+			s.template()
+		case c == '+' && !strings.HasPrefix(s.src[s.i:], "++") && !strings.HasPrefix(s.src[s.i:], "+="):
+			concat := s.joinable
+			s.i++
+			s.token(true)
+			s.concat = concat
+		case isJSIdentByte(c):
+			start := s.i
+			for s.i < len(s.src) && isJSIdentByte(s.src[s.i]) {
+				s.i++
+			}
+			s.token(jsRegexKeywords[s.src[start:s.i]])
+		default:
+			s.i++
+			s.token(c != ')' && c != ']' && c != '}')
+		}
+	}
+	return s.res
+}
+
+// JSStringLiterals returns the string literals of a javascript source, minified or not, with their escape sequences
+// decoded and the literals concatenated with + joined ("/api/" + "users" gives /api/users).
+// The static parts of the template literals are returned separately
+func JSStringLiterals(source string) []string {
+	s := &jsLiteralScanner{src: source}
+	return Unique(s.scan())
+}
+
+// jsLiteralsSource returns source followed by its string literals, one quoted literal per line, so that LinkFinder
+// finds the endpoints built by concatenation or hidden behind escape sequences
+func jsLiteralsSource(source string) string {
+	var b strings.Builder
+	b.WriteString(source)
+	for _, literal := range JSStringLiterals(source) {
+		if strings.ContainsAny(literal, "\"\n") {
+			continue
+		}
+		b.WriteString("\n\"")
+		b.WriteString(literal)
+		b.WriteString("\"")
+	}
+	return b.String()
+}
+
+// WithJSLiterals extracts the string literals of the scripts before running LinkFinder on them (see WithLinkFinder),
+// so the endpoints split across concatenations or escaped in minified bundles are still found
+func WithJSLiterals() CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.jsLiterals = true
+	}
+}
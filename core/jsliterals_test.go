@@ -0,0 +1,62 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestJSStringLiterals(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected []string
+	}{
+		{`var u="/api/"+"users";`, []string{"/api/users"}},
+		{`fetch("\/admin/panel")`, []string{"/admin/panel"}},
+		{`a='x'+b+'y'`, []string{"x", "y"}},
+		{`var r=/"[a-z]+'/g,s="/ok"`, []string{"/ok"}},
+		{"x=a/2/\"/div\"", []string{"/div"}},
+		{"f(`/v1/items/${id}/details`)", []string{"/v1/items/", "/details"}},
+		{"// \"/commented\"\n/* '/block' */ g('/live')", []string{"/live"}},
+		{`i++ + "/p"`, []string{"/p"}},
+	}
+	for _, test := range tests {
+		if literals := JSStringLiterals(test.source); !reflect.DeepEqual(literals, test.expected) {
+			t.Errorf("expected %q for %s, got %q", test.expected, test.source, literals)
+		}
+	}
+}
+
+func TestJSLiteralsLinkFinder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><body><script src="/static/app.min.js"></script></body></html>`)
+		case "/static/app.min.js":
+			w.Header().Set("Content-Type", "application/javascript")
+			fmt.Fprint(w, `!function(){var b="/api/"+"v2/"+"accounts.json";fetch("\/internal\/health.php")}();`)
+		}
+	}))
+	defer ts.Close()
+
+	for _, enabled := range []bool{false, true} {
+		opts := []CrawlerOption{WithDefaultColly(2), WithLinkFinder()}
+		if enabled {
+			opts = append(opts, WithJSLiterals())
+		}
+		found := map[string]bool{}
+		for _, r := range collectReports(NewCrawler(opts...), ts.URL) {
+			if r.OutputType == LinkFinderOutput {
+				found[r.Output] = true
+			}
+		}
+		for _, u := range []string{ts.URL + "/api/v2/accounts.json", ts.URL + "/internal/health.php"} {
+			if found[u] != enabled {
+				t.Errorf("expected %s to be found %t with literals extraction %t, got %v", u, enabled, enabled, found)
+			}
+		}
+	}
+}
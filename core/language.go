@@ -0,0 +1,111 @@
+package core
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	htmlLangRE = regexp.MustCompile(`(?i)<html[^>]*\slang\s*=\s*["']?([a-zA-Z]{2,3}(?:[-_][a-zA-Z0-9]{2,8})?)`)
+	scriptRE   = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>`)
+	tagRE      = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+var languageStopwords = []struct {
+	lang  string
+	words []string
+}{
+	{"en", []string{"the", "and", "of", "to", "is", "in", "that", "for", "with", "you", "this", "are"}},
+	{"fr", []string{"le", "la", "les", "et", "des", "est", "une", "pour", "dans", "que", "vous", "avec"}},
+	{"de", []string{"der", "die", "und", "das", "ist", "nicht", "mit", "sie", "ein", "eine", "für", "auf"}},
+	{"es", []string{"el", "los", "las", "y", "del", "es", "una", "para", "con", "que", "por", "como"}},
+	{"it", []string{"il", "gli", "e", "di", "che", "è", "una", "per", "con", "non", "sono", "della"}},
+	{"pt", []string{"os", "as", "e", "do", "da", "não", "uma", "para", "com", "que", "são", "você"}},
+	{"nl", []string{"de", "het", "en", "van", "een", "is", "niet", "met", "voor", "zijn", "je", "op"}},
+}
+
+var languageScripts = []struct {
+	lang  string
+	table *unicode.RangeTable
+}{
+	{"ja", unicode.Hiragana},
+	{"ja", unicode.Katakana},
+	{"ko", unicode.Hangul},
+	{"zh", unicode.Han},
+	{"ru", unicode.Cyrillic},
+	{"ar", unicode.Arabic},
+	{"he", unicode.Hebrew},
+	{"el", unicode.Greek},
+	{"th", unicode.Thai},
+}
+
+// DetectLanguage returns the language code of an HTML page.
+// The `lang` attribute of the `<html>` tag wins when present, otherwise the language is guessed from the visible text.
+// Returns an empty string when no language could be determined
+func DetectLanguage(body string) string {
+	if m := htmlLangRE.FindStringSubmatch(body); m != nil {
+		return strings.ToLower(strings.ReplaceAll(m[1], "_", "-"))
+	}
+	text := tagRE.ReplaceAllString(scriptRE.ReplaceAllString(body, " "), " ")
+	if lang := detectScriptLanguage(text); lang != "" {
+		return lang
+	}
+	return detectStopwordsLanguage(text)
+}
+
+func detectScriptLanguage(text string) string {
+	counts := make(map[string]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, s := range languageScripts {
+			if unicode.Is(s.table, r) {
+				counts[s.lang]++
+				break
+			}
+		}
+	}
+	// kana is specific to japanese while han is shared with chinese
+	if counts["ja"] > 0 {
+		counts["ja"] += counts["zh"]
+		delete(counts, "zh")
+	}
+	best, bestCount := "", 0
+	for _, s := range languageScripts {
+		if count := counts[s.lang]; count > bestCount {
+			best, bestCount = s.lang, count
+		}
+	}
+	if letters == 0 || bestCount*3 < letters {
+		return ""
+	}
+	return best
+}
+
+func detectStopwordsLanguage(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	seen := make(map[string]int, len(words))
+	for _, w := range words {
+		seen[w]++
+	}
+	best, bestScore := "", 0
+	for _, l := range languageStopwords {
+		score := 0
+		for _, sw := range l.words {
+			score += seen[sw]
+		}
+		if score > bestScore {
+			best, bestScore = l.lang, score
+		}
+	}
+	if bestScore < 3 {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,18 @@
+package core
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	cases := map[string]string{
+		`<html lang="fr-FR"><body>hello</body></html>`:                                         "fr-fr",
+		`<html><body><p>The quick fox and the dog is in the garden with you</p></body></html>`: "en",
+		`<html><body><p>Der Hund und die Katze ist nicht mit der Maus</p></body></html>`:       "de",
+		`<html><body><p>Привет, как дела?</p></body></html>`:                                   "ru",
+		`<html><body></body></html>`:                                                           "",
+	}
+	for body, expected := range cases {
+		if lang := DetectLanguage(body); lang != expected {
+			t.Errorf("DetectLanguage(%s) = %s, expected %s", body, lang, expected)
+		}
+	}
+}
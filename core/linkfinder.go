@@ -7,6 +7,16 @@ import (
 
 var linkFinderRegex = regexp.MustCompile(`(?:"|')(((?:[a-zA-Z]{1,10}://|//)[^"'/]{1,}\.[a-zA-Z]{2,}[^"']{0,})|((?:/|\.\./|\./)[^"'><,;| *()(%%$^/\\\[\]][^"'><,;|()]{1,})|([a-zA-Z0-9_\-/]{1,}/[a-zA-Z0-9_\-/]{1,}\.(?:[a-zA-Z]{1,4}|action)(?:[\?|#][^"|']{0,}|))|([a-zA-Z0-9_\-/]{1,}/[a-zA-Z0-9_\-/]{3,}(?:[\?|#][^"|']{0,}|))|([a-zA-Z0-9_\-]{1,}\.(?:php|asp|aspx|jsp|json|action|html|js|txt|xml)(?:[\?|#][^"|']{0,}|)))(?:"|')`)
 
+var linkFinderExtensions = map[string]bool{".js": true, ".json": true, ".map": true}
+
+// isLinkFinderTarget reports whether a response should be scanned by LinkFinder, based on its url extension or content type
+func isLinkFinderTarget(rawUrl string, contentType string) bool {
+	if linkFinderExtensions[GetExtType(rawUrl)] {
+		return true
+	}
+	return strings.Contains(contentType, "javascript") || strings.Contains(contentType, "json")
+}
+
 func LinkFinder(source string) ([]string, error) {
 	var links []string
 	// source = strings.ToLower(source)
@@ -1,6 +1,12 @@
 package core
 
-import "testing"
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
 
 func Test_ParseJSSource(t *testing.T) {
 	source := `
@@ -8,3 +14,51 @@ func Test_ParseJSSource(t *testing.T) {
 "https:\u002F\u002Fs.yimg.com\u002Fnq\u002Fstore-badges\u002F4\u002Fstore-badges\u002F"`
 	t.Log(LinkFinder(source))
 }
+
+func TestJSNavigationTargets(t *testing.T) {
+	code := `javascript:if(ok){window.location.href='/account/delete?id=1'};window.open("https://example.com/popup", "_blank");location.replace('/next')`
+	targets := JSNavigationTargets(code)
+	expected := []string{"/account/delete?id=1", "https://example.com/popup", "/next"}
+	if len(targets) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, targets)
+	}
+	for i := range expected {
+		if targets[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], targets[i])
+		}
+	}
+}
+
+func TestCrawlerLinkFinder(t *testing.T) {
+	var requested sync.Map
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested.Store(r.URL.Path, true)
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><body><script src="/static/app.js"></script></body></html>`)
+		case "/static/app.js":
+			w.Header().Set("Content-Type", "application/javascript")
+			fmt.Fprint(w, `fetch("/api/v1/users").then(r => r.json()); const orders = "/api/v1/orders?page=1";`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer ts.Close()
+
+	reports := collectReports(NewCrawler(WithDefaultColly(3), WithLinkFinder()), ts.URL)
+	linkfinder := map[string]bool{}
+	for _, r := range reports {
+		if r.OutputType == LinkFinderOutput {
+			linkfinder[r.Output] = true
+		}
+	}
+	for _, u := range []string{ts.URL + "/api/v1/users", ts.URL + "/api/v1/orders?page=1"} {
+		if !linkfinder[u] {
+			t.Errorf("expected linkfinder report for %s, got %v", u, linkfinder)
+		}
+	}
+	if _, ok := requested.Load("/api/v1/users"); !ok {
+		t.Errorf("expected linkfinder endpoint to be crawled")
+	}
+}
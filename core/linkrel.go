@@ -0,0 +1,76 @@
+package core
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// linkRelations are the <link> relations reported as Link, the other relations being reported by the [href] handler
+var linkRelations = map[string]bool{
+	"canonical":     true,
+	"alternate":     true,
+	"preload":       true,
+	"modulepreload": true,
+	"prefetch":      true,
+	"prerender":     true,
+	"next":          true,
+	"prev":          true,
+}
+
+// hostRelations are the <link> relations whose target is a host rather than a resource, reported as Domain
+var hostRelations = map[string]bool{
+	"dns-prefetch": true,
+	"preconnect":   true,
+}
+
+// LinkRelations splits the rel attribute of a <link> element into the relations reported as Link and the ones
+// reported as Domain
+func LinkRelations(rel string) ([]string, []string) {
+	links, hosts := []string{}, []string{}
+	for _, r := range strings.Fields(strings.ToLower(rel)) {
+		if linkRelations[r] {
+			links = append(links, r)
+		} else if hostRelations[r] {
+			hosts = append(hosts, r)
+		}
+	}
+	return links, hosts
+}
+
+// discoverLinkElement emits the target of a <link> element as a Link, with its relations, hreflang, as and type in
+// the report Metadata, or its host as a Domain for the preconnect and dns-prefetch hints
+func discoverLinkElement(emit func(SpiderReport), e *colly.HTMLElement) {
+	links, hosts := LinkRelations(e.Attr("rel"))
+	target := e.Request.AbsoluteURL(strings.TrimSpace(e.Attr("href")))
+	if target == "" {
+		return
+	}
+	if len(links) > 0 {
+		report := SpiderReport{
+			Output:     target,
+			OutputType: Link,
+			Source:     "body",
+			Input:      e.Request.URL,
+			Seed:       requestSeed(e.Request),
+		}.WithMetadata("rel", links)
+		for _, attr := range []string{"hreflang", "as", "type"} {
+			if value := strings.TrimSpace(e.Attr(attr)); value != "" {
+				report = report.WithMetadata(attr, value)
+			}
+		}
+		emit(report)
+	}
+	if len(hosts) > 0 {
+		if u, err := url.Parse(target); err == nil && u.Hostname() != "" {
+			emit(SpiderReport{
+				Output:     strings.ToLower(u.Hostname()),
+				OutputType: Domain,
+				Source:     hosts[0],
+				Input:      e.Request.URL,
+				Seed:       requestSeed(e.Request),
+			})
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLinkRelations(t *testing.T) {
+	links, hosts := LinkRelations("Alternate stylesheet preconnect")
+	if !reflect.DeepEqual(links, []string{"alternate"}) || !reflect.DeepEqual(hosts, []string{"preconnect"}) {
+		t.Errorf("expected the alternate link and the preconnect host, got %v and %v", links, hosts)
+	}
+}
+
+func TestLinkElements(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/" {
+			fmt.Fprint(w, `<html><head>
+<link rel="canonical" href="/home">
+<link rel="alternate" hreflang="fr" href="/fr/">
+<link rel="preload" as="script" href="/static/app.js">
+<link rel="stylesheet" href="/static/site.css">
+<link rel="preconnect" href="https://API.example.com">
+<link rel="dns-prefetch" href="//cdn.example.net">
+</head></html>`)
+		}
+	}))
+	defer ts.Close()
+
+	links := map[string]SpiderReport{}
+	domains := map[string]string{}
+	refs := []string{}
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(1)), ts.URL) {
+		switch r.OutputType {
+		case Link:
+			links[r.Output[len(ts.URL):]] = r
+		case Domain:
+			domains[r.Output] = r.Source
+		case Ref:
+			if strings.HasPrefix(r.Output, ts.URL) {
+				refs = append(refs, r.Output[len(ts.URL):])
+			}
+		}
+	}
+	if len(links) != 3 {
+		t.Fatalf("expected the canonical, alternate and preload links, got %v", links)
+	}
+	if fr := links["/fr/"]; fr.Metadata["hreflang"] != "fr" || !reflect.DeepEqual(fr.Metadata["rel"], []string{"alternate"}) {
+		t.Errorf("expected the alternate link with its hreflang, got %v", fr.Metadata)
+	}
+	if js := links["/static/app.js"]; js.Metadata["as"] != "script" {
+		t.Errorf("expected the preload link with its destination, got %v", js.Metadata)
+	}
+	if expected := map[string]string{"api.example.com": "preconnect", "cdn.example.net": "dns-prefetch"}; !reflect.DeepEqual(domains, expected) {
+		t.Errorf("expected the domains %v, got %v", expected, domains)
+	}
+	if !reflect.DeepEqual(refs, []string{"/static/site.css"}) {
+		t.Errorf("expected the stylesheet to stay a ref, got %v", refs)
+	}
+}
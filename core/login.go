@@ -0,0 +1,191 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+)
+
+// ErrLoginFailed is returned when the login flow doesn't meet its success conditions
+var ErrLoginFailed = errors.New("login failed")
+
+// LoginFlow describes the form login performed before crawling, see WithLoginFlow
+type LoginFlow struct {
+	// URL is the page of the login form. Its hidden fields, e.g CSRF tokens, are submitted along with Fields
+	URL string `yaml:"url" toml:"url" json:"url"`
+	// FormSelector selects the login form in the page, the first form holding a password field when empty
+	FormSelector string `yaml:"form_selector" toml:"form_selector" json:"form_selector,omitempty"`
+	// Action is the url the form is submitted to, the form action when empty
+	Action string `yaml:"action" toml:"action" json:"action,omitempty"`
+	// Fields are the submitted values, e.g the username and the password, overriding the ones of the form
+	Fields map[string]string `yaml:"fields" toml:"fields" json:"fields,omitempty"`
+	// SuccessStatus is the expected status of the response to the form, after redirects
+	SuccessStatus int `yaml:"success_status" toml:"success_status" json:"success_status,omitempty"`
+	// SuccessRegexp must match the body of the response to the form
+	SuccessRegexp string `yaml:"success_regexp" toml:"success_regexp" json:"success_regexp,omitempty"`
+	// SuccessCookie is a cookie the login must set
+	SuccessCookie string `yaml:"success_cookie" toml:"success_cookie" json:"success_cookie,omitempty"`
+}
+
+// loginForm returns the action, method and values of the login form of page
+func (flow *LoginFlow) loginForm(page *url.URL, body io.Reader) (*url.URL, string, url.Values, error) {
+	doc, err := goquery.NewDocumentFromReader(body)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	form := doc.Find("form:has(input[type=password])").First()
+	if flow.FormSelector != "" {
+		form = doc.Find(flow.FormSelector).First()
+	}
+	values := url.Values{}
+	action := page
+	method := http.MethodPost
+	if form.Length() > 0 {
+		form.Find("input[name], textarea[name], select[name]").Each(func(_ int, field *goquery.Selection) {
+			name, _ := field.Attr("name")
+			switch goquery.NodeName(field) {
+			case "select":
+				option := field.Find("option[selected]").First()
+				if option.Length() == 0 {
+					option = field.Find("option").First()
+				}
+				value, ok := option.Attr("value")
+				if !ok {
+					value = strings.TrimSpace(option.Text())
+				}
+				values.Set(name, value)
+			case "textarea":
+				values.Set(name, field.Text())
+			default:
+				inputType := strings.ToLower(field.AttrOr("type", "text"))
+				switch inputType {
+				case "submit", "button", "image", "reset", "file":
+					return
+				case "checkbox", "radio":
+					if _, checked := field.Attr("checked"); !checked {
+						return
+					}
+				}
+				values.Set(name, field.AttrOr("value", ""))
+			}
+		})
+		if formAction, ok := form.Attr("action"); ok && formAction != "" {
+			if action, err = page.Parse(formAction); err != nil {
+				return nil, "", nil, fmt.Errorf("invalid login form action %s: %w", formAction, err)
+			}
+		}
+		if formMethod, ok := form.Attr("method"); ok && strings.EqualFold(formMethod, http.MethodGet) {
+			method = http.MethodGet
+		}
+	} else if flow.Action == "" {
+		return nil, "", nil, fmt.Errorf("no login form found in %s", page)
+	}
+	if flow.Action != "" {
+		if action, err = page.Parse(flow.Action); err != nil {
+			return nil, "", nil, fmt.Errorf("invalid login action %s: %w", flow.Action, err)
+		}
+	}
+	for name, value := range flow.Fields {
+		values.Set(name, value)
+	}
+	return action, method, values, nil
+}
+
+// run submits the login form with client, whose jar keeps the session cookies
+func (flow *LoginFlow) run(ctx context.Context, client *http.Client) error {
+	page, err := url.Parse(flow.URL)
+	if err != nil {
+		return fmt.Errorf("invalid login url %s: %w", flow.URL, err)
+	}
+	var successRegexp *regexp.Regexp
+	if flow.SuccessRegexp != "" {
+		if successRegexp, err = regexp.Compile(flow.SuccessRegexp); err != nil {
+			return fmt.Errorf("invalid login success regexp: %w", err)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, page.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch login page: %w", err)
+	}
+	action, method, values, err := flow.loginForm(resp.Request.URL, io.LimitReader(resp.Body, 5*1024*1024))
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	if method == http.MethodGet {
+		action.RawQuery = values.Encode()
+		req, err = http.NewRequestWithContext(ctx, method, action.String(), nil)
+	} else {
+		req, err = http.NewRequestWithContext(ctx, method, action.String(), strings.NewReader(values.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Referer", page.String())
+	resp, err = client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to submit login form: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 5*1024*1024))
+	if err != nil {
+		return fmt.Errorf("failed to read login response: %w", err)
+	}
+
+	switch {
+	case flow.SuccessStatus != 0 && resp.StatusCode != flow.SuccessStatus:
+		return fmt.Errorf("%w: status %d, expected %d", ErrLoginFailed, resp.StatusCode, flow.SuccessStatus)
+	case flow.SuccessStatus == 0 && resp.StatusCode >= 400:
+		return fmt.Errorf("%w: status %d", ErrLoginFailed, resp.StatusCode)
+	case successRegexp != nil && !successRegexp.Match(body):
+		return fmt.Errorf("%w: response doesn't match %s", ErrLoginFailed, flow.SuccessRegexp)
+	case flow.SuccessCookie != "" && !hasCookie(client.Jar, resp.Request.URL, flow.SuccessCookie):
+		return fmt.Errorf("%w: cookie %s not set", ErrLoginFailed, flow.SuccessCookie)
+	}
+	return nil
+}
+
+func hasCookie(jar http.CookieJar, u *url.URL, name string) bool {
+	if jar == nil {
+		return false
+	}
+	for _, cookie := range jar.Cookies(u) {
+		if cookie.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// login runs the login flow, the session cookies land in the cookie jar of the collector
+func (crawler *Crawler) login(ctx context.Context, c *colly.Collector) error {
+	crawler.cookieJar(c)
+	if err := crawler.loginFlow.run(ctx, crawler.helperClient(30*time.Second)); err != nil {
+		return err
+	}
+	Logger.Infof("Logged in through %s", crawler.loginFlow.URL)
+	return nil
+}
+
+// WithLoginFlow submits the login form described by flow before crawling,
+// the crawl is aborted when the login fails and otherwise runs with the session cookies
+func WithLoginFlow(flow LoginFlow) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.loginFlow = &flow
+	}
+}
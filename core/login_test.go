@@ -0,0 +1,72 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newLoginSite serves a login form protected by a CSRF token, and a private page only reachable with the session cookie
+func newLoginSite() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/login":
+			fmt.Fprint(w, `<html><body><form action="/session" method="post">
+				<input type="hidden" name="csrf" value="t0k3n">
+				<input name="user"><input type="password" name="password">
+				<input type="checkbox" name="remember"><input type="submit" name="go" value="Login">
+			</form></body></html>`)
+		case "/session":
+			r.ParseForm()
+			if r.PostForm.Get("csrf") != "t0k3n" || r.PostForm.Get("user") != "admin" || r.PostForm.Get("password") != "secret" ||
+				r.PostForm.Has("remember") || r.PostForm.Has("go") {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3ss10n", Path: "/"})
+			http.Redirect(w, r, "/home", http.StatusFound)
+		case "/home":
+			fmt.Fprint(w, `<html><body>Welcome admin</body></html>`)
+		case "/private":
+			if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "s3ss10n" {
+				http.Redirect(w, r, "/login", http.StatusFound)
+				return
+			}
+			fmt.Fprint(w, `<html><body><a href="/secret">secret</a></body></html>`)
+		default:
+			fmt.Fprint(w, `<html><body>leaf</body></html>`)
+		}
+	}))
+}
+
+func TestLoginFlow(t *testing.T) {
+	ts := newLoginSite()
+	defer ts.Close()
+
+	flow := LoginFlow{
+		URL:           ts.URL + "/login",
+		Fields:        map[string]string{"user": "admin", "password": "secret"},
+		SuccessRegexp: "Welcome",
+		SuccessCookie: "session",
+	}
+	found := false
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(2), WithLoginFlow(flow)), ts.URL+"/private") {
+		if strings.HasSuffix(r.Output, "/secret") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected the private page to be crawled with the session")
+	}
+
+	flow.Fields["password"] = "wrong"
+	err := flow.run(context.Background(), &http.Client{})
+	if !errors.Is(err, ErrLoginFailed) {
+		t.Errorf("expected a wrong password to fail the login, got %v", err)
+	}
+}
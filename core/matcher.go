@@ -0,0 +1,54 @@
+package core
+
+import (
+	"net/url"
+	"regexp"
+	"unicode/utf8"
+)
+
+type bodyMatcher struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// Match returns a `match` SpiderReport for every occurrence of the matcher regex in body.
+// When contextSize is positive, the reports carry the match offset and up to contextSize bytes around it
+func (bm bodyMatcher) Match(input *url.URL, body string, contextSize int) []SpiderReport {
+	res := []SpiderReport{}
+	for _, loc := range bm.re.FindAllStringIndex(body, -1) {
+		report := SpiderReport{
+			Output:     body[loc[0]:loc[1]],
+			OutputType: Match,
+			Source:     "body",
+			Matcher:    bm.name,
+			Input:      input,
+			dedupKey:   pageDedupKey(input, bm.name, body[loc[0]:loc[1]]),
+		}
+		if contextSize > 0 {
+			report.Offset = loc[0]
+			report.Snippet = Snippet(body, loc[0], loc[1], contextSize)
+		}
+		res = append(res, report)
+	}
+	return res
+}
+
+// Snippet returns body[start:end] surrounded by at most contextSize bytes on each side.
+// The bounds are moved to the nearest rune boundary so the snippet is always valid UTF-8
+func Snippet(body string, start int, end int, contextSize int) string {
+	from := start - contextSize
+	if from < 0 {
+		from = 0
+	}
+	to := end + contextSize
+	if to > len(body) {
+		to = len(body)
+	}
+	for from > 0 && !utf8.RuneStart(body[from]) {
+		from--
+	}
+	for to < len(body) && !utf8.RuneStart(body[to]) {
+		to++
+	}
+	return body[from:to]
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"net/url"
+	"regexp"
+	"testing"
+)
+
+func TestBodyMatcher(t *testing.T) {
+	ts := newTestSite()
+	defer ts.Close()
+
+	matches := 0
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(3), WithBodyMatcher("leaf", `l[a-z]+f`)), ts.URL) {
+		if r.OutputType == Match && r.Output == "leaf" && r.Matcher == "leaf" {
+			matches++
+		}
+	}
+	if matches != 1 {
+		t.Errorf("expected a match report for the leaf page, got %d", matches)
+	}
+
+	// the same value is reported for every page it matches in
+	pages := map[string]bool{}
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(3), WithBodyMatcher("body", `<body>`)), ts.URL) {
+		if r.OutputType == Match {
+			pages[r.Input.Path] = true
+		}
+	}
+	if len(pages) != 3 {
+		t.Errorf("expected a match report for each of the 3 pages, got %v", pages)
+	}
+
+	err := crawlError(t, NewCrawler(WithDefaultColly(3), WithBodyMatcher("broken", `(`)), ts.URL)
+	if err == nil {
+		t.Error("expected the invalid matcher to fail the crawl")
+	}
+}
+
+func TestBodyMatcherContext(t *testing.T) {
+	u, _ := url.Parse("https://example.com/app.js")
+	bm := bodyMatcher{name: "apikey", re: regexp.MustCompile(`key_[a-z0-9]+`)}
+	body := `var conf = {token: "key_abc123", debug: false};`
+	reports := bm.Match(u, body, 8)
+	if len(reports) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(reports))
+	}
+	r := reports[0]
+	if r.Output != "key_abc123" || r.Offset != 20 || r.Snippet != `token: "key_abc123", debug` {
+		t.Errorf("unexpected match report %+v", r)
+	}
+	if reports := bm.Match(u, body, 0); reports[0].Snippet != "" {
+		t.Errorf("expected no snippet without context size, got %s", reports[0].Snippet)
+	}
+}
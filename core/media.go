@@ -0,0 +1,67 @@
+package core
+
+import (
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// mediaSelector matches the elements referencing images and media outside of their src attribute: the responsive
+// images and the <picture> sources (srcset), the preloaded images (imagesrcset), the video posters and the lazy
+// loaded images (data-src, data-srcset)
+const mediaSelector = `[srcset], link[imagesrcset], video[poster], [data-src], [data-srcset]`
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// ParseSrcset returns the urls of the image candidates of a srcset attribute, following the HTML parsing rules so
+// the urls holding commas, frequent on the image CDNs, are kept whole: "a.jpg?w=1,2 1x, b.jpg 2x" gives a.jpg?w=1,2
+// and b.jpg
+func ParseSrcset(srcset string) []string {
+	res := []string{}
+	for i := 0; i < len(srcset); {
+		for i < len(srcset) && (isSpace(srcset[i]) || srcset[i] == ',') {
+			i++
+		}
+		start := i
+		for i < len(srcset) && !isSpace(srcset[i]) {
+			i++
+		}
+		u := srcset[start:i]
+		if trimmed := strings.TrimRight(u, ","); trimmed != u {
+			// a candidate without descriptor
+			u = trimmed
+		} else {
+			// skip the descriptors, up to the comma outside of parentheses ending the candidate
+			depth := 0
+			for ; i < len(srcset); i++ {
+				if c := srcset[i]; c == '(' {
+					depth++
+				} else if c == ')' && depth > 0 {
+					depth--
+				} else if c == ',' && depth == 0 {
+					break
+				}
+			}
+		}
+		if u != "" && !strings.HasPrefix(strings.ToLower(u), "data:") {
+			res = append(res, u)
+		}
+	}
+	return res
+}
+
+// mediaURLs returns the urls of the images and media referenced by e outside of its src attribute
+func mediaURLs(e *colly.HTMLElement) []string {
+	res := []string{}
+	for _, attr := range []string{"srcset", "imagesrcset", "data-srcset"} {
+		res = append(res, ParseSrcset(e.Attr(attr))...)
+	}
+	for _, attr := range []string{"poster", "data-src"} {
+		if u := strings.TrimSpace(e.Attr(attr)); u != "" && !strings.HasPrefix(strings.ToLower(u), "data:") {
+			res = append(res, u)
+		}
+	}
+	return Unique(res)
+}
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseSrcset(t *testing.T) {
+	tests := []struct {
+		srcset   string
+		expected []string
+	}{
+		{"small.jpg 480w, large.jpg 1080w", []string{"small.jpg", "large.jpg"}},
+		{"https://cdn.example.com/img/w_200,h_100/a.jpg 1x,https://cdn.example.com/img/w_400,h_200/a.jpg 2x", []string{"https://cdn.example.com/img/w_200,h_100/a.jpg", "https://cdn.example.com/img/w_400,h_200/a.jpg"}},
+		{" a.png,, b.png, data:image/png;base64,AAAA 2x ", []string{"a.png", "b.png"}},
+		{"", []string{}},
+	}
+	for _, test := range tests {
+		if urls := ParseSrcset(test.srcset); !reflect.DeepEqual(urls, test.expected) {
+			t.Errorf("expected %v for %q, got %v", test.expected, test.srcset, urls)
+		}
+	}
+}
+
+func TestMediaSources(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><link rel="preload" as="image" imagesrcset="/hero-1x.webp 1x, /hero-2x.webp 2x"></head><body>
+<picture><source srcset="/img/photo.avif" type="image/avif"><img src="/img/photo.jpg" srcset="/img/photo@2x.jpg 2x"></picture>
+<video poster="/media/poster.jpg"><source src="/media/clip.mp4" type="video/mp4"></video>
+<img data-src="/lazy/a.jpg" data-srcset="/lazy/a-400.jpg 400w">
+</body></html>`)
+	}))
+	defer ts.Close()
+
+	srcs := []string{}
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(1)), ts.URL) {
+		if r.OutputType == Src {
+			srcs = append(srcs, r.Output[len(ts.URL):])
+		}
+	}
+	sort.Strings(srcs)
+	expected := []string{"/hero-1x.webp", "/hero-2x.webp", "/img/photo.avif", "/img/photo.jpg", "/img/photo@2x.jpg", "/lazy/a-400.jpg", "/lazy/a.jpg", "/media/clip.mp4", "/media/poster.jpg"}
+	if !reflect.DeepEqual(srcs, expected) {
+		t.Errorf("expected the sources %v, got %v", expected, srcs)
+	}
+}
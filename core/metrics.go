@@ -0,0 +1,118 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// crawlMetrics holds the prometheus collectors of a Crawler
+type crawlMetrics struct {
+	registry   *prometheus.Registry
+	requests   *prometheus.CounterVec
+	responses  *prometheus.CounterVec
+	errors     *prometheus.CounterVec
+	bytes      prometheus.Counter
+	queueDepth prometheus.Gauge
+	reports    *prometheus.CounterVec
+}
+
+func newCrawlMetrics() *crawlMetrics {
+	m := &crawlMetrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: CLIName,
+			Name:      "requests_total",
+			Help:      "Number of completed requests, by host.",
+		}, []string{"host"}),
+		responses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: CLIName,
+			Name:      "responses_total",
+			Help:      "Number of responses, by status code.",
+		}, []string{"status"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: CLIName,
+			Name:      "request_errors_total",
+			Help:      "Number of failed requests (network errors and error status codes), by host.",
+		}, []string{"host"}),
+		bytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: CLIName,
+			Name:      "downloaded_bytes_total",
+			Help:      "Number of downloaded body bytes.",
+		}),
+		queueDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: CLIName,
+			Name:      "queue_depth",
+			Help:      "Number of scheduled requests not sent yet.",
+		}),
+		reports: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: CLIName,
+			Name:      "reports_total",
+			Help:      "Number of emitted reports, by output type.",
+		}, []string{"type"}),
+	}
+	m.registry.MustRegister(m.requests, m.responses, m.errors, m.bytes, m.queueDepth, m.reports)
+	return m
+}
+
+// instrument registers the callbacks updating the request metrics on c
+func (m *crawlMetrics) instrument(c *colly.Collector) {
+	c.OnRequest(func(r *colly.Request) {
+		m.queueDepth.Dec()
+	})
+	c.OnResponse(func(r *colly.Response) {
+		m.requests.WithLabelValues(r.Request.URL.Host).Inc()
+		m.responses.WithLabelValues(strconv.Itoa(r.StatusCode)).Inc()
+		m.bytes.Add(float64(len(r.Body)))
+	})
+	c.OnError(func(r *colly.Response, err error) {
+		m.requests.WithLabelValues(r.Request.URL.Host).Inc()
+		m.responses.WithLabelValues(strconv.Itoa(r.StatusCode)).Inc()
+		m.errors.WithLabelValues(r.Request.URL.Host).Inc()
+		m.bytes.Add(float64(len(r.Body)))
+	})
+}
+
+// Metrics returns the prometheus registry holding the crawl metrics
+func (crawler *Crawler) Metrics() *prometheus.Registry {
+	return crawler.metrics.registry
+}
+
+// serveMetrics starts the metrics listener for the duration of the crawl and returns the func shutting it down
+func (crawler *Crawler) serveMetrics() (func(), error) {
+	server := crawler.metricsServer
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for metrics on %s: %w", server.Addr, err)
+	}
+	Logger.Infof("Serving metrics on %s/metrics", listener.Addr())
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			Logger.Errorf("Metrics listener failed: %s", err)
+		}
+	}()
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			Logger.Errorf("Failed to shut down the metrics listener: %s", err)
+		}
+	}, nil
+}
+
+// WithMetricsListener serves the crawl metrics on addr at /metrics while the crawl runs
+func WithMetricsListener(addr string) CrawlerOption {
+	return func(crawler *Crawler) {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(crawler.metrics.registry, promhttp.HandlerOpts{}))
+		crawler.metricsServer = &http.Server{Addr: addr, Handler: mux}
+	}
+}
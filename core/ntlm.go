@@ -0,0 +1,48 @@
+package core
+
+import (
+	"net/http"
+
+	"github.com/Azure/go-ntlmssp"
+)
+
+// ntlmTransport hands the credentials to the ntlmssp negotiator, which reads them from the Basic authorization
+// of the request. The negotiator only sends them when a server challenges with NTLM, Negotiate or Basic
+type ntlmTransport struct {
+	next     ntlmssp.Negotiator
+	user     string
+	password string
+}
+
+func (nt *ntlmTransport) replaceWrapped(replace transportReplacer) error {
+	return replaceNext(&nt.next.RoundTripper, replace)
+}
+
+func (nt *ntlmTransport) unwrap() http.RoundTripper {
+	return nt.next.RoundTripper
+}
+
+func (nt *ntlmTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" {
+		return nt.next.RoundTrip(req)
+	}
+	authorized := req.Clone(req.Context())
+	authorized.SetBasicAuth(nt.user, nt.password)
+	return nt.next.RoundTrip(authorized)
+}
+
+// WithNTLMAuth answers the NTLM challenges of the servers, e.g IIS intranets, with the credentials of user in domain.
+// The handshake needs the connections to be kept alive
+func WithNTLMAuth(domain string, user string, password string) HTTPClientConfigurator {
+	return func(client *http.Client) error {
+		next := client.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		if domain != "" {
+			user = domain + `\` + user
+		}
+		client.Transport = &ntlmTransport{next: ntlmssp.Negotiator{RoundTripper: next}, user: user, password: password}
+		return nil
+	}
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNTLMAuthNegotiates(t *testing.T) {
+	var lock sync.Mutex
+	negotiated := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "NTLM "); ok {
+			message, _ := base64.StdEncoding.DecodeString(token)
+			if bytes.HasPrefix(message, []byte("NTLMSSP\x00\x01")) {
+				lock.Lock()
+				negotiated = true
+				lock.Unlock()
+			}
+		}
+		w.Header().Set("WWW-Authenticate", "NTLM")
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	client := &http.Client{Transport: DefaultHTTPTransport.Clone()}
+	WithNTLMAuth("CORP", "crawler", "secret")(client)
+	resp, err := client.Get(ts.URL)
+	if err == nil {
+		resp.Body.Close()
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if !negotiated {
+		t.Error("expected an NTLM negotiate message")
+	}
+}
@@ -0,0 +1,215 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// openAPIMaxBytes bounds the size of the fetched specs
+const openAPIMaxBytes = 16 * 1024 * 1024
+
+// DefaultOpenAPIPaths are the paths probed on every origin for a Swagger or OpenAPI spec
+var DefaultOpenAPIPaths = []string{
+	"/swagger.json",
+	"/swagger.yaml",
+	"/openapi.json",
+	"/openapi.yaml",
+	"/v2/api-docs",
+	"/v3/api-docs",
+	"/api-docs",
+	"/swagger/v1/swagger.json",
+	"/api/swagger.json",
+	"/api/openapi.json",
+}
+
+// openAPIMethods are the operations of a path item, the other keys (parameters, servers...) are ignored
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// OpenAPISpec is the part of a Swagger 2 or OpenAPI 3 spec locating its operations
+type OpenAPISpec struct {
+	Swagger  string   `json:"swagger" yaml:"swagger"`
+	OpenAPI  string   `json:"openapi" yaml:"openapi"`
+	Host     string   `json:"host" yaml:"host"`
+	BasePath string   `json:"basePath" yaml:"basePath"`
+	Schemes  []string `json:"schemes" yaml:"schemes"`
+	Servers  []struct {
+		URL string `json:"url" yaml:"url"`
+	} `json:"servers" yaml:"servers"`
+	Paths map[string]map[string]any `json:"paths" yaml:"paths"`
+}
+
+// APIOperation is an operation of a spec, URL being its path resolved against the spec base url
+type APIOperation struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	URL    string `json:"url"`
+}
+
+// ParseOpenAPI parses a Swagger 2 or OpenAPI 3 spec, in JSON or YAML
+func ParseOpenAPI(raw []byte) (OpenAPISpec, error) {
+	spec := OpenAPISpec{}
+	var err error
+	if trimmed := bytes.TrimSpace(raw); bytes.HasPrefix(trimmed, []byte("{")) {
+		err = json.Unmarshal(trimmed, &spec)
+	} else {
+		err = yaml.Unmarshal(trimmed, &spec)
+	}
+	if err != nil {
+		return spec, fmt.Errorf("invalid openapi spec: %w", err)
+	}
+	if spec.Swagger == "" && spec.OpenAPI == "" || spec.Paths == nil {
+		return spec, fmt.Errorf("invalid openapi spec: missing version or paths")
+	}
+	return spec, nil
+}
+
+// BaseURL returns the url the paths of the spec are relative to, resolved against specURL where the spec was found
+func (spec OpenAPISpec) BaseURL(specURL *url.URL) *url.URL {
+	if spec.OpenAPI != "" {
+		base := "/"
+		if len(spec.Servers) > 0 && spec.Servers[0].URL != "" {
+			base = spec.Servers[0].URL
+		}
+		if u, err := specURL.Parse(base); err == nil {
+			return u
+		}
+		return &url.URL{Scheme: specURL.Scheme, Host: specURL.Host, Path: "/"}
+	}
+	base := &url.URL{Scheme: specURL.Scheme, Host: specURL.Host, Path: spec.BasePath}
+	if len(spec.Schemes) > 0 && !containsScheme(spec.Schemes, specURL.Scheme) {
+		base.Scheme = spec.Schemes[0]
+	}
+	if spec.Host != "" {
+		base.Host = spec.Host
+	}
+	return base
+}
+
+func containsScheme(schemes []string, scheme string) bool {
+	for _, s := range schemes {
+		if strings.EqualFold(s, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// Operations returns the operations of the spec sorted by path and method, their url resolved against specURL
+func (spec OpenAPISpec) Operations(specURL *url.URL) []APIOperation {
+	base := spec.BaseURL(specURL)
+	prefix := base.Scheme + "://" + base.Host + strings.TrimSuffix(base.Path, "/")
+	paths := make([]string, 0, len(spec.Paths))
+	for path := range spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	res := []APIOperation{}
+	for _, path := range paths {
+		for _, method := range openAPIMethods {
+			if _, ok := spec.Paths[path][method]; !ok {
+				continue
+			}
+			res = append(res, APIOperation{
+				Method: strings.ToUpper(method),
+				Path:   path,
+				URL:    prefix + "/" + strings.TrimPrefix(path, "/"),
+			})
+		}
+	}
+	return res
+}
+
+// isOpenAPICandidate reports whether a crawled response may be a spec, to avoid parsing every JSON and YAML response
+func isOpenAPICandidate(response *colly.Response) bool {
+	path := strings.ToLower(response.Request.URL.Path)
+	for _, marker := range []string{"swagger", "openapi", "api-docs"} {
+		if strings.Contains(path, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchOpenAPI returns the spec at specURL
+func (crawler *Crawler) fetchOpenAPI(specURL string) (OpenAPISpec, error) {
+	resp, err := crawler.helperClient(10 * time.Second).Get(specURL)
+	if err != nil {
+		return OpenAPISpec{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return OpenAPISpec{}, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, openAPIMaxBytes))
+	if err != nil {
+		return OpenAPISpec{}, err
+	}
+	return ParseOpenAPI(raw)
+}
+
+// discoverOpenAPI probes the spec paths of the origin of a response the first time it is seen, and parses the crawled
+// responses which look like a spec. The operations of the specs found are emitted as api-endpoint reports
+func (crawler *Crawler) discoverOpenAPI(c *colly.Collector, emit func(SpiderReport), response *colly.Response) {
+	u := response.Request.URL
+	if isOpenAPICandidate(response) && !crawler.openAPISpecs.Duplicate(u.String()) {
+		if spec, err := ParseOpenAPI(response.Body); err == nil {
+			crawler.emitOpenAPI(c, emit, response.Request, u, spec)
+		}
+	}
+	origin := u.Scheme + "://" + u.Host
+	if crawler.probedOpenAPIHosts.Duplicate(origin) {
+		return
+	}
+	for _, path := range DefaultOpenAPIPaths {
+		specURL, err := url.Parse(origin + path)
+		if err != nil || crawler.openAPISpecs.Duplicate(specURL.String()) {
+			continue
+		}
+		spec, err := crawler.fetchOpenAPI(specURL.String())
+		if err != nil {
+			Logger.Debugf("No openapi spec at %s: %s", specURL, err)
+			continue
+		}
+		crawler.emitOpenAPI(c, emit, response.Request, specURL, spec)
+	}
+}
+
+// emitOpenAPI emits the operations of spec, found at specURL, and visits its GET endpoints without path parameters
+// when enabled
+func (crawler *Crawler) emitOpenAPI(c *colly.Collector, emit func(SpiderReport), request *colly.Request, specURL *url.URL, spec OpenAPISpec) {
+	for _, op := range spec.Operations(specURL) {
+		emit(SpiderReport{
+			Output:     op.Method + " " + op.URL,
+			OutputType: APIEndpoint,
+			Source:     "openapi",
+			Input:      request.URL,
+			Seed:       requestSeed(request),
+		}.WithMetadata("operation", op).WithMetadata("spec", specURL.String()))
+		if !crawler.visitOpenAPI || op.Method != http.MethodGet || strings.Contains(op.Path, "{") {
+			continue
+		}
+		if err := crawler.visit(c, op.URL, requestSeed(request)); err != nil {
+			Logger.Debugf("Failed to visit %s found in the openapi spec %s: %s", op.URL, specURL, err)
+		}
+	}
+}
+
+// WithOpenAPI probes the common Swagger and OpenAPI spec paths of every origin (see DefaultOpenAPIPaths) and parses the
+// crawled specs, emitting an api-endpoint report for every operation with its method and path in the report Metadata.
+// When visit is set, the GET endpoints without path parameters are crawled too
+func WithOpenAPI(visit bool) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.openAPI = true
+		crawler.visitOpenAPI = visit
+	}
+}
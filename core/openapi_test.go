@@ -0,0 +1,89 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+)
+
+func TestOpenAPIOperations(t *testing.T) {
+	specURL, _ := url.Parse("https://example.com/docs/swagger.json")
+	tests := []struct {
+		raw      string
+		expected []APIOperation
+	}{
+		{
+			`{"swagger":"2.0","host":"api.example.com","basePath":"/v1","paths":{"/users":{"get":{},"post":{},"parameters":[]},"/users/{id}":{"delete":{}}}}`,
+			[]APIOperation{
+				{"GET", "/users", "https://api.example.com/v1/users"},
+				{"POST", "/users", "https://api.example.com/v1/users"},
+				{"DELETE", "/users/{id}", "https://api.example.com/v1/users/{id}"},
+			},
+		},
+		{
+			"openapi: 3.0.0\nservers:\n  - url: ../api/v3\npaths:\n  /pets:\n    get:\n      summary: list\n",
+			[]APIOperation{{"GET", "/pets", "https://example.com/api/v3/pets"}},
+		},
+		{
+			`{"openapi":"3.1.0","paths":{"/health":{"head":{}}}}`,
+			[]APIOperation{{"HEAD", "/health", "https://example.com/health"}},
+		},
+	}
+	for _, test := range tests {
+		spec, err := ParseOpenAPI([]byte(test.raw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ops := spec.Operations(specURL); !reflect.DeepEqual(ops, test.expected) {
+			t.Errorf("expected %v for %s, got %v", test.expected, test.raw, ops)
+		}
+	}
+	for _, raw := range []string{`{"name":"package.json"}`, "<html><body>Not found</body></html>"} {
+		if _, err := ParseOpenAPI([]byte(raw)); err == nil {
+			t.Errorf("expected an error for %s", raw)
+		}
+	}
+}
+
+func TestOpenAPIReports(t *testing.T) {
+	var visited atomic.Bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><body>home</body></html>`)
+		case "/v2/api-docs":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"swagger":"2.0","basePath":"/api","paths":{"/users":{"get":{},"post":{}},"/users/{id}":{"get":{}}}}`)
+		case "/api/users":
+			visited.Store(true)
+			fmt.Fprint(w, `[]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	found := []string{}
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(2), WithOpenAPI(true)), ts.URL) {
+		if r.OutputType == APIEndpoint {
+			found = append(found, r.Output)
+			if r.Metadata["spec"] != ts.URL+"/v2/api-docs" {
+				t.Errorf("expected the spec url in the Metadata of %s, got %v", r.Output, r.Metadata)
+			}
+		}
+	}
+	sort.Strings(found)
+	expected := []string{"GET " + ts.URL + "/api/users", "GET " + ts.URL + "/api/users/{id}", "POST " + ts.URL + "/api/users"}
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("expected %v, got %v", expected, found)
+	}
+	if !visited.Load() {
+		t.Errorf("expected the GET endpoint of the spec to be visited")
+	}
+}
@@ -1,143 +1,107 @@
 package core
 
 import (
-	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"os"
+	"strings"
 	"sync"
 )
 
-func OtherSources(domain string, includeSubs bool) []string {
-	noSubs := true
-	if includeSubs {
-		noSubs = false
-	}
-	var urls []string
+// Source is a third-party feed of urls known for a domain, e.g an archive or an internal intel feed.
+// Sources are registered on the crawler with WithSource
+type Source interface {
+	Fetch(ctx context.Context, domain string) ([]string, error)
+}
 
-	fetchFns := []fetchFn{
-		getWaybackURLs,
-		getCommonCrawlURLs,
-		getVirusTotalURLs,
-		getOtxUrls,
-	}
+// SourceFunc adapts a function to a Source
+type SourceFunc func(ctx context.Context, domain string) ([]string, error)
 
-	var wg sync.WaitGroup
+func (fn SourceFunc) Fetch(ctx context.Context, domain string) ([]string, error) {
+	return fn(ctx, domain)
+}
 
-	for _, fn := range fetchFns {
-		wUrlChan := make(chan wurl)
+// DefaultSources returns the built-in sources: Wayback Machine, Common Crawl, urlscan.io, VirusTotal and AlienVault OTX.
+// API keys are read from the URLSCAN_API_KEY, VT_API_KEY and OTX_API_KEY environment variables
+func DefaultSources(includeSubs bool) []Source {
+	return []Source{
+		WaybackSource{IncludeSubs: includeSubs},
+		CommonCrawlSource{IncludeSubs: includeSubs},
+		URLScanSource{APIKey: os.Getenv("URLSCAN_API_KEY")},
+		VirusTotalSource{APIKey: os.Getenv("VT_API_KEY")},
+		OTXSource{APIKey: os.Getenv("OTX_API_KEY")},
+	}
+}
+
+// FetchSources queries the sources concurrently and returns the unique urls they know for domain.
+// A failing source is logged and doesn't prevent the others from contributing
+func FetchSources(ctx context.Context, domain string, sources ...Source) []string {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		urls []string
+	)
+	for _, source := range sources {
 		wg.Add(1)
-		fetch := fn
-		go func() {
+		go func(source Source) {
 			defer wg.Done()
-			resp, err := fetch(domain, noSubs)
+			res, err := source.Fetch(ctx, domain)
 			if err != nil {
-				return
+				Logger.Debugf("Failed to fetch %T urls for %s: %s", source, domain, err)
 			}
-			for _, r := range resp {
-				wUrlChan <- r
+			mu.Lock()
+			defer mu.Unlock()
+			for _, u := range res {
+				if u = strings.TrimSpace(u); u != "" {
+					urls = append(urls, u)
+				}
 			}
-		}()
-
-		go func() {
-			wg.Wait()
-			close(wUrlChan)
-		}()
-
-		for w := range wUrlChan {
-			urls = append(urls, w.url)
-		}
+		}(source)
 	}
+	wg.Wait()
 	return Unique(urls)
 }
 
+// OtherSources returns the urls the default sources know for domain
+func OtherSources(domain string, includeSubs bool) []string {
+	return FetchSources(context.Background(), domain, DefaultSources(includeSubs)...)
+}
+
 type wurl struct {
 	date string
 	url  string
 }
 
-type fetchFn func(string, bool) ([]wurl, error)
-
-func getWaybackURLs(domain string, noSubs bool) ([]wurl, error) {
-	subsWildcard := "*."
-	if noSubs {
-		subsWildcard = ""
-	}
-	res, err := http.Get(
-		fmt.Sprintf("http://web.archive.org/cdx/search/cdx?url=%s%s/*&output=json&collapse=urlkey", subsWildcard, domain),
-	)
-	if err != nil {
-		return []wurl{}, err
-	}
-
-	raw, err := ioutil.ReadAll(res.Body)
-
-	res.Body.Close()
-	if err != nil {
-		return []wurl{}, err
-	}
-
-	var wrapper [][]string
-	err = json.Unmarshal(raw, &wrapper)
-
-	out := make([]wurl, 0, len(wrapper))
-
-	skip := true
-	for _, urls := range wrapper {
-		// The first item is always just the string "original",
-		// so we should skip the first item
-		if skip {
-			skip = false
-			continue
-		}
-		out = append(out, wurl{date: urls[1], url: urls[2]})
+func wurlLocations(wurls []wurl, err error) ([]string, error) {
+	res := make([]string, 0, len(wurls))
+	for _, w := range wurls {
+		res = append(res, w.url)
 	}
-
-	return out, nil
-
+	return res, err
 }
 
-func getCommonCrawlURLs(domain string, noSubs bool) ([]wurl, error) {
-	subsWildcard := "*."
-	if noSubs {
-		subsWildcard = ""
-	}
-	res, err := http.Get(
-		fmt.Sprintf("http://index.commoncrawl.org/CC-MAIN-2019-51-index?url=%s%s/*&output=json", subsWildcard, domain),
-	)
+func sourceGet(ctx context.Context, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
-		return []wurl{}, err
-	}
-
-	defer res.Body.Close()
-	sc := bufio.NewScanner(res.Body)
-
-	out := make([]wurl, 0)
-
-	for sc.Scan() {
-		wrapper := struct {
-			URL       string `json:"url"`
-			Timestamp string `json:"timestamp"`
-		}{}
-		err = json.Unmarshal([]byte(sc.Text()), &wrapper)
-
-		if err != nil {
-			continue
-		}
-
-		out = append(out, wurl{date: wrapper.Timestamp, url: wrapper.URL})
+		return nil, err
 	}
+	return http.DefaultClient.Do(req)
+}
 
-	return out, nil
+// VirusTotalSource fetches the urls detected by VirusTotal, it is skipped without APIKey
+type VirusTotalSource struct {
+	APIKey string
+}
 
+func (s VirusTotalSource) Fetch(ctx context.Context, domain string) ([]string, error) {
+	return wurlLocations(getVirusTotalURLs(ctx, domain, s.APIKey))
 }
 
-func getVirusTotalURLs(domain string, noSubs bool) ([]wurl, error) {
+func getVirusTotalURLs(ctx context.Context, domain string, apiKey string) ([]wurl, error) {
 	out := make([]wurl, 0)
 
-	apiKey := os.Getenv("VT_API_KEY")
 	if apiKey == "" {
 		Logger.Warnf("You are not set VirusTotal API Key yet.")
 		return out, nil
@@ -149,7 +113,7 @@ func getVirusTotalURLs(domain string, noSubs bool) ([]wurl, error) {
 		domain,
 	)
 
-	resp, err := http.Get(fetchURL)
+	resp, err := sourceGet(ctx, fetchURL)
 	if err != nil {
 		return out, err
 	}
@@ -169,47 +133,5 @@ func getVirusTotalURLs(domain string, noSubs bool) ([]wurl, error) {
 		out = append(out, wurl{url: u.URL})
 	}
 
-	return out, nil
-}
-
-func getOtxUrls(domain string, noSubs bool) ([]wurl, error) {
-	var urls []wurl
-	page := 0
-	for {
-		r, err := http.Get(fmt.Sprintf("https://otx.alienvault.com/api/v1/indicators/hostname/%s/url_list?limit=50&page=%d", domain, page))
-		if err != nil {
-			return []wurl{}, err
-		}
-		bytes, err := ioutil.ReadAll(r.Body)
-		if err != nil {
-			return []wurl{}, err
-		}
-		r.Body.Close()
-
-		wrapper := struct {
-			HasNext    bool `json:"has_next"`
-			ActualSize int  `json:"actual_size"`
-			URLList    []struct {
-				Domain   string `json:"domain"`
-				URL      string `json:"url"`
-				Hostname string `json:"hostname"`
-				Httpcode int    `json:"httpcode"`
-				PageNum  int    `json:"page_num"`
-				FullSize int    `json:"full_size"`
-				Paged    bool   `json:"paged"`
-			} `json:"url_list"`
-		}{}
-		err = json.Unmarshal(bytes, &wrapper)
-		if err != nil {
-			return []wurl{}, err
-		}
-		for _, url := range wrapper.URLList {
-			urls = append(urls, wurl{url: url.URL})
-		}
-		if !wrapper.HasNext {
-			break
-		}
-		page++
-	}
-	return urls, nil
+	return out, err
 }
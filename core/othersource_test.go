@@ -1,44 +1,42 @@
 package core
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"testing"
+)
 
 var domain = "yahoo.com"
 
-func TestOtherSources(t *testing.T) {
-	urls := OtherSources(domain, false)
-	t.Log(len(urls))
-	t.Log(urls)
-}
-
-func TestGetCommonCrawlURLs(t *testing.T) {
-	urls, err := getCommonCrawlURLs(domain, false)
-	if err != nil {
-		t.Fatal(err)
+func TestFetchSources(t *testing.T) {
+	sources := []Source{
+		SourceFunc(func(ctx context.Context, d string) ([]string, error) {
+			return []string{"https://" + d + "/a", " https://" + d + "/b ", ""}, nil
+		}),
+		SourceFunc(func(ctx context.Context, d string) ([]string, error) {
+			return []string{"https://" + d + "/a"}, errors.New("partial failure")
+		}),
+		SourceFunc(func(ctx context.Context, d string) ([]string, error) {
+			return nil, errors.New("down")
+		}),
 	}
-	t.Log(len(urls))
-	t.Log(urls)
-}
-
-func TestGetVirusTotalURLs(t *testing.T) {
-	urls, err := getVirusTotalURLs(domain, false)
-	if err != nil {
-		t.Fatal(err)
+	urls := FetchSources(context.Background(), "example.com", sources...)
+	sort.Strings(urls)
+	if len(urls) != 2 || urls[0] != "https://example.com/a" || urls[1] != "https://example.com/b" {
+		t.Fatalf("unexpected urls %v", urls)
 	}
-	t.Log(len(urls))
-	t.Log(urls)
 }
 
-func TestGetWaybackURLs(t *testing.T) {
-	urls, err := getWaybackURLs(domain, false)
-	if err != nil {
-		t.Fatal(err)
-	}
+func TestOtherSources(t *testing.T) {
+	urls := OtherSources(domain, false)
 	t.Log(len(urls))
 	t.Log(urls)
 }
 
-func TestGetOtxUrls(t *testing.T) {
-	urls, err := getOtxUrls(domain, false)
+func TestGetVirusTotalURLs(t *testing.T) {
+	urls, err := getVirusTotalURLs(context.Background(), domain, os.Getenv("VT_API_KEY"))
 	if err != nil {
 		t.Fatal(err)
 	}
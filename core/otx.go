@@ -0,0 +1,84 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+var (
+	// DefaultOTXEndpoint is the AlienVault OTX api
+	DefaultOTXEndpoint = "https://otx.alienvault.com/api/v1"
+	// DefaultOTXPageSize is the number of urls requested per url_list page
+	DefaultOTXPageSize = 50
+)
+
+// OTXSource fetches the urls AlienVault OTX knows for a hostname from its url_list endpoint, page by page
+type OTXSource struct {
+	// APIKey is optional, authenticated requests are less rate limited
+	APIKey string
+	// PageSize is the number of urls per page, DefaultOTXPageSize when 0
+	PageSize int
+	// MaxPages stops the listing after this many pages, no limit when 0
+	MaxPages int
+	// Endpoint is the OTX api, DefaultOTXEndpoint when empty
+	Endpoint string
+}
+
+type otxURLList struct {
+	HasNext bool `json:"has_next"`
+	URLList []struct {
+		URL string `json:"url"`
+	} `json:"url_list"`
+}
+
+func (s OTXSource) Fetch(ctx context.Context, domain string) ([]string, error) {
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultOTXEndpoint
+	}
+	pageSize := s.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultOTXPageSize
+	}
+	res := []string{}
+	// OTX pages start at 1
+	for page := 1; s.MaxPages <= 0 || page <= s.MaxPages; page++ {
+		list, err := s.fetchPage(ctx, fmt.Sprintf("%s/indicators/hostname/%s/url_list?limit=%d&page=%d", endpoint, url.PathEscape(domain), pageSize, page))
+		if err != nil {
+			return res, err
+		}
+		for _, entry := range list.URLList {
+			res = append(res, entry.URL)
+		}
+		if !list.HasNext || len(list.URLList) == 0 {
+			break
+		}
+	}
+	return res, nil
+}
+
+func (s OTXSource) fetchPage(ctx context.Context, pageURL string) (otxURLList, error) {
+	list := otxURLList{}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return list, err
+	}
+	if s.APIKey != "" {
+		req.Header.Set("X-OTX-API-KEY", s.APIKey)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return list, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return list, fmt.Errorf("otx url_list answered %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return list, fmt.Errorf("failed to parse otx url_list: %w", err)
+	}
+	return list, nil
+}
@@ -0,0 +1,36 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOTXSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/indicators/hostname/example.com/url_list" || r.URL.Query().Get("limit") != "2" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		page := r.URL.Query().Get("page")
+		fmt.Fprintf(w, `{"has_next": %t, "url_list": [{"url": "https://example.com/%[2]s-a"}, {"url": "https://example.com/%[2]s-b"}]}`, page != "3", page)
+	}))
+	defer srv.Close()
+
+	urls, err := OTXSource{PageSize: 2, Endpoint: srv.URL}.Fetch(context.Background(), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 6 || urls[5] != "https://example.com/3-b" {
+		t.Errorf("expected the 3 pages, got %v", urls)
+	}
+
+	urls, err = OTXSource{PageSize: 2, MaxPages: 1, Endpoint: srv.URL}.Fetch(context.Background(), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 2 {
+		t.Errorf("expected MaxPages to stop after the first page, got %v", urls)
+	}
+}
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// paramMiner aggregates the query parameter names seen per host, with the number of reports they were seen in
+type paramMiner struct {
+	mu       sync.Mutex
+	hosts    map[string]map[string]int
+	wordlist string
+}
+
+func newParamMiner(wordlist string) *paramMiner {
+	return &paramMiner{hosts: map[string]map[string]int{}, wordlist: wordlist}
+}
+
+func (pm *paramMiner) add(host string, names []string) {
+	if host == "" || len(names) == 0 {
+		return
+	}
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	params, ok := pm.hosts[host]
+	if !ok {
+		params = map[string]int{}
+		pm.hosts[host] = params
+	}
+	for _, name := range names {
+		if name != "" {
+			params[name]++
+		}
+	}
+}
+
+// observe records the query parameters of the url of report, and the fields of the forms
+func (pm *paramMiner) observe(report SpiderReport) {
+	if form, ok := report.Metadata["form"].(HTMLForm); ok {
+		names := make([]string, 0, len(form.Fields))
+		for _, field := range form.Fields {
+			names = append(names, field.Name)
+		}
+		if u, err := url.Parse(form.Action); err == nil {
+			pm.add(u.Host, names)
+		}
+	}
+	if !strings.Contains(report.Output, "?") {
+		return
+	}
+	u, err := url.Parse(report.Output)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "ws" && u.Scheme != "wss") {
+		return
+	}
+	query, _ := url.ParseQuery(u.RawQuery)
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	pm.add(u.Host, names)
+}
+
+// summary returns the parameter names seen per host, with their count
+func (pm *paramMiner) summary() map[string]map[string]int {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	res := make(map[string]map[string]int, len(pm.hosts))
+	for host, params := range pm.hosts {
+		res[host] = make(map[string]int, len(params))
+		for name, count := range params {
+			res[host][name] = count
+		}
+	}
+	return res
+}
+
+// reportParameters emits a parameters report per host and writes the wordlist of all the names seen, if any
+func (crawler *Crawler) reportParameters(ctx context.Context, c chan<- SpiderReport, errC chan<- error) {
+	if crawler.paramMiner == nil {
+		return
+	}
+	summary := crawler.paramMiner.summary()
+	hosts := make([]string, 0, len(summary))
+	for host := range summary {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	all := []string{}
+	for _, host := range hosts {
+		names := make([]string, 0, len(summary[host]))
+		for name := range summary[host] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		all = append(all, names...)
+		crawler.publish(ctx, c, errC, SpiderReport{
+			Output:     host + " " + strings.Join(names, ","),
+			OutputType: Parameters,
+			Source:     "params",
+		}.WithMetadata("host", host).WithMetadata("parameters", summary[host]))
+	}
+	wordlist := crawler.paramMiner.wordlist
+	if wordlist == "" {
+		return
+	}
+	all = Unique(all)
+	sort.Strings(all)
+	content := strings.Join(all, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	if err := os.WriteFile(wordlist, []byte(content), 0644); err != nil {
+		crawler.handleError(errC, fmt.Errorf("failed to write parameters wordlist: %w", err))
+	}
+}
+
+// WithParameterMining aggregates the query parameter names of the urls and the field names of the forms seen on every
+// host, and emits a parameters report per host at the end of the crawl, with the number of reports each name was seen
+// in as report Metadata. When wordlist is set, all the names are written to this file, one per line, to feed
+// parameter discovery tools such as Arjun or ffuf
+func WithParameterMining(wordlist string) CrawlerOption {
+	return func(crawler *Crawler) {
+		if wordlist != "" {
+			wordlist = NormalizePath(wordlist)
+		}
+		crawler.paramMiner = newParamMiner(wordlist)
+	}
+}
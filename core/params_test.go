@@ -0,0 +1,54 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParameterMining(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/" {
+			fmt.Fprint(w, `<html><body>
+<a href="/products?id=1&page=2">products</a>
+<a href="/products?id=2&sort=price#top">cheap</a>
+<a href="https://other.example.com/?ref=home">other</a>
+<form action="/search"><input name="q"><input type="hidden" name="lang" value="en"></form>
+</body></html>`)
+		}
+	}))
+	defer ts.Close()
+	wordlist := filepath.Join(t.TempDir(), "params.txt")
+
+	var summary []SpiderReport
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(1), WithParameterMining(wordlist)), ts.URL) {
+		if r.OutputType == Parameters {
+			summary = append(summary, r)
+		}
+	}
+	host := strings.TrimPrefix(ts.URL, "http://")
+	expected := []string{host + " id,lang,page,q,sort", "other.example.com ref"}
+	if len(summary) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, summary)
+	}
+	for i, r := range summary {
+		if r.Output != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], r.Output)
+		}
+	}
+	if counts, ok := summary[0].Metadata["parameters"].(map[string]int); !ok || counts["id"] != 2 || counts["page"] != 1 {
+		t.Errorf("expected the count of the parameters in the Metadata, got %v", summary[0].Metadata)
+	}
+	raw, err := os.ReadFile(wordlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != "id\nlang\npage\nq\nref\nsort\n" {
+		t.Errorf("unexpected wordlist %q", raw)
+	}
+}
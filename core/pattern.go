@@ -0,0 +1,143 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gocolly/colly/v2"
+)
+
+const PatternPlaceholder = "{id}"
+
+var ErrDuplicatePattern = errors.New("url pattern already visited")
+
+var (
+	numericSegmentRE = regexp.MustCompile(`^\d+$`)
+	uuidSegmentRE    = regexp.MustCompile(`(?i)^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	hashSegmentRE    = regexp.MustCompile(`(?i)^[0-9a-f]{16,}$`)
+)
+
+// PathPattern returns the path template of u: host and path where every
+// numeric, UUID or hash-like segment is replaced by PatternPlaceholder.
+// `https://shop.com/product/123` and `https://shop.com/product/456` share the pattern `shop.com/product/{id}`
+func PathPattern(u *url.URL) string {
+	segments := strings.Split(u.EscapedPath(), "/")
+	for i, s := range segments {
+		if numericSegmentRE.MatchString(s) || uuidSegmentRE.MatchString(s) || hashSegmentRE.MatchString(s) {
+			segments[i] = PatternPlaceholder
+		}
+	}
+	return u.Host + strings.Join(segments, "/")
+}
+
+type patternCounter struct {
+	lock   sync.Mutex
+	counts map[string]int
+}
+
+func newPatternCounter() *patternCounter {
+	return &patternCounter{counts: make(map[string]int)}
+}
+
+// Inc increments the count of pattern and returns the new value
+func (pc *patternCounter) Inc(pattern string) int {
+	pc.lock.Lock()
+	defer pc.lock.Unlock()
+	pc.counts[pattern]++
+	return pc.counts[pattern]
+}
+
+// WithPatternVisitCap limits the number of visited URLs sharing the same path template (see PathPattern) to max.
+// Requests over the cap are aborted before being sent.
+func WithPatternVisitCap(max int) CollyConfigurator {
+	return func(c *colly.Collector) error {
+		counter := newPatternCounter()
+		c.OnRequest(func(r *colly.Request) {
+			pattern := PathPattern(r.URL)
+			if counter.Inc(pattern) > max {
+				Logger.Debugf("Pattern cap reached for %s, skipping %s", pattern, r.URL.String())
+				abortRequest(r)
+			}
+		})
+		return nil
+	}
+}
+
+// patternGroup is the representative url visited for a path template, and the number of distinct urls sharing it
+type patternGroup struct {
+	representative string
+	count          int
+}
+
+// patternDedup keeps the first url visited for every path template holding a placeholder, see WithPatternDedup
+type patternDedup struct {
+	lock   sync.Mutex
+	groups map[string]*patternGroup
+	// seen are the urls counted in the groups
+	seen map[string]bool
+}
+
+func newPatternDedup() *patternDedup {
+	return &patternDedup{groups: make(map[string]*patternGroup), seen: make(map[string]bool)}
+}
+
+// allow reports whether u is the representative of its path template, or has no placeholder in its path
+func (pd *patternDedup) allow(u *url.URL) bool {
+	pattern := PathPattern(u)
+	if !strings.Contains(pattern, PatternPlaceholder) {
+		return true
+	}
+	raw := u.String()
+	pd.lock.Lock()
+	defer pd.lock.Unlock()
+	group, ok := pd.groups[pattern]
+	if !ok {
+		group = &patternGroup{representative: raw}
+		pd.groups[pattern] = group
+	}
+	if !pd.seen[raw] {
+		pd.seen[raw] = true
+		group.count++
+	}
+	return group.representative == raw
+}
+
+// reportPatterns emits an url-pattern report for every path template shared by several urls
+func (crawler *Crawler) reportPatterns(ctx context.Context, c chan<- SpiderReport, errC chan<- error) {
+	pd := crawler.patternDedup
+	if pd == nil {
+		return
+	}
+	pd.lock.Lock()
+	patterns := make([]string, 0, len(pd.groups))
+	groups := make(map[string]patternGroup, len(pd.groups))
+	for pattern, group := range pd.groups {
+		if group.count > 1 {
+			patterns = append(patterns, pattern)
+			groups[pattern] = *group
+		}
+	}
+	pd.lock.Unlock()
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		crawler.publish(ctx, c, errC, SpiderReport{
+			Output:     pattern,
+			OutputType: URLPattern,
+			Source:     "pattern",
+		}.WithMetadata("count", groups[pattern].count).WithMetadata("representative", groups[pattern].representative))
+	}
+}
+
+// WithPatternDedup visits a single url per path template (see PathPattern): `/product/123` is visited and
+// `/product/456` skipped. At the end of the crawl, an url-pattern report is emitted for every template shared by
+// several urls, with their count and the visited one in the report Metadata
+func WithPatternDedup() CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.patternDedup = newPatternDedup()
+	}
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gocolly/colly/v2"
+)
+
+func TestPathPattern(t *testing.T) {
+	cases := map[string]string{
+		"https://shop.com/product/123":                                     "shop.com/product/{id}",
+		"https://shop.com/product/456?color=red":                           "shop.com/product/{id}",
+		"https://shop.com/u/3f2504e0-4f89-11d3-9a0c-0305e82c3301/settings": "shop.com/u/{id}/settings",
+		"https://shop.com/about":                                           "shop.com/about",
+	}
+	for raw, expected := range cases {
+		u, _ := url.Parse(raw)
+		if p := PathPattern(u); p != expected {
+			t.Errorf("PathPattern(%s) = %s, expected %s", raw, p, expected)
+		}
+	}
+}
+
+func TestPatternDedup(t *testing.T) {
+	var products atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><body><a href="/about">about</a>`)
+			for i := 1; i <= 5; i++ {
+				fmt.Fprintf(w, `<a href="/product/%d">product %d</a>`, i, i)
+			}
+			fmt.Fprint(w, `</body></html>`)
+		default:
+			if strings.HasPrefix(r.URL.Path, "/product/") {
+				products.Add(1)
+			}
+			fmt.Fprint(w, `<html><body>page</body></html>`)
+		}
+	}))
+	defer ts.Close()
+
+	var patterns []SpiderReport
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(2), WithPatternDedup()), ts.URL) {
+		if r.OutputType == URLPattern {
+			patterns = append(patterns, r)
+		}
+	}
+	if n := products.Load(); n != 1 {
+		t.Errorf("expected a single product page to be visited, got %d", n)
+	}
+	host := strings.TrimPrefix(ts.URL, "http://")
+	if len(patterns) != 1 || patterns[0].Output != host+"/product/{id}" || patterns[0].Metadata["count"] != 5 {
+		t.Fatalf("expected a single pattern report counting the 5 products, got %v", patterns)
+	}
+	if patterns[0].Metadata["representative"] != ts.URL+"/product/1" {
+		t.Errorf("expected the first product to be the representative, got %v", patterns[0].Metadata)
+	}
+}
+
+func TestPatternVisitCap(t *testing.T) {
+	const products, visitCap = 6, 2
+	var visited atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if strings.HasPrefix(r.URL.Path, "/product/") {
+			visited.Add(1)
+			fmt.Fprint(w, `<html><body>product</body></html>`)
+			return
+		}
+		fmt.Fprint(w, `<html><body>`)
+		for i := 1; i <= products; i++ {
+			fmt.Fprintf(w, `<a href="/product/%d">product %d</a>`, i, i)
+		}
+		fmt.Fprint(w, `</body></html>`)
+	}))
+	defer ts.Close()
+
+	var aborted atomic.Int32
+	countAborted := func(c *colly.Collector) error {
+		c.OnRequest(func(r *colly.Request) {
+			if r.Ctx.Get(abortedContextKey) != "" {
+				aborted.Add(1)
+			}
+		})
+		return nil
+	}
+	collectReports(NewCrawler(WithDefaultColly(2), WithCollyConfig(WithPatternVisitCap(visitCap), countAborted)), ts.URL)
+	if n := visited.Load(); n != visitCap {
+		t.Errorf("expected %d product pages to be visited, got %d", visitCap, n)
+	}
+	if n := aborted.Load(); n != products-visitCap {
+		t.Errorf("expected %d product pages to be aborted, got %d", products-visitCap, n)
+	}
+}
@@ -0,0 +1,247 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+type dialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// isSOCKSProxy reports whether pU is a SOCKS5 proxy, which http.Transport.Proxy can't route through
+func isSOCKSProxy(pU *url.URL) bool {
+	return pU.Scheme == "socks5" || pU.Scheme == "socks5h"
+}
+
+// socksDialContext returns a DialContext connecting through the SOCKS5 proxy pU, authenticated with its user info.
+// With the socks5 scheme host names are resolved locally, with socks5h they are resolved by the proxy
+func socksDialContext(pU *url.URL) (dialContextFunc, error) {
+	forward := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+	// x/net/proxy resolves with the proxy whatever the scheme
+	remote := *pU
+	remote.Scheme = "socks5"
+	dialer, err := proxy.FromURL(&remote, forward)
+	if err != nil {
+		return nil, fmt.Errorf("invalid socks proxy %s: %w", pU.Redacted(), err)
+	}
+	contextDialer, ok := dialer.(proxy.ContextDialer)
+	if !ok {
+		return nil, fmt.Errorf("socks proxy %s doesn't support contexts", pU.Redacted())
+	}
+	if pU.Scheme == "socks5h" {
+		return contextDialer.DialContext, nil
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if net.ParseIP(host) == nil {
+			ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+			if err != nil {
+				return nil, err
+			}
+			if len(ips) == 0 {
+				return nil, fmt.Errorf("no address found for %s", host)
+			}
+			addr = net.JoinHostPort(ips[0].IP.String(), port)
+		}
+		return contextDialer.DialContext(ctx, network, addr)
+	}, nil
+}
+
+// ProxyStrategy selects the proxy of each request of a proxy pool, see WithProxyPool
+type ProxyStrategy string
+
+var (
+	// ProxyRoundRobin uses the healthy proxies in turn
+	ProxyRoundRobin ProxyStrategy = "round-robin"
+	// ProxyRandom uses a random healthy proxy
+	ProxyRandom ProxyStrategy = "random"
+	// ProxyStickyPerHost always uses the same proxy for a host, until it is quarantined
+	ProxyStickyPerHost ProxyStrategy = "sticky"
+)
+
+var (
+	// ProxyPoolMaxFailures is the number of consecutive failures after which a proxy is quarantined
+	ProxyPoolMaxFailures = 3
+	// ProxyPoolQuarantine is how long a failing proxy is left out of the rotation
+	ProxyPoolQuarantine = time.Minute
+)
+
+type pooledProxy struct {
+	url *url.URL
+	// dial connects through the proxy when it is a SOCKS5 one
+	dial             dialContextFunc
+	transport        http.RoundTripper
+	failures         int
+	quarantinedUntil time.Time
+}
+
+// proxyPool rotates the requests over a set of proxies and quarantines the ones which keep failing
+type proxyPool struct {
+	lock     sync.Mutex
+	proxies  []*pooledProxy
+	strategy ProxyStrategy
+	next     int
+	sticky   map[string]*pooledProxy
+	rand     *rand.Rand
+}
+
+func newProxyPool(base *http.Transport, urls []string, strategy ProxyStrategy) (*proxyPool, error) {
+	pool := &proxyPool{
+		strategy: strategy,
+		sticky:   make(map[string]*pooledProxy),
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, raw := range urls {
+		pU, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy %s: %w", raw, err)
+		}
+		p := &pooledProxy{url: pU}
+		if isSOCKSProxy(pU) {
+			if p.dial, err = socksDialContext(pU); err != nil {
+				return nil, err
+			}
+		}
+		p.transport = p.route(base.Clone())
+		pool.proxies = append(pool.proxies, p)
+	}
+	if len(pool.proxies) == 0 {
+		return nil, fmt.Errorf("empty proxy pool")
+	}
+	return pool, nil
+}
+
+// route sends the requests of transport through p
+func (p *pooledProxy) route(transport *http.Transport) *http.Transport {
+	if p.dial != nil {
+		transport.Proxy = nil
+		transport.DialContext = p.dial
+	} else {
+		transport.Proxy = http.ProxyURL(p.url)
+	}
+	return transport
+}
+
+// replaceWrapped replaces the transport of every proxy, which keeps being routed through it
+func (pool *proxyPool) replaceWrapped(replace transportReplacer) error {
+	for _, p := range pool.proxies {
+		rt, err := replace(p.transport)
+		if err != nil {
+			return err
+		}
+		transport, ok := rt.(*http.Transport)
+		if !ok {
+			return fmt.Errorf("can't route the %T transport through proxy %s", rt, p.url.Redacted())
+		}
+		p.transport = p.route(transport)
+	}
+	return nil
+}
+
+// pick returns the proxy of a request to host. When every proxy is quarantined, the first one to be released is used
+func (pool *proxyPool) pick(host string) *pooledProxy {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	now := time.Now()
+	healthy := make([]*pooledProxy, 0, len(pool.proxies))
+	for _, p := range pool.proxies {
+		if !now.Before(p.quarantinedUntil) {
+			healthy = append(healthy, p)
+		}
+	}
+	if len(healthy) == 0 {
+		first := pool.proxies[0]
+		for _, p := range pool.proxies[1:] {
+			if p.quarantinedUntil.Before(first.quarantinedUntil) {
+				first = p
+			}
+		}
+		return first
+	}
+	switch pool.strategy {
+	case ProxyRandom:
+		return healthy[pool.rand.Intn(len(healthy))]
+	case ProxyStickyPerHost:
+		if p, ok := pool.sticky[host]; ok && !now.Before(p.quarantinedUntil) {
+			return p
+		}
+		p := healthy[pool.rand.Intn(len(healthy))]
+		pool.sticky[host] = p
+		return p
+	default:
+		p := healthy[pool.next%len(healthy)]
+		pool.next++
+		return p
+	}
+}
+
+// record updates the health of p after a request, a transport error or a 407 counts as a failure
+func (pool *proxyPool) record(p *pooledProxy, failed bool) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	if !failed {
+		p.failures = 0
+		return
+	}
+	p.failures++
+	if p.failures >= ProxyPoolMaxFailures {
+		Logger.Warnf("Quarantining proxy %s for %s after %d failures", p.url.Redacted(), ProxyPoolQuarantine, p.failures)
+		p.quarantinedUntil = time.Now().Add(ProxyPoolQuarantine)
+		p.failures = 0
+	}
+}
+
+func (pool *proxyPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	p := pool.pick(req.URL.Host)
+	resp, err := p.transport.RoundTrip(req)
+	// a canceled request says nothing about the proxy
+	if req.Context().Err() == nil {
+		pool.record(p, err != nil || resp.StatusCode == http.StatusProxyAuthRequired)
+	}
+	return resp, err
+}
+
+// WithProxyPool rotates the requests over proxies (HTTP(S) or SOCKS5) following strategy.
+// Proxies failing ProxyPoolMaxFailures times in a row are left out of the rotation for ProxyPoolQuarantine.
+// The crawl fails rather than going direct when the pool can't be set
+func WithProxyPool(proxies []string, strategy ProxyStrategy) HTTPClientConfigurator {
+	return func(client *http.Client) error {
+		return replaceNext(&client.Transport, func(rt http.RoundTripper) (http.RoundTripper, error) {
+			base, ok := rt.(*http.Transport)
+			if !ok && rt != nil {
+				return nil, fmt.Errorf("can't route the %T transport of the client through a proxy pool", rt)
+			} else if !ok {
+				base = DefaultHTTPTransport
+			}
+			pool, err := newProxyPool(base, proxies, strategy)
+			if err != nil {
+				return nil, fmt.Errorf("failed to set proxy pool: %w", err)
+			}
+			Logger.Infof("Proxy pool: %d proxies, %s", len(pool.proxies), strategy)
+			return pool, nil
+		})
+	}
+}
+
+// WithProxyFunc routes each request through the proxy returned by proxyFunc, e.g to reach internal targets through
+// an internal proxy and the others through a commercial rotator. A nil url sends the request directly.
+// Besides HTTP(S) proxies, the net/http transport accepts socks5 urls
+func WithProxyFunc(proxyFunc func(*http.Request) (*url.URL, error)) HTTPClientConfigurator {
+	return func(client *http.Client) error {
+		return configureTransport(client, func(transport *http.Transport) error {
+			transport.Proxy = proxyFunc
+			return nil
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package core
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// serveSOCKS5 runs a minimal unauthenticated SOCKS5 server, sending the requested host of each CONNECT to hostC
+func serveSOCKS5(t *testing.T, hostC chan<- string) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				buf := make([]byte, 262)
+				// greeting: version, methods
+				if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+					return
+				}
+				io.ReadFull(conn, buf[:buf[1]])
+				conn.Write([]byte{5, 0})
+				// request: version, command, reserved, address type
+				if _, err := io.ReadFull(conn, buf[:4]); err != nil {
+					return
+				}
+				host := ""
+				switch buf[3] {
+				case 1:
+					io.ReadFull(conn, buf[:4])
+					host = net.IP(buf[:4]).String()
+				case 4:
+					io.ReadFull(conn, buf[:16])
+					host = net.IP(buf[:16]).String()
+				case 3:
+					io.ReadFull(conn, buf[:1])
+					n := int(buf[0])
+					io.ReadFull(conn, buf[:n])
+					host = string(buf[:n])
+				default:
+					return
+				}
+				io.ReadFull(conn, buf[:2])
+				port := binary.BigEndian.Uint16(buf[:2])
+				hostC <- host
+				target, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+				if err != nil {
+					conn.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0})
+					return
+				}
+				defer target.Close()
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				go io.Copy(target, conn)
+				io.Copy(conn, target)
+			}(conn)
+		}
+	}()
+	return l
+}
+
+func TestSOCKSDialContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "through socks")
+	}))
+	defer ts.Close()
+	target, _ := url.Parse(ts.URL)
+	target.Host = "localhost:" + target.Port()
+
+	hostC := make(chan string, 1)
+	l := serveSOCKS5(t, hostC)
+	defer l.Close()
+
+	// with socks5 the proxy is asked for an ip, with socks5h for the host name
+	for scheme, resolvedByProxy := range map[string]bool{"socks5": false, "socks5h": true} {
+		pU, _ := url.Parse(scheme + "://" + l.Addr().String())
+		dial, err := socksDialContext(pU)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client := &http.Client{Transport: &http.Transport{DialContext: dial}}
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, target.String(), nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s: %s", scheme, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if !strings.Contains(string(body), "through socks") {
+			t.Errorf("%s: unexpected body %q", scheme, body)
+		}
+		if host := <-hostC; (host == "localhost") != resolvedByProxy {
+			t.Errorf("%s: unexpected host %s asked to the proxy", scheme, host)
+		}
+	}
+}
+
+func TestProxyPool(t *testing.T) {
+	hits := make(map[string]int)
+	var lock sync.Mutex
+	newProxy := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			hits[name]++
+			lock.Unlock()
+			fmt.Fprint(w, name)
+		}))
+	}
+	a, b := newProxy("a"), newProxy("b")
+	defer a.Close()
+	defer b.Close()
+	// a closed listener makes a dead proxy
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+
+	get := func(client *http.Client, u string) string {
+		resp, err := client.Get(u)
+		if err != nil {
+			return ""
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	client := &http.Client{Transport: &http.Transport{}}
+	WithProxyPool([]string{a.URL, b.URL}, ProxyRoundRobin)(client)
+	for i := 0; i < 4; i++ {
+		get(client, "http://example.com/")
+	}
+	if hits["a"] != 2 || hits["b"] != 2 {
+		t.Errorf("expected round robin over the proxies, got %v", hits)
+	}
+
+	client = &http.Client{Transport: &http.Transport{}}
+	WithProxyPool([]string{a.URL, b.URL}, ProxyStickyPerHost)(client)
+	first := get(client, "http://sticky.example.com/")
+	for i := 0; i < 5; i++ {
+		if got := get(client, "http://sticky.example.com/"); got != first {
+			t.Fatalf("expected the host to stick to proxy %s, got %s", first, got)
+		}
+	}
+
+	client = &http.Client{Transport: &http.Transport{}}
+	WithProxyPool([]string{dead.URL, a.URL}, ProxyRoundRobin)(client)
+	for i := 0; i < 2*ProxyPoolMaxFailures; i++ {
+		get(client, "http://example.com/")
+	}
+	pool := client.Transport.(*proxyPool)
+	if pool.proxies[0].quarantinedUntil.IsZero() {
+		t.Fatal("expected the dead proxy to be quarantined")
+	}
+	for i := 0; i < 3; i++ {
+		if got := get(client, "http://example.com/"); got != "a" {
+			t.Errorf("expected the requests to avoid the quarantined proxy, got %q", got)
+		}
+	}
+
+	crawler := NewCrawler(WithDefaultColly(1), WithHTTPClientOpt(WithProxyPool([]string{"://invalid"}, ProxyRoundRobin)))
+	if err := crawlError(t, crawler, a.URL); err == nil {
+		t.Error("expected an invalid proxy pool to fail the crawl instead of going direct")
+	}
+}
+
+func TestProxyFunc(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "internal proxy")
+	}))
+	defer internal.Close()
+	direct := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "direct")
+	}))
+	defer direct.Close()
+	internalURL, _ := url.Parse(internal.URL)
+
+	client := &http.Client{Transport: &http.Transport{}}
+	WithProxyFunc(func(req *http.Request) (*url.URL, error) {
+		if strings.HasSuffix(req.URL.Hostname(), ".corp") {
+			return internalURL, nil
+		}
+		return nil, nil
+	})(client)
+	for u, expected := range map[string]string{"http://intranet.corp/": "internal proxy", direct.URL: "direct"} {
+		resp, err := client.Get(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != expected {
+			t.Errorf("%s: expected %q, got %q", u, expected, body)
+		}
+	}
+}
+
+func TestHTTPProxyDoesNotShareTransport(t *testing.T) {
+	proxied := &http.Client{Transport: DefaultHTTPTransport.Clone()}
+	WithHTTPProxy("http://127.0.0.1:8080")(proxied)
+	other := &http.Client{Transport: DefaultHTTPTransport.Clone()}
+	WithHTTPProxy("socks5://127.0.0.1:1080")(other)
+
+	if DefaultHTTPTransport.Proxy != nil {
+		t.Error("expected DefaultHTTPTransport to be left untouched")
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	if pU, _ := proxied.Transport.(*http.Transport).Proxy(req); pU == nil || pU.Host != "127.0.0.1:8080" {
+		t.Errorf("expected the http proxy, got %v", pU)
+	}
+	if other.Transport.(*http.Transport).Proxy != nil {
+		t.Error("expected the socks client to dial through its proxy instead of an http proxy")
+	}
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+var (
+	serviceWorkerRegisterRE = regexp.MustCompile(`serviceWorker\s*\.\s*register\s*\(\s*["'\x60]([^"'\x60]+)["'\x60]`)
+	serviceWorkerMarkersRE  = regexp.MustCompile(`caches\s*\.\s*open|precache|skipWaiting|addEventListener\s*\(\s*["']fetch["']`)
+	cacheAddAllRE           = regexp.MustCompile(`(?s)\.addAll\s*\(\s*\[(.*?)\]`)
+	precacheEntryRE         = regexp.MustCompile(`["']?url["']?\s*:\s*["']([^"']+)["']`)
+	jsStringLiteralRE       = regexp.MustCompile(`["'\x60]([^"'\x60]+)["'\x60]`)
+)
+
+// ServiceWorkerRegistrations returns the scripts registered through `navigator.serviceWorker.register(...)` in a page or a script
+func ServiceWorkerRegistrations(source string) []string {
+	res := []string{}
+	for _, m := range serviceWorkerRegisterRE.FindAllStringSubmatch(source, -1) {
+		res = append(res, m[1])
+	}
+	return Unique(res)
+}
+
+// IsServiceWorkerScript reports whether source looks like a service worker (cache handling, fetch listener...)
+func IsServiceWorkerScript(source string) bool {
+	return serviceWorkerMarkersRE.MatchString(source)
+}
+
+// ServiceWorkerRoutes returns the routes cached by a service worker: `cache.addAll([...])` lists and precache manifest entries
+func ServiceWorkerRoutes(source string) []string {
+	res := []string{}
+	for _, m := range cacheAddAllRE.FindAllStringSubmatch(source, -1) {
+		for _, lit := range jsStringLiteralRE.FindAllStringSubmatch(m[1], -1) {
+			res = append(res, lit[1])
+		}
+	}
+	for _, m := range precacheEntryRE.FindAllStringSubmatch(source, -1) {
+		res = append(res, m[1])
+	}
+	return Unique(res)
+}
+
+type webAppManifest struct {
+	StartURL  string `json:"start_url"`
+	Scope     string `json:"scope"`
+	Shortcuts []struct {
+		URL string `json:"url"`
+	} `json:"shortcuts"`
+	ShareTarget struct {
+		Action string `json:"action"`
+	} `json:"share_target"`
+	ProtocolHandlers []struct {
+		URL string `json:"url"`
+	} `json:"protocol_handlers"`
+}
+
+// ManifestRoutes parses a web app manifest and returns its start_url, scope, shortcuts, share target and protocol handlers urls.
+// ok is false when source is not a web app manifest
+func ManifestRoutes(source string) (routes []string, ok bool) {
+	trimmed := strings.TrimSpace(source)
+	if !strings.HasPrefix(trimmed, "{") {
+		return nil, false
+	}
+	manifest := webAppManifest{}
+	if err := json.Unmarshal([]byte(trimmed), &manifest); err != nil {
+		return nil, false
+	}
+	routes = []string{manifest.StartURL, manifest.Scope, manifest.ShareTarget.Action}
+	for _, s := range manifest.Shortcuts {
+		routes = append(routes, s.URL)
+	}
+	for _, p := range manifest.ProtocolHandlers {
+		routes = append(routes, strings.ReplaceAll(p.URL, "%s", ""))
+	}
+	res := []string{}
+	for _, r := range routes {
+		if r != "" {
+			res = append(res, r)
+		}
+	}
+	if len(res) == 0 {
+		return nil, false
+	}
+	return Unique(res), true
+}
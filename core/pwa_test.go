@@ -0,0 +1,51 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPWADiscovery(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><head><link rel="manifest" href="/app.webmanifest"></head>
+<body><script>navigator.serviceWorker.register('/sw.js', {scope: '/'})</script></body></html>`)
+		case "/app.webmanifest":
+			w.Header().Set("Content-Type", "application/manifest+json")
+			fmt.Fprint(w, `{"name": "app", "start_url": "/home?source=pwa", "shortcuts": [{"name": "orders", "url": "/orders"}]}`)
+		case "/sw.js":
+			w.Header().Set("Content-Type", "application/javascript")
+			fmt.Fprint(w, `self.addEventListener('install', e => e.waitUntil(caches.open('v1').then(c => c.addAll(['/offline.html', '/admin/dashboard']))))`)
+		default:
+			fmt.Fprint(w, "ok")
+		}
+	}))
+	defer ts.Close()
+
+	found := map[string]SpiderReport{}
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(3), WithPWADiscovery()), ts.URL) {
+		found[r.Output] = r
+	}
+	expected := map[string]string{
+		ts.URL + "/app.webmanifest": string(Manifest),
+		ts.URL + "/sw.js":           string(ServiceWorker),
+		ts.URL + "/home?source=pwa": "manifest",
+		ts.URL + "/orders":          "manifest",
+		ts.URL + "/offline.html":    "service-worker",
+		ts.URL + "/admin/dashboard": "service-worker",
+	}
+	for u, kind := range expected {
+		r, ok := found[u]
+		if !ok {
+			t.Errorf("expected %s to be discovered", u)
+			continue
+		}
+		if string(r.OutputType) != kind && r.Source != kind {
+			t.Errorf("expected %s to be discovered through %s, got %s/%s", u, kind, r.OutputType, r.Source)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/benji-bou/gospider/stringset"
+)
+
+var ErrDuplicateQuery = errors.New("url already visited with another query")
+
+// DefaultTrackingParams are the tracking parameters stripped by WithStripQueryParams when no parameter is given.
+// A name ending with * matches every parameter starting with the prefix
+var DefaultTrackingParams = []string{
+	"utm_*", "gclid", "gclsrc", "dclid", "fbclid", "msclkid", "yclid", "twclid", "igshid",
+	"mc_cid", "mc_eid", "_ga", "_gl", "_hsenc", "_hsmi", "mkt_tok", "ref_src",
+}
+
+// queryNormalizer rewrites the query of the visited urls, see WithStripQueryParams, WithQueryAllowlist and WithIgnoreQuery
+type queryNormalizer struct {
+	strip []string
+	// allow, when set, are the only parameters kept
+	allow  []string
+	ignore bool
+	// visited dedups the visited urls without their query when the query is ignored
+	visited *stringset.StringFilter
+}
+
+// queryNormalizer returns the query normalizer of the crawler, created on the first call
+func (crawler *Crawler) queryNormalizer() *queryNormalizer {
+	if crawler.queryNorm == nil {
+		crawler.queryNorm = &queryNormalizer{visited: stringset.NewStringFilter()}
+	}
+	return crawler.queryNorm
+}
+
+// matchParam reports whether name matches one of patterns, case insensitively
+func matchParam(patterns []string, name string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(name, prefix) || pattern == name {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterQuery returns the raw query keeping the parameters for which keep is true, in their order and encoding
+func FilterQuery(rawQuery string, keep func(name string) bool) string {
+	kept := []string{}
+	for _, part := range strings.Split(rawQuery, "&") {
+		if part == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(part, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if keep(name) {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, "&")
+}
+
+// normalize removes the stripped and the not allowed parameters of u
+func (qn *queryNormalizer) normalize(u *url.URL) {
+	if u.RawQuery == "" {
+		return
+	}
+	u.RawQuery = FilterQuery(u.RawQuery, func(name string) bool {
+		return !matchParam(qn.strip, name) && (len(qn.allow) == 0 || matchParam(qn.allow, name))
+	})
+	u.ForceQuery = false
+}
+
+// apply returns the normalized u, and whether it is a duplicate of a visited url when the query is ignored
+func (qn *queryNormalizer) apply(u string) (string, bool) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return u, false
+	}
+	qn.normalize(parsed)
+	if qn.ignore {
+		key := *parsed
+		key.RawQuery, key.Fragment, key.RawFragment = "", "", ""
+		if qn.visited.Duplicate(key.String()) {
+			return parsed.String(), true
+		}
+	}
+	return parsed.String(), false
+}
+
+// WithStripQueryParams removes params from the query of the visited urls, DefaultTrackingParams when params is empty.
+// A parameter ending with * strips every parameter starting with the prefix, utm_* strips utm_source and utm_medium
+func WithStripQueryParams(params ...string) CrawlerOption {
+	return func(crawler *Crawler) {
+		if len(params) == 0 {
+			params = DefaultTrackingParams
+		}
+		qn := crawler.queryNormalizer()
+		qn.strip = append(qn.strip, params...)
+	}
+}
+
+// WithQueryAllowlist keeps only params in the query of the visited urls, so the calendar, filter and sort permutations
+// don't make the crawl endless. A parameter ending with * keeps every parameter starting with the prefix
+func WithQueryAllowlist(params ...string) CrawlerOption {
+	return func(crawler *Crawler) {
+		qn := crawler.queryNormalizer()
+		qn.allow = append(qn.allow, params...)
+	}
+}
+
+// WithIgnoreQuery ignores the query of the urls for deduplication: an url is not visited when the same url with
+// another query already was
+func WithIgnoreQuery() CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.queryNormalizer().ignore = true
+	}
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestQueryNormalizer(t *testing.T) {
+	tests := []struct {
+		qn       queryNormalizer
+		u        string
+		expected string
+	}{
+		{queryNormalizer{strip: DefaultTrackingParams}, "https://example.com/?id=1&utm_source=x&UTM_Medium=y&gclid=z#top", "https://example.com/?id=1#top"},
+		{queryNormalizer{strip: DefaultTrackingParams}, "https://example.com/?utm_source=x", "https://example.com/"},
+		{queryNormalizer{allow: []string{"id", "page*"}}, "https://example.com/list?sort=asc&id=1&page_size=10&filter%5B%5D=a", "https://example.com/list?id=1&page_size=10"},
+		{queryNormalizer{strip: []string{"sid"}}, "https://example.com/?q=a%20b&sid=1&q=c", "https://example.com/?q=a%20b&q=c"},
+	}
+	for _, test := range tests {
+		if u, duplicate := test.qn.apply(test.u); u != test.expected || duplicate {
+			t.Errorf("expected %s for %s, got %s (%t)", test.expected, test.u, u, duplicate)
+		}
+	}
+}
+
+func TestIgnoreQuery(t *testing.T) {
+	var mu sync.Mutex
+	visited := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		visited = append(visited, r.URL.RequestURI())
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/" {
+			fmt.Fprint(w, `<html><body>
+<a href="/calendar?month=1&utm_source=news">january</a>
+<a href="/calendar?month=2">february</a>
+<a href="/about?utm_campaign=spring">about</a>
+</body></html>`)
+		}
+	}))
+	defer ts.Close()
+
+	collectReports(NewCrawler(WithDefaultColly(2), WithStripQueryParams(), WithIgnoreQuery()), ts.URL)
+	sort.Strings(visited)
+	expected := []string{"/", "/about", "/calendar?month=1"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("expected %v to be visited, got %v", expected, visited)
+	}
+}
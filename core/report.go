@@ -2,8 +2,10 @@ package core
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/benji-bou/chantools"
 	"golang.org/x/net/publicsuffix"
@@ -12,26 +14,106 @@ import (
 type OutputType string
 
 var (
-	Ref    OutputType = "ref"
-	Src    OutputType = "src"
-	Upload OutputType = "upload-form"
-	Form   OutputType = "form"
-	Url    OutputType = "url"
-	S3     OutputType = "aws-s3"
-	Domain OutputType = "domain"
+	Ref     OutputType = "ref"
+	Src     OutputType = "src"
+	Upload  OutputType = "upload-form"
+	Form    OutputType = "form"
+	Url     OutputType = "url"
+	S3      OutputType = "aws-s3"
+	Domain  OutputType = "domain"
+	Match   OutputType = "match"
+	Extract OutputType = "extract"
+	Record  OutputType = "record"
+
+	// The cloud storage types are the buckets of the providers besides S3
+	GCPBucket      OutputType = "gcp-bucket"
+	AzureContainer OutputType = "azure-blob"
+	DOSpace        OutputType = "do-space"
+	OSSBucket      OutputType = "alibaba-oss"
+
+	// The backend types are the Firebase and Supabase projects, with their project id in the report Metadata
+	FirebaseDatabase OutputType = "firebase-database"
+	FirebaseApp      OutputType = "firebase-app"
+	Supabase         OutputType = "supabase"
+
+	ServiceWorker OutputType = "service-worker"
+	Manifest      OutputType = "manifest"
+	DeadLetter    OutputType = "dead-letter"
+
+	BudgetExhausted OutputType = "budget-exhausted"
+	// Throttled is a host whose concurrency was reduced by WithThrottleOn5xx, with its 5xx rate and new concurrency in
+	// the report Metadata
+	Throttled OutputType = "throttled"
+
+	// Sitemap is an url listed by a sitemap, with its lastmod, changefreq and priority in the report Metadata
+	Sitemap OutputType = "sitemap"
+
+	// Favicon is a favicon, with its Shodan hash in the report Metadata
+	Favicon OutputType = "favicon"
+
+	// Tech is a technology found on a host, with its name and version in the report Metadata
+	Tech OutputType = "tech"
+	// SecurityHeaders lists the missing or weak security headers of an origin, in the report Metadata
+	SecurityHeaders OutputType = "security-headers"
+	// CORS is the CORS policy of an API endpoint for a probed origin, in the report Metadata
+	CORS OutputType = "cors"
+	// Secret is a secret found in a body by a SecretRule, named in the report Matcher
+	Secret OutputType = "secret"
+	// Comment is an html comment holding developer leftovers, listed in the report Metadata
+	Comment OutputType = "comment"
+	// APIRef is an url-shaped value of a JSON or XML response
+	APIRef OutputType = "api-ref"
+	// SourceMapOutput flags an exposed source map, SourceFile is an original source it lists
+	SourceMapOutput OutputType = "source-map"
+	SourceFile      OutputType = "source-file"
+	// APIEndpoint is an operation of a Swagger or OpenAPI spec, with its method and path in the report Metadata
+	APIEndpoint OutputType = "api-endpoint"
+	// WebSocket is a websocket endpoint, with the answer to its handshake probe in the report Metadata
+	WebSocket OutputType = "websocket"
+	// Parameters lists the query parameter names seen on a host, with their count in the report Metadata
+	Parameters OutputType = "parameters"
+	// URLPattern is a path template shared by several urls, with their count in the report Metadata
+	URLPattern OutputType = "url-pattern"
+	// Link is the target of a canonical, alternate or resource hint <link>, with its relations in the report Metadata
+	Link OutputType = "link"
+	// Feed is an item of a RSS or Atom feed, with its title and date in the report Metadata
+	Feed OutputType = "feed"
+
+	LinkFinderOutput OutputType = "linkfinder"
 )
 
+// isURL reports whether the Output of the reports of type ot is an url, relative to the report Input
+func (ot OutputType) isURL() bool {
+	switch ot {
+	case Ref, Src, Upload, Form, Url, ServiceWorker, Manifest, LinkFinderOutput, APIRef, Link, Feed, Sitemap:
+		return true
+	default:
+		return false
+	}
+}
+
 func (ot OutputType) FixUrl(mainUrl *url.URL, newLoc string) string {
+	if !ot.isURL() {
+		return newLoc
+	}
 	return FixUrl(mainUrl, newLoc)
 }
 
 func (ot OutputType) KeepCrawling() func(value SpiderReport) []string {
 	defaultCB := func(v SpiderReport) []string { return []string{} }
 	switch ot {
-	case Ref:
+	case Ref, ServiceWorker, Manifest, Sitemap, APIRef, Link, Feed:
 		return func(v SpiderReport) []string { return []string{v.Output} }
 	// case Url:
 	// return func(v SpiderReport) []string { return []string{v.Output} }
+	case LinkFinderOutput:
+		return func(v SpiderReport) []string {
+			res := []string{v.Output}
+			if strings.Contains(v.Output, ".min.js") {
+				res = append(res, strings.ReplaceAll(v.Output, ".min.js", ".js"))
+			}
+			return res
+		}
 	case Src:
 		return func(v SpiderReport) []string {
 			res := []string{}
@@ -51,14 +133,88 @@ func (ot OutputType) KeepCrawling() func(value SpiderReport) []string {
 }
 
 type SpiderReport struct {
-	Output     string     `json:"output" pp:"Output"`
-	OutputType OutputType `json:"type" pp:"Type"`
-	StatusCode int        `json:"status" pp:"Status"`
-	Source     string     `json:"source" pp:"Source"`
-	Body       string     `json:"-" pp:"-"`
-	Err        error
-	Input      *url.URL `json:"input"`
-	Length     int      `json:"length"`
+	Output      string            `json:"output" pp:"Output"`
+	OutputType  OutputType        `json:"type" pp:"Type"`
+	StatusCode  int               `json:"status" pp:"Status"`
+	Source      string            `json:"source" pp:"Source"`
+	Body        string            `json:"-" pp:"-"`
+	Err         error             `json:"-"`
+	Input       *url.URL          `json:"input"`
+	Length      int               `json:"length"`
+	Language    string            `json:"language,omitempty"`
+	Title       string            `json:"title,omitempty"`
+	ContentType string            `json:"content_type,omitempty"`
+	Matcher     string            `json:"matcher,omitempty"`
+	Record      map[string]string `json:"record,omitempty"`
+	Offset      int               `json:"offset,omitempty"`
+	Snippet     string            `json:"snippet,omitempty"`
+	BodyRef     string            `json:"body_ref,omitempty"`
+	Seed        string            `json:"seed,omitempty"`
+	Retries     int               `json:"retries,omitempty"`
+	Job         map[string]string `json:"job,omitempty"`
+	// Headers are the response headers of the url reports
+	Headers http.Header `json:"headers,omitempty"`
+	// TTFB is the time to the first byte of the response, and Duration the time until it was read
+	TTFB     time.Duration `json:"-"`
+	Duration time.Duration `json:"-"`
+	// FinalURL is the url of the response when the request was redirected, Redirects the urls it was redirected from
+	FinalURL  string   `json:"final_url,omitempty"`
+	Redirects []string `json:"redirects,omitempty"`
+	// Metadata holds the data of a single extraction module, e.g the lastmod of a sitemap entry. The response
+	// attributes shared by the reports of several modules, like the title, the headers or the timings, are fields
+	Metadata map[string]any `json:"metadata,omitempty"`
+
+	bodyStore BodyStore
+	// dedupKey deduplicates the report instead of its Output, see pageDedupKey
+	dedupKey string
+}
+
+// pageDedupKey deduplicates the values extracted or matched by a rule per page rather than per crawl,
+// a price or an id showing on several pages being reported for each of them
+func pageDedupKey(input *url.URL, rule string, value string) string {
+	return input.String() + " " + rule + " " + value
+}
+
+// WithMetadata returns a copy of the receiver with key set to value in its Metadata
+func (ov SpiderReport) WithMetadata(key string, value any) SpiderReport {
+	metadata := make(map[string]any, len(ov.Metadata)+1)
+	for k, v := range ov.Metadata {
+		metadata[k] = v
+	}
+	metadata[key] = value
+	ov.Metadata = metadata
+	return ov
+}
+
+// LoadBody returns the report body, reading it from the crawler BodyStore when it was stored on disk (see WithBodyStore)
+func (ov SpiderReport) LoadBody() (string, error) {
+	if ov.Body != "" || ov.BodyRef == "" {
+		return ov.Body, nil
+	}
+	if ov.bodyStore == nil {
+		return "", fmt.Errorf("no body store attached to report %s", ov.Output)
+	}
+	body, err := ov.bodyStore.Get(ov.BodyRef)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+func (ov SpiderReport) withBody(body string, store BodyStore) SpiderReport {
+	if store == nil {
+		ov.Body = body
+		return ov
+	}
+	ref, err := store.Put([]byte(body))
+	if err != nil {
+		Logger.Errorf("Failed to store body of %s, keeping it in memory: %s", ov.Output, err)
+		ov.Body = body
+		return ov
+	}
+	ov.BodyRef = ref
+	ov.bodyStore = store
+	return ov
 }
 
 func (ov SpiderReport) FixUrl() SpiderReport {
@@ -66,44 +222,79 @@ func (ov SpiderReport) FixUrl() SpiderReport {
 	return ov
 }
 
+// Canonicalize resolves the Output of the url reports against their Input and normalizes it with cz
+func (ov SpiderReport) Canonicalize(cz Canonicalizer) SpiderReport {
+	if ov.OutputType.isURL() {
+		ov.Output = cz.Resolve(ov.Input, ov.Output)
+	}
+	return ov
+}
+
 // SubdomainsDerivatedValues: search for subdomains in the body of the SpiderReport receiver
 // if body is empty, no search are performed
 // the resulting Outputs values are clone of reveiver execpt for the output which will be the fqdn found and outputType will be set to `Domain`
 func (ov SpiderReport) SubdomainsDerivatedValues() ([]SpiderReport, error) {
 	res := []SpiderReport{}
-	if len(ov.Body) > 0 {
+	body, err := ov.LoadBody()
+	if err != nil {
+		return res, fmt.Errorf("failed fetching subdomains derivated value for %s %s: %w", ov.OutputType, ov.Output, err)
+	}
+	if len(body) > 0 {
 		topDomain, err := publicsuffix.EffectiveTLDPlusOne(ov.Input.Hostname())
 		if err != nil {
 			return res, fmt.Errorf("failed fetching subdomains derivated value for %s %s: %w", ov.OutputType, ov.Output, err)
 		}
-		for _, fqdn := range GetSubdomains(ov.Body, topDomain) {
+		for _, fqdn := range GetSubdomains(body, topDomain) {
 			res = append(res, SpiderReport{
 				Output:     fqdn,
 				OutputType: Domain,
 				Source:     ov.Source,
 				Body:       ov.Body,
+				BodyRef:    ov.BodyRef,
 				StatusCode: ov.StatusCode,
 				Input:      ov.Input,
+				Seed:       ov.Seed,
+				bodyStore:  ov.bodyStore,
 			})
 		}
 	}
 	return res, nil
 }
+
+// CloudStorageDerivatedValues: search for cloud storage urls (see GetCloudStorage) in the body of the SpiderReport receiver.
+// The resulting Outputs are clones of the receiver with the url found as output, and the type of its provider
+func (ov SpiderReport) CloudStorageDerivatedValues() ([]SpiderReport, error) {
+	res := []SpiderReport{}
+	body, err := ov.LoadBody()
+	if err != nil {
+		return res, fmt.Errorf("failed fetching cloud storage derivated value for %s %s: %w", ov.OutputType, ov.Output, err)
+	}
+	for _, storage := range GetCloudStorage(body) {
+		res = append(res, SpiderReport{
+			Output:     storage.URL,
+			OutputType: storage.OutputType,
+			Source:     ov.Source,
+			Body:       ov.Body,
+			BodyRef:    ov.BodyRef,
+			StatusCode: ov.StatusCode,
+			Input:      ov.Input,
+			Seed:       ov.Seed,
+			bodyStore:  ov.bodyStore,
+		})
+	}
+	return res, nil
+}
+
+// AwsS3DerivatedValues returns the S3 reports of CloudStorageDerivatedValues
 func (ov SpiderReport) AwsS3DerivatedValues() ([]SpiderReport, error) {
+	storage, err := ov.CloudStorageDerivatedValues()
 	res := []SpiderReport{}
-	if len(ov.Body) > 0 {
-		for _, s3 := range GetAWSS3(ov.Body) {
-			res = append(res, SpiderReport{
-				Output:     s3,
-				OutputType: S3,
-				Source:     ov.Source,
-				Body:       ov.Body,
-				StatusCode: ov.StatusCode,
-				Input:      ov.Input,
-			})
+	for _, report := range storage {
+		if report.OutputType == S3 {
+			res = append(res, report)
 		}
 	}
-	return res, nil
+	return res, err
 }
 
 func (ov SpiderReport) DerivatedValues() ([]SpiderReport, error) {
@@ -111,12 +302,12 @@ func (ov SpiderReport) DerivatedValues() ([]SpiderReport, error) {
 	if err != nil {
 		return nil, err
 	}
-	awsS3, err := ov.AwsS3DerivatedValues()
+	storage, err := ov.CloudStorageDerivatedValues()
 	if err != nil {
 		return subDomains, err
 	}
 
-	return append(subDomains, awsS3...), nil
+	return append(subDomains, storage...), nil
 }
 
 func (ov SpiderReport) AsyncDerivatedValues() (<-chan []SpiderReport, <-chan error) {
@@ -0,0 +1,229 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Resolver resolves the host names dialed by the crawler, *net.Resolver is one
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// resolverDialContext dials addr through dial, after resolving its host with resolver. The addresses are tried in turn
+func resolverDialContext(dial dialContextFunc, resolver Resolver) dialContextFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if net.ParseIP(host) != nil {
+			return dial(ctx, network, addr)
+		}
+		ips, err := resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		if len(ips) == 0 {
+			return nil, fmt.Errorf("no address found for %s", host)
+		}
+		for _, ip := range ips {
+			var conn net.Conn
+			conn, err = dial(ctx, network, net.JoinHostPort(ip.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+		}
+		return nil, err
+	}
+}
+
+// WithDNSResolver resolves the host names with resolver, e.g a DNSCache of a DoHResolver
+func WithDNSResolver(resolver Resolver) HTTPClientConfigurator {
+	return func(client *http.Client) error {
+		return configureTransport(client, func(transport *http.Transport) error {
+			transport.DialContext = resolverDialContext(transportDialContext(transport), resolver)
+			return nil
+		})
+	}
+}
+
+// transportDialContext returns the DialContext of transport, which a nil one defaults to
+func transportDialContext(transport *http.Transport) dialContextFunc {
+	if transport.DialContext == nil {
+		return (&net.Dialer{}).DialContext
+	}
+	return transport.DialContext
+}
+
+// NewDNSServerResolver returns a resolver querying the DNS server addr (host or host:port)
+func NewDNSServerResolver(addr string) *net.Resolver {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "53")
+	}
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+// WithResolver resolves the host names with the DNS server addr (host or host:port) instead of the system resolver,
+// e.g to bypass a split-horizon DNS
+func WithResolver(addr string) HTTPClientConfigurator {
+	return func(client *http.Client) error {
+		Logger.Infof("Resolver: %s", addr)
+		return WithDNSResolver(NewDNSServerResolver(addr))(client)
+	}
+}
+
+// DoHResolver resolves host names with DNS-over-HTTPS (RFC 8484)
+type DoHResolver struct {
+	// Endpoint is the DoH url, e.g https://cloudflare-dns.com/dns-query
+	Endpoint string
+	// Client sends the DNS queries, http.DefaultClient when nil
+	Client *http.Client
+}
+
+// LookupIPAddr queries the A and AAAA records of host
+func (r *DoHResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	var ips []net.IPAddr
+	var lastErr error
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		found, err := r.query(ctx, host, qtype)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		ips = append(ips, found...)
+	}
+	if len(ips) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return ips, nil
+}
+
+func (r *DoHResolver) query(ctx context.Context, host string, qtype dnsmessage.Type) ([]net.IPAddr, error) {
+	name, err := dnsmessage.NewName(dnsFQDN(host))
+	if err != nil {
+		return nil, fmt.Errorf("invalid host %s: %w", host, err)
+	}
+	// RFC 8484 recommends the ID 0 for caching
+	msg := dnsmessage.Message{
+		Header:    dnsmessage.Header{RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	packed, err := msg.Pack()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.Endpoint, bytes.NewReader(packed))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("doh %s answered %d", r.Endpoint, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, err
+	}
+	var answer dnsmessage.Message
+	if err := answer.Unpack(body); err != nil {
+		return nil, fmt.Errorf("invalid doh answer for %s: %w", host, err)
+	}
+	if answer.RCode != dnsmessage.RCodeSuccess {
+		return nil, fmt.Errorf("failed to resolve %s: %s", host, answer.RCode)
+	}
+	ips := make([]net.IPAddr, 0, len(answer.Answers))
+	for _, rr := range answer.Answers {
+		switch res := rr.Body.(type) {
+		case *dnsmessage.AResource:
+			ips = append(ips, net.IPAddr{IP: net.IP(res.A[:])})
+		case *dnsmessage.AAAAResource:
+			ips = append(ips, net.IPAddr{IP: net.IP(res.AAAA[:])})
+		}
+	}
+	return ips, nil
+}
+
+func dnsFQDN(host string) string {
+	if len(host) > 0 && host[len(host)-1] == '.' {
+		return host
+	}
+	return host + "."
+}
+
+// NewDoHResolver returns a resolver querying the DNS-over-HTTPS endpoint, e.g https://cloudflare-dns.com/dns-query.
+// The queries don't go through the proxies of the crawl, and the certificate of the endpoint is always verified
+func NewDoHResolver(endpoint string) *DoHResolver {
+	transport := DefaultHTTPTransport.Clone()
+	tlsConfig := transportTLSConfig(transport)
+	tlsConfig.InsecureSkipVerify = false
+	transport.TLSClientConfig = tlsConfig
+	return &DoHResolver{
+		Endpoint: endpoint,
+		Client:   &http.Client{Transport: transport, Timeout: 10 * time.Second},
+	}
+}
+
+// WithDoH resolves the host names with the DNS-over-HTTPS endpoint, see NewDoHResolver
+func WithDoH(endpoint string) HTTPClientConfigurator {
+	return func(client *http.Client) error {
+		Logger.Infof("DNS-over-HTTPS: %s", endpoint)
+		return WithDNSResolver(NewDoHResolver(endpoint))(client)
+	}
+}
+
+// hostMappingDialContext dials the address mapped to the host of addr, an ip or ip:port, and addr itself when unmapped
+func hostMappingDialContext(dial dialContextFunc, mapping map[string]string) dialContextFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if target, ok := mapping[strings.ToLower(host)]; ok {
+			if _, _, err := net.SplitHostPort(target); err != nil {
+				target = net.JoinHostPort(target, port)
+			}
+			addr = target
+		}
+		return dial(ctx, network, addr)
+	}
+}
+
+// WithHostMapping dials the address mapped to a host (an ip or ip:port) instead of resolving it, like /etc/hosts.
+// The Host header and the TLS SNI keep the host name, to crawl staging virtual hosts or sites before a DNS cutover
+func WithHostMapping(mapping map[string]string) HTTPClientConfigurator {
+	return func(client *http.Client) error {
+		lowered := make(map[string]string, len(mapping))
+		for host, target := range mapping {
+			lowered[strings.ToLower(host)] = target
+		}
+		return configureTransport(client, func(transport *http.Transport) error {
+			transport.DialContext = hostMappingDialContext(transportDialContext(transport), lowered)
+			return nil
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+type staticResolver map[string][]net.IPAddr
+
+func (r staticResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	return r[host], nil
+}
+
+func TestDoHResolver(t *testing.T) {
+	doh := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		var query dnsmessage.Message
+		if r.Header.Get("Content-Type") != "application/dns-message" || query.Unpack(raw) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		answer := dnsmessage.Message{Header: dnsmessage.Header{Response: true}, Questions: query.Questions}
+		q := query.Questions[0]
+		if q.Name.String() == "crawl.test." && q.Type == dnsmessage.TypeA {
+			answer.Answers = append(answer.Answers, dnsmessage.Resource{
+				Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+				Body:   &dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}},
+			})
+		}
+		packed, _ := answer.Pack()
+		w.Header().Set("Content-Type", "application/dns-message")
+		w.Write(packed)
+	}))
+	defer doh.Close()
+
+	ips, err := (&DoHResolver{Endpoint: doh.URL}).LookupIPAddr(context.Background(), "crawl.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ips) != 1 || !ips[0].IP.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Errorf("expected crawl.test to resolve to 127.0.0.1, got %v", ips)
+	}
+}
+
+func TestResolverDialContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer ts.Close()
+	tsURL, _ := url.Parse(ts.URL)
+
+	client := &http.Client{Transport: DefaultHTTPTransport.Clone()}
+	WithDNSResolver(staticResolver{"crawl.test": {{IP: net.IPv4(127, 0, 0, 1)}}})(client)
+	resp, err := client.Get("http://crawl.test:" + tsURL.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "crawl.test:"+tsURL.Port() {
+		t.Errorf("expected the Host header to be kept, got %s", body)
+	}
+}
+
+func TestHostMapping(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host + " " + r.TLS.ServerName))
+	}))
+	defer ts.Close()
+	tsURL, _ := url.Parse(ts.URL)
+
+	client := &http.Client{Transport: DefaultHTTPTransport.Clone()}
+	WithHostMapping(map[string]string{"Staging.crawl.test": tsURL.Host})(client)
+	resp, err := client.Get("https://staging.crawl.test/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "staging.crawl.test staging.crawl.test" {
+		t.Errorf("expected the Host header and the SNI to keep the host name, got %s", body)
+	}
+}
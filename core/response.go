@@ -0,0 +1,93 @@
+package core
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+const requestStartContextKey = "gospider.start"
+
+// redirectTracker records the redirect chains of the requests of a collector.
+// The chains are keyed by the headers of the request sent by colly: the colly request keeps a pointer to them,
+// and they are the headers of the first request handed to the redirect policy
+type redirectTracker struct {
+	chains sync.Map
+}
+
+// follow records the chain of req, via are the requests redirected so far. next is the redirect policy,
+// the default one of net/http when nil
+func (rt *redirectTracker) follow(req *http.Request, via []*http.Request, next func(req *http.Request, via []*http.Request) error) error {
+	var err error
+	if next != nil {
+		err = next(req, via)
+	} else if len(via) >= 10 {
+		err = http.ErrUseLastResponse
+	}
+	if err != nil {
+		return err
+	}
+	chain := make([]string, 0, len(via))
+	for _, r := range via {
+		chain = append(chain, r.URL.String())
+	}
+	rt.chains.Store(&via[0].Header, chain)
+	return nil
+}
+
+// chain returns and forgets the urls r was redirected from, the requested url first
+func (rt *redirectTracker) chain(r *colly.Request) []string {
+	if r == nil || r.Headers == nil {
+		return nil
+	}
+	chain, ok := rt.chains.LoadAndDelete(r.Headers)
+	if !ok {
+		return nil
+	}
+	return chain.([]string)
+}
+
+// instrument tracks the redirects of c, whose client is crawler.httpClient or the default one of colly,
+// and the durations of its requests
+func (rt *redirectTracker) instrument(crawler *Crawler, c *colly.Collector) {
+	next := crawler.httpClient.CheckRedirect
+	crawler.httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return rt.follow(req, via, next)
+	}
+	// colly calls the handler after checking the allowed domains, and leaves the rest of the policy to it
+	c.SetRedirectHandler(func(req *http.Request, via []*http.Request) error {
+		return rt.follow(req, via, func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return http.ErrUseLastResponse
+			}
+			if req.URL.Host != via[len(via)-1].URL.Host {
+				req.Header.Del("Authorization")
+			}
+			return nil
+		})
+	})
+	c.TraceHTTP = true
+	c.OnRequest(func(r *colly.Request) {
+		r.Ctx.Put(requestStartContextKey, time.Now())
+	})
+}
+
+// withResponse returns a copy of the receiver with the headers and timings of response, and the urls it was redirected from
+func (ov SpiderReport) withResponse(response *colly.Response, redirects []string) SpiderReport {
+	if response.Headers != nil {
+		ov.Headers = response.Headers.Clone()
+	}
+	if response.Trace != nil {
+		ov.TTFB = response.Trace.FirstByteDuration
+	}
+	if start, ok := response.Request.Ctx.GetAny(requestStartContextKey).(time.Time); ok {
+		ov.Duration = time.Since(start)
+	}
+	if len(redirects) > 0 {
+		ov.Redirects = redirects
+		ov.FinalURL = response.Request.URL.String()
+	}
+	return ov
+}
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReportResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/moved", http.StatusMovedPermanently)
+		case "/moved":
+			http.Redirect(w, r, "/final", http.StatusFound)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Set("X-Served-By", "test")
+			fmt.Fprint(w, `<html><body>final</body></html>`)
+		}
+	}))
+	defer ts.Close()
+
+	for name, crawler := range map[string]*Crawler{
+		"colly client":   NewCrawler(WithDefaultColly(1)),
+		"crawler client": NewCrawler(WithDefaultColly(1), WithHTTPClientOpt()),
+	} {
+		var report *SpiderReport
+		for _, r := range collectReports(crawler, ts.URL+"/old") {
+			if r.OutputType == Url {
+				found := r
+				report = &found
+			}
+		}
+		if report == nil {
+			t.Fatalf("%s: expected an url report", name)
+		}
+		if report.FinalURL != ts.URL+"/final" || len(report.Redirects) != 2 || report.Redirects[0] != ts.URL+"/old" || report.Redirects[1] != ts.URL+"/moved" {
+			t.Errorf("%s: unexpected redirects %v to %s", name, report.Redirects, report.FinalURL)
+		}
+		if report.Headers.Get("X-Served-By") != "test" || report.ContentType != "text/html" {
+			t.Errorf("%s: unexpected headers %v", name, report.Headers)
+		}
+		if report.TTFB <= 0 || report.Duration < report.TTFB {
+			t.Errorf("%s: unexpected timings, ttfb %s and duration %s", name, report.TTFB, report.Duration)
+		}
+	}
+}
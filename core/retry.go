@@ -0,0 +1,71 @@
+package core
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+const (
+	retryCountContextKey   = "gospider.retries"
+	retryPlannedContextKey = "gospider.retry"
+)
+
+// requestRetries returns how many times r was retried
+func requestRetries(r *colly.Request) int {
+	if r == nil || r.Ctx == nil {
+		return 0
+	}
+	retries, _ := r.Ctx.GetAny(retryCountContextKey).(int)
+	return retries
+}
+
+// isRetrying reports whether the failed request r is going to be retried
+func isRetrying(r *colly.Request) bool {
+	return r != nil && r.Ctx != nil && r.Ctx.Get(retryPlannedContextKey) != ""
+}
+
+// planRetry flags failed requests which are going to be retried, so the other error handlers ignore them
+func (crawler *Crawler) planRetry(r *colly.Response) {
+	if crawler.maxRetries <= 0 || !isRetryable(r.StatusCode) || crawler.control.isStopped() {
+		return
+	}
+	if requestRetries(r.Request) < crawler.maxRetries {
+		r.Request.Ctx.Put(retryPlannedContextKey, "1")
+	}
+}
+
+// retryDelay returns the jittered exponential backoff before the retry number attempt (starting at 0)
+func (crawler *Crawler) retryDelay(attempt int) time.Duration {
+	delay := crawler.retryBackoff << attempt
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay)))
+}
+
+// retry sends r again after the backoff. It must run after every error handler of r
+func (crawler *Crawler) retry(r *colly.Response, err error) {
+	retries := requestRetries(r.Request)
+	delay := crawler.retryDelay(retries)
+	Logger.Debugf("Retrying %s in %s after %s (attempt %d/%d)", r.Request.URL, delay, err, retries+1, crawler.maxRetries)
+	time.Sleep(delay)
+	r.Request.Ctx.Put(retryCountContextKey, retries+1)
+	r.Request.Ctx.Put(retryPlannedContextKey, "")
+	crawler.metrics.queueDepth.Inc()
+	if err := r.Request.Retry(); err != nil {
+		crawler.metrics.queueDepth.Dec()
+		Logger.Errorf("Failed to retry %s: %s", r.Request.URL, err)
+	}
+}
+
+// WithRetry retries requests failing with a transient error (network errors, timeouts, 429 and 5xx) up to max times,
+// waiting backoff, then twice as long, between attempts with a ±50% jitter.
+// The Url report of a request holds the number of retries it took
+func WithRetry(max int, backoff time.Duration) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.maxRetries = max
+		crawler.retryBackoff = backoff
+	}
+}
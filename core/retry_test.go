@@ -0,0 +1,48 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithRetry(t *testing.T) {
+	var attempts atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) <= 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body>ok</body></html>`))
+	}))
+	defer ts.Close()
+
+	reports := collectReports(NewCrawler(WithDefaultColly(1), WithRetry(3, 10*time.Millisecond)), ts.URL)
+	if attempts.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts.Load())
+	}
+	if len(reports) != 1 || reports[0].OutputType != Url || reports[0].Retries != 2 {
+		t.Fatalf("expected one url report with 2 retries, got %+v", reports)
+	}
+
+	attempts.Store(-100)
+	reports = collectReports(NewCrawler(WithDefaultColly(1), WithRetry(2, time.Millisecond)), ts.URL)
+	if attempts.Load() != -97 {
+		t.Errorf("expected the request to be given up after 2 retries, got %d attempts", attempts.Load()+100)
+	}
+	if len(reports) != 0 {
+		t.Errorf("expected no report for a failing url, got %+v", reports)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	crawler := NewCrawler(WithRetry(5, 100*time.Millisecond))
+	for attempt, base := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond} {
+		if d := crawler.retryDelay(attempt); d < base/2 || d >= base*3/2 {
+			t.Errorf("attempt %d: delay %s out of the jittered range of %s", attempt, d, base)
+		}
+	}
+}
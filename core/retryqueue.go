@@ -0,0 +1,174 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// retryQueueSavePeriod is how often a changed retry queue is saved during a crawl
+const retryQueueSavePeriod = 5 * time.Second
+
+// RetryEntry is a request that failed with a retryable error
+type RetryEntry struct {
+	URL       string `json:"url"`
+	Seed      string `json:"seed,omitempty"`
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// RetryQueue keeps track of failed requests across crawls. It is persisted as JSON in path every few seconds while
+// changed and at the end of the crawl, so a killed crawl loses at most its last failed urls. Entries failing more than
+// maxAttempts times are moved to the dead letters until they are reported
+type RetryQueue struct {
+	lock        sync.Mutex
+	path        string
+	maxAttempts int
+	// dirty is set when the queue changed since it was last saved
+	dirty bool
+
+	Pending     map[string]*RetryEntry `json:"pending"`
+	DeadLetters []RetryEntry           `json:"dead_letters"`
+}
+
+// LoadRetryQueue reads the retry queue stored in path. A missing file results in an empty queue
+func LoadRetryQueue(path string, maxAttempts int) (*RetryQueue, error) {
+	path = NormalizePath(path)
+	q := &RetryQueue{
+		path:        path,
+		maxAttempts: maxAttempts,
+		Pending:     make(map[string]*RetryEntry),
+		DeadLetters: make([]RetryEntry, 0),
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read retry queue %s: %w", path, err)
+	}
+	if err := json.Unmarshal(raw, q); err != nil {
+		return nil, fmt.Errorf("failed to parse retry queue %s: %w", path, err)
+	}
+	if q.Pending == nil {
+		q.Pending = make(map[string]*RetryEntry)
+	}
+	return q, nil
+}
+
+// Push records a failed attempt for u
+func (q *RetryQueue) Push(u string, seed string, cause error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	entry, ok := q.Pending[u]
+	if !ok {
+		entry = &RetryEntry{URL: u, Seed: seed}
+		q.Pending[u] = entry
+	}
+	entry.Attempts++
+	if cause != nil {
+		entry.LastError = cause.Error()
+	}
+	if entry.Attempts >= q.maxAttempts {
+		delete(q.Pending, u)
+		q.DeadLetters = append(q.DeadLetters, *entry)
+	}
+	q.dirty = true
+}
+
+// Done removes u from the queue after a successful attempt
+func (q *RetryQueue) Done(u string) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if _, ok := q.Pending[u]; ok {
+		delete(q.Pending, u)
+		q.dirty = true
+	}
+}
+
+// Entries returns the pending entries, sorted by url
+func (q *RetryQueue) Entries() []RetryEntry {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	res := make([]RetryEntry, 0, len(q.Pending))
+	for _, e := range q.Pending {
+		res = append(res, *e)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].URL < res[j].URL })
+	return res
+}
+
+// Dead returns the entries which exhausted their attempts and weren't reported yet
+func (q *RetryQueue) Dead() []RetryEntry {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return append([]RetryEntry{}, q.DeadLetters...)
+}
+
+// TakeDead returns the entries which exhausted their attempts and removes them from the queue, so they are reported once
+func (q *RetryQueue) TakeDead() []RetryEntry {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	dead := q.DeadLetters
+	if len(dead) > 0 {
+		q.DeadLetters = make([]RetryEntry, 0)
+		q.dirty = true
+	}
+	return dead
+}
+
+// Save writes the queue to its file
+func (q *RetryQueue) Save() error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.save()
+}
+
+// run saves the queue every retryQueueSavePeriod while it changes, until stopC is closed
+func (q *RetryQueue) run(stopC <-chan struct{}) {
+	ticker := time.NewTicker(retryQueueSavePeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			q.lock.Lock()
+			if q.dirty {
+				if err := q.save(); err != nil {
+					Logger.Error(err)
+				}
+			}
+			q.lock.Unlock()
+		case <-stopC:
+			return
+		}
+	}
+}
+
+func (q *RetryQueue) save() error {
+	raw, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize retry queue: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(q.path), 0o755); err != nil {
+		return fmt.Errorf("failed to save retry queue %s: %w", q.path, err)
+	}
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("failed to save retry queue %s: %w", q.path, err)
+	}
+	if err := os.Rename(tmp, q.path); err != nil {
+		return fmt.Errorf("failed to save retry queue %s: %w", q.path, err)
+	}
+	q.dirty = false
+	return nil
+}
+
+// isRetryable reports whether a failed response is worth retrying: network errors, timeouts, 429 and 5xx
+func isRetryable(statusCode int) bool {
+	return statusCode == 0 || statusCode == 429 || statusCode >= 500
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPersistentRetryQueue(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><body><a href="/flaky">flaky</a><a href="/down">down</a></body></html>`)
+		case "/flaky":
+			if failing.Load() {
+				w.WriteHeader(http.StatusBadGateway)
+			}
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "retry.json")
+	collectReports(NewCrawler(WithDefaultColly(3), WithPersistentRetryQueue(path, 2)), ts.URL)
+	q, err := LoadRetryQueue(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries := q.Entries(); len(entries) != 2 || entries[0].Attempts != 1 {
+		t.Fatalf("expected 2 pending entries after first crawl, got %+v", entries)
+	}
+
+	failing.Store(false)
+	reports := collectReports(NewCrawler(WithDefaultColly(3), WithPersistentRetryQueue(path, 2)), ts.URL)
+	q, _ = LoadRetryQueue(path, 2)
+	if entries := q.Entries(); len(entries) != 0 {
+		t.Errorf("expected retry queue to be drained, got %+v", entries)
+	}
+	dead := []string{}
+	for _, r := range reports {
+		if r.OutputType == DeadLetter {
+			dead = append(dead, r.Output)
+		}
+	}
+	if len(dead) != 1 || dead[0] != ts.URL+"/down" {
+		t.Errorf("expected %s/down to be a dead letter, got %v", ts.URL, dead)
+	}
+	if dead := q.Dead(); len(dead) != 0 {
+		t.Errorf("expected the reported dead letters to be dropped, got %+v", dead)
+	}
+
+	if err := os.WriteFile(path, []byte("{corrupt"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := crawlError(t, NewCrawler(WithDefaultColly(3), WithPersistentRetryQueue(path, 2)), ts.URL); err == nil {
+		t.Error("expected a corrupt retry queue to fail the crawl")
+	}
+	if raw, _ := os.ReadFile(path); string(raw) != "{corrupt" {
+		t.Errorf("expected the corrupt retry queue to be left untouched, got %s", raw)
+	}
+}
+
+func TestPersistentRetryQueueStorage(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><body><a href="/flaky">flaky</a></body></html>`)
+		case "/flaky":
+			requests.Add(1)
+			if failing.Load() {
+				w.WriteHeader(http.StatusBadGateway)
+			}
+		}
+	}))
+	defer ts.Close()
+
+	// the storage records /flaky as visited, the retry queue requests it again
+	store := newStorageDedup()
+	path := filepath.Join(t.TempDir(), "retry.json")
+	collectReports(NewCrawler(WithDefaultColly(3), WithDedupStore(store), WithPersistentRetryQueue(path, 2)), ts.URL)
+	failing.Store(false)
+	collectReports(NewCrawler(WithDefaultColly(3), WithDedupStore(store), WithPersistentRetryQueue(path, 2)), ts.URL)
+	if n := requests.Load(); n != 2 {
+		t.Errorf("expected the queued url to be requested again, got %d requests", n)
+	}
+	q, _ := LoadRetryQueue(path, 2)
+	if entries := q.Entries(); len(entries) != 0 {
+		t.Errorf("expected retry queue to be drained, got %+v", entries)
+	}
+}
@@ -1,6 +1,302 @@
 package core
 
-// func ParseRobots(site *url.URL, crawler *Crawler, c *colly.Collector, wg *sync.WaitGroup) {
-// 	defer wg.Done()
-// 	crawler.ParseRobots()
-// }
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+var ErrDisallowedByRobots = errors.New("url disallowed by robots.txt")
+
+// RobotsRule is an Allow or Disallow line of a robots.txt group. Pattern supports the `*` and `$` wildcards
+type RobotsRule struct {
+	Allow   bool
+	Pattern string
+	// re matches Pattern when it has a wildcard, compiled by ParseRobots
+	re *regexp.Regexp
+}
+
+// RobotsGroup holds the rules applying to a set of user agents
+type RobotsGroup struct {
+	Agents     []string
+	Rules      []RobotsRule
+	CrawlDelay time.Duration
+}
+
+// Robots is a parsed robots.txt
+type Robots struct {
+	Groups   []RobotsGroup
+	Sitemaps []string
+}
+
+type RobotsDirective string
+
+const (
+	RobotsAllow    RobotsDirective = "allow"
+	RobotsDisallow RobotsDirective = "disallow"
+	RobotsSitemap  RobotsDirective = "sitemap"
+)
+
+// RobotsEntry is an url found in a robots.txt, with the directive and the user agents it comes from
+type RobotsEntry struct {
+	URL       string
+	Directive RobotsDirective
+	Agents    []string
+}
+
+// Entries resolves the rule paths and the sitemaps of robots against target. Rule patterns are cut at their first wildcard
+func (robots *Robots) Entries(target *url.URL) []RobotsEntry {
+	res := []RobotsEntry{}
+	seen := make(map[string]bool)
+	for _, g := range robots.Groups {
+		for _, rule := range g.Rules {
+			path := strings.TrimSuffix(rule.Pattern, "$")
+			if i := strings.IndexByte(path, '*'); i >= 0 {
+				path = path[:i]
+			}
+			u := FixUrl(target, path)
+			if path == "" || u == "" || seen[u] {
+				continue
+			}
+			seen[u] = true
+			directive := RobotsDisallow
+			if rule.Allow {
+				directive = RobotsAllow
+			}
+			res = append(res, RobotsEntry{URL: u, Directive: directive, Agents: g.Agents})
+		}
+	}
+	for _, sitemapURL := range robots.Sitemaps {
+		if u := FixUrl(target, sitemapURL); u != "" && !seen[u] {
+			seen[u] = true
+			res = append(res, RobotsEntry{URL: u, Directive: RobotsSitemap})
+		}
+	}
+	return res
+}
+
+// ParseRobots parses a robots.txt following RFC 9309: consecutive User-agent lines open a group,
+// Allow/Disallow/Crawl-delay lines apply to the current group and Sitemap lines are global
+func ParseRobots(r io.Reader) (*Robots, error) {
+	robots := &Robots{}
+	var group *RobotsGroup
+	inAgents := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				robots.Groups = append(robots.Groups, RobotsGroup{})
+				group = &robots.Groups[len(robots.Groups)-1]
+				inAgents = true
+			}
+			group.Agents = append(group.Agents, strings.ToLower(value))
+			continue
+		case "allow", "disallow":
+			// an empty Disallow allows everything
+			if group != nil && value != "" {
+				group.Rules = append(group.Rules, RobotsRule{Allow: key == "allow", Pattern: value, re: compileRobotsPattern(value)})
+			}
+		case "crawl-delay":
+			if delay, err := strconv.ParseFloat(value, 64); group != nil && err == nil && delay > 0 {
+				group.CrawlDelay = time.Duration(delay * float64(time.Second))
+			}
+		case "sitemap":
+			if value != "" {
+				robots.Sitemaps = append(robots.Sitemaps, value)
+			}
+		}
+		inAgents = false
+	}
+	return robots, scanner.Err()
+}
+
+// Group returns the group applying to userAgent: the group with the longest agent contained
+// in userAgent, or the `*` group. It returns nil when no group applies
+func (robots *Robots) Group(userAgent string) *RobotsGroup {
+	userAgent = strings.ToLower(userAgent)
+	var best, wildcard *RobotsGroup
+	bestLen := 0
+	for i := range robots.Groups {
+		g := &robots.Groups[i]
+		for _, agent := range g.Agents {
+			switch {
+			case agent == "*":
+				if wildcard == nil {
+					wildcard = g
+				}
+			case agent != "" && strings.Contains(userAgent, agent) && len(agent) > bestLen:
+				best, bestLen = g, len(agent)
+			}
+		}
+	}
+	if best != nil {
+		return best
+	}
+	return wildcard
+}
+
+// Allowed reports whether path (with its query) may be crawled: the longest matching rule wins,
+// Allow winning ties. A path matching no rule is allowed
+func (g *RobotsGroup) Allowed(path string) bool {
+	if g == nil {
+		return true
+	}
+	allowed, matchLen := true, -1
+	for _, rule := range g.Rules {
+		if !rule.match(path) {
+			continue
+		}
+		if l := len(rule.Pattern); l > matchLen || (l == matchLen && rule.Allow) {
+			allowed, matchLen = rule.Allow, l
+		}
+	}
+	return allowed
+}
+
+// match reports whether path matches the pattern of the rule
+func (rule RobotsRule) match(path string) bool {
+	re := rule.re
+	if re == nil {
+		// rules built without ParseRobots
+		re = compileRobotsPattern(rule.Pattern)
+	}
+	if re == nil {
+		return strings.HasPrefix(path, rule.Pattern)
+	}
+	return re.MatchString(path)
+}
+
+// compileRobotsPattern compiles a robots.txt pattern where `*` matches any sequence and a trailing `$`
+// anchors the end of the path. It returns nil for a pattern without wildcard, matched as a prefix
+func compileRobotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	if !anchored && !strings.Contains(pattern, "*") {
+		return nil
+	}
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// robotsPolicy caches the robots.txt of each host and enforces them on the requests of a crawl
+type robotsPolicy struct {
+	client *http.Client
+
+	lock  sync.Mutex
+	hosts map[string]*robotsHost
+}
+
+type robotsHost struct {
+	once  sync.Once
+	group *RobotsGroup
+	rate  hostRate
+}
+
+func newRobotsPolicy() *robotsPolicy {
+	return &robotsPolicy{
+		client: &http.Client{Transport: DefaultHTTPTransport.Clone(), Timeout: 10 * time.Second},
+		hosts:  make(map[string]*robotsHost),
+	}
+}
+
+// host returns the robots.txt rules of the host of u applying to userAgent, fetching them on first use
+func (rp *robotsPolicy) host(u *url.URL, userAgent string) *robotsHost {
+	key := u.Scheme + "://" + u.Host
+	rp.lock.Lock()
+	rh, ok := rp.hosts[key]
+	if !ok {
+		rh = &robotsHost{}
+		rp.hosts[key] = rh
+	}
+	rp.lock.Unlock()
+	rh.once.Do(func() {
+		robots := rp.fetch(key + "/robots.txt")
+		rh.group = robots.Group(userAgent)
+		if rh.group != nil {
+			rh.rate.delay = rh.group.CrawlDelay
+		}
+	})
+	return rh
+}
+
+// disallowAllRobots disallows every path to every user agent
+var disallowAllRobots = &Robots{Groups: []RobotsGroup{{Agents: []string{"*"}, Rules: []RobotsRule{{Pattern: "/"}}}}}
+
+// fetch returns the robots.txt at robotsURL as RFC 9309 requires a crawler to handle it: an unavailable
+// robots.txt (4xx) allows everything while an unreachable one (5xx or network error) disallows everything
+func (rp *robotsPolicy) fetch(robotsURL string) *Robots {
+	resp, err := rp.client.Get(robotsURL)
+	if err != nil {
+		Logger.Debugf("Disallowing the host of unreachable %s: %s", robotsURL, err)
+		return disallowAllRobots
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 500:
+		Logger.Debugf("Disallowing the host of %s: it returned %s", robotsURL, resp.Status)
+		return disallowAllRobots
+	case resp.StatusCode >= 300:
+		Logger.Debugf("No robots.txt rules for %s: it returned %s", robotsURL, resp.Status)
+		return &Robots{}
+	}
+	robots, err := ParseRobots(io.LimitReader(resp.Body, 500*1024))
+	if err != nil {
+		// the rules parsed before the error still apply
+		Logger.Debugf("Failed to parse %s: %s", robotsURL, err)
+	}
+	return robots
+}
+
+// allowed reports whether u may be crawled by the collector c
+func (rp *robotsPolicy) allowed(c *colly.Collector, u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Host == "" {
+		return true
+	}
+	return rp.host(parsed, c.UserAgent).group.Allowed(parsed.RequestURI())
+}
+
+// instrument spaces the requests to each host by its Crawl-delay
+func (rp *robotsPolicy) instrument(c *colly.Collector) {
+	c.OnRequest(func(r *colly.Request) {
+		if wait := rp.host(r.URL, c.UserAgent).rate.reserve(); wait > 0 {
+			time.Sleep(wait)
+		}
+	})
+}
+
+// WithRespectRobots enforces the robots.txt of the crawled hosts: disallowed urls are not visited
+// and the requests to a host are spaced by its Crawl-delay. Rules are picked from the group matching the collector user agent.
+// A host whose robots.txt is unreachable (5xx or network error) is not crawled, one without robots.txt (4xx) is crawled entirely
+func WithRespectRobots() CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.robotsPolicy = newRobotsPolicy()
+	}
+}
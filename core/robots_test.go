@@ -0,0 +1,151 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testRobots = `
+# comment
+User-agent: gospider
+User-agent: otherbot
+Disallow: /private
+Allow: /private/public
+Disallow: /*.php$
+Crawl-delay: 0.5
+
+User-agent: *
+Disallow: /
+
+Sitemap: https://example.com/sitemap.xml
+`
+
+func TestParseRobots(t *testing.T) {
+	robots, err := ParseRobots(strings.NewReader(testRobots))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(robots.Groups) != 2 || len(robots.Sitemaps) != 1 {
+		t.Fatalf("unexpected robots %+v", robots)
+	}
+	g := robots.Group("Mozilla/5.0 (compatible; GoSpider/1.1)")
+	if g == nil || g.CrawlDelay != 500*time.Millisecond {
+		t.Fatalf("expected the gospider group, got %+v", g)
+	}
+	for path, allowed := range map[string]bool{
+		"/":                  true,
+		"/private":           false,
+		"/private/secret":    false,
+		"/private/public/a":  true,
+		"/index.php":         false,
+		"/index.php?id=1":    true,
+		"/dir/index.php":     false,
+		"/index.phpx":        true,
+		"/other/private/abc": true,
+	} {
+		if g.Allowed(path) != allowed {
+			t.Errorf("expected %s allowed=%v", path, allowed)
+		}
+	}
+	for _, rule := range g.Rules {
+		if (rule.re != nil) != strings.ContainsAny(rule.Pattern, "*$") {
+			t.Errorf("expected only the wildcard patterns to be compiled at parse time, got %+v", rule)
+		}
+	}
+	if !(RobotsRule{Pattern: "/*.php$"}).match("/index.php") {
+		t.Error("expected a rule built without ParseRobots to match its wildcard pattern")
+	}
+	if robots.Group("curl/8.0").Allowed("/anything") {
+		t.Error("expected the wildcard group to disallow everything for other agents")
+	}
+	if (&Robots{}).Group("curl") != nil {
+		t.Error("expected no group for an empty robots.txt")
+	}
+}
+
+func TestWithRespectRobots(t *testing.T) {
+	lock := sync.Mutex{}
+	requests := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests[r.URL.Path]++
+		lock.Unlock()
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprint(w, "User-agent: *\nDisallow: /admin\n")
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><a href="/admin/users">admin</a><a href="/public">public</a></body></html>`)
+	}))
+	defer ts.Close()
+
+	collectReports(NewCrawler(WithDefaultColly(3), WithRespectRobots()), ts.URL+"/")
+	if requests["/admin/users"] != 0 {
+		t.Error("expected disallowed url not to be visited")
+	}
+	if requests["/public"] != 1 {
+		t.Error("expected allowed url to be visited")
+	}
+}
+
+func TestRespectRobotsUnavailable(t *testing.T) {
+	for status, visited := range map[int]bool{
+		http.StatusNotFound:            true,
+		http.StatusForbidden:           true,
+		http.StatusInternalServerError: false,
+		http.StatusServiceUnavailable:  false,
+	} {
+		lock := sync.Mutex{}
+		requests := map[string]int{}
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			requests[r.URL.Path]++
+			lock.Unlock()
+			if r.URL.Path == "/robots.txt" {
+				w.WriteHeader(status)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><body><a href="/public">public</a></body></html>`)
+		}))
+
+		collectReports(NewCrawler(WithDefaultColly(3), WithRespectRobots()), ts.URL+"/")
+		ts.Close()
+		if (requests["/public"] == 1) != visited || (requests["/"] == 1) != visited {
+			t.Errorf("expected a robots.txt answering %d to allow crawling: %v, got %v", status, visited, requests)
+		}
+	}
+}
+
+func TestRobotsEntries(t *testing.T) {
+	robots, err := ParseRobots(strings.NewReader(testRobots + "Sitemap: /news-sitemap.xml\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, _ := url.Parse("https://example.com")
+	got := map[string]RobotsDirective{}
+	for _, entry := range robots.Entries(target) {
+		got[entry.URL] = entry.Directive
+	}
+	expected := map[string]RobotsDirective{
+		"https://example.com/private":          RobotsDisallow,
+		"https://example.com/private/public":   RobotsAllow,
+		"https://example.com/":                 RobotsDisallow,
+		"https://example.com/sitemap.xml":      RobotsSitemap,
+		"https://example.com/news-sitemap.xml": RobotsSitemap,
+	}
+	if len(got) != len(expected) {
+		t.Errorf("expected %d entries, got %v", len(expected), got)
+	}
+	for u, directive := range expected {
+		if got[u] != directive {
+			t.Errorf("expected %s entry for %s, got %q", directive, u, got[u])
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// ExtractionRule describes a site specific extraction: every element matched by CSS or XPath
+// produces a report of type OutputType holding either the element text or the value of Attr
+type ExtractionRule struct {
+	Name       string     `json:"name" yaml:"name" toml:"name"`
+	CSS        string     `json:"css,omitempty" yaml:"css,omitempty" toml:"css"`
+	XPath      string     `json:"xpath,omitempty" yaml:"xpath,omitempty" toml:"xpath"`
+	Attr       string     `json:"attr,omitempty" yaml:"attr,omitempty" toml:"attr"`
+	OutputType OutputType `json:"type,omitempty" yaml:"type,omitempty" toml:"type"`
+}
+
+func (rule ExtractionRule) Validate() error {
+	if rule.CSS == "" && rule.XPath == "" {
+		return fmt.Errorf("extraction rule %s: one of css or xpath is required", rule.Name)
+	}
+	if rule.CSS != "" && rule.XPath != "" {
+		return fmt.Errorf("extraction rule %s: css and xpath are mutually exclusive", rule.Name)
+	}
+	return nil
+}
+
+func (rule ExtractionRule) outputType() OutputType {
+	if rule.OutputType == "" {
+		return Extract
+	}
+	return rule.OutputType
+}
+
+func (rule ExtractionRule) report(value string, request *colly.Request) SpiderReport {
+	value = strings.TrimSpace(value)
+	return SpiderReport{
+		Output:     value,
+		OutputType: rule.outputType(),
+		Source:     "body",
+		Matcher:    rule.Name,
+		Input:      request.URL,
+		Seed:       requestSeed(request),
+		dedupKey:   pageDedupKey(request.URL, rule.Name, value),
+	}
+}
+
+// Register hooks the rule on c. Every extracted value is passed to emit
+func (rule ExtractionRule) Register(c *colly.Collector, emit func(SpiderReport)) {
+	if rule.CSS != "" {
+		c.OnHTML(rule.CSS, func(e *colly.HTMLElement) {
+			value := e.Text
+			if rule.Attr != "" {
+				value = e.Attr(rule.Attr)
+			}
+			emit(rule.report(value, e.Request))
+		})
+		return
+	}
+	c.OnXML(rule.XPath, func(e *colly.XMLElement) {
+		value := e.Text
+		if rule.Attr != "" {
+			value = e.Attr(rule.Attr)
+		}
+		emit(rule.report(value, e.Request))
+	})
+}
+
+// RecordTemplate assembles several named fields into a single structured `record` report.
+// Every element matched by Root (CSS or XPath, defaults to the whole document) produces one record,
+// Fields selectors are evaluated relatively to it and must use the same selector kind as Root
+type RecordTemplate struct {
+	Name   string           `json:"name" yaml:"name" toml:"name"`
+	Root   string           `json:"root,omitempty" yaml:"root,omitempty" toml:"root"`
+	XPath  bool             `json:"xpath,omitempty" yaml:"xpath,omitempty" toml:"xpath"`
+	Fields []ExtractionRule `json:"fields" yaml:"fields" toml:"fields"`
+}
+
+func (tpl RecordTemplate) Validate() error {
+	if len(tpl.Fields) == 0 {
+		return fmt.Errorf("record template %s: at least one field is required", tpl.Name)
+	}
+	for _, field := range tpl.Fields {
+		if err := field.Validate(); err != nil {
+			return fmt.Errorf("record template %s: %w", tpl.Name, err)
+		}
+		if tpl.XPath != (field.XPath != "") {
+			return fmt.Errorf("record template %s: field %s does not use the same selector kind as root", tpl.Name, field.Name)
+		}
+	}
+	return nil
+}
+
+func (tpl RecordTemplate) report(record map[string]string, request *colly.Request) (SpiderReport, bool) {
+	empty := true
+	for _, v := range record {
+		if v != "" {
+			empty = false
+			break
+		}
+	}
+	if empty {
+		return SpiderReport{}, false
+	}
+	output, err := json.Marshal(record)
+	if err != nil {
+		return SpiderReport{}, false
+	}
+	return SpiderReport{
+		Output:     string(output),
+		OutputType: Record,
+		Source:     "body",
+		Matcher:    tpl.Name,
+		Record:     record,
+		Input:      request.URL,
+		Seed:       requestSeed(request),
+	}, true
+}
+
+// Register hooks the template on c. Every assembled record is passed to emit
+func (tpl RecordTemplate) Register(c *colly.Collector, emit func(SpiderReport)) {
+	if tpl.XPath {
+		root := tpl.Root
+		if root == "" {
+			root = "/html"
+		}
+		c.OnXML(root, func(e *colly.XMLElement) {
+			record := make(map[string]string, len(tpl.Fields))
+			for _, field := range tpl.Fields {
+				if field.Attr != "" {
+					record[field.Name] = strings.TrimSpace(e.ChildAttr(field.XPath, field.Attr))
+				} else {
+					record[field.Name] = strings.TrimSpace(e.ChildText(field.XPath))
+				}
+			}
+			if report, ok := tpl.report(record, e.Request); ok {
+				emit(report)
+			}
+		})
+		return
+	}
+	root := tpl.Root
+	if root == "" {
+		root = "html"
+	}
+	c.OnHTML(root, func(e *colly.HTMLElement) {
+		record := make(map[string]string, len(tpl.Fields))
+		for _, field := range tpl.Fields {
+			if field.Attr != "" {
+				record[field.Name] = strings.TrimSpace(e.ChildAttr(field.CSS, field.Attr))
+			} else {
+				record[field.Name] = strings.TrimSpace(e.ChildText(field.CSS))
+			}
+		}
+		if report, ok := tpl.report(record, e.Request); ok {
+			emit(report)
+		}
+	})
+}
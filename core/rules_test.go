@@ -0,0 +1,109 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocolly/colly/v2"
+)
+
+func TestExtractionRule(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><span class="price">12.50</span><a id="next" href="/p/2">next</a></body></html>`))
+	}))
+	defer ts.Close()
+
+	rules := []ExtractionRule{
+		{Name: "price", CSS: "span.price", OutputType: "price"},
+		{Name: "next", XPath: `//a[@id="next"]`, Attr: "href"},
+	}
+	c := colly.NewCollector()
+	found := map[string]SpiderReport{}
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		rule.Register(c, func(report SpiderReport) {
+			found[report.Matcher] = report
+		})
+	}
+	if err := c.Visit(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if r := found["price"]; r.Output != "12.50" || r.OutputType != "price" {
+		t.Errorf("unexpected price report %+v", r)
+	}
+	if r := found["next"]; r.Output != "/p/2" || r.OutputType != Extract {
+		t.Errorf("unexpected next report %+v", r)
+	}
+	if err := (ExtractionRule{Name: "empty"}).Validate(); err == nil {
+		t.Error("expected validation error for rule without selector")
+	}
+}
+
+func TestExtractionRulesCrawl(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><span class="price">12.50</span><span class="price">12.50</span><a href="/p/2">next</a></body></html>`))
+	}))
+	defer ts.Close()
+
+	rule := ExtractionRule{Name: "price", CSS: "span.price", OutputType: "price"}
+	pages := map[string]int{}
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(2), WithExtractionRules(rule)), ts.URL+"/") {
+		if r.OutputType == "price" && r.Output == "12.50" {
+			pages[r.Input.Path]++
+		}
+	}
+	if pages["/"] != 1 || pages["/p/2"] != 1 {
+		t.Errorf("expected the price to be reported once per page, got %v", pages)
+	}
+
+	if err := crawlError(t, NewCrawler(WithDefaultColly(2), WithExtractionRules(ExtractionRule{Name: "empty"})), ts.URL); err == nil {
+		t.Error("expected an invalid rule to fail the crawl")
+	}
+}
+
+func TestRecordTemplate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body>
+<div class="item"><h2>Foo</h2><span class="price">1</span><a href="/foo">x</a></div>
+<div class="item"><h2>Bar</h2><span class="price">2</span><a href="/bar">x</a></div>
+</body></html>`))
+	}))
+	defer ts.Close()
+
+	tpl := RecordTemplate{
+		Name: "product",
+		Root: "div.item",
+		Fields: []ExtractionRule{
+			{Name: "title", CSS: "h2"},
+			{Name: "price", CSS: ".price"},
+			{Name: "link", CSS: "a", Attr: "href"},
+		},
+	}
+	if err := tpl.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	c := colly.NewCollector()
+	records := []SpiderReport{}
+	tpl.Register(c, func(report SpiderReport) {
+		records = append(records, report)
+	})
+	if err := c.Visit(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if r := records[1]; r.OutputType != Record || r.Record["title"] != "Bar" || r.Record["price"] != "2" || r.Record["link"] != "/bar" {
+		t.Errorf("unexpected record %+v", r)
+	}
+
+	if err := crawlError(t, NewCrawler(WithDefaultColly(1), WithRecordTemplates(RecordTemplate{Name: "empty"})), ts.URL); err == nil {
+		t.Error("expected an invalid template to fail the crawl")
+	}
+}
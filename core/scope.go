@@ -0,0 +1,213 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+var ErrOutOfScope = errors.New("url out of scope")
+
+// hostRule matches an hostname, the subdomains of a domain for a *.example.com wildcard, or the ip hosts of a CIDR range
+type hostRule struct {
+	host     string
+	wildcard bool
+	cidr     *net.IPNet
+}
+
+func parseHostRule(rule string) (hostRule, error) {
+	rule = strings.TrimSpace(rule)
+	if strings.Contains(rule, "/") {
+		_, cidr, err := net.ParseCIDR(rule)
+		if err != nil {
+			return hostRule{}, fmt.Errorf("invalid CIDR range %s: %w", rule, err)
+		}
+		return hostRule{cidr: cidr}, nil
+	}
+	if domain, ok := strings.CutPrefix(rule, "*."); ok {
+		return hostRule{host: strings.ToLower(ASCIIHost(domain)), wildcard: true}, nil
+	}
+	if rule == "" || strings.ContainsAny(rule, "*:") && net.ParseIP(rule) == nil {
+		return hostRule{}, fmt.Errorf("invalid host %q, expected an hostname, *.domain or a CIDR range", rule)
+	}
+	return hostRule{host: strings.ToLower(ASCIIHost(rule))}, nil
+}
+
+func (rule hostRule) match(hostname string) bool {
+	if rule.cidr != nil {
+		ip := net.ParseIP(hostname)
+		return ip != nil && rule.cidr.Contains(ip)
+	}
+	if rule.wildcard {
+		return strings.HasSuffix(hostname, "."+rule.host)
+	}
+	return hostname == rule.host
+}
+
+// Scope decides which urls are crawled. An url is in scope when it matches one of the included hosts or regexps, or
+// when there is none, starts with one of the included path prefixes, when there is any, and matches none of the
+// exclusions. The scope applies to the urls found in the pages as well as to the sitemaps and the sources
+type Scope struct {
+	includeHosts   []hostRule
+	excludeHosts   []hostRule
+	includePaths   []string
+	excludePaths   []string
+	includeRegexps []*regexp.Regexp
+	excludeRegexps []*regexp.Regexp
+	// RecordOutOfScope reports the out of scope urls, flagged by an "out_of_scope" Metadata, without visiting them.
+	// They are dropped otherwise
+	RecordOutOfScope bool
+}
+
+func NewScope() *Scope {
+	return &Scope{}
+}
+
+func addHostRules(rules []hostRule, hosts []string) ([]hostRule, error) {
+	for _, host := range hosts {
+		rule, err := parseHostRule(host)
+		if err != nil {
+			return rules, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func addRegexps(res []*regexp.Regexp, exprs []string) ([]*regexp.Regexp, error) {
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return res, fmt.Errorf("failed to compile scope regex %s: %w", expr, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// IncludeHost adds hosts to the scope: hostnames, wildcards (*.example.com matches the subdomains of example.com, not
+// example.com itself) or CIDR ranges (10.0.0.0/8 matches the ip hosts of the range)
+func (s *Scope) IncludeHost(hosts ...string) error {
+	var err error
+	s.includeHosts, err = addHostRules(s.includeHosts, hosts)
+	return err
+}
+
+// ExcludeHost removes hosts from the scope, with the syntax of IncludeHost
+func (s *Scope) ExcludeHost(hosts ...string) error {
+	var err error
+	s.excludeHosts, err = addHostRules(s.excludeHosts, hosts)
+	return err
+}
+
+// IncludePath restricts the scope to the urls whose path starts with one of prefixes
+func (s *Scope) IncludePath(prefixes ...string) {
+	s.includePaths = append(s.includePaths, prefixes...)
+}
+
+// ExcludePath removes from the scope the urls whose path starts with one of prefixes
+func (s *Scope) ExcludePath(prefixes ...string) {
+	s.excludePaths = append(s.excludePaths, prefixes...)
+}
+
+// IncludeRegexp adds the urls matching exprs to the scope
+func (s *Scope) IncludeRegexp(exprs ...string) error {
+	var err error
+	s.includeRegexps, err = addRegexps(s.includeRegexps, exprs)
+	return err
+}
+
+// ExcludeRegexp removes the urls matching exprs from the scope
+func (s *Scope) ExcludeRegexp(exprs ...string) error {
+	var err error
+	s.excludeRegexps, err = addRegexps(s.excludeRegexps, exprs)
+	return err
+}
+
+func matchHost(rules []hostRule, hostname string) bool {
+	for _, rule := range rules {
+		if rule.match(hostname) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPath(prefixes []string, path string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows reports whether u is in scope. Every url is in the scope of a nil Scope
+func (s *Scope) Allows(u *url.URL) bool {
+	if s == nil {
+		return true
+	}
+	hostname := strings.ToLower(ASCIIHost(u.Hostname()))
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	raw := u.String()
+	if len(s.includeHosts)+len(s.includeRegexps) > 0 && !matchHost(s.includeHosts, hostname) && !InScope(u, s.includeRegexps) {
+		return false
+	}
+	if len(s.includePaths) > 0 && !matchPath(s.includePaths, path) {
+		return false
+	}
+	if matchHost(s.excludeHosts, hostname) || matchPath(s.excludePaths, path) {
+		return false
+	}
+	for _, re := range s.excludeRegexps {
+		if re.MatchString(raw) {
+			return false
+		}
+	}
+	return true
+}
+
+// AllowsURL reports whether the url u is in scope, an invalid url being out of scope
+func (s *Scope) AllowsURL(u string) bool {
+	if s == nil {
+		return true
+	}
+	parsed, err := url.Parse(u)
+	return err == nil && s.Allows(parsed)
+}
+
+// allowsReport reports whether the url of report, if its Output is one, is in scope
+func (s *Scope) allowsReport(report SpiderReport) bool {
+	return s == nil || !report.OutputType.isURL() || s.AllowsURL(report.Output)
+}
+
+// WithScopeFilter aborts the requests of the collector out of scope, including the ones which aren't scheduled by the
+// crawler such as the replayed and submitted requests
+func WithScopeFilter(scope *Scope) CollyConfigurator {
+	return func(c *colly.Collector) error {
+		c.OnRequest(func(r *colly.Request) {
+			if !scope.Allows(r.URL) {
+				Logger.Debugf("Aborting %s out of scope", r.URL)
+				abortRequest(r)
+			}
+		})
+		return nil
+	}
+}
+
+// WithCrawlScope crawls the urls in scope only. The reports of the urls out of scope are dropped, or flagged when
+// scope.RecordOutOfScope is set
+func WithCrawlScope(scope *Scope) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.scope = scope
+		crawler.collyConfigrationOpt = append(crawler.collyConfigrationOpt, WithScopeFilter(scope))
+	}
+}
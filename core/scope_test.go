@@ -0,0 +1,114 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestScope(t *testing.T) {
+	scope := NewScope()
+	if err := scope.IncludeHost("example.com", "*.example.org", "10.0.0.0/8", "bücher.de"); err != nil {
+		t.Fatal(err)
+	}
+	if err := scope.ExcludeHost("admin.example.org"); err != nil {
+		t.Fatal(err)
+	}
+	if err := scope.ExcludeRegexp(`\?.*logout`); err != nil {
+		t.Fatal(err)
+	}
+	scope.ExcludePath("/private/")
+	tests := map[string]bool{
+		"https://EXAMPLE.com/a":              true,
+		"https://www.example.com/":           false,
+		"https://api.example.org/v1":         true,
+		"https://a.b.example.org/":           true,
+		"https://example.org/":               false,
+		"https://admin.example.org/":         false,
+		"http://10.1.2.3:8080/":              true,
+		"http://192.168.0.1/":                false,
+		"https://xn--bcher-kva.de/":          true,
+		"https://example.com/private/x":      false,
+		"https://example.com/account?logout": false,
+		"mailto:contact@example.com":         false,
+	}
+	for u, expected := range tests {
+		if scope.AllowsURL(u) != expected {
+			t.Errorf("expected %s in scope to be %t", u, expected)
+		}
+	}
+
+	scope = NewScope()
+	scope.IncludePath("/docs/", "/api/")
+	if !scope.AllowsURL("https://any.host/docs/intro") || scope.AllowsURL("https://any.host/blog") {
+		t.Errorf("expected the path prefixes to restrict the scope")
+	}
+	if !(*Scope)(nil).AllowsURL("https://any.host/") {
+		t.Errorf("expected every url in the scope of a nil scope")
+	}
+	for _, rule := range []string{"10.0.0.0/33", "*", "a*b.com", ""} {
+		if err := NewScope().IncludeHost(rule); err == nil {
+			t.Errorf("expected an error for the host rule %q", rule)
+		}
+	}
+}
+
+func TestCrawlScope(t *testing.T) {
+	var lock sync.Mutex
+	visited := []string{}
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		visited = append(visited, r.URL.Path)
+		lock.Unlock()
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><body><a href="/docs/a">a</a><a href="/admin/users">admin</a><a href="https://other.test/">other</a></body></html>`)
+		case "/sitemap.xml":
+			fmt.Fprintf(w, `<urlset><url><loc>%[1]s/docs/b</loc></url><url><loc>%[1]s/admin/b</loc></url></urlset>`, ts.URL)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+		}
+	}))
+	defer ts.Close()
+
+	for _, record := range []bool{false, true} {
+		lock.Lock()
+		visited = []string{}
+		lock.Unlock()
+		scope := NewScope()
+		scope.ExcludePath("/admin/")
+		if err := scope.IncludeHost("127.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+		scope.RecordOutOfScope = record
+		outOfScope := []string{}
+		for _, r := range collectReports(NewCrawler(WithDefaultColly(2), WithSitemap(), WithSitemapPaths("/sitemap.xml"), WithCrawlScope(scope)), ts.URL) {
+			if r.Metadata["out_of_scope"] == true {
+				outOfScope = append(outOfScope, strings.TrimPrefix(r.Output, ts.URL))
+			} else if r.OutputType.isURL() && !scope.AllowsURL(r.Output) {
+				t.Errorf("expected %s out of scope not to be reported unflagged", r.Output)
+			}
+		}
+		sort.Strings(outOfScope)
+		expected := []string{}
+		if record {
+			expected = []string{"/admin/b", "/admin/users", "https://other.test/"}
+		}
+		if !reflect.DeepEqual(outOfScope, expected) {
+			t.Errorf("expected the reports out of scope %v when recorded is %t, got %v", expected, record, outOfScope)
+		}
+		lock.Lock()
+		sort.Strings(visited)
+		if expected := []string{"/", "/docs/a", "/docs/b", "/sitemap.xml"}; !reflect.DeepEqual(visited, expected) {
+			t.Errorf("expected the visits %v, got %v", expected, visited)
+		}
+		lock.Unlock()
+	}
+}
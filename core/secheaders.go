@@ -0,0 +1,176 @@
+package core
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// hstsMinMaxAge is the max-age below which HSTS is reported as weak, 180 days
+const hstsMinMaxAge = 180 * 24 * 60 * 60
+
+// SecurityHeaderIssue is a missing or weak security header of a response
+type SecurityHeaderIssue struct {
+	Header string `json:"header"`
+	Issue  string `json:"issue"`
+}
+
+// String returns the header followed by its issue
+func (issue SecurityHeaderIssue) String() string {
+	return issue.Header + ": " + issue.Issue
+}
+
+// parseCSP returns the source lists of the directives of a Content-Security-Policy, keyed by their lowercased name.
+// As browsers do, only the first occurrence of a directive is kept
+func parseCSP(policy string) map[string][]string {
+	directives := map[string][]string{}
+	for _, directive := range strings.Split(policy, ";") {
+		fields := strings.Fields(directive)
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.ToLower(fields[0])
+		if _, ok := directives[name]; !ok {
+			directives[name] = fields[1:]
+		}
+	}
+	return directives
+}
+
+func analyzeHSTS(value string) string {
+	if value == "" {
+		return "missing"
+	}
+	for _, directive := range strings.Split(value, ";") {
+		name, maxAge, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if !strings.EqualFold(strings.TrimSpace(name), "max-age") {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.Trim(strings.TrimSpace(maxAge), `"`))
+		if err != nil {
+			return "invalid max-age"
+		}
+		if seconds < hstsMinMaxAge {
+			return "max-age below 180 days"
+		}
+		return ""
+	}
+	return "no max-age"
+}
+
+func analyzeCSP(policy string) []string {
+	if policy == "" {
+		return []string{"missing"}
+	}
+	directives := parseCSP(policy)
+	sources, ok := directives["script-src"]
+	if !ok {
+		if sources, ok = directives["default-src"]; !ok {
+			return []string{"no script-src or default-src"}
+		}
+	}
+	issues := []string{}
+	for _, source := range sources {
+		switch strings.ToLower(source) {
+		case "'unsafe-inline'":
+			issues = append(issues, "scripts allow 'unsafe-inline'")
+		case "'unsafe-eval'":
+			issues = append(issues, "scripts allow 'unsafe-eval'")
+		case "*", "http:", "https:", "data:":
+			issues = append(issues, "scripts allow "+source)
+		}
+	}
+	return issues
+}
+
+func analyzeCORS(headers http.Header) string {
+	origin := strings.TrimSpace(headers.Get("Access-Control-Allow-Origin"))
+	credentials := strings.EqualFold(strings.TrimSpace(headers.Get("Access-Control-Allow-Credentials")), "true")
+	switch {
+	case origin == "*" && credentials:
+		return "any origin with credentials"
+	case origin == "*":
+		return "any origin"
+	case strings.EqualFold(origin, "null"):
+		return "null origin"
+	}
+	return ""
+}
+
+// AnalyzeSecurityHeaders returns the missing or weak security headers of a response to u: HSTS (over https),
+// Content-Security-Policy, X-Frame-Options (unless the CSP sets frame-ancestors), X-Content-Type-Options and a
+// permissive Access-Control-Allow-Origin
+func AnalyzeSecurityHeaders(u *url.URL, headers http.Header) []SecurityHeaderIssue {
+	issues := []SecurityHeaderIssue{}
+	add := func(header string, issue string) {
+		if issue != "" {
+			issues = append(issues, SecurityHeaderIssue{Header: header, Issue: issue})
+		}
+	}
+	if u.Scheme == "https" {
+		add("Strict-Transport-Security", analyzeHSTS(headers.Get("Strict-Transport-Security")))
+	}
+	policy := headers.Get("Content-Security-Policy")
+	for _, issue := range analyzeCSP(policy) {
+		add("Content-Security-Policy", issue)
+	}
+	if _, ok := parseCSP(policy)["frame-ancestors"]; !ok {
+		switch frameOptions := strings.TrimSpace(headers.Get("X-Frame-Options")); {
+		case frameOptions == "":
+			add("X-Frame-Options", "missing")
+		case !strings.EqualFold(frameOptions, "DENY") && !strings.EqualFold(frameOptions, "SAMEORIGIN"):
+			add("X-Frame-Options", "invalid value "+frameOptions)
+		}
+	}
+	switch contentTypeOptions := strings.TrimSpace(headers.Get("X-Content-Type-Options")); {
+	case contentTypeOptions == "":
+		add("X-Content-Type-Options", "missing")
+	case !strings.EqualFold(contentTypeOptions, "nosniff"):
+		add("X-Content-Type-Options", "invalid value "+contentTypeOptions)
+	}
+	add("Access-Control-Allow-Origin", analyzeCORS(headers))
+	return issues
+}
+
+// analyzeSecurityHeaders emits the security headers issues of the first html page of every origin
+func (crawler *Crawler) analyzeSecurityHeaders(emit func(SpiderReport), response *colly.Response) {
+	if response.StatusCode >= 300 || responseContentType(response) != "text/html" {
+		return
+	}
+	u := response.Request.URL
+	origin := u.Scheme + "://" + u.Host
+	if crawler.analyzedOrigins.Duplicate(origin) {
+		return
+	}
+	var headers http.Header
+	if response.Headers != nil {
+		headers = *response.Headers
+	}
+	issues := AnalyzeSecurityHeaders(u, headers)
+	summary := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		summary = append(summary, issue.String())
+	}
+	if len(summary) == 0 {
+		summary = append(summary, "no issue")
+	}
+	emit(SpiderReport{
+		Output:     origin + " " + strings.Join(summary, ", "),
+		OutputType: SecurityHeaders,
+		Source:     "header",
+		StatusCode: response.StatusCode,
+		Input:      u,
+		Seed:       requestSeed(response.Request),
+	}.WithMetadata("origin", origin).WithMetadata("issues", issues))
+}
+
+// WithSecurityHeaders emits a security-headers report for every origin, listing the missing or weak security headers
+// of its first html page (see AnalyzeSecurityHeaders) in the report Metadata
+func WithSecurityHeaders() CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.securityHeaders = true
+	}
+}
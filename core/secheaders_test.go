@@ -0,0 +1,73 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeSecurityHeaders(t *testing.T) {
+	secure := http.Header{}
+	secure.Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
+	secure.Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none'")
+	secure.Set("X-Content-Type-Options", "nosniff")
+
+	weak := http.Header{}
+	weak.Set("Strict-Transport-Security", "max-age=3600")
+	weak.Set("Content-Security-Policy", "script-src 'self' 'unsafe-inline' https:")
+	weak.Set("X-Frame-Options", "ALLOW-FROM https://example.com")
+	weak.Set("Access-Control-Allow-Origin", "*")
+	weak.Set("Access-Control-Allow-Credentials", "true")
+
+	tests := []struct {
+		u        string
+		headers  http.Header
+		expected []SecurityHeaderIssue
+	}{
+		{"https://example.com", secure, []SecurityHeaderIssue{}},
+		{"http://example.com", http.Header{}, []SecurityHeaderIssue{
+			{"Content-Security-Policy", "missing"},
+			{"X-Frame-Options", "missing"},
+			{"X-Content-Type-Options", "missing"},
+		}},
+		{"https://example.com", weak, []SecurityHeaderIssue{
+			{"Strict-Transport-Security", "max-age below 180 days"},
+			{"Content-Security-Policy", "scripts allow 'unsafe-inline'"},
+			{"Content-Security-Policy", "scripts allow https:"},
+			{"X-Frame-Options", "invalid value ALLOW-FROM https://example.com"},
+			{"X-Content-Type-Options", "missing"},
+			{"Access-Control-Allow-Origin", "any origin with credentials"},
+		}},
+	}
+	for _, test := range tests {
+		u, _ := url.Parse(test.u)
+		if issues := AnalyzeSecurityHeaders(u, test.headers); !reflect.DeepEqual(issues, test.expected) {
+			t.Errorf("expected %v for %s %v, got %v", test.expected, test.u, test.headers, issues)
+		}
+	}
+}
+
+func TestSecurityHeadersReport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("X-Frame-Options", "DENY")
+		w.Write([]byte(`<html><body><a href="/page">page</a></body></html>`))
+	}))
+	defer ts.Close()
+
+	reports := []SpiderReport{}
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(2), WithSecurityHeaders()), ts.URL) {
+		if r.OutputType == SecurityHeaders {
+			reports = append(reports, r)
+		}
+	}
+	expected := ts.URL + " Content-Security-Policy: missing, X-Content-Type-Options: missing"
+	if len(reports) != 1 || reports[0].Output != expected {
+		t.Fatalf("expected a single report %q, got %+v", expected, reports)
+	}
+	if origin := reports[0].Metadata["origin"]; origin != ts.URL {
+		t.Errorf("expected the origin %s in the report Metadata, got %v", ts.URL, origin)
+	}
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+)
+
+// secretSnippetContext is the context size of the secret snippets when WithMatchContext is not set
+const secretSnippetContext = 40
+
+// SecretRule detects a secret in the response bodies. The secret is the first group of Pattern, or the whole match
+// when it has no group. When MinEntropy is positive, the secrets whose Shannon entropy (bits per byte) is
+// below it are dropped, to filter out placeholders of the generic rules
+type SecretRule struct {
+	Name       string  `json:"name" yaml:"name"`
+	Pattern    string  `json:"pattern" yaml:"pattern"`
+	MinEntropy float64 `json:"min_entropy,omitempty" yaml:"min_entropy,omitempty"`
+}
+
+// DefaultSecretRules are the secrets WithSecretScanning looks for, besides the user rules
+var DefaultSecretRules = []SecretRule{
+	{Name: "aws-access-key-id", Pattern: `\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`},
+	{Name: "aws-secret-access-key", Pattern: `(?i)aws.{0,20}?(?:secret|private).{0,20}?["'\x60]([0-9a-zA-Z/+]{40})["'\x60]`, MinEntropy: 4},
+	{Name: "gcp-api-key", Pattern: `\bAIza[0-9A-Za-z_-]{35}\b`},
+	{Name: "gcp-service-account", Pattern: `"client_email"\s*:\s*"([^"\s]+@[^"\s]+\.iam\.gserviceaccount\.com)"`},
+	{Name: "slack-token", Pattern: `\bxox[abposr]-[0-9A-Za-z-]{10,}`},
+	{Name: "slack-webhook", Pattern: `https://hooks\.slack\.com/services/T[0-9A-Za-z]+/B[0-9A-Za-z]+/[0-9A-Za-z]+`},
+	{Name: "github-token", Pattern: `\bgh[pousr]_[0-9A-Za-z]{36}\b`},
+	{Name: "stripe-secret-key", Pattern: `\b[sr]k_live_[0-9A-Za-z]{24,}`},
+	{Name: "private-key", Pattern: `-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`},
+	{Name: "jwt", Pattern: `\beyJ[0-9A-Za-z_-]{10,}\.eyJ[0-9A-Za-z_-]{10,}\.[0-9A-Za-z_-]{10,}`},
+	{Name: "generic-secret", Pattern: `(?i)(?:api[_-]?key|secret|token|passw(?:or)?d)["']?\s*[:=]\s*["'\x60]([0-9A-Za-z_\-+/=.]{16,})["'\x60]`, MinEntropy: 3.5},
+}
+
+// secretScanner is a compiled SecretRule
+type secretScanner struct {
+	name       string
+	re         *regexp.Regexp
+	minEntropy float64
+}
+
+func (rule SecretRule) compile() (secretScanner, error) {
+	if rule.Name == "" {
+		return secretScanner{}, fmt.Errorf("secret rule %s: a name is required", rule.Pattern)
+	}
+	re, err := regexp.Compile(rule.Pattern)
+	if err != nil {
+		return secretScanner{}, fmt.Errorf("secret rule %s: %w", rule.Name, err)
+	}
+	return secretScanner{name: rule.Name, re: re, minEntropy: rule.MinEntropy}, nil
+}
+
+// Validate reports whether the rule has a name and a valid pattern
+func (rule SecretRule) Validate() error {
+	_, err := rule.compile()
+	return err
+}
+
+// ShannonEntropy returns the Shannon entropy of s, in bits per byte
+func ShannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := map[byte]int{}
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(len(s))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// Scan returns a `secret` SpiderReport for every secret of body, with its offset and a snippet of contextSize bytes
+// around it (secretSnippetContext when not positive)
+func (scanner secretScanner) Scan(input *url.URL, body string, contextSize int) []SpiderReport {
+	if contextSize <= 0 {
+		contextSize = secretSnippetContext
+	}
+	res := []SpiderReport{}
+	for _, loc := range scanner.re.FindAllStringSubmatchIndex(body, -1) {
+		start, end := loc[0], loc[1]
+		if len(loc) > 2 && loc[2] >= 0 {
+			start, end = loc[2], loc[3]
+		}
+		secret := body[start:end]
+		if scanner.minEntropy > 0 && ShannonEntropy(secret) < scanner.minEntropy {
+			continue
+		}
+		res = append(res, SpiderReport{
+			Output:     secret,
+			OutputType: Secret,
+			Source:     "body",
+			Matcher:    scanner.name,
+			Input:      input,
+			Offset:     start,
+			Snippet:    Snippet(body, start, end, contextSize),
+		})
+	}
+	return res
+}
+
+// WithSecretScanning scans the response bodies for the secrets of DefaultSecretRules and rules (AWS and GCP keys,
+// Slack tokens, private keys, high entropy assignments...) and emits a `secret` report, tagged with the rule name and
+// holding a snippet of the match, for every one found. The default rules are only added by the first call.
+// An invalid rule fails the crawl
+func WithSecretScanning(rules ...SecretRule) CrawlerOption {
+	return func(crawler *Crawler) {
+		if crawler.secretScanners == nil {
+			rules = append(append([]SecretRule{}, DefaultSecretRules...), rules...)
+		}
+		for _, rule := range rules {
+			scanner, err := rule.compile()
+			if err != nil {
+				crawler.optionErrors = append(crawler.optionErrors, err)
+				continue
+			}
+			crawler.secretScanners = append(crawler.secretScanners, scanner)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+type sessionRefreshContextKey struct{}
+
+// sessionTransport detects the responses of an expired session: a 401, a redirect to the login page or a body
+// matching loggedOut. It then logs in again, the crawl being paused meanwhile, and sends the request again
+type sessionTransport struct {
+	next      http.RoundTripper
+	crawler   *Crawler
+	loggedOut *regexp.Regexp
+
+	lock       sync.Mutex
+	generation int
+}
+
+// isLoginPage reports whether u is the page of the login form
+func (st *sessionTransport) isLoginPage(u *url.URL) bool {
+	login, err := url.Parse(st.crawler.loginFlow.URL)
+	return err == nil && u.Host == login.Host && u.Path == login.Path
+}
+
+// expired reports whether resp was served to a logged out user, its body is restored when read
+func (st *sessionTransport) expired(req *http.Request, resp *http.Response) bool {
+	switch {
+	case st.isLoginPage(req.URL):
+		return false
+	case resp.StatusCode == http.StatusUnauthorized:
+		return true
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		location, err := req.URL.Parse(resp.Header.Get("Location"))
+		return err == nil && st.isLoginPage(location)
+	case st.loggedOut != nil:
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return err == nil && st.loggedOut.Match(body)
+	}
+	return false
+}
+
+// refresh logs in again unless another request already did since generation, the session of the failed request
+func (st *sessionTransport) refresh(ctx context.Context, generation int) bool {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	if generation != st.generation {
+		return true
+	}
+	Logger.Warnf("Session expired, logging in again through %s", st.crawler.loginFlow.URL)
+	if !st.crawler.IsPaused() {
+		st.crawler.control.setPaused(true)
+		defer st.crawler.control.setPaused(false)
+	}
+	ctx = context.WithValue(ctx, sessionRefreshContextKey{}, true)
+	if err := st.crawler.loginFlow.run(ctx, st.crawler.helperClient(30*time.Second)); err != nil {
+		Logger.Errorf("Failed to refresh the session, stopping the crawl: %s", err)
+		st.crawler.Stop()
+		return false
+	}
+	st.generation++
+	return true
+}
+
+func (st *sessionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// the requests of the login flow itself are never refreshed
+	if req.Context().Value(sessionRefreshContextKey{}) != nil {
+		return st.next.RoundTrip(req)
+	}
+	st.lock.Lock()
+	generation := st.generation
+	st.lock.Unlock()
+	resp, err := st.next.RoundTrip(req)
+	if err != nil || !st.expired(req, resp) {
+		return resp, err
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+	if !st.refresh(req.Context(), generation) {
+		return resp, nil
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	// the Cookie header was set by the client before the refresh
+	retry.Header.Del("Cookie")
+	if jar := st.crawler.httpClient.Jar; jar != nil {
+		for _, cookie := range jar.Cookies(retry.URL) {
+			retry.AddCookie(cookie)
+		}
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return st.next.RoundTrip(retry)
+}
+
+// watchSession wraps the transport of the collector so expired sessions are refreshed. The collector is switched to a
+// copy of the crawler client, which shares its cookie jar with the login flow
+func (crawler *Crawler) watchSession(c *colly.Collector) {
+	crawler.wrapTransport(c, func(next http.RoundTripper) http.RoundTripper {
+		return &sessionTransport{next: next, crawler: crawler, loggedOut: crawler.sessionLoggedOut}
+	})
+}
+
+// WithSessionRefresh logs in again with the login flow (see WithLoginFlow) when the session expires mid-crawl, the crawl
+// being paused meanwhile. A session is expired on a 401, a redirect to the login page, or a page matching loggedOut when
+// it isn't empty. The pages served to the logged out crawler are requested again with the new session
+func WithSessionRefresh(loggedOut string) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.sessionRefresh = true
+		if loggedOut == "" {
+			return
+		}
+		re, err := regexp.Compile(loggedOut)
+		if err != nil {
+			Logger.Errorf("Invalid logged out regex %s: %s", loggedOut, err)
+			return
+		}
+		crawler.sessionLoggedOut = re
+	}
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newExpiringSite serves private pages with a session expiring after 2 requests. It returns the number of logins and
+// of private pages served logged in
+func newExpiringSite() (*httptest.Server, func() (int, int)) {
+	var lock sync.Mutex
+	logins := 0
+	private := 0
+	uses := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/login":
+			fmt.Fprint(w, `<html><body><form action="/session" method="post"><input name="user"><input type="password" name="password"></form></body></html>`)
+		case "/session":
+			logins++
+			session := fmt.Sprintf("session-%d", logins)
+			uses[session] = 0
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: session, Path: "/"})
+			fmt.Fprint(w, `<html><body>Welcome</body></html>`)
+		default:
+			cookie, err := r.Cookie("session")
+			if err != nil || uses[cookie.Value] >= 2 {
+				http.Redirect(w, r, "/login", http.StatusFound)
+				return
+			}
+			uses[cookie.Value]++
+			private++
+			fmt.Fprint(w, `<html><body>private <a href="/p1">1</a><a href="/p2">2</a><a href="/p3">3</a><a href="/p4">4</a></body></html>`)
+		}
+	}))
+	return ts, func() (int, int) {
+		lock.Lock()
+		defer lock.Unlock()
+		return logins, private
+	}
+}
+
+func TestSessionRefresh(t *testing.T) {
+	ts, served := newExpiringSite()
+	defer ts.Close()
+
+	flow := LoginFlow{URL: ts.URL + "/login", Fields: map[string]string{"user": "admin", "password": "secret"}, SuccessRegexp: "Welcome"}
+	crawler := NewCrawler(WithDefaultColly(2), WithLoginFlow(flow), WithSessionRefresh(""),
+		WithCollyConfig(WithLimit(1, 0, 0)))
+	for _, r := range collectReports(crawler, ts.URL+"/private") {
+		if r.OutputType == Url && strings.Contains(r.Output, "/p") {
+			if r.StatusCode != http.StatusOK || strings.Contains(r.Body, "<form") {
+				t.Errorf("expected %s to be crawled logged in, got %d", r.Output, r.StatusCode)
+			}
+		}
+	}
+	// /private and /p1 to /p4 with sessions of 2 requests
+	if logins, private := served(); private != 5 || logins != 3 {
+		t.Errorf("expected 5 private pages crawled with 3 logins, got %d pages and %d logins", private, logins)
+	}
+}
@@ -0,0 +1,193 @@
+package core
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSCredentials sign the requests of WithAWSSigV4
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is set for temporary credentials
+	SessionToken string
+}
+
+// AWSCredentialsFromEnv reads the credentials of the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN variables
+func AWSCredentialsFromEnv() AWSCredentials {
+	return AWSCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+const sigV4Algorithm = "AWS4-HMAC-SHA256"
+
+// sigV4Escape percent-encodes s as SigV4 expects, keeping only the RFC 3986 unreserved characters
+func sigV4Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' ||
+			(keepSlash && c == '/') {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// sigV4CanonicalURI encodes the path of u, twice for the services other than S3
+func sigV4CanonicalURI(u *url.URL, service string) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	if service == "s3" {
+		return path
+	}
+	return sigV4Escape(path, true)
+}
+
+func sigV4CanonicalQuery(u *url.URL) string {
+	query := u.Query()
+	pairs := make([]string, 0, len(query))
+	for key, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, sigV4Escape(key, false)+"="+sigV4Escape(value, false))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// signSigV4 sets the X-Amz-Date and Authorization headers of req, signed at now for service in region
+func signSigV4(req *http.Request, payload []byte, creds AWSCredentials, region string, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	if service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || strings.HasPrefix(name, "x-amz-") {
+			trimmed := make([]string, len(values))
+			for i, v := range values {
+				trimmed[i] = strings.Join(strings.Fields(v), " ")
+			}
+			headers[name] = strings.Join(trimmed, ",")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		sigV4CanonicalURI(req.URL, service),
+		sigV4CanonicalQuery(req.URL),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{sigV4Algorithm, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// sigV4Transport signs the requests to hosts, every host when empty
+type sigV4Transport struct {
+	next    http.RoundTripper
+	creds   AWSCredentials
+	region  string
+	service string
+	hosts   []string
+}
+
+func (st *sigV4Transport) replaceWrapped(replace transportReplacer) error {
+	return replaceNext(&st.next, replace)
+}
+
+func (st *sigV4Transport) unwrap() http.RoundTripper {
+	return st.next
+}
+
+func (st *sigV4Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !hostIn(st.hosts, req.URL.Hostname()) {
+		return st.next.RoundTrip(req)
+	}
+	signed := req.Clone(req.Context())
+	var payload []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if payload, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		signed.Body = io.NopCloser(bytes.NewReader(payload))
+	}
+	signSigV4(signed, payload, st.creds, st.region, st.service, time.Now())
+	return st.next.RoundTrip(signed)
+}
+
+// WithAWSSigV4 signs the requests to hosts (every host when empty) with AWS Signature Version 4, for targets such as
+// API Gateway (service execute-api) or S3 (service s3) requiring IAM authentication
+func WithAWSSigV4(creds AWSCredentials, region string, service string, hosts ...string) HTTPClientConfigurator {
+	return func(client *http.Client) error {
+		next := client.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		client.Transport = &sigV4Transport{next: next, creds: creds, region: region, service: service, hosts: hosts}
+		return nil
+	}
+}
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignSigV4(t *testing.T) {
+	// vectors of the AWS Signature Version 4 test suite
+	creds := AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	now, _ := time.Parse("20060102T150405Z", "20150830T123600Z")
+	for u, signature := range map[string]string{
+		"https://example.amazonaws.com/":                             "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		"https://example.amazonaws.com/?Param2=value2&Param1=value1": "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, u, nil)
+		signSigV4(req, nil, creds, "us-east-1", "service", now)
+		expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=" + signature
+		if got := req.Header.Get("Authorization"); got != expected {
+			t.Errorf("%s: expected %s, got %s", u, expected, got)
+		}
+	}
+}
+
+func TestSigV4Transport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") || r.Header.Get("X-Amz-Content-Sha256") != sha256Hex(body) ||
+			r.Header.Get("X-Amz-Security-Token") != "session" || string(body) != "payload" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer ts.Close()
+
+	client := &http.Client{}
+	WithAWSSigV4(AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "session"}, "eu-west-1", "s3")(client)
+	resp, err := client.Post(ts.URL+"/bucket/key", "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected a signed request, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,10 @@
+package core
+
+import "context"
+
+// Sink receives every report emitted by the crawler, e.g to store them in a database or a message queue.
+// Sinks are closed when the crawl ends
+type Sink interface {
+	Send(ctx context.Context, report SpiderReport) error
+	Close() error
+}
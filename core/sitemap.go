@@ -1,7 +1,258 @@
 package core
 
-// func ParseSiteMap(site *url.URL, crawler *Crawler, c *colly.Collector, wg *sync.WaitGroup) {
-// 	defer wg.Done()
-// 	crawler.ParseSiteMap()
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
-// }
+	"github.com/gocolly/colly/v2"
+)
+
+var (
+	// DefaultSitemapPaths are the paths probed for sitemaps on each site, see WithSitemapPaths
+	DefaultSitemapPaths = []string{"/sitemap.xml", "/sitemap.xml.gz", "/sitemap_news.xml", "/sitemap_index.xml", "/sitemap-index.xml", "/sitemapindex.xml",
+		"/sitemap-news.xml", "/post-sitemap.xml", "/page-sitemap.xml", "/portfolio-sitemap.xml", "/home_slider-sitemap.xml", "/category-sitemap.xml",
+		"/author-sitemap.xml"}
+	// DefaultSitemapMaxBytes is the maximum uncompressed size of a sitemap file, the limit of the sitemap protocol
+	DefaultSitemapMaxBytes int64 = 50 * 1024 * 1024
+	// DefaultSitemapMaxEntries is the maximum number of urls read from the sitemaps of a site
+	DefaultSitemapMaxEntries = 500000
+	// sitemapMaxDepth bounds the nesting of sitemap indexes
+	sitemapMaxDepth = 5
+)
+
+var errSitemapEntriesLimit = errors.New("sitemap entries limit reached")
+
+// SitemapEntry is an url listed by a sitemap
+type SitemapEntry struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod"`
+	ChangeFreq string `xml:"changefreq"`
+	Priority   string `xml:"priority"`
+	// Sitemap is the url of the sitemap listing the entry
+	Sitemap string `xml:"-"`
+}
+
+// report returns the sitemap report of the entry, found while crawling seed
+func (entry SitemapEntry) report(seed string) SpiderReport {
+	input, _ := url.Parse(entry.Sitemap)
+	report := SpiderReport{
+		Output:     entry.Loc,
+		OutputType: Sitemap,
+		Source:     "sitemap",
+		Input:      input,
+		Seed:       seed,
+	}
+	for key, value := range map[string]string{"sitemap": entry.Sitemap, "lastmod": entry.LastMod, "changefreq": entry.ChangeFreq, "priority": entry.Priority} {
+		if value = strings.TrimSpace(value); value != "" {
+			report = report.WithMetadata(key, value)
+		}
+	}
+	return report
+}
+
+// sitemapReader reads sitemaps, following sitemap indexes and decompressing gzipped files
+type sitemapReader struct {
+	client     *http.Client
+	maxBytes   int64
+	maxEntries int
+
+	entries int
+	seen    map[string]bool
+}
+
+func (crawler *Crawler) newSitemapReader() *sitemapReader {
+	sr := &sitemapReader{
+		client:     crawler.helperClient(30 * time.Second),
+		maxBytes:   DefaultSitemapMaxBytes,
+		maxEntries: DefaultSitemapMaxEntries,
+		seen:       make(map[string]bool),
+	}
+	if crawler.sitemapMaxBytes > 0 {
+		sr.maxBytes = crawler.sitemapMaxBytes
+	}
+	if crawler.sitemapMaxEntries > 0 {
+		sr.maxEntries = crawler.sitemapMaxEntries
+	}
+	return sr
+}
+
+// read returns the entries of the sitemap at sitemapURL, recursively reading the sitemaps of an index
+func (sr *sitemapReader) read(sitemapURL string) ([]SitemapEntry, error) {
+	res := []SitemapEntry{}
+	err := sr.walk(sitemapURL, 0, func(entry SitemapEntry) {
+		res = append(res, entry)
+	})
+	if errors.Is(err, errSitemapEntriesLimit) {
+		Logger.Warnf("Stopped reading sitemaps after %d entries", sr.maxEntries)
+		err = nil
+	}
+	return res, err
+}
+
+func (sr *sitemapReader) walk(sitemapURL string, depth int, emit func(SitemapEntry)) error {
+	if sr.seen[sitemapURL] {
+		return nil
+	}
+	sr.seen[sitemapURL] = true
+	resp, err := sr.client.Get(sitemapURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	body, err := sitemapBody(resp, sr.maxBytes)
+	if err != nil {
+		return fmt.Errorf("failed to read sitemap %s: %w", sitemapURL, err)
+	}
+	Logger.Debugf("Reading sitemap %s", sitemapURL)
+
+	// plain text sitemaps list one url per line
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] != '<' {
+		scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+		for scanner.Scan() {
+			if loc := strings.TrimSpace(scanner.Text()); strings.HasPrefix(loc, "http") {
+				if err := sr.add(SitemapEntry{Loc: loc, Sitemap: sitemapURL}, emit); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	children := []string{}
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	dec.Strict = false
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse sitemap %s: %w", sitemapURL, err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "url":
+			entry := SitemapEntry{}
+			if err := dec.DecodeElement(&entry, &start); err != nil {
+				return fmt.Errorf("failed to parse sitemap %s: %w", sitemapURL, err)
+			}
+			entry.Loc = strings.TrimSpace(entry.Loc)
+			entry.Sitemap = sitemapURL
+			if entry.Loc == "" {
+				continue
+			}
+			if err := sr.add(entry, emit); err != nil {
+				return err
+			}
+		case "sitemap":
+			child := SitemapEntry{}
+			if err := dec.DecodeElement(&child, &start); err != nil {
+				return fmt.Errorf("failed to parse sitemap index %s: %w", sitemapURL, err)
+			}
+			if loc := strings.TrimSpace(child.Loc); loc != "" {
+				children = append(children, loc)
+			}
+		}
+	}
+	if len(children) > 0 && depth >= sitemapMaxDepth {
+		Logger.Warnf("Ignoring %d sitemaps nested too deep in %s", len(children), sitemapURL)
+		return nil
+	}
+	for _, child := range children {
+		if err := sr.walk(child, depth+1, emit); err != nil {
+			if errors.Is(err, errSitemapEntriesLimit) {
+				return err
+			}
+			Logger.Debugf("Failed to read sitemap %s: %s", child, err)
+		}
+	}
+	return nil
+}
+
+func (sr *sitemapReader) add(entry SitemapEntry, emit func(SitemapEntry)) error {
+	if sr.entries >= sr.maxEntries {
+		return errSitemapEntriesLimit
+	}
+	sr.entries++
+	emit(entry)
+	return nil
+}
+
+// sitemapBody reads at most maxBytes of a sitemap, decompressing it when gzipped
+func sitemapBody(resp *http.Response, maxBytes int64) ([]byte, error) {
+	reader := bufio.NewReader(resp.Body)
+	// gzip magic number, whatever the extension or content type says
+	if magic, err := reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		return readLimited(gz, maxBytes)
+	}
+	return readLimited(reader, maxBytes)
+}
+
+func readLimited(r io.Reader, maxBytes int64) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxBytes {
+		return nil, fmt.Errorf("sitemap larger than %d bytes", maxBytes)
+	}
+	return body, nil
+}
+
+// discoverSitemap emits the entries of a sitemap found while crawling request, unless it was already read
+func (crawler *Crawler) discoverSitemap(emit func(SpiderReport), request *colly.Request, sitemapURL string) {
+	if sitemapURL == "" || crawler.discoveredSitemaps.Duplicate(sitemapURL) {
+		return
+	}
+	for _, entry := range crawler.readSitemap(crawler.newSitemapReader(), sitemapURL) {
+		emit(entry.report(requestSeed(request)))
+	}
+}
+
+// discoverRobotsSitemaps emits the entries of the sitemaps declared by a robots.txt fetched during the crawl
+func (crawler *Crawler) discoverRobotsSitemaps(emit func(SpiderReport), request *colly.Request, body string) {
+	robots, err := ParseRobots(strings.NewReader(body))
+	if err != nil {
+		Logger.Debugf("Failed to parse robots %s: %s", request.URL, err)
+		return
+	}
+	for _, sitemapURL := range robots.Sitemaps {
+		crawler.discoverSitemap(emit, request, request.AbsoluteURL(sitemapURL))
+	}
+}
+
+// WithSitemapPaths sets the paths probed for sitemaps on each site, replacing DefaultSitemapPaths.
+// To extend the defaults, pass append(DefaultSitemapPaths, paths...)
+func WithSitemapPaths(paths ...string) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.sitemapPaths = paths
+	}
+}
+
+// WithSitemapLimits bounds the uncompressed size of each sitemap file and the number of urls read from the sitemaps of a site
+func WithSitemapLimits(maxBytes int64, maxEntries int) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.sitemapMaxBytes = maxBytes
+		crawler.sitemapMaxEntries = maxEntries
+	}
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestSitemapReaderIndex(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap_index.xml":
+			fmt.Fprintf(w, `<?xml version="1.0"?><sitemapindex><sitemap><loc>%[1]s/a.xml.gz</loc></sitemap><sitemap><loc>%[1]s/b.txt</loc></sitemap><sitemap><loc>%[1]s/sitemap_index.xml</loc></sitemap></sitemapindex>`, srv.URL)
+		case "/a.xml.gz":
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			fmt.Fprintf(gz, `<urlset><url><loc>%[1]s/one</loc><lastmod>2024-01-02</lastmod><priority>0.8</priority></url><url><loc> %[1]s/two </loc></url></urlset>`, srv.URL)
+			gz.Close()
+			w.Write(buf.Bytes())
+		case "/b.txt":
+			fmt.Fprintf(w, "%[1]s/three\n\nnot an url\n%[1]s/four\n", srv.URL)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	crawler := NewCrawler()
+	entries, err := crawler.newSitemapReader().read(srv.URL + "/sitemap_index.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 entries, got %+v", entries)
+	}
+	if entries[0].Loc != srv.URL+"/one" || entries[0].LastMod != "2024-01-02" || entries[0].Priority != "0.8" || entries[0].Sitemap != srv.URL+"/a.xml.gz" {
+		t.Errorf("unexpected first entry %+v", entries[0])
+	}
+	if entries[1].Loc != srv.URL+"/two" {
+		t.Errorf("expected a trimmed location, got %q", entries[1].Loc)
+	}
+
+	limited := NewCrawler(WithSitemapLimits(0, 3))
+	entries, err = limited.newSitemapReader().read(srv.URL + "/sitemap_index.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("expected the entries limit to stop at 3, got %d", len(entries))
+	}
+
+	tiny := NewCrawler(WithSitemapLimits(16, 0))
+	if entries, _ := tiny.newSitemapReader().read(srv.URL + "/b.txt"); len(entries) != 0 {
+		t.Errorf("expected an oversized sitemap to be rejected, got %+v", entries)
+	}
+}
+
+func TestSitemapEntryReport(t *testing.T) {
+	entry := SitemapEntry{Loc: "https://example.com/a", LastMod: "2024-01-02", Priority: "0.5", Sitemap: "https://example.com/sitemap.xml"}
+	report := entry.report("https://example.com")
+	if report.OutputType != Sitemap || report.Output != entry.Loc || report.Seed != "https://example.com" || report.Input.String() != entry.Sitemap {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if report.Metadata["lastmod"] != "2024-01-02" || report.Metadata["priority"] != "0.5" || report.Metadata["sitemap"] != entry.Sitemap {
+		t.Errorf("unexpected metadata %v", report.Metadata)
+	}
+	if _, ok := report.Metadata["changefreq"]; ok {
+		t.Errorf("expected no changefreq metadata, got %v", report.Metadata)
+	}
+}
+
+func TestParseSiteMapPaths(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/custom/map.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, "https://example.com/from-custom")
+	}))
+	defer srv.Close()
+	target, _ := url.Parse(srv.URL)
+
+	if entries := NewCrawler().parseSiteMap(target); len(entries) != 0 {
+		t.Fatalf("expected no entry at the default paths, got %+v", entries)
+	}
+	crawler := NewCrawler(WithSitemapPaths(append(DefaultSitemapPaths, "/custom/map.txt")...))
+	entries := crawler.parseSiteMap(target)
+	if len(entries) != 1 || entries[0].Loc != "https://example.com/from-custom" {
+		t.Fatalf("expected the custom sitemap entry, got %+v", entries)
+	}
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CachedSource serves the urls of Source from a disk cache in Dir while they are younger than TTL, so the
+// expensive and rate limited archive queries are re-used across runs on the same domain.
+// Failed fetches are not cached
+type CachedSource struct {
+	Source Source
+	Dir    string
+	TTL    time.Duration
+}
+
+type sourceCacheEntry struct {
+	Source    string    `json:"source"`
+	Domain    string    `json:"domain"`
+	FetchedAt time.Time `json:"fetched_at"`
+	URLs      []string  `json:"urls"`
+}
+
+// path returns the cache file of domain, named after the source type, its settings and the domain
+func (cs CachedSource) path(domain string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%T %+v %s", cs.Source, cs.Source, domain)))
+	return filepath.Join(NormalizePath(cs.Dir), hex.EncodeToString(sum[:])+".json")
+}
+
+func (cs CachedSource) Fetch(ctx context.Context, domain string) ([]string, error) {
+	path := cs.path(domain)
+	if raw, err := os.ReadFile(path); err == nil {
+		entry := sourceCacheEntry{}
+		if err := json.Unmarshal(raw, &entry); err == nil && time.Since(entry.FetchedAt) < cs.TTL {
+			Logger.Debugf("Using %d cached %s urls for %s", len(entry.URLs), entry.Source, domain)
+			return entry.URLs, nil
+		}
+	}
+	urls, err := cs.Source.Fetch(ctx, domain)
+	if err != nil {
+		return urls, err
+	}
+	if err := cs.store(path, sourceCacheEntry{Source: fmt.Sprintf("%T", cs.Source), Domain: domain, FetchedAt: time.Now(), URLs: urls}); err != nil {
+		Logger.Warnf("Failed to cache source urls for %s: %s", domain, err)
+	}
+	return urls, nil
+}
+
+func (cs CachedSource) store(path string, entry sourceCacheEntry) error {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// WithSourceCache caches the urls returned by the crawler sources in dir for ttl, see CachedSource
+func WithSourceCache(dir string, ttl time.Duration) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.sourceCacheDir = dir
+		crawler.sourceCacheTTL = ttl
+	}
+}
+
+// fetchSources returns the urls the crawler sources know for domain, through the source cache when set
+func (crawler *Crawler) fetchSources(ctx context.Context, domain string) []string {
+	sources := crawler.sources
+	if crawler.sourceCacheDir != "" && crawler.sourceCacheTTL > 0 {
+		sources = make([]Source, 0, len(crawler.sources))
+		for _, source := range crawler.sources {
+			sources = append(sources, CachedSource{Source: source, Dir: crawler.sourceCacheDir, TTL: crawler.sourceCacheTTL})
+		}
+	}
+	return FetchSources(ctx, domain, sources...)
+}
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type countingSource struct {
+	calls *int
+	err   error
+}
+
+func (s countingSource) Fetch(ctx context.Context, domain string) ([]string, error) {
+	*s.calls++
+	return []string{"https://" + domain + "/a"}, s.err
+}
+
+func TestCachedSource(t *testing.T) {
+	dir := t.TempDir()
+	calls := 0
+	cached := CachedSource{Source: countingSource{calls: &calls}, Dir: dir, TTL: time.Hour}
+	for i := 0; i < 2; i++ {
+		urls, err := cached.Fetch(context.Background(), "example.com")
+		if err != nil || len(urls) != 1 || urls[0] != "https://example.com/a" {
+			t.Fatalf("unexpected urls %v %v", urls, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected the second fetch to be served from the cache, got %d calls", calls)
+	}
+	if _, err := cached.Fetch(context.Background(), "other.com"); err != nil || calls != 2 {
+		t.Errorf("expected another domain to miss the cache, got %d calls", calls)
+	}
+
+	expired := CachedSource{Source: countingSource{calls: &calls}, Dir: dir, TTL: time.Nanosecond}
+	expired.Fetch(context.Background(), "example.com")
+	if calls != 3 {
+		t.Errorf("expected an expired entry to be fetched again, got %d calls", calls)
+	}
+
+	failing := CachedSource{Source: countingSource{calls: &calls, err: errors.New("down")}, Dir: t.TempDir(), TTL: time.Hour}
+	failing.Fetch(context.Background(), "example.com")
+	failing.Fetch(context.Background(), "example.com")
+	if calls != 5 {
+		t.Errorf("expected failed fetches not to be cached, got %d calls", calls)
+	}
+}
@@ -0,0 +1,171 @@
+package core
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// sourceMapMaxBytes bounds the size of the fetched source maps
+const sourceMapMaxBytes = 32 * 1024 * 1024
+
+var sourceMappingURLRE = regexp.MustCompile(`(?m)^\s*//[#@]\s*sourceMappingURL=(\S+)\s*$|/\*[#@]\s*sourceMappingURL=(\S+)\s*\*/`)
+
+// SourceMap is the part of a source map revealing the original sources
+type SourceMap struct {
+	Version        int      `json:"version"`
+	SourceRoot     string   `json:"sourceRoot"`
+	Sources        []string `json:"sources"`
+	SourcesContent []string `json:"sourcesContent"`
+}
+
+// SourcePaths returns the paths of the original sources, prefixed by the source root
+func (sm SourceMap) SourcePaths() []string {
+	paths := make([]string, 0, len(sm.Sources))
+	for _, source := range sm.Sources {
+		if source == "" {
+			continue
+		}
+		if sm.SourceRoot != "" && !strings.Contains(source, "://") {
+			source = strings.TrimSuffix(sm.SourceRoot, "/") + "/" + strings.TrimPrefix(source, "/")
+		}
+		paths = append(paths, source)
+	}
+	return Unique(paths)
+}
+
+// ParseSourceMap parses a source map, which must declare its version and sources
+func ParseSourceMap(raw []byte) (SourceMap, error) {
+	sm := SourceMap{}
+	if err := json.Unmarshal(raw, &sm); err != nil {
+		return sm, fmt.Errorf("invalid source map: %w", err)
+	}
+	if sm.Version == 0 || sm.Sources == nil {
+		return sm, fmt.Errorf("invalid source map: missing version or sources")
+	}
+	return sm, nil
+}
+
+// SourceMappingURL returns the source map declared by a script through its last sourceMappingURL comment, or by
+// the SourceMap (or deprecated X-SourceMap) header of its response
+func SourceMappingURL(headers http.Header, script string) string {
+	if headers != nil {
+		if u := headers.Get("SourceMap"); u != "" {
+			return u
+		}
+		if u := headers.Get("X-SourceMap"); u != "" {
+			return u
+		}
+	}
+	matches := sourceMappingURLRE.FindAllStringSubmatch(script, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	last := matches[len(matches)-1]
+	return last[1] + last[2]
+}
+
+// loadSourceMap returns the source map at mapURL, decoding the data urls instead of fetching them
+func (crawler *Crawler) loadSourceMap(mapURL string) ([]byte, error) {
+	if strings.HasPrefix(mapURL, "data:") {
+		meta, data, ok := strings.Cut(strings.TrimPrefix(mapURL, "data:"), ",")
+		if !ok {
+			return nil, fmt.Errorf("invalid data url")
+		}
+		if strings.HasSuffix(meta, ";base64") {
+			return base64.StdEncoding.DecodeString(data)
+		}
+		return []byte(data), nil
+	}
+	resp, err := crawler.helperClient(30 * time.Second).Get(mapURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, sourceMapMaxBytes))
+}
+
+// discoverSourceMap fetches the source map of a script response and emits it as an exposed source map, its original
+// sources as source-file reports and the endpoints found in their content
+func (crawler *Crawler) discoverSourceMap(emit func(SpiderReport), response *colly.Response, script string) {
+	contentType := responseContentType(response)
+	if !strings.Contains(contentType, "javascript") && GetExtType(response.Request.URL.String()) != ".js" {
+		return
+	}
+	var headers http.Header
+	if response.Headers != nil {
+		headers = *response.Headers
+	}
+	mapURL := SourceMappingURL(headers, script)
+	if mapURL == "" {
+		return
+	}
+	inline := strings.HasPrefix(mapURL, "data:")
+	if !inline {
+		mapURL = response.Request.AbsoluteURL(mapURL)
+		if mapURL == "" || crawler.discoveredSourceMaps.Duplicate(mapURL) {
+			return
+		}
+	}
+	raw, err := crawler.loadSourceMap(mapURL)
+	if err != nil {
+		Logger.Debugf("Failed to load the source map of %s: %s", response.Request.URL, err)
+		return
+	}
+	sm, err := ParseSourceMap(raw)
+	if err != nil {
+		Logger.Debugf("Failed to parse the source map of %s: %s", response.Request.URL, err)
+		return
+	}
+	request := response.Request
+	paths := sm.SourcePaths()
+	if !inline {
+		emit(SpiderReport{
+			Output:     mapURL,
+			OutputType: SourceMapOutput,
+			Source:     "source-map",
+			StatusCode: http.StatusOK,
+			Input:      request.URL,
+			Seed:       requestSeed(request),
+		}.WithMetadata("sources", len(paths)))
+	}
+	for _, path := range paths {
+		emit(SpiderReport{
+			Output:     path,
+			OutputType: SourceFile,
+			Source:     "source-map",
+			Input:      request.URL,
+			Seed:       requestSeed(request),
+		})
+	}
+	for _, content := range sm.SourcesContent {
+		links, _ := LinkFinder(content)
+		for _, link := range links {
+			emit(SpiderReport{
+				Output:     link,
+				OutputType: LinkFinderOutput,
+				Source:     "source-map",
+				Input:      request.URL,
+				Seed:       requestSeed(request),
+			})
+		}
+	}
+}
+
+// WithSourceMaps fetches the source maps declared by the scripts, emits a source-map report flagging every exposed one,
+// a source-file report for each original source it lists, and crawls the endpoints found in their content
+func WithSourceMaps() CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.sourceMaps = true
+	}
+}
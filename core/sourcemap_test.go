@@ -0,0 +1,85 @@
+package core
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSourceMappingURL(t *testing.T) {
+	headers := http.Header{}
+	headers.Set("SourceMap", "/maps/header.js.map")
+	tests := []struct {
+		headers  http.Header
+		script   string
+		expected string
+	}{
+		{nil, "var a=1;\n//# sourceMappingURL=app.js.map\n", "app.js.map"},
+		{nil, "var a=1;\n//@ sourceMappingURL=old.js.map", "old.js.map"},
+		{nil, "/*# sourceMappingURL=style.css.map */", "style.css.map"},
+		{nil, `var s = "//# sourceMappingURL=fake.map";`, ""},
+		{headers, "//# sourceMappingURL=app.js.map", "/maps/header.js.map"},
+	}
+	for _, test := range tests {
+		if u := SourceMappingURL(test.headers, test.script); u != test.expected {
+			t.Errorf("expected %q for %q, got %q", test.expected, test.script, u)
+		}
+	}
+}
+
+func TestSourcePaths(t *testing.T) {
+	sm, err := ParseSourceMap([]byte(`{"version":3,"sourceRoot":"src/","sources":["app.ts","webpack:///node_modules/x/index.js",""],"mappings":""}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"src/app.ts", "webpack:///node_modules/x/index.js"}
+	if paths := sm.SourcePaths(); !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected %v, got %v", expected, paths)
+	}
+	if _, err := ParseSourceMap([]byte(`{"name":"package.json"}`)); err == nil {
+		t.Errorf("expected an error for a json document which is not a source map")
+	}
+}
+
+func TestSourceMapReports(t *testing.T) {
+	inline := base64.StdEncoding.EncodeToString([]byte(`{"version":3,"sources":["inline.ts"],"mappings":""}`))
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><body><script src="/static/app.min.js"></script><script src="/static/vendor.js"></script></body></html>`)
+		case "/static/app.min.js":
+			w.Header().Set("Content-Type", "application/javascript")
+			fmt.Fprint(w, "var a=1;\n//# sourceMappingURL=app.min.js.map\n")
+		case "/static/vendor.js":
+			w.Header().Set("Content-Type", "application/javascript")
+			fmt.Fprint(w, "var v=1;\n//# sourceMappingURL=data:application/json;charset=utf-8;base64,"+inline+"\n")
+		case "/static/app.min.js.map":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"version":3,"sources":["../src/api.ts"],"sourcesContent":["fetch('/api/internal/users')"],"mappings":""}`)
+		}
+	}))
+	defer ts.Close()
+
+	found := map[OutputType][]string{}
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(2), WithSourceMaps()), ts.URL) {
+		if r.Source == "source-map" {
+			found[r.OutputType] = append(found[r.OutputType], r.Output)
+		}
+	}
+	for _, outputs := range found {
+		sort.Strings(outputs)
+	}
+	expected := map[OutputType][]string{
+		SourceMapOutput:  {ts.URL + "/static/app.min.js.map"},
+		SourceFile:       {"../src/api.ts", "inline.ts"},
+		LinkFinderOutput: {ts.URL + "/api/internal/users"},
+	}
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("expected the reports %v, got %v", expected, found)
+	}
+}
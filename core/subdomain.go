@@ -0,0 +1,55 @@
+package core
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// inCollectorScope reports whether u passes the domain and url filters of c, the checks colly runs before a visit
+func inCollectorScope(c *colly.Collector, u *url.URL) bool {
+	if slices.Contains(c.DisallowedDomains, u.Hostname()) {
+		return false
+	}
+	if len(c.AllowedDomains) > 0 && !slices.Contains(c.AllowedDomains, u.Hostname()) {
+		return false
+	}
+	for _, filter := range c.DisallowedURLFilters {
+		if filter.MatchString(u.String()) {
+			return false
+		}
+	}
+	return len(c.URLFilters) == 0 || InScope(u, c.URLFilters)
+}
+
+// crawlSubdomain visits the root of the host of a domain report, once per host, when it is a subdomain of the
+// domain of the seed of the report within the scope of c and of the crawler
+func (crawler *Crawler) crawlSubdomain(c *colly.Collector, report SpiderReport) {
+	seed, err := url.Parse(report.Seed)
+	if err != nil || seed.Hostname() == "" {
+		return
+	}
+	host := strings.TrimSuffix(strings.ToLower(report.Output), ".")
+	domain := GetDomain(seed)
+	if host == seed.Hostname() || domain == "" || GetDomain(&url.URL{Host: host}) != domain {
+		return
+	}
+	target := &url.URL{Scheme: seed.Scheme, Host: host, Path: "/"}
+	if !inCollectorScope(c, target) || !crawler.scope.Allows(target) || crawler.crawledSubdomains.Duplicate(host) {
+		return
+	}
+	Logger.Debugf("Crawling the subdomain %s found by %s", host, report.Source)
+	if err := crawler.visit(c, target.String(), report.Seed); err != nil {
+		Logger.Debugf("Failed to visit the subdomain %s: %s", host, err)
+	}
+}
+
+// WithCrawlDiscoveredSubdomains crawls the subdomains reported as domain, by the CSP or the resource hints of the
+// pages, when they share the domain of their seed and match the scope of the collector
+func WithCrawlDiscoveredSubdomains() CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.crawlSubdomains = true
+	}
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCrawlDiscoveredSubdomains(t *testing.T) {
+	var lock sync.Mutex
+	hosts := map[string]bool{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		hosts[r.Host] = true
+		lock.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		if r.Host == "www.crawl.test" {
+			w.Header().Set("Content-Security-Policy", "default-src 'self' api.crawl.test admin.crawl.test cdn.other.test")
+			w.Write([]byte(`<html><head><link rel="preconnect" href="https://static.crawl.test"></head></html>`))
+		}
+	}))
+	defer ts.Close()
+	tsURL, _ := url.Parse(ts.URL)
+	mapping := map[string]string{}
+	for _, host := range []string{"www.crawl.test", "api.crawl.test", "admin.crawl.test", "static.crawl.test", "cdn.other.test"} {
+		mapping[host] = tsURL.Host
+	}
+
+	crawler := NewCrawler(WithDefaultColly(2), WithCSPDomains(false), WithCrawlDiscoveredSubdomains(), WithHTTPClientOpt(WithHostMapping(mapping)), WithCollyConfig(
+		WithScope(`^https?://([^/]+\.)?crawl\.test(:\d+)?(/|$)`),
+		WithDisallowedRegexFilter(`//admin\.`),
+	))
+	collectReports(crawler, "http://www.crawl.test/")
+	lock.Lock()
+	defer lock.Unlock()
+	expected := map[string]bool{"www.crawl.test": true, "api.crawl.test": true, "static.crawl.test": true}
+	if !reflect.DeepEqual(hosts, expected) {
+		t.Errorf("expected the crawled hosts %v, got %v", expected, hosts)
+	}
+}
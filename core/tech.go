@@ -0,0 +1,265 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+var scriptTagRE = regexp.MustCompile(`(?is)<script\s[^>]*>`)
+
+// TechRule fingerprints a technology from the responses. Every pattern is a case insensitive regular expression whose
+// first group, when matched, is the version of the technology. An empty pattern only requires the header, cookie or meta tag
+type TechRule struct {
+	Name string `json:"name" yaml:"name"`
+	// Headers maps response header names to the pattern of their value
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// Cookies maps the names of the cookies set by the responses to the pattern of their value
+	Cookies map[string]string `json:"cookies,omitempty" yaml:"cookies,omitempty"`
+	// Meta maps the name of the meta tags of the pages to the pattern of their content
+	Meta map[string]string `json:"meta,omitempty" yaml:"meta,omitempty"`
+	// Scripts are patterns of the src of the script tags of the pages
+	Scripts []string `json:"scripts,omitempty" yaml:"scripts,omitempty"`
+}
+
+// DefaultTechRules are the technologies WithTechFingerprint detects when no rule is given
+var DefaultTechRules = []TechRule{
+	{Name: "nginx", Headers: map[string]string{"Server": `nginx(?:/([\d.]+))?`}},
+	{Name: "Apache", Headers: map[string]string{"Server": `apache(?:/([\d.]+))?`}},
+	{Name: "Microsoft IIS", Headers: map[string]string{"Server": `microsoft-iis(?:/([\d.]+))?`}},
+	{Name: "LiteSpeed", Headers: map[string]string{"Server": `litespeed`}},
+	{Name: "Cloudflare", Headers: map[string]string{"Server": `cloudflare`, "CF-Ray": ``}, Cookies: map[string]string{"__cf_bm": ``}},
+	{Name: "Amazon CloudFront", Headers: map[string]string{"X-Amz-Cf-Id": ``}},
+	{Name: "Akamai", Headers: map[string]string{"X-Akamai-Transformed": ``}},
+	{Name: "Fastly", Headers: map[string]string{"X-Served-By": `cache-`, "Fastly-Debug-Digest": ``}},
+	{Name: "Varnish", Headers: map[string]string{"X-Varnish": ``}},
+	{Name: "PHP", Headers: map[string]string{"X-Powered-By": `php(?:/([\d.]+))?`}, Cookies: map[string]string{"PHPSESSID": ``}},
+	{Name: "ASP.NET", Headers: map[string]string{"X-Powered-By": `asp\.net`, "X-AspNet-Version": `([\d.]+)`}, Cookies: map[string]string{"ASP.NET_SessionId": ``}},
+	{Name: "Java", Cookies: map[string]string{"JSESSIONID": ``}},
+	{Name: "Express", Headers: map[string]string{"X-Powered-By": `express`}},
+	{Name: "Laravel", Cookies: map[string]string{"laravel_session": ``}},
+	{Name: "Django", Cookies: map[string]string{"csrftoken": ``, "django_language": ``}},
+	{Name: "Ruby on Rails", Headers: map[string]string{"X-Powered-By": `phusion passenger`}, Cookies: map[string]string{"_rails_session": ``}},
+	{Name: "WordPress", Meta: map[string]string{"generator": `wordpress(?: ([\d.]+))?`}, Scripts: []string{`/wp-(?:content|includes)/`}, Headers: map[string]string{"Link": `rel="https://api\.w\.org/"`}},
+	{Name: "Drupal", Meta: map[string]string{"generator": `drupal(?: (\d+))?`}, Headers: map[string]string{"X-Drupal-Cache": ``, "X-Generator": `drupal(?: (\d+))?`}},
+	{Name: "Joomla", Meta: map[string]string{"generator": `joomla!?(?: ([\d.]+))?`}},
+	{Name: "Shopify", Headers: map[string]string{"X-ShopId": ``}, Scripts: []string{`cdn\.shopify\.com`}},
+	{Name: "Next.js", Headers: map[string]string{"X-Powered-By": `next\.js(?: ([\d.]+))?`}, Scripts: []string{`/_next/static/`}},
+	{Name: "Nuxt.js", Scripts: []string{`/_nuxt/`}},
+	{Name: "jQuery", Scripts: []string{`jquery(?:[.-]([\d.]+\d))?(?:\.min)?\.js`}},
+	{Name: "Bootstrap", Scripts: []string{`bootstrap(?:[.-]([\d.]+\d))?(?:\.bundle)?(?:\.min)?\.js`}},
+	{Name: "React", Scripts: []string{`react(?:-dom)?(?:[.-]([\d.]+\d))?(?:\.production)?(?:\.min)?\.js`}},
+	{Name: "Google Tag Manager", Scripts: []string{`googletagmanager\.com/gt[am]\.js`}},
+	{Name: "Google Analytics", Scripts: []string{`google-analytics\.com/(?:ga|analytics)\.js`}},
+}
+
+// TechMatch is a technology found by a TechRule, Source is the part of the response it was found in
+type TechMatch struct {
+	Name    string
+	Version string
+	Source  string
+}
+
+// String returns the name of the technology followed by its version, when known
+func (match TechMatch) String() string {
+	if match.Version == "" {
+		return match.Name
+	}
+	return match.Name + " " + match.Version
+}
+
+// techFingerprint is a compiled TechRule
+type techFingerprint struct {
+	name    string
+	headers map[string]*regexp.Regexp
+	cookies map[string]*regexp.Regexp
+	meta    map[string]*regexp.Regexp
+	scripts []*regexp.Regexp
+}
+
+func compileTechPatterns(rule string, patterns map[string]string) (map[string]*regexp.Regexp, error) {
+	compiled := make(map[string]*regexp.Regexp, len(patterns))
+	for name, pattern := range patterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("tech rule %s: invalid pattern of %s: %w", rule, name, err)
+		}
+		compiled[name] = re
+	}
+	return compiled, nil
+}
+
+func (rule TechRule) compile() (techFingerprint, error) {
+	fp := techFingerprint{name: rule.Name}
+	if rule.Name == "" {
+		return fp, fmt.Errorf("tech rule: a name is required")
+	}
+	if len(rule.Headers)+len(rule.Cookies)+len(rule.Meta)+len(rule.Scripts) == 0 {
+		return fp, fmt.Errorf("tech rule %s: one of headers, cookies, meta or scripts is required", rule.Name)
+	}
+	var err error
+	if fp.headers, err = compileTechPatterns(rule.Name, rule.Headers); err != nil {
+		return fp, err
+	}
+	if fp.cookies, err = compileTechPatterns(rule.Name, rule.Cookies); err != nil {
+		return fp, err
+	}
+	meta := make(map[string]string, len(rule.Meta))
+	for name, pattern := range rule.Meta {
+		meta[strings.ToLower(name)] = pattern
+	}
+	if fp.meta, err = compileTechPatterns(rule.Name, meta); err != nil {
+		return fp, err
+	}
+	for _, pattern := range rule.Scripts {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return fp, fmt.Errorf("tech rule %s: invalid script pattern: %w", rule.Name, err)
+		}
+		fp.scripts = append(fp.scripts, re)
+	}
+	return fp, nil
+}
+
+// Validate reports whether the rule has a name and valid patterns
+func (rule TechRule) Validate() error {
+	_, err := rule.compile()
+	return err
+}
+
+// techEvidence is the parts of a response the technologies are searched in
+type techEvidence struct {
+	headers http.Header
+	cookies map[string]string
+	meta    map[string][]string
+	scripts []string
+}
+
+func newTechEvidence(headers http.Header, body string) techEvidence {
+	evidence := techEvidence{headers: headers, cookies: map[string]string{}, meta: map[string][]string{}}
+	for _, cookie := range (&http.Response{Header: headers}).Cookies() {
+		evidence.cookies[cookie.Name] = cookie.Value
+	}
+	for _, tag := range metaTagRE.FindAllString(body, -1) {
+		attrs := tagAttributes(tag)
+		if name := strings.ToLower(attrs["name"]); name != "" {
+			evidence.meta[name] = append(evidence.meta[name], attrs["content"])
+		}
+	}
+	for _, tag := range scriptTagRE.FindAllString(body, -1) {
+		if src := tagAttributes(tag)["src"]; src != "" {
+			evidence.scripts = append(evidence.scripts, src)
+		}
+	}
+	return evidence
+}
+
+// techVersion returns whether re matches value, and the version it captured
+func techVersion(re *regexp.Regexp, value string) (bool, string) {
+	m := re.FindStringSubmatch(value)
+	if m == nil {
+		return false, ""
+	}
+	if len(m) > 1 {
+		return true, m[1]
+	}
+	return true, ""
+}
+
+// match returns the technology of the fingerprint found in evidence, preferring the matches giving a version
+func (fp techFingerprint) match(evidence techEvidence) (TechMatch, bool) {
+	found := TechMatch{}
+	ok := false
+	check := func(re *regexp.Regexp, value string, source string) {
+		if matched, version := techVersion(re, value); matched && (!ok || found.Version == "" && version != "") {
+			found, ok = TechMatch{Name: fp.name, Version: version, Source: source}, true
+		}
+	}
+	for name, re := range fp.headers {
+		for _, value := range evidence.headers.Values(name) {
+			check(re, value, "header")
+		}
+	}
+	for name, re := range fp.cookies {
+		if value, exists := evidence.cookies[name]; exists {
+			check(re, value, "cookie")
+		}
+	}
+	for name, re := range fp.meta {
+		for _, content := range evidence.meta[name] {
+			check(re, content, "meta")
+		}
+	}
+	for _, re := range fp.scripts {
+		for _, src := range evidence.scripts {
+			check(re, src, "script")
+		}
+	}
+	return found, ok
+}
+
+// FingerprintTech returns the technologies of rules found in the headers of a response and in its html body, sorted by name.
+// Invalid rules are ignored
+func FingerprintTech(headers http.Header, body string, rules ...TechRule) []TechMatch {
+	fingerprints := make([]techFingerprint, 0, len(rules))
+	for _, rule := range rules {
+		if fp, err := rule.compile(); err == nil {
+			fingerprints = append(fingerprints, fp)
+		}
+	}
+	return fingerprintTech(fingerprints, newTechEvidence(headers, body))
+}
+
+func fingerprintTech(fingerprints []techFingerprint, evidence techEvidence) []TechMatch {
+	matches := []TechMatch{}
+	for _, fp := range fingerprints {
+		if match, ok := fp.match(evidence); ok {
+			matches = append(matches, match)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Name < matches[j].Name })
+	return matches
+}
+
+// discoverTech emits the technologies found in response. The reports are named after the host, so they are
+// deduplicated per host
+func (crawler *Crawler) discoverTech(emit func(SpiderReport), response *colly.Response, body string) {
+	var headers http.Header
+	if response.Headers != nil {
+		headers = *response.Headers
+	}
+	host := response.Request.URL.Host
+	for _, match := range fingerprintTech(crawler.techRules, newTechEvidence(headers, body)) {
+		emit(SpiderReport{
+			Output:     host + " " + match.String(),
+			OutputType: Tech,
+			Source:     match.Source,
+			StatusCode: response.StatusCode,
+			Input:      response.Request.URL,
+			Seed:       requestSeed(response.Request),
+		}.WithMetadata("host", host).WithMetadata("name", match.Name).WithMetadata("version", match.Version))
+	}
+}
+
+// WithTechFingerprint emits a tech report "<host> <name> [version]" for every technology of rules (DefaultTechRules when
+// empty) found on a host, with its name and version in the report Metadata. The technologies are found in the response
+// headers and cookies, and the meta and script tags of the pages. Invalid rules are logged and ignored
+func WithTechFingerprint(rules ...TechRule) CrawlerOption {
+	return func(crawler *Crawler) {
+		if len(rules) == 0 {
+			rules = DefaultTechRules
+		}
+		for _, rule := range rules {
+			fp, err := rule.compile()
+			if err != nil {
+				Logger.Errorf("Failed to add tech rule: %s", err)
+				continue
+			}
+			crawler.techRules = append(crawler.techRules, fp)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestFingerprintTech(t *testing.T) {
+	headers := http.Header{}
+	headers.Set("Server", "nginx/1.25.3")
+	headers.Set("X-Powered-By", "PHP/8.2.1")
+	headers.Add("Set-Cookie", "PHPSESSID=abc; Path=/")
+	body := `<html><head><meta name="Generator" content="WordPress 6.4.2">
+<script src="/wp-includes/js/jquery/jquery.min.js?ver=3.7.1"></script>
+<script type="text/javascript" src='https://code.jquery.com/jquery-3.7.1.min.js'></script></head></html>`
+
+	expected := []TechMatch{
+		{Name: "PHP", Version: "8.2.1", Source: "header"},
+		{Name: "WordPress", Version: "6.4.2", Source: "meta"},
+		{Name: "jQuery", Version: "3.7.1", Source: "script"},
+		{Name: "nginx", Version: "1.25.3", Source: "header"},
+	}
+	if matches := FingerprintTech(headers, body, DefaultTechRules...); !reflect.DeepEqual(matches, expected) {
+		t.Errorf("expected %+v, got %+v", expected, matches)
+	}
+	if matches := FingerprintTech(http.Header{}, "<html></html>", DefaultTechRules...); len(matches) != 0 {
+		t.Errorf("expected no technology, got %+v", matches)
+	}
+}
+
+func TestTechRuleValidate(t *testing.T) {
+	for _, rule := range []TechRule{{Name: "empty"}, {Headers: map[string]string{"Server": "x"}}, {Name: "invalid", Scripts: []string{"("}}} {
+		if rule.Validate() == nil {
+			t.Errorf("expected %+v to be invalid", rule)
+		}
+	}
+}
+
+func TestTechReports(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "Apache/2.4.58")
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><meta name="generator" content="Custom CMS 2"></head><body><a href="/page">page</a></body></html>`)
+	}))
+	defer ts.Close()
+
+	rules := append([]TechRule{{Name: "Custom CMS", Meta: map[string]string{"generator": `custom cms (\d+)`}}}, DefaultTechRules...)
+	host := ts.Listener.Addr().String()
+	found := map[string]any{}
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(2), WithTechFingerprint(rules...)), ts.URL) {
+		if r.OutputType == Tech {
+			found[r.Output] = r.Metadata["version"]
+		}
+	}
+	expected := map[string]any{host + " Apache 2.4.58": "2.4.58", host + " Custom CMS 2": "2"}
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("expected the tech reports %v, got %v", expected, found)
+	}
+}
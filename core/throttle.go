@@ -0,0 +1,297 @@
+package core
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// hostThrottle is a per host semaphore whose limit shrinks when the rolling 5xx rate spikes
+// and slowly ramps back up once the host answers correctly again
+type hostThrottle struct {
+	lock     sync.Mutex
+	cond     *sync.Cond
+	inFlight int
+	limit    int
+	statuses []bool
+	next     int
+	filled   bool
+}
+
+func newHostThrottle(limit int, window int) *hostThrottle {
+	ht := &hostThrottle{limit: limit, statuses: make([]bool, window)}
+	ht.cond = sync.NewCond(&ht.lock)
+	return ht
+}
+
+func (ht *hostThrottle) acquire() {
+	ht.lock.Lock()
+	defer ht.lock.Unlock()
+	for ht.inFlight >= ht.limit {
+		ht.cond.Wait()
+	}
+	ht.inFlight++
+}
+
+func (ht *hostThrottle) release() {
+	ht.lock.Lock()
+	defer ht.lock.Unlock()
+	ht.inFlight--
+	ht.cond.Broadcast()
+}
+
+// record stores the outcome of a request and returns the 5xx rate over the window when it is full
+func (ht *hostThrottle) record(serverError bool) (float64, bool) {
+	ht.statuses[ht.next] = serverError
+	ht.next = (ht.next + 1) % len(ht.statuses)
+	if ht.next == 0 {
+		ht.filled = true
+	}
+	if !ht.filled {
+		return 0, false
+	}
+	errors := 0
+	for _, e := range ht.statuses {
+		if e {
+			errors++
+		}
+	}
+	return float64(errors) / float64(len(ht.statuses)), true
+}
+
+func (ht *hostThrottle) resetWindow() {
+	for i := range ht.statuses {
+		ht.statuses[i] = false
+	}
+	ht.next = 0
+	ht.filled = false
+}
+
+type throttleTransport struct {
+	next      http.RoundTripper
+	maxLimit  int
+	window    int
+	threshold float64
+	// onReduce is called when the concurrency of host is reduced to limit, its 5xx rate having reached rate
+	onReduce func(host string, rate float64, limit int)
+
+	lock  sync.Mutex
+	hosts map[string]*hostThrottle
+}
+
+func newThrottleTransport(maxConcurrent int, window int, threshold float64) *throttleTransport {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	if window < 1 {
+		window = 1
+	}
+	return &throttleTransport{
+		maxLimit:  maxConcurrent,
+		window:    window,
+		threshold: threshold,
+		hosts:     make(map[string]*hostThrottle),
+	}
+}
+
+func (tt *throttleTransport) host(host string) *hostThrottle {
+	tt.lock.Lock()
+	defer tt.lock.Unlock()
+	ht, ok := tt.hosts[host]
+	if !ok {
+		ht = newHostThrottle(tt.maxLimit, tt.window)
+		tt.hosts[host] = ht
+	}
+	return ht
+}
+
+// RoundTrip holds a slot of the host until the response body is closed, the body being part of the load on the host
+func (tt *throttleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ht := tt.host(req.URL.Host)
+	ht.acquire()
+	resp, err := tt.next.RoundTrip(req)
+	if err != nil {
+		ht.release()
+		return resp, err
+	}
+	resp.Body = &harBody{ReadCloser: resp.Body, finish: func(int64, []byte) { ht.release() }}
+
+	ht.lock.Lock()
+	rate, full := ht.record(resp.StatusCode >= 500)
+	reduced := false
+	switch {
+	case !full:
+	case rate >= tt.threshold && ht.limit > 1:
+		ht.limit /= 2
+		ht.resetWindow()
+		reduced = true
+		Logger.Warnf("5xx rate of %s reached %.0f%%, reducing concurrency to %d", req.URL.Host, rate*100, ht.limit)
+	case rate == 0 && ht.limit < tt.maxLimit:
+		ht.limit++
+		ht.resetWindow()
+		ht.cond.Broadcast()
+		Logger.Infof("%s recovered, raising concurrency to %d", req.URL.Host, ht.limit)
+	}
+	limit := ht.limit
+	ht.lock.Unlock()
+	if reduced && tt.onReduce != nil {
+		tt.onReduce(req.URL.Host, rate, limit)
+	}
+	return resp, nil
+}
+
+// throttleTraffic wraps the transport of the collector so the hosts answering 5xx are throttled
+func (crawler *Crawler) throttleTraffic(c *colly.Collector) {
+	crawler.wrapTransport(c, func(next http.RoundTripper) http.RoundTripper {
+		crawler.throttle.next = next
+		return crawler.throttle
+	})
+}
+
+// WithThrottleOn5xx bounds the number of concurrent requests per host to maxConcurrent and halves it every time the
+// 5xx rate over the last window responses reaches threshold (0 to 1), emitting a throttled report for the host.
+// The limit is raised again, one step at a time, after each window without server error.
+// A request holds its slot until its response body is closed
+func WithThrottleOn5xx(maxConcurrent int, window int, threshold float64) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.throttle = newThrottleTransport(maxConcurrent, window, threshold)
+	}
+}
+
+var (
+	// AdaptiveThrottleMinBackoff is the first delay applied to a host answering 429 or 503 without Retry-After
+	AdaptiveThrottleMinBackoff = time.Second
+	// AdaptiveThrottleMaxBackoff bounds the delay between two requests to a host
+	AdaptiveThrottleMaxBackoff = time.Minute
+)
+
+// hostRate spaces the requests to a host by delay, and holds them until notBefore after a Retry-After
+type hostRate struct {
+	lock      sync.Mutex
+	delay     time.Duration
+	notBefore time.Time
+}
+
+type adaptiveTransport struct {
+	next http.RoundTripper
+
+	lock  sync.Mutex
+	hosts map[string]*hostRate
+}
+
+func (at *adaptiveTransport) host(host string) *hostRate {
+	at.lock.Lock()
+	defer at.lock.Unlock()
+	hr, ok := at.hosts[host]
+	if !ok {
+		hr = &hostRate{}
+		at.hosts[host] = hr
+	}
+	return hr
+}
+
+// reserve returns how long the request must wait and books its slot
+func (hr *hostRate) reserve() time.Duration {
+	hr.lock.Lock()
+	defer hr.lock.Unlock()
+	now := time.Now()
+	slot := hr.notBefore
+	if slot.Before(now) {
+		slot = now
+	}
+	hr.notBefore = slot.Add(hr.delay)
+	return slot.Sub(now)
+}
+
+func (hr *hostRate) update(host string, resp *http.Response) {
+	hr.lock.Lock()
+	defer hr.lock.Unlock()
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		// ramp back up, halving the delay after each successful response
+		if hr.delay > 0 {
+			hr.delay /= 2
+			if hr.delay < AdaptiveThrottleMinBackoff {
+				hr.delay = 0
+				Logger.Infof("%s recovered, removing request delay", host)
+			}
+		}
+		return
+	}
+	hr.delay *= 2
+	if hr.delay < AdaptiveThrottleMinBackoff {
+		hr.delay = AdaptiveThrottleMinBackoff
+	}
+	if hr.delay > AdaptiveThrottleMaxBackoff {
+		hr.delay = AdaptiveThrottleMaxBackoff
+	}
+	wait := hr.delay
+	if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+		wait = retryAfter
+	}
+	if notBefore := time.Now().Add(wait); notBefore.After(hr.notBefore) {
+		hr.notBefore = notBefore
+	}
+	Logger.Warnf("%s answered %d, pausing for %s and spacing requests by %s", host, resp.StatusCode, wait, hr.delay)
+}
+
+// parseRetryAfter reads a Retry-After header, in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func (at *adaptiveTransport) replaceWrapped(replace transportReplacer) error {
+	return replaceNext(&at.next, replace)
+}
+
+func (at *adaptiveTransport) unwrap() http.RoundTripper {
+	return at.next
+}
+
+func (at *adaptiveTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	hr := at.host(req.URL.Host)
+	if wait := hr.reserve(); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+	resp, err := at.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	hr.update(req.URL.Host, resp)
+	return resp, nil
+}
+
+// WithAdaptiveThrottle slows down hosts answering 429 or 503: their next requests wait for the Retry-After
+// header (or an exponential backoff when missing) and are then spaced by a per host delay which doubles on every
+// 429/503 and halves on every other response until it vanishes
+func WithAdaptiveThrottle() HTTPClientConfigurator {
+	return func(client *http.Client) error {
+		next := client.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		client.Transport = &adaptiveTransport{next: next, hosts: make(map[string]*hostRate)}
+		return nil
+	}
+}
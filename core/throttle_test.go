@@ -0,0 +1,159 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestThrottleOn5xx(t *testing.T) {
+	status := http.StatusInternalServerError
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	tt := newThrottleTransport(8, 4, 0.5)
+	tt.next = http.DefaultTransport
+	client := &http.Client{Transport: tt}
+	for i := 0; i < 8; i++ {
+		resp, err := client.Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	u, _ := url.Parse(ts.URL)
+	ht := tt.host(u.Host)
+	if ht.limit != 2 {
+		t.Fatalf("expected concurrency to be halved twice, got %d", ht.limit)
+	}
+	status = http.StatusOK
+	for i := 0; i < 4; i++ {
+		resp, err := client.Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if ht.limit != 3 {
+		t.Fatalf("expected concurrency to ramp up to 3, got %d", ht.limit)
+	}
+}
+
+func TestThrottleOn5xxSlot(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("body"))
+	}))
+	defer ts.Close()
+
+	tt := newThrottleTransport(1, 4, 0.5)
+	tt.next = http.DefaultTransport
+	client := &http.Client{Transport: tt}
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(ts.URL)
+	ht := tt.host(u.Host)
+	if ht.inFlight != 1 {
+		t.Errorf("expected the slot to be held until the body is closed, got %d requests in flight", ht.inFlight)
+	}
+	resp.Body.Close()
+	resp.Body.Close()
+	if ht.inFlight != 0 {
+		t.Errorf("expected the slot to be released once, got %d requests in flight", ht.inFlight)
+	}
+}
+
+func TestThrottleOn5xxReport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><a href="/1">1</a><a href="/2">2</a><a href="/3">3</a><a href="/4">4</a></body></html>`)
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	throttled := []SpiderReport{}
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(2), WithThrottleOn5xx(4, 4, 0.5)), ts.URL) {
+		if r.OutputType == Throttled {
+			throttled = append(throttled, r)
+		}
+	}
+	if len(throttled) != 1 || throttled[0].Output != u.Host || throttled[0].Metadata["concurrency"] != 2 {
+		t.Errorf("expected a throttled report for %s, got %+v", u.Host, throttled)
+	}
+}
+
+func TestThrottleOn5xxRestart(t *testing.T) {
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body>leaf</body></html>`)
+	}))
+	defer ts.Close()
+
+	crawler := NewCrawler(WithDefaultColly(2), WithThrottleOn5xx(4, 4, 0.5))
+	collectReports(crawler, ts.URL+"/first")
+	// the second crawl wraps the original transport again instead of the throttle itself
+	collectReports(crawler, ts.URL+"/second")
+	if requests.Load() != 2 {
+		t.Errorf("expected both crawls to request their seed, got %d requests", requests.Load())
+	}
+}
+
+func TestAdaptiveThrottle(t *testing.T) {
+	limited := true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if limited {
+			limited = false
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	client := &http.Client{}
+	WithAdaptiveThrottle()(client)
+	get := func() time.Duration {
+		start := time.Now()
+		resp, err := client.Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return time.Since(start)
+	}
+	get()
+	if elapsed := get(); elapsed < time.Second {
+		t.Errorf("expected the request following a 429 to honor Retry-After, waited %s", elapsed)
+	}
+	u, _ := url.Parse(ts.URL)
+	if hr := client.Transport.(*adaptiveTransport).host(u.Host); hr.delay != 0 {
+		t.Errorf("expected the delay to ramp back down after a success, got %s", hr.delay)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d, ok := parseRetryAfter("120"); !ok || d != 2*time.Minute {
+		t.Errorf("unexpected seconds Retry-After %s", d)
+	}
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if d, ok := parseRetryAfter(date); !ok || d < 59*time.Minute {
+		t.Errorf("unexpected date Retry-After %s", d)
+	}
+	if _, ok := parseRetryAfter("soon"); ok {
+		t.Error("expected invalid Retry-After to be ignored")
+	}
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	titleRE   = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	metaTagRE = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	attrRE    = regexp.MustCompile(`(?is)([a-z:-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+)
+
+// tagAttributes returns the attributes of an html tag, keyed by their lowercased name
+func tagAttributes(tag string) map[string]string {
+	attrs := map[string]string{}
+	for _, attr := range attrRE.FindAllStringSubmatch(tag, -1) {
+		attrs[strings.ToLower(attr[1])] = attr[2] + attr[3] + attr[4]
+	}
+	return attrs
+}
+
+// cleanTitle unescapes the entities of title and collapses its whitespaces
+func cleanTitle(title string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(title)), " ")
+}
+
+// ExtractTitle returns the <title> of an html page, its og:title meta tag when it has no title, or an empty string
+func ExtractTitle(body string) string {
+	if m := titleRE.FindStringSubmatch(body); m != nil {
+		if title := cleanTitle(m[1]); title != "" {
+			return title
+		}
+	}
+	for _, tag := range metaTagRE.FindAllString(body, -1) {
+		attrs := tagAttributes(tag)
+		if strings.EqualFold(attrs["property"], "og:title") {
+			return cleanTitle(attrs["content"])
+		}
+	}
+	return ""
+}
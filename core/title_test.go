@@ -0,0 +1,35 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExtractTitle(t *testing.T) {
+	tests := map[string]string{
+		"<html><head><title>\n  Admin &amp; Login\n</title></head></html>":                                "Admin & Login",
+		`<html><head><meta content='Shop home' property="og:title"><title> </title></head></html>`:        "Shop home",
+		`<html><head><meta property=og:title content="Blog"><meta name="description" content="x"></head>`: "Blog",
+		`{"title": "not html"}`: "",
+	}
+	for body, expected := range tests {
+		if title := ExtractTitle(body); title != expected {
+			t.Errorf("expected title %q for %s, got %q", expected, body, title)
+		}
+	}
+}
+
+func TestTitleReport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>Dashboard</title></head><body></body></html>`)
+	}))
+	defer ts.Close()
+
+	reports := collectReports(NewCrawler(WithDefaultColly(1), WithTitleExtraction()), ts.URL)
+	if len(reports) == 0 || reports[0].OutputType != Url || reports[0].Title != "Dashboard" {
+		t.Errorf("expected the title of the page in its url report, got %+v", reports)
+	}
+}
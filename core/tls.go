@@ -0,0 +1,62 @@
+package core
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// transportTLSConfig returns a copy of the TLS configuration of transport to configure
+func transportTLSConfig(transport *http.Transport) *tls.Config {
+	if transport.TLSClientConfig == nil {
+		return &tls.Config{}
+	}
+	return transport.TLSClientConfig.Clone()
+}
+
+// WithTLSVerify enables or disables the verification of the server certificates, disabled by DefaultHTTPTransport
+func WithTLSVerify(verify bool) HTTPClientConfigurator {
+	return func(client *http.Client) error {
+		return configureTransport(client, func(transport *http.Transport) error {
+			tlsConfig := transportTLSConfig(transport)
+			tlsConfig.InsecureSkipVerify = !verify
+			transport.TLSClientConfig = tlsConfig
+			return nil
+		})
+	}
+}
+
+// WithCACert verifies the server certificates against the system roots and the PEM certificates of pemPath,
+// e.g a private CA of an internal crawl. It enables the verification, and fails the crawl when pemPath can't be loaded
+func WithCACert(pemPath string) HTTPClientConfigurator {
+	return func(client *http.Client) error {
+		pool, err := loadCACert(pemPath)
+		if err != nil {
+			return fmt.Errorf("failed to set CA certificates: %w", err)
+		}
+		return configureTransport(client, func(transport *http.Transport) error {
+			tlsConfig := transportTLSConfig(transport)
+			tlsConfig.RootCAs = pool
+			tlsConfig.InsecureSkipVerify = false
+			transport.TLSClientConfig = tlsConfig
+			return nil
+		})
+	}
+}
+
+func loadCACert(pemPath string) (*x509.CertPool, error) {
+	raw, err := os.ReadFile(NormalizePath(pemPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", pemPath, err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(raw) {
+		return nil, fmt.Errorf("no certificate found in %s", pemPath)
+	}
+	return pool, nil
+}
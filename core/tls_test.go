@@ -0,0 +1,104 @@
+package core
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTLSVerification(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := os.WriteFile(caPath, caPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tc := range map[string]struct {
+		opts    []HTTPClientConfigurator
+		success bool
+	}{
+		"insecure default": {nil, true},
+		"verified":         {[]HTTPClientConfigurator{WithTLSVerify(true)}, false},
+		"private ca":       {[]HTTPClientConfigurator{WithCACert(caPath)}, true},
+	} {
+		client := &http.Client{Transport: DefaultHTTPTransport.Clone()}
+		for _, o := range tc.opts {
+			o(client)
+		}
+		resp, err := client.Get(ts.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if (err == nil) != tc.success {
+			t.Errorf("%s: expected success %v, got %v", name, tc.success, err)
+		}
+	}
+	if !DefaultHTTPTransport.TLSClientConfig.InsecureSkipVerify {
+		t.Error("expected DefaultHTTPTransport to be left untouched")
+	}
+
+	invalidPath := filepath.Join(t.TempDir(), "invalid.pem")
+	if err := os.WriteFile(invalidPath, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{invalidPath, filepath.Join(t.TempDir(), "missing.pem")} {
+		if err := crawlError(t, NewCrawler(WithDefaultColly(1), WithHTTPClientOpt(WithCACert(path))), ts.URL); err == nil {
+			t.Errorf("expected the CA bundle %s to fail the crawl", filepath.Base(path))
+		}
+	}
+}
+
+func TestTLSUnderWrappers(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := os.WriteFile(caPath, caPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Transport: DefaultHTTPTransport.Clone()}
+	for _, o := range []HTTPClientConfigurator{WithHTTPAuth("user", "password"), WithTLSVerify(true), WithCACert(caPath)} {
+		if err := o(client); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := client.Transport.(*httpAuthTransport); !ok {
+		t.Fatalf("expected the auth transport to be kept, got %T", client.Transport)
+	}
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("expected the private ca to be trusted: %s", err)
+	}
+	resp.Body.Close()
+
+	client = &http.Client{Transport: DefaultHTTPTransport.Clone()}
+	for _, o := range []HTTPClientConfigurator{WithTor(TorConfig{}), WithTLSVerify(true), WithHostMapping(map[string]string{"example.com": "127.0.0.1"})} {
+		if err := o(client); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tt, ok := client.Transport.(*torTransport)
+	if !ok {
+		t.Fatalf("expected the tor transport to be kept, got %T", client.Transport)
+	}
+	if tt.transport.TLSClientConfig.InsecureSkipVerify || tt.transport.Proxy != nil {
+		t.Error("expected the tor transport to be configured and to keep its routing")
+	}
+
+	client = &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) { return nil, nil })}
+	if err := WithTLSVerify(true)(client); err == nil {
+		t.Error("expected an error configuring a transport which isn't an *http.Transport")
+	}
+}
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
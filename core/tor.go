@@ -0,0 +1,148 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TorConfig describes the local Tor daemon a crawl is routed through, see WithTor
+type TorConfig struct {
+	// SOCKSAddr is the Tor SOCKS port, 127.0.0.1:9050 when empty. Host names are resolved by Tor
+	SOCKSAddr string
+	// ControlAddr is the Tor control port used to request new circuits, 127.0.0.1:9051 when empty
+	ControlAddr string
+	// ControlPassword authenticates on the control port, an empty password matches a control port without authentication
+	ControlPassword string
+	// RotateEvery requests a new circuit every RotateEvery requests, never when 0
+	RotateEvery int
+	// RotateOnStatus requests a new circuit when a response has one of these status codes, e.g 403 or 429 when banned
+	RotateOnStatus []int
+}
+
+// torTransport routes the requests through Tor and signals NEWNYM on the control port to rotate the circuits
+type torTransport struct {
+	config    TorConfig
+	dial      dialContextFunc
+	transport *http.Transport
+
+	lock     sync.Mutex
+	requests int
+}
+
+func (tt *torTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := tt.transport.RoundTrip(req)
+	rotate := false
+	tt.lock.Lock()
+	tt.requests++
+	if tt.config.RotateEvery > 0 && tt.requests%tt.config.RotateEvery == 0 {
+		rotate = true
+	}
+	tt.lock.Unlock()
+	if err == nil && contains(tt.config.RotateOnStatus, resp.StatusCode) {
+		Logger.Infof("%s answered %d through tor, requesting a new circuit", req.URL.Host, resp.StatusCode)
+		rotate = true
+	}
+	if rotate {
+		if err := tt.newCircuit(); err != nil {
+			Logger.Errorf("Failed to rotate tor circuit: %s", err)
+		}
+	}
+	return resp, err
+}
+
+// route sends the requests of transport through tor
+func (tt *torTransport) route(transport *http.Transport) *http.Transport {
+	transport.Proxy = nil
+	transport.DialContext = tt.dial
+	return transport
+}
+
+// replaceWrapped replaces the transport routed through tor, which keeps being routed through it
+func (tt *torTransport) replaceWrapped(replace transportReplacer) error {
+	rt, err := replace(tt.transport)
+	if err != nil {
+		return err
+	}
+	transport, ok := rt.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("can't route the %T transport through tor", rt)
+	}
+	tt.transport = tt.route(transport)
+	return nil
+}
+
+// newCircuit signals NEWNYM on the control port, and drops the idle connections still bound to the previous circuits
+func (tt *torTransport) newCircuit() error {
+	if err := torSignalNewnym(tt.config.ControlAddr, tt.config.ControlPassword); err != nil {
+		return err
+	}
+	tt.transport.CloseIdleConnections()
+	return nil
+}
+
+// torSignalNewnym asks the Tor daemon listening on controlAddr to use new circuits for the next connections
+func torSignalNewnym(controlAddr string, password string) error {
+	conn, err := net.DialTimeout("tcp", controlAddr, 10*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to reach tor control port %s: %w", controlAddr, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	reader := bufio.NewReader(conn)
+	command := func(cmd string) error {
+		if _, err := fmt.Fprintf(conn, "%s\r\n", cmd); err != nil {
+			return err
+		}
+		reply, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(reply, "250") {
+			return fmt.Errorf("tor control port answered %q", strings.TrimSpace(reply))
+		}
+		return nil
+	}
+	if err := command(fmt.Sprintf("AUTHENTICATE %q", password)); err != nil {
+		return fmt.Errorf("failed to authenticate on tor control port: %w", err)
+	}
+	if err := command("SIGNAL NEWNYM"); err != nil {
+		return fmt.Errorf("failed to signal NEWNYM: %w", err)
+	}
+	command("QUIT")
+	return nil
+}
+
+// WithTor routes the requests through a local Tor daemon, rotating its circuits every config.RotateEvery requests
+// and whenever a response status is in config.RotateOnStatus. The crawl fails rather than going direct when it can't be set
+func WithTor(config TorConfig) HTTPClientConfigurator {
+	return func(client *http.Client) error {
+		if config.SOCKSAddr == "" {
+			config.SOCKSAddr = "127.0.0.1:9050"
+		}
+		if config.ControlAddr == "" {
+			config.ControlAddr = "127.0.0.1:9051"
+		}
+		dial, err := socksDialContext(&url.URL{Scheme: "socks5h", Host: config.SOCKSAddr})
+		if err != nil {
+			return fmt.Errorf("failed to set tor: %w", err)
+		}
+		return replaceNext(&client.Transport, func(rt http.RoundTripper) (http.RoundTripper, error) {
+			base, ok := rt.(*http.Transport)
+			if !ok && rt != nil {
+				return nil, fmt.Errorf("can't route the %T transport of the client through tor", rt)
+			} else if !ok {
+				base = DefaultHTTPTransport
+			}
+			tt := &torTransport{config: config, dial: dial}
+			tt.transport = tt.route(base.Clone())
+			Logger.Infof("Tor: %s", config.SOCKSAddr)
+			return tt, nil
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// serveTorControl runs a fake Tor control port accepting password and counting the NEWNYM signals
+func serveTorControl(t *testing.T, password string) (net.Listener, func() int) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lock sync.Mutex
+	newnym := 0
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			reader := bufio.NewReader(conn)
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					break
+				}
+				line = strings.TrimSpace(line)
+				switch {
+				case strings.HasPrefix(line, "AUTHENTICATE"):
+					if line != fmt.Sprintf("AUTHENTICATE %q", password) {
+						fmt.Fprint(conn, "515 Authentication failed\r\n")
+						continue
+					}
+					fmt.Fprint(conn, "250 OK\r\n")
+				case line == "SIGNAL NEWNYM":
+					lock.Lock()
+					newnym++
+					lock.Unlock()
+					fmt.Fprint(conn, "250 OK\r\n")
+				case line == "QUIT":
+					fmt.Fprint(conn, "250 closing connection\r\n")
+				}
+			}
+			conn.Close()
+		}
+	}()
+	return l, func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return newnym
+	}
+}
+
+func TestTorTransportRotation(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/banned" {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer ts.Close()
+	control, newnym := serveTorControl(t, "secret")
+	defer control.Close()
+
+	tt := &torTransport{
+		config:    TorConfig{ControlAddr: control.Addr().String(), ControlPassword: "secret", RotateEvery: 3, RotateOnStatus: []int{429}},
+		transport: &http.Transport{},
+	}
+	client := &http.Client{Transport: tt}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if n := newnym(); n != 1 {
+		t.Errorf("expected a new circuit after 3 requests, got %d", n)
+	}
+	resp, err := client.Get(ts.URL + "/banned")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if n := newnym(); n != 2 {
+		t.Errorf("expected a new circuit after a ban, got %d", n)
+	}
+
+	if err := torSignalNewnym(control.Addr().String(), "wrong"); err == nil {
+		t.Error("expected a wrong control password to fail")
+	}
+}
+
+func TestTorFailure(t *testing.T) {
+	ts := newTestSite()
+	defer ts.Close()
+
+	// tor can't route the replayed responses
+	crawler := NewCrawler(WithDefaultColly(1), WithHTTPClientOpt(WithReplay(t.TempDir()), WithTor(TorConfig{})))
+	if err := crawlError(t, crawler, ts.URL); err == nil {
+		t.Error("expected a tor failure to fail the crawl instead of going direct")
+	}
+}
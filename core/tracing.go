@@ -0,0 +1,92 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gocolly/colly/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const (
+	tracerName      = "github.com/benji-bou/gospider/core"
+	traceContextKey = "gospider.trace"
+	traceSpanKey    = "gospider.span"
+)
+
+func defaultTracer() trace.Tracer {
+	return noop.NewTracerProvider().Tracer(tracerName)
+}
+
+// requestTraceContext returns the context holding the span of request r
+func requestTraceContext(r *colly.Request) context.Context {
+	if r != nil && r.Ctx != nil {
+		if ctx, ok := r.Ctx.GetAny(traceContextKey).(context.Context); ok {
+			return ctx
+		}
+	}
+	return context.Background()
+}
+
+func requestSpan(r *colly.Request) trace.Span {
+	if r != nil && r.Ctx != nil {
+		if span, ok := r.Ctx.GetAny(traceSpanKey).(trace.Span); ok {
+			return span
+		}
+	}
+	return nil
+}
+
+// traceRequests opens a span for every request of c, closed once the response is scraped or the request failed
+func (crawler *Crawler) traceRequests(c *colly.Collector) {
+	c.OnRequest(func(r *colly.Request) {
+		ctx, span := crawler.tracer.Start(context.Background(), "crawl.request", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+			attribute.String("http.method", r.Method),
+			attribute.String("url.full", r.URL.String()),
+			attribute.Int("crawl.depth", r.Depth),
+			attribute.String("crawl.seed", requestSeed(r)),
+		))
+		r.Ctx.Put(traceContextKey, ctx)
+		r.Ctx.Put(traceSpanKey, span)
+	})
+	c.OnResponse(func(r *colly.Response) {
+		if span := requestSpan(r.Request); span != nil {
+			span.SetAttributes(
+				attribute.Int("http.status_code", r.StatusCode),
+				attribute.Int("http.response.body.size", len(r.Body)),
+			)
+		}
+	})
+	c.OnScraped(func(r *colly.Response) {
+		if span := requestSpan(r.Request); span != nil {
+			span.End()
+		}
+	})
+	c.OnError(func(r *colly.Response, err error) {
+		if span := requestSpan(r.Request); span != nil {
+			span.SetAttributes(attribute.Int("http.status_code", r.StatusCode))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			span.End()
+		}
+	})
+}
+
+// onHTML registers f on c inside a span child of the request span
+func (crawler *Crawler) onHTML(c *colly.Collector, selector string, f colly.HTMLCallback) {
+	c.OnHTML(selector, func(e *colly.HTMLElement) {
+		_, span := crawler.tracer.Start(requestTraceContext(e.Request), fmt.Sprintf("crawl.extract %s", selector))
+		defer span.End()
+		f(e)
+	})
+}
+
+// WithTracerProvider traces requests, extraction handlers and sink writes with the tracers of provider
+func WithTracerProvider(provider trace.TracerProvider) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.tracer = provider.Tracer(tracerName, trace.WithInstrumentationVersion(VERSION))
+	}
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// DefaultURLScanEndpoint is the urlscan.io search api
+	DefaultURLScanEndpoint = "https://urlscan.io/api/v1/search/"
+	// DefaultURLScanPageSize is the number of results requested per search page
+	DefaultURLScanPageSize = 100
+	// DefaultURLScanMaxRateLimitWaits is the number of times a rate limited search is retried
+	DefaultURLScanMaxRateLimitWaits = 3
+)
+
+// URLScanSource fetches the urls of the scans urlscan.io holds for a domain.
+// Without APIKey the search is anonymous and more strictly rate limited.
+// Rate limited searches wait for the reset advertised by urlscan.io before retrying
+type URLScanSource struct {
+	APIKey string
+	// PageSize is the number of results per page, DefaultURLScanPageSize when 0
+	PageSize int
+	// MaxResults stops the search after this many results, no limit when 0
+	MaxResults int
+	// Endpoint is the search api, DefaultURLScanEndpoint when empty
+	Endpoint string
+}
+
+type urlscanSearch struct {
+	Results []struct {
+		Task struct {
+			URL string `json:"url"`
+		} `json:"task"`
+		Page struct {
+			URL string `json:"url"`
+		} `json:"page"`
+		Sort []json.RawMessage `json:"sort"`
+	} `json:"results"`
+	HasMore bool `json:"has_more"`
+}
+
+func (s URLScanSource) Fetch(ctx context.Context, domain string) ([]string, error) {
+	pageSize := s.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultURLScanPageSize
+	}
+	params := url.Values{}
+	params.Set("q", "domain:"+domain)
+	params.Set("size", fmt.Sprint(pageSize))
+	res := []string{}
+	seen := make(map[string]bool)
+	for {
+		search, err := s.search(ctx, params)
+		if err != nil {
+			return res, err
+		}
+		for _, result := range search.Results {
+			for _, u := range []string{result.Task.URL, result.Page.URL} {
+				if u != "" && !seen[u] {
+					seen[u] = true
+					res = append(res, u)
+				}
+			}
+		}
+		if s.MaxResults > 0 && len(res) >= s.MaxResults {
+			return res[:s.MaxResults], nil
+		}
+		if !search.HasMore || len(search.Results) == 0 {
+			return res, nil
+		}
+		// the next page starts after the sort values of the last result
+		last := search.Results[len(search.Results)-1].Sort
+		after := make([]string, 0, len(last))
+		for _, v := range last {
+			after = append(after, strings.Trim(string(v), `"`))
+		}
+		params.Set("search_after", strings.Join(after, ","))
+	}
+}
+
+// search runs a search query, waiting for the rate limit reset when urlscan.io answers 429
+func (s URLScanSource) search(ctx context.Context, params url.Values) (urlscanSearch, error) {
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultURLScanEndpoint
+	}
+	search := urlscanSearch{}
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+params.Encode(), nil)
+		if err != nil {
+			return search, err
+		}
+		if s.APIKey != "" {
+			req.Header.Set("API-Key", s.APIKey)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return search, err
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < DefaultURLScanMaxRateLimitWaits {
+			resp.Body.Close()
+			wait, ok := parseRetryAfter(resp.Header.Get("X-Rate-Limit-Reset-After"))
+			if !ok {
+				wait, ok = parseRetryAfter(resp.Header.Get("Retry-After"))
+			}
+			if !ok {
+				wait = time.Duration(attempt+1) * 10 * time.Second
+			}
+			Logger.Warnf("urlscan.io rate limit reached, retrying in %s", wait)
+			select {
+			case <-time.After(wait):
+				continue
+			case <-ctx.Done():
+				return search, ctx.Err()
+			}
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return search, fmt.Errorf("urlscan.io search answered %s", resp.Status)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&search); err != nil {
+			return search, fmt.Errorf("failed to parse urlscan.io search: %w", err)
+		}
+		return search, nil
+	}
+}
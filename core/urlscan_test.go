@@ -0,0 +1,46 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestURLScanSource(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("API-Key") != "secret" {
+			t.Errorf("expected the api key header, got %q", r.Header.Get("API-Key"))
+		}
+		if r.URL.Query().Get("q") != "domain:example.com" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		switch {
+		case requests == 1:
+			w.Header().Set("X-Rate-Limit-Reset-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case r.URL.Query().Get("search_after") == "":
+			fmt.Fprint(w, `{"results":[{"task":{"url":"https://example.com/a"},"page":{"url":"https://example.com/a/"},"sort":[1700000000000,"abc"]}],"has_more":true}`)
+		case r.URL.Query().Get("search_after") == "1700000000000,abc":
+			fmt.Fprint(w, `{"results":[{"task":{"url":"https://example.com/a"},"page":{"url":"https://example.com/b"},"sort":[1600000000000,"def"]}],"has_more":false}`)
+		default:
+			t.Errorf("unexpected search_after %s", r.URL.Query().Get("search_after"))
+		}
+	}))
+	defer srv.Close()
+
+	urls, err := URLScanSource{APIKey: "secret", Endpoint: srv.URL}.Fetch(context.Background(), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"https://example.com/a", "https://example.com/a/", "https://example.com/b"}
+	if fmt.Sprint(urls) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, urls)
+	}
+	if requests != 3 {
+		t.Errorf("expected the rate limited search to be retried, got %d requests", requests)
+	}
+}
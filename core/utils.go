@@ -10,6 +10,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/gocolly/colly/v2"
 	"github.com/mitchellh/go-homedir"
 	"golang.org/x/net/publicsuffix"
 )
@@ -159,12 +160,12 @@ func DecodeChars(s string) string {
 }
 
 func InScope(u *url.URL, regexps []*regexp.Regexp) bool {
-    for _, r := range regexps {
-        if r.MatchString(u.String()) {
-            return true
-        }
-    }
-    return false
+	for _, r := range regexps {
+		if r.MatchString(u.String()) {
+			return true
+		}
+	}
+	return false
 }
 
 // NormalizePath the path
@@ -202,11 +203,21 @@ func ReadingLines(filename string) []string {
 	return result
 }
 
-func contains(i []int,j int) bool {
-    for _, value := range i {
-        if value == j {
-            return true
-        }
-    }
-    return false
-}
\ No newline at end of file
+func contains(i []int, j int) bool {
+	for _, value := range i {
+		if value == j {
+			return true
+		}
+	}
+	return false
+}
+
+const seedContextKey = "gospider.seed"
+
+// requestSeed returns the seed url r originates from
+func requestSeed(r *colly.Request) string {
+	if r == nil || r.Ctx == nil {
+		return ""
+	}
+	return r.Ctx.Get(seedContextKey)
+}
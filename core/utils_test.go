@@ -1,6 +1,7 @@
 package core
 
 import (
+	"net/http"
 	"testing"
 )
 
@@ -12,3 +13,18 @@ func TestGetExtType(t *testing.T) {
 func TestFixUrl(t *testing.T) {
 	//
 }
+
+func TestSniffContentType(t *testing.T) {
+	header := http.Header{}
+	header.Set("Content-Type", "application/javascript; charset=utf-8")
+	if ct := SniffContentType(header, []byte("var a = 1;")); ct != "application/javascript" {
+		t.Errorf("expected header content type, got %s", ct)
+	}
+	if ct := SniffContentType(nil, []byte(`{"a": 1}`)); ct != "application/json" {
+		t.Errorf("expected application/json, got %s", ct)
+	}
+	header.Set("Content-Type", "application/octet-stream")
+	if ct := SniffContentType(header, []byte("<!DOCTYPE html><html></html>")); ct != "text/html" {
+		t.Errorf("expected text/html, got %s", ct)
+	}
+}
@@ -0,0 +1,164 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// ErrNotRecorded is returned in replay mode for the requests without cassette
+var ErrNotRecorded = errors.New("no recorded response")
+
+// cassette is a recorded response, see WithRecord
+type cassette struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Proto  string      `json:"proto"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// cassettePath returns the file of the cassette of a request, named after the hash of its method, url and body
+func cassettePath(dir string, method string, u string, body []byte) string {
+	sum := sha256.New()
+	fmt.Fprintf(sum, "%s %s\n", method, u)
+	sum.Write(body)
+	return filepath.Join(dir, hex.EncodeToString(sum.Sum(nil))+".json")
+}
+
+// requestPayload reads the body of req, which is restored
+func requestPayload(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	payload, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(payload))
+	return payload, nil
+}
+
+// recordTransport writes a cassette for every response once its body is read
+type recordTransport struct {
+	next http.RoundTripper
+	dir  string
+}
+
+func (rt *recordTransport) replaceWrapped(replace transportReplacer) error {
+	return replaceNext(&rt.next, replace)
+}
+
+func (rt *recordTransport) unwrap() http.RoundTripper {
+	return rt.next
+}
+
+func (rt *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	payload, err := requestPayload(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := rt.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	path := cassettePath(rt.dir, req.Method, req.URL.String(), payload)
+	recorded := cassette{Method: req.Method, URL: req.URL.String(), Status: resp.StatusCode, Proto: resp.Proto, Header: resp.Header.Clone()}
+	resp.Body = &harBody{ReadCloser: resp.Body, body: &bytes.Buffer{}, finish: func(_ int64, body []byte) {
+		recorded.Body = body
+		if err := recorded.save(path); err != nil {
+			Logger.Errorf("Failed to record %s %s: %s", recorded.Method, recorded.URL, err)
+		}
+	}}
+	return resp, nil
+}
+
+func (c cassette) save(path string) error {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// replayTransport serves the recorded responses, without network
+type replayTransport struct {
+	dir string
+}
+
+func (rt *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	payload, err := requestPayload(req)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := os.ReadFile(cassettePath(rt.dir, req.Method, req.URL.String(), payload))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w for %s %s", ErrNotRecorded, req.Method, req.URL)
+	} else if err != nil {
+		return nil, err
+	}
+	recorded := cassette{}
+	if err := json.Unmarshal(raw, &recorded); err != nil {
+		return nil, fmt.Errorf("invalid cassette for %s %s: %w", req.Method, req.URL, err)
+	}
+	major, minor, ok := http.ParseHTTPVersion(recorded.Proto)
+	if !ok {
+		recorded.Proto, major, minor = "HTTP/1.1", 1, 1
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(recorded.Status) + " " + http.StatusText(recorded.Status),
+		StatusCode:    recorded.Status,
+		Proto:         recorded.Proto,
+		ProtoMajor:    major,
+		ProtoMinor:    minor,
+		Header:        recorded.Header,
+		Body:          io.NopCloser(bytes.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+// WithRecord writes every response to a cassette in dir, which WithReplay serves back.
+// The cassettes are keyed by the method, url and body of the requests, their headers being ignored.
+// The crawl fails when dir can't be created
+func WithRecord(dir string) HTTPClientConfigurator {
+	return func(client *http.Client) error {
+		dir = NormalizePath(dir)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create cassette dir %s: %w", dir, err)
+		}
+		next := client.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		client.Transport = &recordTransport{next: next, dir: dir}
+		return nil
+	}
+}
+
+// WithReplay serves the responses recorded by WithRecord in dir without network, making crawls reproducible offline.
+// The requests without cassette fail with ErrNotRecorded. It replaces the transport sending the requests, under the
+// transports wrapping it (e.g WithHTTPAuth)
+func WithReplay(dir string) HTTPClientConfigurator {
+	return func(client *http.Client) error {
+		return replaceNext(&client.Transport, func(http.RoundTripper) (http.RoundTripper, error) {
+			return &replayTransport{dir: NormalizePath(dir)}, nil
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func reportOutputs(reports []SpiderReport) []string {
+	outputs := make([]string, 0, len(reports))
+	for _, r := range reports {
+		outputs = append(outputs, string(r.OutputType)+" "+r.Output)
+	}
+	sort.Strings(outputs)
+	return outputs
+}
+
+func TestRecordReplay(t *testing.T) {
+	ts := newTestSite()
+	site := ts.URL
+	dir := t.TempDir()
+
+	recorded := reportOutputs(collectReports(NewCrawler(WithDefaultColly(3), WithHTTPClientOpt(WithRecord(dir))), site))
+	ts.Close()
+	if len(recorded) == 0 {
+		t.Fatal("expected reports")
+	}
+
+	replayed := reportOutputs(collectReports(NewCrawler(WithDefaultColly(3), WithHTTPClientOpt(WithReplay(dir))), site))
+	if len(replayed) != len(recorded) {
+		t.Fatalf("expected the replayed crawl to report %v, got %v", recorded, replayed)
+	}
+	for i := range recorded {
+		if recorded[i] != replayed[i] {
+			t.Errorf("expected %s, got %s", recorded[i], replayed[i])
+		}
+	}
+
+	client := &http.Client{}
+	WithReplay(dir)(client)
+	if _, err := client.Get(site + "/unknown"); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("expected ErrNotRecorded, got %v", err)
+	}
+
+	// a file is in the way of the cassette dir
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := crawlError(t, NewCrawler(WithDefaultColly(3), WithHTTPClientOpt(WithRecord(filepath.Join(file, "cassettes")))), site); err == nil {
+		t.Error("expected an unusable cassette dir to fail the crawl")
+	}
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var (
+	// DefaultWaybackEndpoint is the Wayback Machine CDX server
+	DefaultWaybackEndpoint = "http://web.archive.org/cdx/search/cdx"
+	// DefaultWaybackPageSize is the number of captures requested per CDX page
+	DefaultWaybackPageSize = 10000
+)
+
+// WaybackSource fetches the urls archived by the Wayback Machine through the CDX API.
+// Captures are requested page by page with a resume key, so large domains are fully enumerated
+// instead of timing out on a single response
+type WaybackSource struct {
+	IncludeSubs bool
+	// StatusCodes keeps the captures answered with one of these status codes, e.g "200"
+	StatusCodes []string
+	// MimeTypes keeps the captures of one of these mime types, e.g "text/html"
+	MimeTypes []string
+	// From and To bound the capture dates, as timestamp prefixes (yyyyMMddhhmmss)
+	From string
+	To   string
+	// Collapse is the CDX field captures are collapsed on, urlkey when empty
+	Collapse string
+	// PageSize is the number of captures per page, DefaultWaybackPageSize when 0
+	PageSize int
+	// Endpoint is the CDX server, DefaultWaybackEndpoint when empty
+	Endpoint string
+}
+
+func (s WaybackSource) Fetch(ctx context.Context, domain string) ([]string, error) {
+	res := []string{}
+	resumeKey := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		page, next, err := s.fetchPage(ctx, domain, resumeKey)
+		res = append(res, page...)
+		if err != nil {
+			return res, err
+		}
+		if next == "" || next == resumeKey {
+			return res, nil
+		}
+		Logger.Debugf("Fetched %d wayback urls for %s, resuming at %s", len(res), domain, next)
+		resumeKey = next
+	}
+}
+
+// query returns the CDX query of a page of the captures of domain
+func (s WaybackSource) query(domain string, resumeKey string) string {
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultWaybackEndpoint
+	}
+	pageSize := s.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultWaybackPageSize
+	}
+	collapse := s.Collapse
+	if collapse == "" {
+		collapse = "urlkey"
+	}
+	target := domain + "/*"
+	if s.IncludeSubs {
+		target = "*." + target
+	}
+	params := url.Values{}
+	params.Set("url", target)
+	params.Set("output", "json")
+	params.Set("fl", "timestamp,original")
+	params.Set("collapse", collapse)
+	params.Set("limit", fmt.Sprint(pageSize))
+	params.Set("showResumeKey", "true")
+	if len(s.StatusCodes) > 0 {
+		params.Add("filter", "statuscode:("+strings.Join(s.StatusCodes, "|")+")")
+	}
+	if len(s.MimeTypes) > 0 {
+		params.Add("filter", "mimetype:("+strings.Join(s.MimeTypes, "|")+")")
+	}
+	if s.From != "" {
+		params.Set("from", s.From)
+	}
+	if s.To != "" {
+		params.Set("to", s.To)
+	}
+	if resumeKey != "" {
+		params.Set("resumeKey", resumeKey)
+	}
+	return endpoint + "?" + params.Encode()
+}
+
+// fetchPage returns the urls of a CDX page and the resume key of the next one, empty on the last page
+func (s WaybackSource) fetchPage(ctx context.Context, domain string, resumeKey string) ([]string, string, error) {
+	resp, err := sourceGet(ctx, s.query(domain, resumeKey))
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("wayback cdx answered %s", resp.Status)
+	}
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(strings.TrimSpace(string(raw))) == 0 {
+		return nil, "", nil
+	}
+	var rows [][]string
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		return nil, "", fmt.Errorf("failed to parse wayback cdx page: %w", err)
+	}
+	urls := make([]string, 0, len(rows))
+	next := ""
+	for i, row := range rows {
+		// the first row is the header, the resume key follows an empty row
+		if i == 0 {
+			continue
+		}
+		if len(row) == 0 {
+			if i+1 < len(rows) && len(rows[i+1]) > 0 {
+				next = rows[i+1][0]
+			}
+			break
+		}
+		if len(row) > 1 {
+			urls = append(urls, row[1])
+		}
+	}
+	return urls, next, nil
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWaybackSourcePagination(t *testing.T) {
+	queries := []string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		queries = append(queries, r.URL.RawQuery)
+		if q.Get("url") != "*.example.com/*" || q.Get("limit") != "2" || q.Get("showResumeKey") != "true" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		switch q.Get("resumeKey") {
+		case "":
+			fmt.Fprint(w, `[["timestamp","original"],["20200101000000","https://example.com/a"],["20200102000000","https://example.com/b"],[],["key-1"]]`)
+		case "key-1":
+			fmt.Fprint(w, `[["timestamp","original"],["20200103000000","https://sub.example.com/c"]]`)
+		default:
+			t.Errorf("unexpected resume key %s", q.Get("resumeKey"))
+		}
+	}))
+	defer srv.Close()
+
+	source := WaybackSource{
+		IncludeSubs: true,
+		StatusCodes: []string{"200", "301"},
+		MimeTypes:   []string{"text/html"},
+		From:        "2020",
+		PageSize:    2,
+		Endpoint:    srv.URL,
+	}
+	urls, err := source.Fetch(context.Background(), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 3 || urls[2] != "https://sub.example.com/c" {
+		t.Fatalf("unexpected urls %v", urls)
+	}
+	if len(queries) != 2 {
+		t.Fatalf("expected 2 pages, got %d", len(queries))
+	}
+	q := source.query("example.com", "")
+	for _, want := range []string{"filter=statuscode%3A%28200%7C301%29", "filter=mimetype%3A%28text%2Fhtml%29", "from=2020", "collapse=urlkey"} {
+		if !strings.Contains(q, want) {
+			t.Errorf("expected %s in query %s", want, q)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// webSocketGUID is the key suffix of the handshake accept value (RFC 6455)
+const webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var (
+	webSocketURLRE         = regexp.MustCompile(`(?i)\bwss?://[\w.-]+(?::\d+)?(?:/[^\s"'<>()\x60\\]*)?`)
+	webSocketConstructorRE = regexp.MustCompile(`new\s+WebSocket\s*\(\s*["'\x60]([^"'\x60$]+)["'\x60]`)
+)
+
+// DefaultWebSocketProtocols are the subprotocols offered by the handshake probe, the endpoint picking the one it speaks
+var DefaultWebSocketProtocols = []string{"graphql-transport-ws", "graphql-ws", "mqtt", "wamp.2.json", "v12.stomp", "v11.stomp", "soap", "xmpp"}
+
+// WebSocketHandshake is the answer of an endpoint to a websocket opening handshake
+type WebSocketHandshake struct {
+	Live       bool   `json:"live"`
+	StatusCode int    `json:"status"`
+	Protocol   string `json:"protocol,omitempty"`
+	Extensions string `json:"extensions,omitempty"`
+}
+
+// WebSocketURLs returns the ws:// and wss:// urls of a page or a script, and the relative urls passed to
+// `new WebSocket(...)` resolved against request
+func WebSocketURLs(request *colly.Request, source string) []string {
+	res := webSocketURLRE.FindAllString(source, -1)
+	for _, m := range webSocketConstructorRE.FindAllStringSubmatch(source, -1) {
+		u := m[1]
+		if lower := strings.ToLower(u); !strings.HasPrefix(lower, "ws://") && !strings.HasPrefix(lower, "wss://") {
+			u = absoluteURL(request, u)
+			if strings.HasPrefix(u, "https://") {
+				u = "wss://" + strings.TrimPrefix(u, "https://")
+			} else if strings.HasPrefix(u, "http://") {
+				u = "ws://" + strings.TrimPrefix(u, "http://")
+			} else {
+				continue
+			}
+		}
+		res = append(res, u)
+	}
+	return Unique(res)
+}
+
+// ProbeWebSocket sends an opening handshake to the websocket endpoint u, offering protocols, and closes the
+// connection as soon as the endpoint answered
+func ProbeWebSocket(client *http.Client, u string, protocols []string) (WebSocketHandshake, error) {
+	handshake := WebSocketHandshake{}
+	if strings.HasPrefix(u, "wss://") {
+		u = "https://" + strings.TrimPrefix(u, "wss://")
+	} else if strings.HasPrefix(u, "ws://") {
+		u = "http://" + strings.TrimPrefix(u, "ws://")
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return handshake, err
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return handshake, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	if len(protocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(protocols, ", "))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return handshake, err
+	}
+	resp.Body.Close()
+	accept := sha1.Sum([]byte(key + webSocketGUID))
+	handshake.StatusCode = resp.StatusCode
+	handshake.Live = resp.StatusCode == http.StatusSwitchingProtocols &&
+		resp.Header.Get("Sec-WebSocket-Accept") == base64.StdEncoding.EncodeToString(accept[:])
+	handshake.Protocol = resp.Header.Get("Sec-WebSocket-Protocol")
+	handshake.Extensions = resp.Header.Get("Sec-WebSocket-Extensions")
+	return handshake, nil
+}
+
+// discoverWebSockets emits the websocket urls of a response, probing them once when enabled
+func (crawler *Crawler) discoverWebSockets(emit func(SpiderReport), request *colly.Request, body string) {
+	for _, u := range WebSocketURLs(request, body) {
+		report := SpiderReport{
+			Output:     u,
+			OutputType: WebSocket,
+			Source:     "body",
+			Input:      request.URL,
+			Seed:       requestSeed(request),
+		}
+		if crawler.probeWebSockets && !crawler.probedWebSockets.Duplicate(u) {
+			handshake, err := ProbeWebSocket(crawler.helperClient(10*time.Second), u, DefaultWebSocketProtocols)
+			if err != nil {
+				Logger.Debugf("Failed to probe the websocket %s: %s", u, err)
+			} else {
+				report = report.WithMetadata("handshake", handshake)
+				report.StatusCode = handshake.StatusCode
+			}
+		}
+		emit(report)
+	}
+}
+
+// WithWebSockets emits a websocket report for every ws:// and wss:// url found in the pages and scripts. When probe is
+// set, an opening handshake is sent to each endpoint and its answer, whether it is live and the subprotocol it picked
+// among DefaultWebSocketProtocols, is added to the report Metadata
+func WithWebSockets(probe bool) CrawlerOption {
+	return func(crawler *Crawler) {
+		crawler.webSockets = true
+		crawler.probeWebSockets = probe
+	}
+}
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// webSocketHandler answers the handshakes on /live, picking graphql-ws when offered
+func webSocketHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/live" || !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		accept := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + webSocketGUID))
+		fmt.Fprintf(buf, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n", base64.StdEncoding.EncodeToString(accept[:]))
+		if strings.Contains(r.Header.Get("Sec-WebSocket-Protocol"), "graphql-ws") {
+			fmt.Fprint(buf, "Sec-WebSocket-Protocol: graphql-ws\r\n")
+		}
+		fmt.Fprint(buf, "\r\n")
+		buf.Flush()
+	})
+}
+
+func TestProbeWebSocket(t *testing.T) {
+	ts := httptest.NewServer(webSocketHandler(http.NotFoundHandler()))
+	defer ts.Close()
+	wsURL := "ws://" + strings.TrimPrefix(ts.URL, "http://")
+
+	handshake, err := ProbeWebSocket(http.DefaultClient, wsURL+"/live", DefaultWebSocketProtocols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := WebSocketHandshake{Live: true, StatusCode: http.StatusSwitchingProtocols, Protocol: "graphql-ws"}
+	if handshake != expected {
+		t.Errorf("expected %+v, got %+v", expected, handshake)
+	}
+	handshake, err = ProbeWebSocket(http.DefaultClient, wsURL+"/dead", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if handshake.Live || handshake.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a dead endpoint, got %+v", handshake)
+	}
+}
+
+func TestWebSocketReports(t *testing.T) {
+	ts := httptest.NewServer(webSocketHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><body><script src="/app.js"></script></body></html>`)
+		case "/app.js":
+			w.Header().Set("Content-Type", "application/javascript")
+			fmt.Fprint(w, `var live=new WebSocket("/live");var feed="wss://stream.example.com/feed?v=2";`)
+		default:
+			http.NotFound(w, r)
+		}
+	})))
+	defer ts.Close()
+	wsURL := "ws://" + strings.TrimPrefix(ts.URL, "http://")
+
+	found := []string{}
+	for _, r := range collectReports(NewCrawler(WithDefaultColly(2), WithWebSockets(true)), ts.URL) {
+		if r.OutputType != WebSocket {
+			continue
+		}
+		found = append(found, r.Output)
+		if r.Output == wsURL+"/live" {
+			if handshake, ok := r.Metadata["handshake"].(WebSocketHandshake); !ok || !handshake.Live {
+				t.Errorf("expected %s to be live, got %v", r.Output, r.Metadata)
+			}
+		}
+	}
+	sort.Strings(found)
+	expected := []string{wsURL + "/live", "wss://stream.example.com/feed?v=2"}
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("expected %v, got %v", expected, found)
+	}
+}
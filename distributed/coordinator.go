@@ -0,0 +1,126 @@
+package distributed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/benji-bou/chantools"
+	"github.com/benji-bou/gospider/core"
+	"github.com/benji-bou/gospider/stringset"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Coordinator dispatches the urls of a crawl to the workers and collects their reports
+type Coordinator struct {
+	js     jetstream.JetStream
+	prefix string
+
+	dispatched *stringset.StringFilter
+	// processed dedups the done events, an url redelivered to another worker being reported done twice
+	processed *stringset.StringFilter
+	outputs   *stringset.StringFilter
+	pending   int
+}
+
+func NewCoordinator(js jetstream.JetStream, prefix string) *Coordinator {
+	return &Coordinator{
+		js:         js,
+		prefix:     prefix,
+		dispatched: stringset.NewStringFilter(),
+		processed:  stringset.NewStringFilter(),
+		outputs:    stringset.NewStringFilter(),
+	}
+}
+
+func (co *Coordinator) dispatch(ctx context.Context, entry core.FrontierEntry) error {
+	if co.dispatched.Duplicate(entry.URL) {
+		return nil
+	}
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := co.js.Publish(ctx, urlsSubject(co.prefix), raw); err != nil {
+		return fmt.Errorf("failed to dispatch %s: %w", entry.URL, err)
+	}
+	co.pending++
+	return nil
+}
+
+// Start dispatches seeds and streams the reports of the workers until every dispatched url is processed or ctx is done
+func (co *Coordinator) Start(ctx context.Context, seeds ...string) (<-chan core.SpiderReport, <-chan error) {
+	return chantools.NewWithErr(func(outputC chan<- core.SpiderReport, errC chan<- error, params ...any) {
+		if err := setupStreams(ctx, co.js, co.prefix); err != nil {
+			errC <- err
+			return
+		}
+		consumer, err := co.js.OrderedConsumer(ctx, streamName(co.prefix, "EVENTS"), jetstream.OrderedConsumerConfig{
+			DeliverPolicy: jetstream.DeliverNewPolicy,
+		})
+		if err != nil {
+			errC <- fmt.Errorf("failed to consume events: %w", err)
+			return
+		}
+		msgs, err := consumer.Messages()
+		if err != nil {
+			errC <- fmt.Errorf("failed to consume events: %w", err)
+			return
+		}
+		defer msgs.Stop()
+		go func() {
+			<-ctx.Done()
+			msgs.Stop()
+		}()
+
+		for _, seed := range seeds {
+			if err := co.dispatch(ctx, core.FrontierEntry{URL: seed, Seed: seed}); err != nil {
+				errC <- err
+			}
+		}
+		for co.pending > 0 {
+			msg, err := msgs.Next()
+			if err != nil {
+				if ctx.Err() == nil {
+					errC <- fmt.Errorf("failed to read events: %w", err)
+				}
+				return
+			}
+			co.handleEvent(ctx, msg, outputC, errC)
+		}
+	})
+}
+
+func (co *Coordinator) handleEvent(ctx context.Context, msg jetstream.Msg, outputC chan<- core.SpiderReport, errC chan<- error) {
+	event := msg.Subject()[strings.LastIndex(msg.Subject(), ".")+1:]
+	switch event {
+	case eventDiscovered:
+		entry := core.FrontierEntry{}
+		if err := json.Unmarshal(msg.Data(), &entry); err != nil {
+			errC <- fmt.Errorf("invalid discovered event: %w", err)
+			return
+		}
+		if err := co.dispatch(ctx, entry); err != nil {
+			errC <- err
+		}
+	case eventDone:
+		entry := core.FrontierEntry{}
+		if err := json.Unmarshal(msg.Data(), &entry); err != nil {
+			errC <- fmt.Errorf("invalid done event: %w", err)
+			return
+		}
+		if !co.processed.Duplicate(entry.URL) {
+			co.pending--
+		}
+	case eventReport:
+		report := core.SpiderReport{}
+		if err := json.Unmarshal(msg.Data(), &report); err != nil {
+			errC <- fmt.Errorf("invalid report event: %w", err)
+			return
+		}
+		if report.Output != "" && !co.outputs.Duplicate(report.Output) {
+			outputC <- report
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Package distributed spreads a crawl over several machines through NATS JetStream.
+//
+// A Coordinator publishes the seeds on the `<prefix>.urls` work queue. Workers pull urls from it, crawl them
+// and publish back the reports and discovered links on `<prefix>.events.>`. The coordinator dispatches the links
+// it has not seen yet and completes once every dispatched url was processed
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	eventDiscovered = "discovered"
+	eventDone       = "done"
+	eventReport     = "report"
+)
+
+// AckWait is the time a worker has to process an url before it is delivered to another worker
+var AckWait = 5 * time.Minute
+
+func urlsSubject(prefix string) string {
+	return prefix + ".urls"
+}
+
+func eventSubject(prefix string, event string) string {
+	return prefix + ".events." + event
+}
+
+func streamName(prefix string, name string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "*", "_", ">", "_").Replace(prefix)) + "_" + name
+}
+
+// setupStreams creates or updates the work queue and the event streams of prefix
+func setupStreams(ctx context.Context, js jetstream.JetStream, prefix string) error {
+	_, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      streamName(prefix, "URLS"),
+		Subjects:  []string{urlsSubject(prefix)},
+		Retention: jetstream.WorkQueuePolicy,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create urls stream: %w", err)
+	}
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      streamName(prefix, "EVENTS"),
+		Subjects:  []string{eventSubject(prefix, ">")},
+		Retention: jetstream.LimitsPolicy,
+		MaxAge:    24 * time.Hour,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create events stream: %w", err)
+	}
+	return nil
+}
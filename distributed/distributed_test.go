@@ -0,0 +1,168 @@
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benji-bou/gospider/core"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func runJetStream(t *testing.T) jetstream.JetStream {
+	js, _ := connectJetStream(t)
+	return js
+}
+
+// connectJetStream runs a JetStream server and returns a connection to it
+func connectJetStream(t *testing.T) (jetstream.JetStream, *nats.Conn) {
+	ns, err := server.NewServer(&server.Options{Port: -1, JetStream: true, StoreDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go ns.Start()
+	t.Cleanup(ns.Shutdown)
+	if !ns.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server not ready")
+	}
+	nc, err := nats.Connect(ns.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(nc.Close)
+	js, err := jetstream.New(nc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return js, nc
+}
+
+func TestCoordinatorWorkers(t *testing.T) {
+	js := runJetStream(t)
+
+	lock := sync.Mutex{}
+	requests := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests[r.URL.Path]++
+		lock.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><a href="/a">a</a><a href="/b">b</a><a href="/c">c</a></body></html>`)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := NewWorker(js, "test", core.WithDefaultColly(3)).Run(workerCtx); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	outputs := map[string]int{}
+	outputC, errC := NewCoordinator(js, "test").Start(ctx, ts.URL)
+	for outputC != nil || errC != nil {
+		select {
+		case r, ok := <-outputC:
+			if !ok {
+				outputC = nil
+				continue
+			}
+			outputs[r.Output]++
+		case err, ok := <-errC:
+			if !ok {
+				errC = nil
+				continue
+			}
+			t.Error(err)
+		}
+	}
+	stopWorkers()
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		t.Fatal("crawl did not complete")
+	}
+	for _, path := range []string{"/", "/a", "/b", "/c"} {
+		if requests[path] != 1 {
+			t.Errorf("expected %s to be requested once across workers, got %d", path, requests[path])
+		}
+	}
+	for _, u := range []string{ts.URL, ts.URL + "/a", ts.URL + "/b", ts.URL + "/c"} {
+		if outputs[u] != 1 {
+			t.Errorf("expected one report for %s, got %d", u, outputs[u])
+		}
+	}
+}
+
+func TestCoordinatorAbortedRequests(t *testing.T) {
+	js := runJetStream(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><a href="/item/1">1</a><a href="/item/2">2</a><a href="/item/3">3</a></body></html>`)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	workerCtx, stopWorker := context.WithCancel(ctx)
+	workerDone := make(chan struct{})
+	go func() {
+		defer close(workerDone)
+		worker := NewWorker(js, "aborted", core.WithDefaultColly(3), core.WithCollyConfig(core.WithPatternVisitCap(1)))
+		if err := worker.Run(workerCtx); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	outputC, errC := NewCoordinator(js, "aborted").Start(ctx, ts.URL)
+	for outputC != nil || errC != nil {
+		select {
+		case _, ok := <-outputC:
+			if !ok {
+				outputC = nil
+			}
+		case err, ok := <-errC:
+			if !ok {
+				errC = nil
+				continue
+			}
+			t.Error(err)
+		}
+	}
+	stopWorker()
+	<-workerDone
+	if ctx.Err() != nil {
+		t.Fatal("expected the crawl to complete with the requests aborted by the pattern cap acknowledged")
+	}
+}
+
+func TestWorkerConnectionLost(t *testing.T) {
+	js, nc := connectJetStream(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	errC := make(chan error, 1)
+	go func() {
+		errC <- NewWorker(js, "lost", core.WithDefaultColly(3)).Run(ctx)
+	}()
+	time.Sleep(time.Second)
+	nc.Close()
+	if err := <-errC; err == nil {
+		t.Error("expected the worker to return the lost connection")
+	}
+	if ctx.Err() != nil {
+		t.Error("expected the worker to stop on the lost connection")
+	}
+}
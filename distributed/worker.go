@@ -0,0 +1,194 @@
+package distributed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benji-bou/gospider/core"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Worker crawls the urls dispatched by a Coordinator
+type Worker struct {
+	js     jetstream.JetStream
+	prefix string
+	opts   []core.CrawlerOption
+}
+
+// NewWorker returns a worker crawling with opts. Scope, depth and limits must be set on the workers,
+// discovered links are only deduplicated by the coordinator
+func NewWorker(js jetstream.JetStream, prefix string, opts ...core.CrawlerOption) *Worker {
+	return &Worker{js: js, prefix: prefix, opts: opts}
+}
+
+// errConsume marks the failures to pull urls from the work queue, which stop the worker
+var errConsume = errors.New("failed to fetch urls")
+
+// Run crawls dispatched urls until ctx is done. It returns the failures of the crawl, and stops on a failure
+// to pull urls from the work queue, e.g when the connection is lost
+func (w *Worker) Run(ctx context.Context) error {
+	if err := setupStreams(ctx, w.js, w.prefix); err != nil {
+		return err
+	}
+	consumer, err := w.js.CreateOrUpdateConsumer(ctx, streamName(w.prefix, "URLS"), jetstream.ConsumerConfig{
+		Durable:   "workers",
+		AckPolicy: jetstream.AckExplicitPolicy,
+		AckWait:   AckWait,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to consume urls: %w", err)
+	}
+	f := &workerFrontier{js: w.js, prefix: w.prefix, consumer: consumer, inflight: make(map[string]jetstream.Msg)}
+	crawler := core.NewCrawler(append(w.opts, core.WithFrontier(f), core.WithSink(&reportSink{js: w.js, prefix: w.prefix}))...)
+	go func() {
+		<-ctx.Done()
+		crawler.Stop()
+	}()
+	stopHeartbeatC := make(chan struct{})
+	defer close(stopHeartbeatC)
+	go f.heartbeat(stopHeartbeatC)
+	var errs []error
+	outputC, errC := crawler.Start()
+	for outputC != nil || errC != nil {
+		select {
+		case _, ok := <-outputC:
+			if !ok {
+				outputC = nil
+			}
+		case err, ok := <-errC:
+			if !ok {
+				errC = nil
+				continue
+			}
+			core.Logger.Error(err)
+			errs = append(errs, err)
+			if errors.Is(err, errConsume) {
+				crawler.Stop()
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// workerFrontier pulls the urls from the work queue and forwards the discovered links to the coordinator
+type workerFrontier struct {
+	js       jetstream.JetStream
+	prefix   string
+	consumer jetstream.Consumer
+
+	lock     sync.Mutex
+	inflight map[string]jetstream.Msg
+}
+
+// Duplicate never filters, the coordinator deduplicates the reports of all workers
+func (f *workerFrontier) Duplicate(output string) bool {
+	return false
+}
+
+func (f *workerFrontier) publish(ctx context.Context, event string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = f.js.Publish(ctx, eventSubject(f.prefix, event), raw)
+	return err
+}
+
+func (f *workerFrontier) Push(ctx context.Context, entry core.FrontierEntry) error {
+	if err := f.publish(ctx, eventDiscovered, entry); err != nil {
+		return fmt.Errorf("failed to publish discovered %s: %w", entry.URL, err)
+	}
+	return nil
+}
+
+func (f *workerFrontier) Pop(ctx context.Context) (core.FrontierEntry, bool, error) {
+	entry := core.FrontierEntry{}
+	batch, err := f.consumer.Fetch(1, jetstream.FetchMaxWait(time.Second))
+	if err != nil {
+		return entry, false, fmt.Errorf("%w: %w", errConsume, err)
+	}
+	msg, ok := <-batch.Messages()
+	if !ok {
+		if err := batch.Error(); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			return entry, false, fmt.Errorf("%w: %w", errConsume, err)
+		}
+		return entry, false, nil
+	}
+	if err := json.Unmarshal(msg.Data(), &entry); err != nil {
+		msg.Term()
+		return entry, false, fmt.Errorf("invalid url message: %w", err)
+	}
+	f.lock.Lock()
+	f.inflight[entry.URL] = msg
+	f.lock.Unlock()
+	return entry, true, nil
+}
+
+// heartbeat tells the work queue the urls being crawled are still in progress every third of AckWait,
+// so that the long crawls aren't delivered to another worker
+func (f *workerFrontier) heartbeat(stopC <-chan struct{}) {
+	ticker := time.NewTicker(AckWait / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopC:
+			return
+		case <-ticker.C:
+		}
+		f.lock.Lock()
+		inflight := make(map[string]jetstream.Msg, len(f.inflight))
+		for u, msg := range f.inflight {
+			inflight[u] = msg
+		}
+		f.lock.Unlock()
+		for u, msg := range inflight {
+			if err := msg.InProgress(); err != nil {
+				core.Logger.Warnf("Failed to extend the processing of %s: %s", u, err)
+			}
+		}
+	}
+}
+
+// Done acknowledges the url and tells the coordinator it was processed, even when the acknowledgement fails so the
+// coordinator doesn't wait for it
+func (f *workerFrontier) Done(ctx context.Context, entry core.FrontierEntry) error {
+	f.lock.Lock()
+	msg, ok := f.inflight[entry.URL]
+	delete(f.inflight, entry.URL)
+	f.lock.Unlock()
+	var ackErr error
+	if ok {
+		if err := msg.Ack(); err != nil {
+			ackErr = fmt.Errorf("failed to ack %s: %w", entry.URL, err)
+		}
+	}
+	return errors.Join(ackErr, f.publish(ctx, eventDone, entry))
+}
+
+// Pending keeps the worker polling, it stops with its context
+func (f *workerFrontier) Pending(ctx context.Context) (int64, error) {
+	return 1, nil
+}
+
+// reportSink publishes the reports of a worker to the coordinator
+type reportSink struct {
+	js     jetstream.JetStream
+	prefix string
+}
+
+func (rs *reportSink) Send(ctx context.Context, report core.SpiderReport) error {
+	raw, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	_, err = rs.js.Publish(ctx, eventSubject(rs.prefix, eventReport), raw)
+	return err
+}
+
+func (rs *reportSink) Close() error {
+	return nil
+}
@@ -62,13 +62,13 @@ func NewCrawler(siteList []string) *core.Crawler {
 		core.WithRobot(),
 		core.WithDefaultColly(3),
 		// core.WithFilterLength(),
+		core.WithHTTPClientOpt(
+			// core.WithHTTPProxy(proxy)
+			core.WithHTTPTimeout(5),
+			core.WithHTTPNoRedirect(),
+		),
 		core.WithCollyConfig(
 			append([]core.CollyConfigurator{
-				core.WithHTTPClientOpt(
-					// core.WithHTTPProxy(proxy)
-					core.WithHTTPTimeout(5),
-					core.WithHTTPNoRedirect(),
-				),
 				// core.WithBurpFile(burpFile),
 				// core.WithCookie(cookie),
 				// core.WithHeader(headers...),
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/benji-bou/gospider/core"
+)
+
+type JobStatus string
+
+const (
+	JobRunning     JobStatus = "running"
+	JobDone        JobStatus = "done"
+	JobCancelled   JobStatus = "cancelled"
+	JobInterrupted JobStatus = "interrupted"
+)
+
+// CrawlRequest is the body of `POST /crawls`
+type CrawlRequest struct {
+	Sites []string `json:"sites"`
+	core.Config
+}
+
+const redactedValue = "[redacted]"
+
+// redacted returns a copy of req without its secrets: the cookie, the header and login field values, the proxy
+// password and the API keys. Jobs hold the redacted request, so it is neither saved nor served
+func (req CrawlRequest) redacted() CrawlRequest {
+	if req.Cookie != "" {
+		req.Cookie = redactedValue
+	}
+	if len(req.Headers) > 0 {
+		headers := make(map[string]string, len(req.Headers))
+		for name := range req.Headers {
+			headers[name] = redactedValue
+		}
+		req.Headers = headers
+	}
+	if req.Login != nil {
+		login := *req.Login
+		login.Fields = make(map[string]string, len(req.Login.Fields))
+		for name := range req.Login.Fields {
+			login.Fields[name] = redactedValue
+		}
+		req.Login = &login
+	}
+	if proxy, err := url.Parse(req.Proxy); err == nil && proxy.User != nil {
+		req.Proxy = proxy.Redacted()
+	}
+	if req.Sources.URLScanAPIKey != "" {
+		req.Sources.URLScanAPIKey = redactedValue
+	}
+	return req
+}
+
+// Job is the persisted state of a crawl
+type Job struct {
+	ID         string       `json:"id"`
+	Status     JobStatus    `json:"status"`
+	Request    CrawlRequest `json:"request"`
+	Reports    int          `json:"reports"`
+	Errors     int          `json:"errors"`
+	CreatedAt  time.Time    `json:"created_at"`
+	FinishedAt *time.Time   `json:"finished_at,omitempty"`
+}
+
+type job struct {
+	lock    sync.Mutex
+	state   Job
+	dir     string
+	crawler *core.Crawler
+	doneC   chan struct{}
+}
+
+func newJobID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+func (j *job) resultsPath() string {
+	return filepath.Join(j.dir, "results.jsonl")
+}
+
+func (j *job) snapshot() Job {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.state
+}
+
+// save writes the job state to disk, the caller must hold the lock
+func (j *job) save() error {
+	raw, err := json.MarshalIndent(j.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(j.dir, "job.json.tmp")
+	if err := os.WriteFile(tmp, raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(j.dir, "job.json"))
+}
+
+func (j *job) finish(status JobStatus) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	if j.state.Status == JobRunning {
+		j.state.Status = status
+	}
+	now := time.Now().UTC()
+	j.state.FinishedAt = &now
+	if err := j.save(); err != nil {
+		core.Logger.Errorf("Failed to save job %s: %s", j.state.ID, err)
+	}
+}
+
+func loadJob(dir string) (*job, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, "job.json"))
+	if err != nil {
+		return nil, err
+	}
+	j := &job{dir: dir, doneC: make(chan struct{})}
+	if err := json.Unmarshal(raw, &j.state); err != nil {
+		return nil, fmt.Errorf("failed to parse job %s: %w", dir, err)
+	}
+	close(j.doneC)
+	return j, nil
+}
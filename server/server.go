@@ -0,0 +1,287 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benji-bou/gospider/core"
+)
+
+// Server runs crawls as jobs managed through an HTTP API:
+//
+//	POST   /crawls              start a crawl from a CrawlRequest
+//	GET    /crawls              list the jobs
+//	GET    /crawls/{id}         job state
+//	GET    /crawls/{id}/results JSONL reports of the job
+//	DELETE /crawls/{id}         stop the crawl and delete the job
+//
+// Job states and results are persisted in a directory per job so they survive restarts,
+// jobs still running when the server stopped are reported as interrupted
+type Server struct {
+	dir   string
+	opts  []core.CrawlerOption
+	token string
+
+	lock sync.Mutex
+	jobs map[string]*job
+}
+
+// New returns a server persisting its jobs in dir. opts are applied to the crawler of every job
+func New(dir string, opts ...core.CrawlerOption) (*Server, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create job directory: %w", err)
+	}
+	s := &Server{dir: dir, opts: opts, jobs: make(map[string]*job)}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job directory: %w", err)
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		j, err := loadJob(filepath.Join(dir, e.Name()))
+		if err != nil {
+			core.Logger.Warnf("Ignoring job %s: %s", e.Name(), err)
+			continue
+		}
+		if j.state.Status == JobRunning {
+			j.state.Status = JobInterrupted
+			if err := j.save(); err != nil {
+				core.Logger.Errorf("Failed to save job %s: %s", j.state.ID, err)
+			}
+		}
+		s.jobs[j.state.ID] = j
+	}
+	return s, nil
+}
+
+// RequireToken rejects the requests not authenticated with an "Authorization: Bearer <token>" header.
+// An empty token accepts every request
+func (s *Server) RequireToken(token string) {
+	s.token = token
+}
+
+// authorized reports whether r carries the token required by the server
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="gospider"`)
+		writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+		return
+	}
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	if parts[0] != "crawls" || len(parts) > 3 {
+		http.NotFound(w, r)
+		return
+	}
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodPost:
+		s.createCrawl(w, r)
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		s.listCrawls(w)
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		s.getCrawl(w, r, parts[1])
+	case len(parts) == 2 && r.Method == http.MethodDelete:
+		s.deleteCrawl(w, r, parts[1])
+	case len(parts) == 3 && parts[2] == "results" && r.Method == http.MethodGet:
+		s.crawlResults(w, r, parts[1])
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		core.Logger.Errorf("Failed to write response: %s", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func (s *Server) job(id string) (*job, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	j, ok := s.jobs[id]
+	return j, ok
+}
+
+func (s *Server) createCrawl(w http.ResponseWriter, r *http.Request) {
+	req := CrawlRequest{}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid crawl request: %w", err))
+		return
+	}
+	if len(req.Sites) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("invalid crawl request: no site to crawl"))
+		return
+	}
+	j, err := s.start(req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, j.snapshot())
+}
+
+func (s *Server) start(req CrawlRequest) (*job, error) {
+	id := newJobID()
+	j := &job{
+		dir:   filepath.Join(s.dir, id),
+		doneC: make(chan struct{}),
+		state: Job{ID: id, Status: JobRunning, Request: req.redacted(), CreatedAt: time.Now().UTC()},
+	}
+	if err := os.MkdirAll(j.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create job directory: %w", err)
+	}
+	if err := j.save(); err != nil {
+		return nil, fmt.Errorf("failed to save job: %w", err)
+	}
+	results, err := os.Create(j.resultsPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job results: %w", err)
+	}
+
+	crawlerOpts, collyOpts := req.Config.Options()
+	crawlerOpts = append(crawlerOpts, s.opts...)
+	crawlerOpts = append(crawlerOpts,
+		core.WithCollyConfig(collyOpts...),
+		core.WithOutput(results),
+		core.WithOutputFormat(core.JSONLFormatter{}),
+		core.WithJobMetadata(map[string]string{"job": id}),
+	)
+	j.crawler = core.NewCrawler(crawlerOpts...)
+
+	s.lock.Lock()
+	s.jobs[id] = j
+	s.lock.Unlock()
+
+	go func() {
+		defer close(j.doneC)
+		defer results.Close()
+		outputC, errC := j.crawler.Start(req.Sites...)
+		for outputC != nil || errC != nil {
+			select {
+			case _, ok := <-outputC:
+				if !ok {
+					outputC = nil
+					continue
+				}
+				j.lock.Lock()
+				j.state.Reports++
+				j.lock.Unlock()
+			case _, ok := <-errC:
+				if !ok {
+					errC = nil
+					continue
+				}
+				j.lock.Lock()
+				j.state.Errors++
+				j.lock.Unlock()
+			}
+		}
+		j.finish(JobDone)
+	}()
+	return j, nil
+}
+
+func (s *Server) listCrawls(w http.ResponseWriter) {
+	s.lock.Lock()
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j.snapshot())
+	}
+	s.lock.Unlock()
+	sort.Slice(jobs, func(i, k int) bool {
+		return jobs[i].CreatedAt.Before(jobs[k].CreatedAt)
+	})
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+func (s *Server) getCrawl(w http.ResponseWriter, r *http.Request, id string) {
+	j, ok := s.job(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown crawl %s", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, j.snapshot())
+}
+
+func (s *Server) crawlResults(w http.ResponseWriter, r *http.Request, id string) {
+	j, ok := s.job(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown crawl %s", id))
+		return
+	}
+	results, err := os.Open(j.resultsPath())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to read results: %w", err))
+		return
+	}
+	defer results.Close()
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("X-Crawl-Status", string(j.snapshot().Status))
+	if _, err := io.Copy(w, results); err != nil {
+		core.Logger.Errorf("Failed to send results of job %s: %s", id, err)
+	}
+}
+
+func (s *Server) deleteCrawl(w http.ResponseWriter, r *http.Request, id string) {
+	j, ok := s.job(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown crawl %s", id))
+		return
+	}
+	if j.crawler != nil && j.snapshot().Status == JobRunning {
+		j.lock.Lock()
+		j.state.Status = JobCancelled
+		j.lock.Unlock()
+		j.crawler.Stop()
+	}
+	<-j.doneC
+	s.lock.Lock()
+	delete(s.jobs, id)
+	s.lock.Unlock()
+	if err := os.RemoveAll(j.dir); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to delete crawl %s: %w", id, err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Wait blocks until every running job is finished
+func (s *Server) Wait() {
+	s.lock.Lock()
+	jobs := make([]*job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	s.lock.Unlock()
+	for _, j := range jobs {
+		<-j.doneC
+	}
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestSite() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><a href="/a">a</a></body></html>`)
+	}))
+}
+
+func TestServer(t *testing.T) {
+	site := newTestSite()
+	defer site.Close()
+	dir := t.TempDir()
+	s, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := httptest.NewServer(s)
+	defer api.Close()
+
+	resp, err := http.Post(api.URL+"/crawls", "application/json", strings.NewReader(fmt.Sprintf(`{"sites":[%q],"depth":2}`, site.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := Job{}
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || created.ID == "" {
+		t.Fatalf("expected crawl to be created, got %s %+v", resp.Status, created)
+	}
+	s.Wait()
+
+	resp, err = http.Get(api.URL + "/crawls/" + created.ID + "/results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reports := 0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		report := map[string]any{}
+		if err := json.Unmarshal(scanner.Bytes(), &report); err != nil {
+			t.Fatalf("invalid result line %s: %s", scanner.Text(), err)
+		}
+		reports++
+	}
+	resp.Body.Close()
+	if reports == 0 || resp.Header.Get("X-Crawl-Status") != string(JobDone) {
+		t.Errorf("expected results of a finished crawl, got %d reports with status %s", reports, resp.Header.Get("X-Crawl-Status"))
+	}
+
+	// jobs are reloaded from disk
+	reloaded, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j, ok := reloaded.job(created.ID)
+	if !ok || j.snapshot().Status != JobDone || j.snapshot().Reports != reports {
+		t.Errorf("expected the finished job to be reloaded, got %+v", j)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, api.URL+"/crawls/"+created.ID, nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected crawl to be deleted, got %s", resp.Status)
+	}
+	resp, _ = http.Get(api.URL + "/crawls/" + created.ID)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected deleted crawl to be gone, got %s", resp.Status)
+	}
+}
+
+func TestServerRedactsSecrets(t *testing.T) {
+	site := newTestSite()
+	defer site.Close()
+	dir := t.TempDir()
+	s, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := httptest.NewServer(s)
+	defer api.Close()
+
+	body := fmt.Sprintf(`{"sites":[%q],"cookie":"session=s3cret","headers":{"Authorization":"Bearer s3cret"},
+"login":{"url":%q,"fields":{"password":"s3cret"}},"sources":{"urlscan_api_key":"s3cret"}}`, site.URL, site.URL)
+	resp, err := http.Post(api.URL+"/crawls", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := Job{}
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || created.Request.Login == nil {
+		t.Fatalf("expected crawl to be created, got %s %+v", resp.Status, created)
+	}
+	s.Wait()
+
+	resp, err = http.Get(api.URL + "/crawls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	saved, err := os.ReadFile(filepath.Join(dir, created.ID, "job.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, raw := range map[string][]byte{"listed": listed, "saved": saved} {
+		if strings.Contains(string(raw), "s3cret") {
+			t.Errorf("expected the %s job not to hold secrets, got %s", name, raw)
+		}
+	}
+	if created.Request.Headers["Authorization"] != redactedValue || created.Request.Login.Fields["password"] != redactedValue {
+		t.Errorf("expected the redacted request to keep the names of its secrets, got %+v", created.Request)
+	}
+}
+
+func TestServerInterruptedJob(t *testing.T) {
+	dir := t.TempDir()
+	j := &job{dir: filepath.Join(dir, "42"), state: Job{ID: "42", Status: JobRunning, CreatedAt: time.Now()}}
+	if err := os.MkdirAll(j.dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.save(); err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded, ok := s.job("42"); !ok || loaded.snapshot().Status != JobInterrupted {
+		t.Errorf("expected running job to be reloaded as interrupted")
+	}
+}
+
+func TestServerToken(t *testing.T) {
+	s, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.RequireToken("secret")
+	api := httptest.NewServer(s)
+	defer api.Close()
+
+	for header, expected := range map[string]int{
+		"":              http.StatusUnauthorized,
+		"Bearer wrong":  http.StatusUnauthorized,
+		"Basic secret":  http.StatusUnauthorized,
+		"Bearer secret": http.StatusOK,
+	} {
+		req, _ := http.NewRequest(http.MethodGet, api.URL+"/crawls", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != expected {
+			t.Errorf("expected %d with Authorization %q, got %s", expected, header, resp.Status)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package sinks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benji-bou/gospider/core"
+)
+
+// reportMappings is the index template mapping used for SpiderReport documents
+var reportMappings = map[string]any{
+	"properties": map[string]any{
+		"output":       map[string]any{"type": "keyword"},
+		"type":         map[string]any{"type": "keyword"},
+		"status":       map[string]any{"type": "integer"},
+		"source":       map[string]any{"type": "keyword"},
+		"input":        map[string]any{"type": "keyword"},
+		"seed":         map[string]any{"type": "keyword"},
+		"content_type": map[string]any{"type": "keyword"},
+		"language":     map[string]any{"type": "keyword"},
+		"matcher":      map[string]any{"type": "keyword"},
+		"snippet":      map[string]any{"type": "text"},
+		"error":        map[string]any{"type": "text"},
+		"@timestamp":   map[string]any{"type": "date"},
+	},
+}
+
+type ElasticsearchOption func(es *Elasticsearch)
+
+// Elasticsearch is a core.Sink indexing reports in batches through the bulk API
+type Elasticsearch struct {
+	client        *http.Client
+	url           string
+	index         string
+	username      string
+	password      string
+	apiKey        string
+	batchSize     int
+	flushInterval time.Duration
+	maxRetries    int
+	retryBackoff  time.Duration
+	template      bool
+
+	lock    sync.Mutex
+	buffer  bytes.Buffer
+	pending int
+	stopC   chan struct{}
+	doneC   chan struct{}
+}
+
+func WithElasticsearchClient(client *http.Client) ElasticsearchOption {
+	return func(es *Elasticsearch) {
+		es.client = client
+	}
+}
+
+func WithElasticsearchBasicAuth(username string, password string) ElasticsearchOption {
+	return func(es *Elasticsearch) {
+		es.username = username
+		es.password = password
+	}
+}
+
+func WithElasticsearchAPIKey(apiKey string) ElasticsearchOption {
+	return func(es *Elasticsearch) {
+		es.apiKey = apiKey
+	}
+}
+
+// WithElasticsearchBatch sets the number of reports per bulk request and the maximum time a report waits in the buffer
+func WithElasticsearchBatch(size int, flushInterval time.Duration) ElasticsearchOption {
+	return func(es *Elasticsearch) {
+		es.batchSize = size
+		es.flushInterval = flushInterval
+	}
+}
+
+// WithElasticsearchRetry sets how many times a failed bulk request is retried, waiting backoff, then twice as long, between attempts
+func WithElasticsearchRetry(maxRetries int, backoff time.Duration) ElasticsearchOption {
+	return func(es *Elasticsearch) {
+		es.maxRetries = maxRetries
+		es.retryBackoff = backoff
+	}
+}
+
+// WithoutElasticsearchTemplate disables the index template installation
+func WithoutElasticsearchTemplate() ElasticsearchOption {
+	return func(es *Elasticsearch) {
+		es.template = false
+	}
+}
+
+// NewElasticsearch returns a sink indexing reports in index of the cluster at url.
+// Unless disabled, an index template mapping the report fields is installed for index
+func NewElasticsearch(url string, index string, opts ...ElasticsearchOption) (*Elasticsearch, error) {
+	es := &Elasticsearch{
+		client:        &http.Client{Timeout: 30 * time.Second},
+		url:           strings.TrimRight(url, "/"),
+		index:         index,
+		batchSize:     500,
+		flushInterval: 5 * time.Second,
+		maxRetries:    3,
+		retryBackoff:  time.Second,
+		template:      true,
+		stopC:         make(chan struct{}),
+		doneC:         make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(es)
+	}
+	if es.template {
+		if err := es.putIndexTemplate(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	go es.flushLoop()
+	return es, nil
+}
+
+func (es *Elasticsearch) putIndexTemplate(ctx context.Context) error {
+	body, err := json.Marshal(map[string]any{
+		"index_patterns": []string{es.index + "*"},
+		"template": map[string]any{
+			"mappings": reportMappings,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build elasticsearch index template: %w", err)
+	}
+	resp, err := es.do(ctx, http.MethodPut, "/_index_template/"+es.index, "application/json", body)
+	if err != nil {
+		return fmt.Errorf("failed to install elasticsearch index template: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to install elasticsearch index template: %s %s", resp.Status, msg)
+	}
+	return nil
+}
+
+func (es *Elasticsearch) do(ctx context.Context, method string, path string, contentType string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, es.url+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if es.apiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+es.apiKey)
+	} else if es.username != "" {
+		req.SetBasicAuth(es.username, es.password)
+	}
+	return es.client.Do(req)
+}
+
+func (es *Elasticsearch) Send(ctx context.Context, report core.SpiderReport) error {
+	doc, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to serialize report: %w", err)
+	}
+	// add the indexing time to the document
+	doc = append([]byte(fmt.Sprintf(`{"@timestamp":%q,`, time.Now().UTC().Format(time.RFC3339Nano))), doc[1:]...)
+
+	es.lock.Lock()
+	defer es.lock.Unlock()
+	fmt.Fprintf(&es.buffer, `{"index":{"_index":%q}}`+"\n", es.index)
+	es.buffer.Write(doc)
+	es.buffer.WriteByte('\n')
+	es.pending++
+	if es.pending >= es.batchSize {
+		return es.flush(ctx)
+	}
+	return nil
+}
+
+// Flush sends the buffered reports
+func (es *Elasticsearch) Flush(ctx context.Context) error {
+	es.lock.Lock()
+	defer es.lock.Unlock()
+	return es.flush(ctx)
+}
+
+func (es *Elasticsearch) flush(ctx context.Context) error {
+	if es.pending == 0 {
+		return nil
+	}
+	body := append([]byte{}, es.buffer.Bytes()...)
+	count := es.pending
+	es.buffer.Reset()
+	es.pending = 0
+
+	backoff := es.retryBackoff
+	var err error
+	for attempt := 0; attempt <= es.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		var retry bool
+		retry, err = es.bulk(ctx, body)
+		if err == nil || !retry {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to index %d reports: %w", count, err)
+	}
+	return nil
+}
+
+// bulk sends a bulk request. retry is true when the failure is transient
+func (es *Elasticsearch) bulk(ctx context.Context, body []byte) (retry bool, err error) {
+	resp, err := es.do(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", body)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return true, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return true, fmt.Errorf("bulk request failed: %s", resp.Status)
+	}
+	if resp.StatusCode >= 300 {
+		return false, fmt.Errorf("bulk request failed: %s %s", resp.Status, raw)
+	}
+	result := struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+			Error  struct {
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}{}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return false, fmt.Errorf("failed to parse bulk response: %w", err)
+	}
+	if !result.Errors {
+		return false, nil
+	}
+	failed, reason := 0, ""
+	for _, item := range result.Items {
+		for _, action := range item {
+			if action.Status >= 300 {
+				failed++
+				reason = action.Error.Reason
+			}
+		}
+	}
+	return false, fmt.Errorf("%d documents rejected, last error: %s", failed, reason)
+}
+
+func (es *Elasticsearch) flushLoop() {
+	defer close(es.doneC)
+	if es.flushInterval <= 0 {
+		<-es.stopC
+		return
+	}
+	ticker := time.NewTicker(es.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := es.Flush(context.Background()); err != nil {
+				core.Logger.Errorf("Elasticsearch sink: %s", err)
+			}
+		case <-es.stopC:
+			return
+		}
+	}
+}
+
+// Close flushes the remaining reports and stops the periodic flush
+func (es *Elasticsearch) Close() error {
+	select {
+	case <-es.stopC:
+		return nil
+	default:
+		close(es.stopC)
+	}
+	<-es.doneC
+	return es.Flush(context.Background())
+}
@@ -0,0 +1,82 @@
+package sinks
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benji-bou/gospider/core"
+)
+
+func TestElasticsearchSink(t *testing.T) {
+	var lock sync.Mutex
+	template, bulkCalls, docs := false, 0, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/_index_template/gospider":
+			template = true
+			w.Write([]byte(`{"acknowledged":true}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/_bulk":
+			bulkCalls++
+			if bulkCalls == 1 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			sc := bufio.NewScanner(r.Body)
+			lines := 0
+			for sc.Scan() {
+				lines++
+			}
+			docs += lines / 2
+			w.Write([]byte(`{"errors":false,"items":[]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	es, err := NewElasticsearch(ts.URL, "gospider", WithElasticsearchBatch(2, time.Hour), WithElasticsearchRetry(2, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []string{"https://a.com", "https://b.com", "https://c.com"} {
+		if err := es.Send(context.Background(), core.SpiderReport{Output: u, OutputType: core.Url}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := es.Close(); err != nil {
+		t.Fatal(err)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if !template {
+		t.Error("expected index template to be installed")
+	}
+	if docs != 3 || bulkCalls != 3 {
+		t.Errorf("expected 3 documents in 3 bulk calls (one retried), got %d documents in %d calls", docs, bulkCalls)
+	}
+}
+
+func TestElasticsearchSinkRejectedDocuments(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"errors":true,"items":[{"index":{"status":400,"error":{"reason":"mapper_parsing_exception"}}}]}`))
+	}))
+	defer ts.Close()
+
+	es, err := NewElasticsearch(ts.URL, "gospider", WithoutElasticsearchTemplate(), WithElasticsearchBatch(1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer es.Close()
+	err = es.Send(context.Background(), core.SpiderReport{Output: "https://a.com", OutputType: core.Url})
+	if err == nil || !strings.Contains(err.Error(), "mapper_parsing_exception") {
+		t.Errorf("expected rejected document error, got %v", err)
+	}
+}
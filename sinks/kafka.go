@@ -0,0 +1,236 @@
+package sinks
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benji-bou/gospider/core"
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaEncoding is the serialization of the reports published by the Kafka sink
+type KafkaEncoding string
+
+const (
+	KafkaJSON KafkaEncoding = "json"
+	KafkaAvro KafkaEncoding = "avro"
+)
+
+// KafkaAvroSchema is the Avro schema of the reports published with the KafkaAvro encoding
+const KafkaAvroSchema = `{
+  "type": "record",
+  "name": "SpiderReport",
+  "namespace": "gospider",
+  "fields": [
+    {"name": "output", "type": "string"},
+    {"name": "type", "type": "string"},
+    {"name": "status", "type": "int"},
+    {"name": "source", "type": "string"},
+    {"name": "input", "type": ["null", "string"]},
+    {"name": "seed", "type": ["null", "string"]},
+    {"name": "content_type", "type": ["null", "string"]},
+    {"name": "error", "type": ["null", "string"]},
+    {"name": "timestamp", "type": {"type": "long", "logicalType": "timestamp-millis"}}
+  ]
+}`
+
+type kafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+type KafkaOption func(k *Kafka)
+
+// Kafka is a core.Sink publishing reports to a topic. Reports are buffered and written in batches,
+// Send blocks while a full batch is being written so a slow cluster slows the crawl down instead of growing memory
+type Kafka struct {
+	writer        kafkaWriter
+	encoding      KafkaEncoding
+	batchSize     int
+	flushInterval time.Duration
+
+	lock   sync.Mutex
+	buffer []kafka.Message
+	stopC  chan struct{}
+	doneC  chan struct{}
+}
+
+func WithKafkaEncoding(encoding KafkaEncoding) KafkaOption {
+	return func(k *Kafka) {
+		k.encoding = encoding
+	}
+}
+
+// WithKafkaBatch sets the number of reports per write and the maximum time a report waits in the buffer
+func WithKafkaBatch(size int, flushInterval time.Duration) KafkaOption {
+	return func(k *Kafka) {
+		k.batchSize = size
+		k.flushInterval = flushInterval
+	}
+}
+
+// WithKafkaWriter replaces the default kafka.Writer, e.g to configure TLS or SASL
+func WithKafkaWriter(writer *kafka.Writer) KafkaOption {
+	return func(k *Kafka) {
+		k.writer = writer
+	}
+}
+
+// NewKafka returns a sink publishing reports on topic. Messages are keyed by report seed so reports of a seed keep their order
+func NewKafka(brokers []string, topic string, opts ...KafkaOption) (*Kafka, error) {
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("kafka sink: at least one broker is required")
+	}
+	k := &Kafka{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireOne,
+			BatchTimeout: 10 * time.Millisecond,
+		},
+		encoding:      KafkaJSON,
+		batchSize:     100,
+		flushInterval: time.Second,
+	}
+	for _, o := range opts {
+		o(k)
+	}
+	if k.encoding != KafkaJSON && k.encoding != KafkaAvro {
+		return nil, fmt.Errorf("kafka sink: unknown encoding %s", k.encoding)
+	}
+	k.start()
+	return k, nil
+}
+
+func (k *Kafka) start() {
+	k.stopC = make(chan struct{})
+	k.doneC = make(chan struct{})
+	go k.flushLoop()
+}
+
+func (k *Kafka) encode(report core.SpiderReport) ([]byte, error) {
+	if k.encoding == KafkaAvro {
+		return encodeAvroReport(report, time.Now()), nil
+	}
+	return json.Marshal(report)
+}
+
+func (k *Kafka) Send(ctx context.Context, report core.SpiderReport) error {
+	value, err := k.encode(report)
+	if err != nil {
+		return fmt.Errorf("failed to serialize report: %w", err)
+	}
+	key := report.Seed
+	if key == "" {
+		key = report.Output
+	}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	k.buffer = append(k.buffer, kafka.Message{
+		Key:   []byte(key),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "type", Value: []byte(report.OutputType)},
+			{Key: "encoding", Value: []byte(k.encoding)},
+		},
+	})
+	if len(k.buffer) >= k.batchSize {
+		return k.flush(ctx)
+	}
+	return nil
+}
+
+// Flush writes the buffered reports
+func (k *Kafka) Flush(ctx context.Context) error {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	return k.flush(ctx)
+}
+
+func (k *Kafka) flush(ctx context.Context) error {
+	if len(k.buffer) == 0 {
+		return nil
+	}
+	msgs := k.buffer
+	k.buffer = nil
+	if err := k.writer.WriteMessages(ctx, msgs...); err != nil {
+		return fmt.Errorf("failed to publish %d reports: %w", len(msgs), err)
+	}
+	return nil
+}
+
+func (k *Kafka) flushLoop() {
+	defer close(k.doneC)
+	if k.flushInterval <= 0 {
+		<-k.stopC
+		return
+	}
+	ticker := time.NewTicker(k.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := k.Flush(context.Background()); err != nil {
+				core.Logger.Errorf("Kafka sink: %s", err)
+			}
+		case <-k.stopC:
+			return
+		}
+	}
+}
+
+// Close publishes the remaining reports and closes the writer
+func (k *Kafka) Close() error {
+	select {
+	case <-k.stopC:
+		return nil
+	default:
+		close(k.stopC)
+	}
+	<-k.doneC
+	err := k.Flush(context.Background())
+	if cerr := k.writer.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// encodeAvroReport encodes report with the Avro binary encoding of KafkaAvroSchema
+func encodeAvroReport(report core.SpiderReport, now time.Time) []byte {
+	buf := make([]byte, 0, 256)
+	buf = avroString(buf, report.Output)
+	buf = avroString(buf, string(report.OutputType))
+	buf = binary.AppendVarint(buf, int64(report.StatusCode))
+	buf = avroString(buf, report.Source)
+	input := ""
+	if report.Input != nil {
+		input = report.Input.String()
+	}
+	errMsg := ""
+	if report.Err != nil {
+		errMsg = report.Err.Error()
+	}
+	for _, optional := range []string{input, report.Seed, report.ContentType, errMsg} {
+		buf = avroOptionalString(buf, optional)
+	}
+	return binary.AppendVarint(buf, now.UnixMilli())
+}
+
+func avroString(buf []byte, s string) []byte {
+	buf = binary.AppendVarint(buf, int64(len(s)))
+	return append(buf, s...)
+}
+
+// avroOptionalString encodes a ["null", "string"] union, empty strings being encoded as null
+func avroOptionalString(buf []byte, s string) []byte {
+	if s == "" {
+		return binary.AppendVarint(buf, 0)
+	}
+	buf = binary.AppendVarint(buf, 1)
+	return avroString(buf, s)
+}
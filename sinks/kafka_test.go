@@ -0,0 +1,70 @@
+package sinks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/benji-bou/gospider/core"
+	"github.com/segmentio/kafka-go"
+)
+
+type fakeKafkaWriter struct {
+	writes [][]kafka.Message
+	closed bool
+}
+
+func (fw *fakeKafkaWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	fw.writes = append(fw.writes, msgs)
+	return nil
+}
+
+func (fw *fakeKafkaWriter) Close() error {
+	fw.closed = true
+	return nil
+}
+
+func TestKafkaSinkBatching(t *testing.T) {
+	fw := &fakeKafkaWriter{}
+	k := &Kafka{writer: fw, encoding: KafkaJSON, batchSize: 2, flushInterval: time.Hour}
+	k.start()
+	for _, u := range []string{"https://a.com", "https://b.com", "https://c.com"} {
+		if err := k.Send(context.Background(), core.SpiderReport{Output: u, OutputType: core.Url, Seed: "https://a.com"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(fw.writes) != 1 || len(fw.writes[0]) != 2 {
+		t.Fatalf("expected one batch of 2 messages before close, got %v", fw.writes)
+	}
+	if err := k.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(fw.writes) != 2 || !fw.closed {
+		t.Fatalf("expected remaining message to be flushed and writer closed")
+	}
+	msg := fw.writes[0][0]
+	if string(msg.Key) != "https://a.com" {
+		t.Errorf("expected messages keyed by seed, got %s", msg.Key)
+	}
+	decoded := map[string]any{}
+	if err := json.Unmarshal(msg.Value, &decoded); err != nil || decoded["output"] != "https://a.com" {
+		t.Errorf("unexpected json message %s", msg.Value)
+	}
+}
+
+func TestEncodeAvroReport(t *testing.T) {
+	raw := encodeAvroReport(core.SpiderReport{Output: "ab", OutputType: "c", StatusCode: 200, Source: "d"}, time.UnixMilli(1))
+	expected := []byte{
+		4, 'a', 'b', // output
+		2, 'c', // type
+		0x90, 0x03, // status 200 zigzag
+		2, 'd', // source
+		0, 0, 0, 0, // null input, seed, content_type, error
+		2, // timestamp 1 zigzag
+	}
+	if !bytes.Equal(raw, expected) {
+		t.Errorf("unexpected avro encoding %v, expected %v", raw, expected)
+	}
+}
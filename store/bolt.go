@@ -0,0 +1,139 @@
+// Package store provides persistent core.DedupStore implementations, keeping the outputs reported by a crawl across
+// restarts so they aren't reported again. They are also colly storages, keeping the visited urls and the cookies
+package store
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	seenBucket    = []byte("seen")
+	visitedBucket = []byte("visited")
+	cookiesBucket = []byte("cookies")
+)
+
+// Bolt is a core.FallibleDedupStore and a colly storage.Storage backed by a BoltDB file
+type Bolt struct {
+	db *bolt.DB
+	failures
+}
+
+func NewBolt(path string) (*Bolt, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bolt store %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{seenBucket, visitedBucket, cookiesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to init bolt store %s: %w", path, err)
+	}
+	return &Bolt{db: db}, nil
+}
+
+func (b *Bolt) Duplicate(output string) bool {
+	var duplicate bool
+	// the concurrent calls are committed together, Batch may run the function again
+	err := b.db.Batch(func(tx *bolt.Tx) error {
+		duplicate = false
+		bucket := tx.Bucket(seenBucket)
+		if bucket.Get([]byte(output)) != nil {
+			duplicate = true
+			return nil
+		}
+		return bucket.Put([]byte(output), []byte{})
+	})
+	if err != nil {
+		b.fail(fmt.Errorf("failed to record %s in bolt store: %w", output, err))
+	}
+	return duplicate
+}
+
+// Init implements storage.Storage, the store being initialized by NewBolt
+func (b *Bolt) Init() error {
+	return nil
+}
+
+// Visited implements storage.Storage
+func (b *Bolt) Visited(requestID uint64) error {
+	return b.db.Batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(visitedBucket).Put(requestKey(requestID), []byte{})
+	})
+}
+
+// IsVisited implements storage.Storage
+func (b *Bolt) IsVisited(requestID uint64) (bool, error) {
+	visited := false
+	err := b.db.View(func(tx *bolt.Tx) error {
+		visited = tx.Bucket(visitedBucket).Get(requestKey(requestID)) != nil
+		return nil
+	})
+	return visited, err
+}
+
+// Cookies implements storage.Storage
+func (b *Bolt) Cookies(u *url.URL) string {
+	var cookies string
+	err := b.db.View(func(tx *bolt.Tx) error {
+		cookies = string(tx.Bucket(cookiesBucket).Get([]byte(u.Host)))
+		return nil
+	})
+	if err != nil {
+		b.fail(fmt.Errorf("failed to read the cookies of %s in bolt store: %w", u.Host, err))
+	}
+	return cookies
+}
+
+// SetCookies implements storage.Storage
+func (b *Bolt) SetCookies(u *url.URL, cookies string) {
+	err := b.db.Batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(cookiesBucket).Put([]byte(u.Host), []byte(cookies))
+	})
+	if err != nil {
+		b.fail(fmt.Errorf("failed to record the cookies of %s in bolt store: %w", u.Host, err))
+	}
+}
+
+func requestKey(requestID uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, requestID)
+	return key
+}
+
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
+
+// failures collects the errors of a store until Err is called
+type failures struct {
+	lock sync.Mutex
+	errs []error
+}
+
+func (f *failures) fail(err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.errs = append(f.errs, err)
+}
+
+// Err returns the failures since the previous call, or nil
+func (f *failures) Err() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	err := errors.Join(f.errs...)
+	f.errs = nil
+	return err
+}
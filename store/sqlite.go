@@ -0,0 +1,93 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLite is a core.FallibleDedupStore and a colly storage.Storage backed by a SQLite database
+type SQLite struct {
+	db *sql.DB
+	failures
+}
+
+func NewSQLite(path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite store %s: %w", path, err)
+	}
+	// sqlite serializes writes, a single connection avoids busy errors
+	db.SetMaxOpenConns(1)
+	for _, stmt := range []string{
+		`PRAGMA journal_mode=WAL`,
+		`CREATE TABLE IF NOT EXISTS seen (output TEXT PRIMARY KEY) WITHOUT ROWID`,
+		`CREATE TABLE IF NOT EXISTS visited (id INTEGER PRIMARY KEY)`,
+		`CREATE TABLE IF NOT EXISTS cookies (host TEXT PRIMARY KEY, cookies TEXT NOT NULL) WITHOUT ROWID`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to init sqlite store %s: %w", path, err)
+		}
+	}
+	return &SQLite{db: db}, nil
+}
+
+func (s *SQLite) Duplicate(output string) bool {
+	res, err := s.db.Exec(`INSERT OR IGNORE INTO seen (output) VALUES (?)`, output)
+	if err != nil {
+		s.fail(fmt.Errorf("failed to record %s in sqlite store: %w", output, err))
+		return false
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		s.fail(fmt.Errorf("failed to record %s in sqlite store: %w", output, err))
+		return false
+	}
+	return inserted == 0
+}
+
+// Init implements storage.Storage, the store being initialized by NewSQLite
+func (s *SQLite) Init() error {
+	return nil
+}
+
+// Visited implements storage.Storage
+func (s *SQLite) Visited(requestID uint64) error {
+	_, err := s.db.Exec(`INSERT OR IGNORE INTO visited (id) VALUES (?)`, int64(requestID))
+	return err
+}
+
+// IsVisited implements storage.Storage
+func (s *SQLite) IsVisited(requestID uint64) (bool, error) {
+	err := s.db.QueryRow(`SELECT id FROM visited WHERE id = ?`, int64(requestID)).Scan(new(int64))
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Cookies implements storage.Storage
+func (s *SQLite) Cookies(u *url.URL) string {
+	var cookies string
+	err := s.db.QueryRow(`SELECT cookies FROM cookies WHERE host = ?`, u.Host).Scan(&cookies)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.fail(fmt.Errorf("failed to read the cookies of %s in sqlite store: %w", u.Host, err))
+	}
+	return cookies
+}
+
+// SetCookies implements storage.Storage
+func (s *SQLite) SetCookies(u *url.URL, cookies string) {
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO cookies (host, cookies) VALUES (?, ?)`, u.Host, cookies)
+	if err != nil {
+		s.fail(fmt.Errorf("failed to record the cookies of %s in sqlite store: %w", u.Host, err))
+	}
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
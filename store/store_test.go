@@ -0,0 +1,139 @@
+package store
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/benji-bou/gospider/core"
+	"github.com/gocolly/colly/v2/storage"
+)
+
+type closableStore interface {
+	core.FallibleDedupStore
+	storage.Storage
+	Close() error
+}
+
+var stores = map[string]func(path string) (closableStore, error){
+	"bolt":   func(path string) (closableStore, error) { return NewBolt(path) },
+	"sqlite": func(path string) (closableStore, error) { return NewSQLite(path) },
+}
+
+func TestStores(t *testing.T) {
+	dir := t.TempDir()
+	for name, open := range stores {
+		path := filepath.Join(dir, name+".db")
+		s, err := open(path)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if s.Duplicate("https://example.com/a") {
+			t.Errorf("%s: unexpected duplicate on first insert", name)
+		}
+		if !s.Duplicate("https://example.com/a") {
+			t.Errorf("%s: expected duplicate on second insert", name)
+		}
+		if err := s.Visited(42); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		u := &url.URL{Scheme: "https", Host: "example.com"}
+		s.SetCookies(u, "session=1")
+		s.Close()
+
+		// the state survives a restart
+		s, err = open(path)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if !s.Duplicate("https://example.com/a") || s.Duplicate("https://example.com/b") {
+			t.Errorf("%s: expected dedup state to be persisted", name)
+		}
+		if visited, err := s.IsVisited(42); !visited || err != nil {
+			t.Errorf("%s: expected the visited request to be persisted, got %v %v", name, visited, err)
+		}
+		if visited, _ := s.IsVisited(43); visited {
+			t.Errorf("%s: unexpected visited request", name)
+		}
+		if cookies := s.Cookies(u); cookies != "session=1" {
+			t.Errorf("%s: expected the cookies to be persisted, got %q", name, cookies)
+		}
+		if err := s.Err(); err != nil {
+			t.Errorf("%s: unexpected failure %s", name, err)
+		}
+		s.Close()
+
+		// the failures are reported once
+		if s.Duplicate("https://example.com/c") || s.Err() == nil {
+			t.Errorf("%s: expected a closed store to report its failure", name)
+		}
+		if err := s.Err(); err != nil {
+			t.Errorf("%s: expected the failure to be reported once, got %s", name, err)
+		}
+	}
+}
+
+func TestStoresPersistVisits(t *testing.T) {
+	var lock sync.Mutex
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests++
+		lock.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "1"})
+		}
+		fmt.Fprint(w, `<html><body><a href="/a">a</a></body></html>`)
+	}))
+	defer ts.Close()
+
+	for name, open := range stores {
+		path := filepath.Join(t.TempDir(), name+".db")
+		crawl := func() int {
+			s, err := open(path)
+			if err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+			defer s.Close()
+			lock.Lock()
+			requests = 0
+			lock.Unlock()
+			outputC, errC := core.NewCrawler(core.WithDefaultColly(2), core.WithDedupStore(s)).Start(ts.URL + "/")
+			for outputC != nil || errC != nil {
+				select {
+				case _, ok := <-outputC:
+					if !ok {
+						outputC = nil
+					}
+				case _, ok := <-errC:
+					if !ok {
+						errC = nil
+					}
+				}
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			return requests
+		}
+		if n := crawl(); n != 2 {
+			t.Errorf("%s: expected the 2 pages to be requested, got %d", name, n)
+		}
+		if n := crawl(); n != 0 {
+			t.Errorf("%s: expected a restarted crawl not to request the visited pages, got %d", name, n)
+		}
+		s, err := open(path)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		u, _ := url.Parse(ts.URL)
+		if cookies := s.Cookies(u); cookies == "" {
+			t.Errorf("%s: expected the cookies of the crawl to be stored", name)
+		}
+		s.Close()
+	}
+}